@@ -21,12 +21,32 @@ type API struct {
 	historyDB     *historydb.HistoryDB
 	config        *configAPI
 	l2DB          *l2db.L2DB
-	stateDB       *statedb.StateDB
+	stateDB       stateDBReader
+	finality      FinalitySource
 	hermezAddress ethCommon.Address
 	validate      *validator.Validate
 	coordnet      *coordinatornetwork.CoordinatorNetwork
 }
 
+// stateDBReader is the subset of the StateDB methods used by the API.  The API
+// runs concurrently with the synchronizer, so it only reads the last
+// checkpoint of the StateDB, which never contains a half-processed batch.
+type stateDBReader interface {
+	LastRead(fn func(sdbLast *statedb.Last) error) error
+	LastGetCurrentBatch() (common.BatchNum, error)
+	LastGetAccount(idx common.AccountIdx) (*common.Account, error)
+	LastGetVouch(idx common.VouchIdx) (*common.Vouch, error)
+	LastGetScore(idx common.AccountIdx) (*common.Score, error)
+	OpenAt(batchNum common.BatchNum) (*statedb.CheckpointView, error)
+}
+
+// FinalitySource gives the last finalized batch, and is implemented by the
+// Synchronizer.  The batches after it can still be discarded by a reorg, so
+// when the API has a FinalitySource the state endpoints don't serve them.
+type FinalitySource interface {
+	FinalizedBatchNum() common.BatchNum
+}
+
 type CoordinatorNetworkConfig struct {
 	BootstrapPeers []multiaddr.Multiaddr
 	EthPrivKey     *ecdsa.PrivateKey
@@ -41,6 +61,7 @@ type Config struct {
 	HistoryDB                *historydb.HistoryDB
 	L2DB                     *l2db.L2DB
 	StateDB                  *statedb.StateDB
+	Finality                 FinalitySource
 	EthClient                *ethclient.Client
 	ForgerAddress            *ethCommon.Address
 	CoordinatorNetworkConfig *CoordinatorNetworkConfig
@@ -67,10 +88,19 @@ func NewAPI(setup Config) (*API, error) {
 			ChainID:         consts.ChainID,
 		},
 		l2DB:          setup.L2DB,
-		stateDB:       setup.StateDB,
+		finality:      setup.Finality,
 		hermezAddress: consts.HermezAddress,
 		validate:      nil, //TODO: Add validations
 	}
+	if setup.StateDB != nil {
+		a.stateDB = setup.StateDB
+	}
+
+	// Add the state endpoints, which read from the StateDB and can be
+	// queried at a past batch with ?batchNum=
+	if setup.ExplorerEndpoints && setup.Server != nil && a.stateDB != nil {
+		a.addStateEndpoints(setup.Server)
+	}
 
 	// Setup coordinator network (libp2p interface) <=TODO
 	// if setup.CoordinatorNetworkConfig != nil {
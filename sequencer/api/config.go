@@ -18,9 +18,7 @@ type rollupConstants struct {
 	MaxL1UserTx             int                    `json:"maxL1UserTx"`
 	MaxL1Tx                 int                    `json:"maxL1Tx"`
 	InputSHAConstantBytes   int                    `json:"inputSHAConstantBytes"`
-	NumBuckets              int                    `json:"numBuckets"`
 	// MaxWithdrawalDelay      int                    `json:"maxWithdrawalDelay"`
-	ExchangeMultiplier int `json:"exchangeMultiplier"`
 }
 
 type configAPI struct {
@@ -43,6 +41,5 @@ func newRollupConstants(publicConstants common.RollupConstants) *rollupConstants
 		MaxL1Tx:                 common.RollupConstMaxL1Tx,
 		InputSHAConstantBytes:   common.RollupConstInputSHAConstantBytes,
 		// MaxWithdrawalDelay:      common.RollupConstMaxWithdrawalDelay,
-		ExchangeMultiplier: common.RollupConstExchangeMultiplier,
 	}
 }
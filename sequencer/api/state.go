@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
+	"tokamak-sybil-resistance/database/statedb"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-merkletree"
+	"github.com/iden3/go-merkletree/db"
+)
+
+// errBadRequest wraps the errors caused by invalid request parameters
+var errBadRequest = errors.New("bad request")
+
+// errNotFinalized is returned when the state is requested at a batch that is
+// not finalized yet
+var errNotFinalized = errors.New("batch not finalized")
+
+type errorMsg struct {
+	Message string `json:"message"`
+}
+
+// stateItem is the common part of the responses of the state endpoints: the
+// batch the item was read at, the root of its merkle tree and the proof of
+// the item against that root
+type stateItem struct {
+	BatchNum    common.BatchNum                 `json:"batchNum"`
+	Root        *big.Int                        `json:"root"`
+	MerkleProof *merkletree.CircomVerifierProof `json:"merkleProof"`
+}
+
+type accountAPI struct {
+	stateItem
+	AccountIndex common.AccountIdx     `json:"accountIndex"`
+	BJJ          babyjub.PublicKeyComp `json:"bjj"`
+	EthAddr      ethCommon.Address     `json:"ethereumAddress"`
+	Nonce        common.Nonce          `json:"nonce"`
+	Balance      *big.Int              `json:"balance"`
+}
+
+type scoreAPI struct {
+	stateItem
+	AccountIndex common.AccountIdx `json:"accountIndex"`
+	Score        uint32            `json:"score"`
+}
+
+type vouchAPI struct {
+	stateItem
+	FromAccountIndex common.AccountIdx `json:"fromAccountIndex"`
+	ToAccountIndex   common.AccountIdx `json:"toAccountIndex"`
+	Value            bool              `json:"value"`
+}
+
+// addStateEndpoints adds the endpoints that read from the StateDB to server
+func (a *API) addStateEndpoints(server *gin.Engine) {
+	v1 := server.Group("/v1")
+	v1.GET("/accounts/:accountIndex", a.getAccount)
+	v1.GET("/accounts-by-bjj/:bjj", a.getAccountByBJJ)
+	v1.GET("/scores/:accountIndex", a.getScore)
+	v1.GET("/vouches/:fromAccountIndex/:toAccountIndex", a.getVouch)
+}
+
+func (a *API) getAccount(c *gin.Context) {
+	idx, err := parseAccountIdx(c, "accountIndex")
+	if err != nil {
+		retError(c, err)
+		return
+	}
+	var res *accountAPI
+	if err := a.stateRead(c, func(sdb *statedb.Last, batchNum common.BatchNum) error {
+		res, err = newAccountAPI(sdb, batchNum, idx)
+		return err
+	}); err != nil {
+		retError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// getAccountByBJJ returns the account with the smallest index of the given
+// BabyJubJub public key, so that wallets that only know the key can find its
+// account index
+func (a *API) getAccountByBJJ(c *gin.Context) {
+	var pk babyjub.PublicKeyComp
+	if err := pk.UnmarshalText([]byte(c.Param("bjj"))); err != nil {
+		retError(c, common.Wrap(fmt.Errorf("%w: invalid bjj %q", errBadRequest, c.Param("bjj"))))
+		return
+	}
+	var res *accountAPI
+	if err := a.stateRead(c, func(sdb *statedb.Last, batchNum common.BatchNum) error {
+		idx, err := sdb.GetIdxByBJJ(pk)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		res, err = newAccountAPI(sdb, batchNum, idx)
+		return err
+	}); err != nil {
+		retError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func newAccountAPI(sdb *statedb.Last, batchNum common.BatchNum,
+	idx common.AccountIdx) (*accountAPI, error) {
+	account, err := sdb.GetAccount(idx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	proof, err := sdb.MTGetAccountProof(idx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &accountAPI{
+		stateItem:    newStateItem(batchNum, proof),
+		AccountIndex: account.Idx,
+		BJJ:          account.BJJ,
+		EthAddr:      account.EthAddr,
+		Nonce:        account.Nonce,
+		Balance:      account.Balance,
+	}, nil
+}
+
+func (a *API) getScore(c *gin.Context) {
+	idx, err := parseAccountIdx(c, "accountIndex")
+	if err != nil {
+		retError(c, err)
+		return
+	}
+	var res scoreAPI
+	if err := a.stateRead(c, func(sdb *statedb.Last, batchNum common.BatchNum) error {
+		score, err := sdb.GetScore(idx)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		proof, err := sdb.MTGetScoreProof(idx)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		res = scoreAPI{
+			stateItem:    newStateItem(batchNum, proof),
+			AccountIndex: idx,
+			Score:        score.Value,
+		}
+		return nil
+	}); err != nil {
+		retError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (a *API) getVouch(c *gin.Context) {
+	fromIdx, err := parseAccountIdx(c, "fromAccountIndex")
+	if err != nil {
+		retError(c, err)
+		return
+	}
+	toIdx, err := parseAccountIdx(c, "toAccountIndex")
+	if err != nil {
+		retError(c, err)
+		return
+	}
+	idx := common.GenerateVouchIdx(fromIdx, toIdx)
+	var res vouchAPI
+	if err := a.stateRead(c, func(sdb *statedb.Last, batchNum common.BatchNum) error {
+		vouch, err := sdb.GetVouch(idx)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		proof, err := sdb.MTGetVouchProof(idx)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		res = vouchAPI{
+			stateItem:        newStateItem(batchNum, proof),
+			FromAccountIndex: fromIdx,
+			ToAccountIndex:   toIdx,
+			Value:            vouch.Value,
+		}
+		return nil
+	}); err != nil {
+		retError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// stateRead calls fn with a view of the StateDB at the batch given by the
+// batchNum query parameter, or at the last batch if it's not set.  When the
+// API has a FinalitySource, only finalized batches are read, and the last
+// finalized batch is the default.
+func (a *API) stateRead(c *gin.Context,
+	fn func(sdb *statedb.Last, batchNum common.BatchNum) error) error {
+	var batchNum common.BatchNum
+	batchNumStr, ok := c.GetQuery("batchNum")
+	if ok {
+		n, err := strconv.ParseUint(batchNumStr, 10, 32)
+		if err != nil {
+			return common.Wrap(fmt.Errorf("%w: invalid batchNum %q", errBadRequest, batchNumStr))
+		}
+		batchNum = common.BatchNum(n)
+		if a.finality != nil && batchNum > a.finality.FinalizedBatchNum() {
+			return common.Wrap(fmt.Errorf("%w: %d", errNotFinalized, batchNum))
+		}
+	} else if a.finality != nil {
+		batchNum = a.finality.FinalizedBatchNum()
+	}
+
+	// By default read the last checkpoint directly, unless the last
+	// finalized batch is an older one
+	if !ok {
+		read := false
+		if err := a.stateDB.LastRead(func(sdb *statedb.Last) error {
+			currentBatch, err := sdb.GetCurrentBatch()
+			if err != nil {
+				return common.Wrap(err)
+			}
+			if a.finality != nil && currentBatch != batchNum {
+				return nil
+			}
+			read = true
+			return fn(sdb, currentBatch)
+		}); err != nil {
+			return common.Wrap(err)
+		} else if read {
+			return nil
+		}
+	}
+	view, err := a.stateDB.OpenAt(batchNum)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer view.Close()
+	return fn(view.Last, view.BatchNum())
+}
+
+func newStateItem(batchNum common.BatchNum, proof *merkletree.CircomVerifierProof) stateItem {
+	return stateItem{
+		BatchNum:    batchNum,
+		Root:        proof.Root.BigInt(),
+		MerkleProof: proof,
+	}
+}
+
+func parseAccountIdx(c *gin.Context, name string) (common.AccountIdx, error) {
+	idx, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		return 0, common.Wrap(fmt.Errorf("%w: invalid %s %q", errBadRequest, name, c.Param(name)))
+	}
+	return common.AccountIdx(idx), nil
+}
+
+// retError replies to the request with the status code that matches err
+func retError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+	case errors.Is(err, kvdb.ErrCheckpointNotFound), errors.Is(err, db.ErrNotFound),
+		errors.Is(err, statedb.ErrIdxNotFound), errors.Is(err, errNotFinalized):
+		status = http.StatusNotFound
+	}
+	c.JSON(status, errorMsg{Message: err.Error()})
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/test/til"
+	"tokamak-sybil-resistance/txprocessor"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-merkletree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stateSet = `
+	Type: Blockchain
+
+	CreateAccountDeposit A: 2000 // Idx=256
+	CreateAccountDeposit B: 1000 // Idx=257
+
+	> batchL1 // forge L1UserTxs{nil}, freeze L1UserTxs{2}
+	> batchL1 // forge L1UserTxs{2}
+	> block
+`
+
+// stateRoots are the roots of the StateDB trees at a batch
+type stateRoots struct {
+	account, vouch, score *big.Int
+}
+
+// newTestStateDB returns a StateDB with the batches of stateSet followed by a
+// batch that sets the score of A and the vouch of A to B, and the roots of its
+// trees at every batch
+func newTestStateDB(t *testing.T) (*statedb.StateDB, map[common.BatchNum]stateRoots) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	sdb, err := statedb.NewStateDB(statedb.Config{Path: dir, Keep: 128,
+		Type: statedb.TypeSynchronizer, NLevels: statedb.MaxNLevels})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sdb.Close()
+		os.RemoveAll(dir) //nolint:errcheck
+	})
+
+	tc := til.NewContext(0, common.RollupConstMaxL1UserTx)
+	blocks, err := tc.GenerateBlocks(stateSet)
+	require.NoError(t, err)
+	require.NoError(t, tc.FillBlocksExtra(blocks, &til.ConfigExtra{CoordUser: "A"}))
+	tc.FillBlocksL1UserTxsBatchNum(blocks)
+	require.NoError(t, tc.FillBlocksForgedL1UserTxs(blocks))
+
+	roots := map[common.BatchNum]stateRoots{}
+	addRoots := func() {
+		roots[sdb.CurrentBatch()] = stateRoots{account: sdb.GetMTRootAccount(),
+			vouch: sdb.GetMTRootVouch(), score: sdb.GetMTRootScore()}
+	}
+	tp := txprocessor.NewTxProcessor(sdb, txprocessor.Config{
+		NLevels:  statedb.MaxNLevels,
+		MaxFeeTx: common.RollupConstMaxFeeIdxCoordinator,
+		MaxTx:    512,
+		MaxL1Tx:  common.RollupConstMaxL1Tx,
+	})
+	for _, block := range blocks {
+		for _, batch := range block.Rollup.Batches {
+			_, err := tp.ProcessTxs(nil, batch.L1UserTxs, batch.L1CoordinatorTxs,
+				common.L2TxsToPoolL2Txs(batch.L2Txs))
+			require.NoError(t, err)
+			addRoots()
+		}
+	}
+	// The scores and vouches are not set by the txs, so set them in a
+	// batch of their own
+	_, err = sdb.ApplyBulkUpdate(&statedb.BulkUpdate{
+		Vouches: []common.Vouch{{Idx: common.GenerateVouchIdx(256, 257), Value: true}},
+		Scores:  []common.Score{{Idx: 256, Value: 7}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, sdb.MakeCheckpoint())
+	addRoots()
+	return sdb, roots
+}
+
+type testFinality struct {
+	batchNum common.BatchNum
+}
+
+func (f *testFinality) FinalizedBatchNum() common.BatchNum {
+	return f.batchNum
+}
+
+// newTestStateServer returns a server with the state endpoints over sdb
+func newTestStateServer(sdb *statedb.StateDB, finality FinalitySource) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	a := &API{stateDB: sdb, finality: finality}
+	a.addStateEndpoints(server)
+	return server
+}
+
+// doGet does a GET request of path to server and returns the status code
+// and the body of the response
+func doGet(t *testing.T, server *gin.Engine, path string) (int, []byte) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+// checkStateItem checks that the response has the fields of stateItem, that
+// its batchNum is expected and that its merkleProof proves against its root,
+// which must be the root of the tree at that batch
+func checkStateItem(t *testing.T, body []byte, batchNum common.BatchNum, root *big.Int) {
+	var item struct {
+		BatchNum    common.BatchNum                `json:"batchNum"`
+		Root        *big.Int                       `json:"root"`
+		MerkleProof merkletree.CircomVerifierProof `json:"merkleProof"`
+	}
+	require.NoError(t, json.Unmarshal(body, &item))
+	assert.Equal(t, batchNum, item.BatchNum)
+	assert.Equal(t, root, item.Root)
+	require.NotNil(t, item.MerkleProof.Root)
+	assert.Equal(t, item.Root, item.MerkleProof.Root.BigInt())
+	assert.Equal(t, 0, item.MerkleProof.Fnc)
+}
+
+func checkFields(t *testing.T, body []byte, fields ...string) {
+	var res map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &res))
+	expected := append([]string{"batchNum", "root", "merkleProof"}, fields...)
+	actual := make([]string, 0, len(res))
+	for field := range res {
+		actual = append(actual, field)
+	}
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestStateEndpoints(t *testing.T) {
+	sdb, roots := newTestStateDB(t)
+	server := newTestStateServer(sdb, nil)
+	lastBatch := common.BatchNum(len(roots))
+
+	status, body := doGet(t, server, "/v1/accounts/256")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkFields(t, body, "accountIndex", "bjj", "ethereumAddress", "nonce", "balance")
+	checkStateItem(t, body, lastBatch, roots[lastBatch].account)
+	var account accountAPI
+	require.NoError(t, json.Unmarshal(body, &account))
+	assert.Equal(t, common.AccountIdx(256), account.AccountIndex)
+	assert.Equal(t, "2000", account.Balance.String())
+
+	status, body = doGet(t, server, fmt.Sprintf("/v1/accounts-by-bjj/%s", account.BJJ))
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch, roots[lastBatch].account)
+
+	status, body = doGet(t, server, "/v1/scores/256")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkFields(t, body, "accountIndex", "score")
+	checkStateItem(t, body, lastBatch, roots[lastBatch].score)
+	var score scoreAPI
+	require.NoError(t, json.Unmarshal(body, &score))
+	assert.Equal(t, uint32(7), score.Score)
+
+	status, body = doGet(t, server, "/v1/vouches/256/257")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkFields(t, body, "fromAccountIndex", "toAccountIndex", "value")
+	checkStateItem(t, body, lastBatch, roots[lastBatch].vouch)
+	var vouch vouchAPI
+	require.NoError(t, json.Unmarshal(body, &vouch))
+	assert.True(t, vouch.Value)
+
+	// a past batch is read from its checkpoint
+	status, body = doGet(t, server, "/v1/accounts/257?batchNum=2")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, 2, roots[2].account)
+
+	var unknownBJJ babyjub.PrivateKey
+	copy(unknownBJJ[:], "not the key of any til account")
+	errorCases := []struct {
+		path   string
+		status int
+	}{
+		{"/v1/accounts/abc", http.StatusBadRequest},
+		{"/v1/accounts/-1", http.StatusBadRequest},
+		{"/v1/accounts/256?batchNum=abc", http.StatusBadRequest},
+		{"/v1/vouches/256/abc", http.StatusBadRequest},
+		{"/v1/accounts-by-bjj/abc", http.StatusBadRequest},
+		// statedb.ErrIdxNotFound
+		{fmt.Sprintf("/v1/accounts-by-bjj/%s", unknownBJJ.Public().Compress()), http.StatusNotFound},
+		// not found in the tree
+		{"/v1/accounts/300", http.StatusNotFound},
+		{"/v1/scores/257", http.StatusNotFound},
+		{"/v1/vouches/257/256", http.StatusNotFound},
+		// the account and the vouch don't exist yet at batches 1 and 2
+		{"/v1/accounts/256?batchNum=1", http.StatusNotFound},
+		{"/v1/vouches/256/257?batchNum=2", http.StatusNotFound},
+		// kvdb.ErrCheckpointNotFound
+		{fmt.Sprintf("/v1/accounts/256?batchNum=%d", lastBatch+1), http.StatusNotFound},
+	}
+	for _, tc := range errorCases {
+		status, body := doGet(t, server, tc.path)
+		assert.Equal(t, tc.status, status, tc.path)
+		var msg errorMsg
+		require.NoError(t, json.Unmarshal(body, &msg), tc.path)
+		assert.NotEmpty(t, msg.Message, tc.path)
+	}
+}
+
+func TestStateEndpointsFinality(t *testing.T) {
+	sdb, roots := newTestStateDB(t)
+	lastBatch := common.BatchNum(len(roots))
+	finality := &testFinality{batchNum: lastBatch - 1}
+	server := newTestStateServer(sdb, finality)
+
+	// By default the last finalized batch is read, which is older than
+	// the last checkpoint
+	status, body := doGet(t, server, "/v1/scores/256")
+	assert.Equal(t, http.StatusNotFound, status, string(body))
+	status, body = doGet(t, server, "/v1/accounts/256")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch-1, roots[lastBatch-1].account)
+
+	// The batches up to the finalized one can be read explicitly, but not
+	// the later ones
+	status, body = doGet(t, server, fmt.Sprintf("/v1/accounts/256?batchNum=%d", lastBatch-1))
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch-1, roots[lastBatch-1].account)
+	status, body = doGet(t, server, fmt.Sprintf("/v1/scores/256?batchNum=%d", lastBatch))
+	assert.Equal(t, http.StatusNotFound, status, string(body))
+	assert.Contains(t, string(body), errNotFinalized.Error())
+
+	// When the last checkpoint is finalized it's read by default
+	finality.batchNum = lastBatch
+	status, body = doGet(t, server, "/v1/scores/256")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch, roots[lastBatch].score)
+	status, body = doGet(t, server, fmt.Sprintf("/v1/scores/256?batchNum=%d", lastBatch))
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch, roots[lastBatch].score)
+
+	// Without a FinalitySource every batch can be read, and the last one
+	// is the default
+	server = newTestStateServer(sdb, nil)
+	status, body = doGet(t, server, "/v1/scores/256")
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch, roots[lastBatch].score)
+	status, body = doGet(t, server, fmt.Sprintf("/v1/accounts/256?batchNum=%d", lastBatch))
+	require.Equal(t, http.StatusOK, status, string(body))
+	checkStateItem(t, body, lastBatch, roots[lastBatch].account)
+}
@@ -23,13 +23,3 @@ type Slot struct {
 	Forger ethCommon.Address
 	URL    string
 }
-
-// BidCoordinator contains the coordinator info of a bid, along with the bid value
-type BidCoordinator struct {
-	SlotNum           int64             `meddler:"slot_num"`
-	DefaultSlotSetBid [6]*big.Int       `meddler:"default_slot_set_bid,json"`
-	BidValue          *big.Int          `meddler:"bid_value,bigint"`
-	Bidder            ethCommon.Address `meddler:"bidder_addr"` // address of the bidder
-	Forger            ethCommon.Address `meddler:"forger_addr"` // address of the forger
-	URL               string            `meddler:"url"`         // URL of the coordinators API
-}
@@ -23,12 +23,11 @@ type BlockData struct {
 // RollupData contains information returned by the Rollup smart contract
 type RollupData struct {
 	// L1UserTxs that were submitted in the block
-	L1UserTxs            []L1Tx
-	Batches              []BatchData
-	Withdrawals          []WithdrawInfo
-	UpdateBucketWithdraw []BucketUpdate
-	Vars                 *RollupVariables
-	AddedTokens          []Token
+	L1UserTxs   []L1Tx
+	Batches     []BatchData
+	Withdrawals []WithdrawInfo
+	Vars        *RollupVariables
+	AddedTokens []Token
 }
 
 // NewRollupData creates an empty RollupData with the slices initialized.
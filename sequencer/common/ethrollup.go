@@ -17,15 +17,19 @@ const (
 	RollupConstReservedIDx = 255
 	// RollupConstExitIDx IDX 1 is reserved for exits
 	RollupConstExitIDx = 1
+	// RollupConstExplodeIDx IDX 2 is reserved for force explodes
+	RollupConstExplodeIDx = 2
 	// RollupConstLimitTokens Max number of tokens allowed to be registered inside the rollup
 	RollupConstLimitTokens = (1 << 32) //nolint:gomnd
 	// RollupConstL1CoordinatorTotalBytes [4 bytes] token + [32 bytes] babyjub + [65 bytes]
 	// compressedSignature
 	RollupConstL1CoordinatorTotalBytes = 101
 	// RollupConstL1UserTotalBytes [20 bytes] fromEthAddr + [32 bytes] fromBjj-compressed + [6
-	// bytes] fromIdx + [5 bytes] depositAmountFloat40 + [5 bytes] amountFloat40 + [4 bytes]
-	// tokenId + [6 bytes] toIdx
-	RollupConstL1UserTotalBytes = 78
+	// bytes] fromIdx + [5 bytes] depositAmountF + [5 bytes] amountF + [6 bytes] toIdx
+	RollupConstL1UserTotalBytes = 74
+	// RollupConstL1UserNoBJJTotalBytes is the length of the L1 user txs without
+	// fromBjj-compressed, which the Rollup Smart Contract packs without it
+	RollupConstL1UserNoBJJTotalBytes = RollupConstL1UserTotalBytes - 32
 	// RollupConstMaxL1UserTx Maximum L1-user transactions allowed to be queued in a batch
 	RollupConstMaxL1UserTx = 128
 	// RollupConstMaxL1Tx Maximum L1 transactions allowed to be queued in a batch
@@ -37,18 +41,19 @@ const (
 	RollupConstInputSHAConstantBytes = 18546
 	// RollupConstMaxWithdrawalDelay max withdrawal delay in seconds
 	RollupConstMaxWithdrawalDelay = 2 * 7 * 24 * 60 * 60
-	// RollupConstExchangeMultiplier exchange multiplier
-	RollupConstExchangeMultiplier = 1e14
+	// RollupConstAmountFDecimals is the number of decimals of wei dropped in
+	// the 40 bit amounts of the L1 user txs, which the contract converts
+	// to wei with _float2Fix as amountF * 10^(18-8)
+	RollupConstAmountFDecimals = 18 - 8
 )
 
-// TODO: Check and Set the following
 var (
-	// RollupConstLimitDepositAmount Max deposit amount allowed (depositAmount: L1 --> L2)
-	RollupConstLimitDepositAmount, _ = new(big.Int).SetString(
-		"", 10)
-	// RollupConstLimitL2TransferAmount Max amount allowed (amount L2 --> L2)
-	RollupConstLimitL2TransferAmount, _ = new(big.Int).SetString(
-		"", 10)
+	// RollupConstLimitDepositAmount Max deposit amount allowed (depositAmount: L1 --> L2),
+	// _LIMIT_LOAD_AMOUNT in the contract (2^128, exclusive)
+	RollupConstLimitDepositAmount = new(big.Int).Lsh(big.NewInt(1), 128) //nolint:gomnd
+	// RollupConstLimitL2TransferAmount Max amount allowed (amount L2 --> L2),
+	// _LIMIT_L2TRANSFER_AMOUNT in the contract (2^192, exclusive)
+	RollupConstLimitL2TransferAmount = new(big.Int).Lsh(big.NewInt(1), 192) //nolint:gomnd
 	// RollupConstEthAddressInternalOnly This ethereum address is used internally for rollup
 	// accounts that don't have ethereum address, only Babyjubjub.
 	// This non-ethereum accounts can be created by the coordinator and allow users to have a
@@ -57,32 +62,39 @@ var (
 		"0xFFfFfFffFFfffFFfFFfFFFFFffFFFffffFfFFFfF")
 )
 
-// BucketParams are the parameter variables of each Bucket of Rollup Smart
-// Contract
-type BucketParams struct {
-	CeilUSD         *big.Int
-	BlockStamp      *big.Int
-	Withdrawals     *big.Int
-	RateBlocks      *big.Int
-	RateWithdrawals *big.Int
-	MaxWithdrawals  *big.Int
+// RollupAmountToF encodes an amount in wei as the 40 bit amount of an L1 user
+// tx, the inverse of _float2Fix in the contract.  It fails if the amount can't
+// be encoded without loss.
+func RollupAmountToF(amount *big.Int) (uint64, error) {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(RollupConstAmountFDecimals), nil)
+	amountF, rem := new(big.Int).QuoRem(amount, factor, new(big.Int))
+	if rem.Sign() != 0 {
+		return 0, Wrap(ErrFloat40NotEnoughPrecission)
+	}
+	if amountF.Sign() < 0 || !amountF.IsUint64() || amountF.Uint64() > maxFloat40Value {
+		return 0, Wrap(ErrFloat40Overflow)
+	}
+	return amountF.Uint64(), nil
 }
 
-// BucketUpdate are the bucket updates (tracking the withdrawals value changes)
-// in Rollup Smart Contract
-type BucketUpdate struct {
-	EthBlockNum int64    `meddler:"eth_block_num"`
-	NumBucket   int      `meddler:"num_bucket"`
-	BlockStamp  int64    `meddler:"block_stamp"`
-	Withdrawals *big.Int `meddler:"withdrawals,bigint"`
+// RollupAmountFromF decodes the 40 bit amount of an L1 user tx to wei, as
+// _float2Fix in the contract
+func RollupAmountFromF(amountF uint64) *big.Int {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(RollupConstAmountFDecimals), nil)
+	return factor.Mul(factor, new(big.Int).SetUint64(amountF))
 }
 
 // RollupVariables are the variables of the Rollup Smart Contract
 type RollupVariables struct {
-	EthBlockNum           int64          `meddler:"eth_block_num"`
-	ForgeL1L2BatchTimeout int64          `meddler:"forge_l1_timeout" validate:"required"`
-	Buckets               []BucketParams `meddler:"buckets,json"`
-	SafeMode              bool           `meddler:"safe_mode"`
+	EthBlockNum           int64 `meddler:"eth_block_num"`
+	ForgeL1L2BatchTimeout int64 `meddler:"forge_l1_timeout" validate:"required"`
+	SafeMode              bool  `meddler:"safe_mode"`
+	// Owner is the owner of the Smart Contract, nil if it's unknown (the
+	// vars were synced before the owner was tracked)
+	Owner *ethCommon.Address `meddler:"owner"`
+	// Verifiers are the verifiers set in the last (re)initialization.  An
+	// empty list means that they are the ones in the RollupConstants.
+	Verifiers []RollupVerifierStruct `meddler:"verifiers,json"`
 }
 
 // RollupVerifierStruct is the information about verifiers of the Rollup Smart Contract
@@ -115,5 +127,27 @@ func (c *RollupConstants) FindVerifierIdx(MaxTx, NLevels int64) (int, error) {
 // Copy returns a deep copy of the Variables
 func (v *RollupVariables) Copy() *RollupVariables {
 	vCpy := *v
+	if v.Owner != nil {
+		owner := *v.Owner
+		vCpy.Owner = &owner
+	}
+	if v.Verifiers != nil {
+		vCpy.Verifiers = append([]RollupVerifierStruct{}, v.Verifiers...)
+	}
 	return &vCpy
 }
+
+// GetVerifiers returns the current verifiers: the ones in the RollupVariables
+// or, if they are empty, the ones in the RollupConstants
+func (v *RollupVariables) GetVerifiers(consts *RollupConstants) []RollupVerifierStruct {
+	if len(v.Verifiers) == 0 {
+		return consts.Verifiers
+	}
+	return v.Verifiers
+}
+
+// FindVerifierIdx tries to find a matching verifier in the current verifiers
+// (see GetVerifiers) and returns its index
+func (v *RollupVariables) FindVerifierIdx(consts *RollupConstants, MaxTx, NLevels int64) (int, error) {
+	return (&RollupConstants{Verifiers: v.GetVerifiers(consts)}).FindVerifierIdx(MaxTx, NLevels)
+}
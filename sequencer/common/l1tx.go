@@ -196,37 +196,35 @@ func (tx L1Tx) TxCompressedData(chainID uint16) (*big.Int, error) {
 	return bi, nil
 }
 
-// L1UserTxFromBytes decodes a L1Tx from []byte
+// L1UserTxFromBytes decodes a L1Tx from []byte, packed as in the L1 user tx
+// queues of the Rollup Smart Contract: [20 bytes] fromEthAddr + [32 bytes]
+// fromBjj-compressed in big endian, only if the tx has it + [6 bytes] fromIdx
+// + [5 bytes] depositAmountF + [5 bytes] amountF + [6 bytes] toIdx
 func L1UserTxFromBytes(b []byte) (*L1Tx, error) {
-	if len(b) != RollupConstL1UserTotalBytes {
+	if len(b) != RollupConstL1UserTotalBytes && len(b) != RollupConstL1UserNoBJJTotalBytes {
 		return nil,
-			Wrap(fmt.Errorf("cannot parse L1Tx bytes, expected length %d, current: %d",
-				68, len(b)))
+			Wrap(fmt.Errorf("cannot parse L1Tx bytes, expected length %d or %d, current: %d",
+				RollupConstL1UserTotalBytes, RollupConstL1UserNoBJJTotalBytes, len(b)))
 	}
 
 	tx := &L1Tx{
 		UserOrigin: true,
 	}
 	var err error
+	withBJJ := len(b) == RollupConstL1UserTotalBytes
 	tx.FromEthAddr = ethCommon.BytesToAddress(b[0:20])
-
-	pkCompB := b[20:52]
-	pkCompL := SwapEndianness(pkCompB)
-	copy(tx.FromBJJ[:], pkCompL)
-	fromIdx, err := AccountIdxFromBytes(b[52:55])
-	if err != nil {
-		return nil, Wrap(err)
+	b = b[20:]
+	if withBJJ {
+		copy(tx.FromBJJ[:], SwapEndianness(b[0:32]))
+		b = b[32:]
 	}
-	tx.FromIdx = fromIdx
-	tx.DepositAmount, err = Float40FromBytes(b[58:63]).BigInt()
+	tx.FromIdx, err = l1UserTxIdxFromBytes(b[0:6])
 	if err != nil {
 		return nil, Wrap(err)
 	}
-	tx.Amount, err = Float40FromBytes(b[63:68]).BigInt()
-	if err != nil {
-		return nil, Wrap(err)
-	}
-	tx.ToIdx, err = AccountIdxFromBytes(b[72:75])
+	tx.DepositAmount = RollupAmountFromF(uint40FromBytes(b[6:11]))
+	tx.Amount = RollupAmountFromF(uint40FromBytes(b[11:16]))
+	tx.ToIdx, err = l1UserTxIdxFromBytes(b[16:22])
 	if err != nil {
 		return nil, Wrap(err)
 	}
@@ -234,6 +232,26 @@ func L1UserTxFromBytes(b []byte) (*L1Tx, error) {
 	return tx, nil
 }
 
+// l1UserTxIdxFromBytes decodes the 6 bytes idxs of the L1 user txs packed by
+// the Rollup Smart Contract
+func l1UserTxIdxFromBytes(b []byte) (AccountIdx, error) {
+	var idxBytes [8]byte
+	copy(idxBytes[8-len(b):], b)
+	idx := binary.BigEndian.Uint64(idxBytes[:])
+	if idx > maxAccountIdxValue {
+		return 0, Wrap(ErrIdxOverflow)
+	}
+	return AccountIdx(idx), nil
+}
+
+// uint40FromBytes decodes the 5 bytes amounts of the L1 user txs packed by the
+// Rollup Smart Contract
+func uint40FromBytes(b []byte) uint64 {
+	var vBytes [8]byte
+	copy(vBytes[8-len(b):], b)
+	return binary.BigEndian.Uint64(vBytes[:])
+}
+
 // L1TxFromDataAvailability decodes a L1Tx from []byte (Data Availability)
 func L1TxFromDataAvailability(b []byte, nLevels uint32) (*L1Tx, error) {
 	idxLen := nLevels / 8 //nolint:gomnd
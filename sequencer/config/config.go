@@ -8,6 +8,7 @@ import (
 	"time"
 	"tokamak-sybil-resistance/api/stateapiupdater"
 	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/go-playground/validator"
@@ -185,6 +186,10 @@ type Coordinator struct {
 		// in an ethereum transaction from the suggested gas price by
 		// the ethereum node
 		GasPriceIncPerc int64 `validate:"gte=0" env:"TONNODE_ETHCLIENT_GASPRICEINCPERC"`
+		// GasPricer selects how the gas of the forgeBatch transactions,
+		// and of the rest of transactions sent by the coordinator, is
+		// priced
+		GasPricer eth.GasPricerConfig
 		// CheckLoopInterval is the waiting interval between receipt
 		// checks of ethereum transactions in the TxManager
 		CheckLoopInterval Duration `validate:"required" env:"TONNODE_ETHCLIENT_CHECKLOOPINTERVAL"`
@@ -208,6 +213,18 @@ type Coordinator struct {
 			// Password used to decrypt the keys in the keystore
 			Password string `validate:"required" env:"TONNODE_KEYSTORE_PASSWORD"`
 		} `validate:"required"`
+		// Signer is the signer of the transactions of the forger
+		// account
+		Signer struct {
+			// Type is the kind of signer: KeyStore (default) signs
+			// with the key of the forger in Keystore, and External
+			// signs with a Clef compatible external signer, so the
+			// key is never loaded in the node
+			Type eth.SignerType `env:"TONNODE_ETHCLIENT_SIGNER_TYPE"`
+			// URL is the endpoint (http, ws or ipc) of the external
+			// signer
+			URL string `env:"TONNODE_ETHCLIENT_SIGNER_URL"`
+		}
 		// ForgeBatchGasCost contains the cost of each action in the
 		// ForgeBatch transaction.
 		ForgeBatchGasCost ForgeBatchGasCost `validate:"required"`
@@ -234,6 +251,24 @@ type Coordinator struct {
 	}
 }
 
+const (
+	// DatabaseDriverPostgres selects PostgreSQL as the SQL backend
+	DatabaseDriverPostgres = "postgres"
+	// DatabaseDriverSQLite selects an embedded SQLite DB as the SQL backend
+	DatabaseDriverSQLite = "sqlite"
+)
+
+// Database selects the SQL backend of the HistoryDB and L2DB.  The PostgreSQL
+// configuration is only required when using the postgres driver.
+type Database struct {
+	// Driver is the SQL backend, either "postgres" or "sqlite".  If it's
+	// not set, postgres is used
+	Driver string `validate:"omitempty,oneof=postgres sqlite" env:"TONNODE_DATABASE_DRIVER"`
+	// SQLitePath is the path of the SQLite database file.  Only used with
+	// the sqlite driver
+	SQLitePath string `env:"TONNODE_DATABASE_SQLITEPATH"`
+}
+
 // PostgreSQL is the postgreSQL configuration parameters.  It's possible to use
 // differentiated SQL connections for read/write.  If the read configuration is
 // not provided, the write one it's going to be used for both reads and writes
@@ -258,6 +293,13 @@ type PostgreSQL struct {
 	PasswordRead string `env:"TONNODE_POSTGRESQL_PASSWORDREAD"`
 	// Name of the PostgreSQL read server database
 	NameRead string `env:"TONNODE_POSTGRESQL_NAMEREAD"`
+	// MaxReplicaLag is the maximum number of blocks the read server can be
+	// behind the write server to be used for explorer reads.  When the lag
+	// is bigger, explorer reads fall back to the write server
+	MaxReplicaLag int64 `env:"TONNODE_POSTGRESQL_MAXREPLICALAG"`
+	// ReplicaLagCheckInterval is the waiting interval between checks of
+	// the read server lag
+	ReplicaLagCheckInterval Duration `env:"TONNODE_POSTGRESQL_REPLICALAGCHECKINTERVAL"`
 }
 
 // NodeDebug specifies debug configuration parameters
@@ -280,12 +322,39 @@ type Node struct {
 		Path string `validate:"required" env:"TONNODE_STATEDB_PATH"`
 		// Keep is the number of checkpoints to keep
 		Keep int `validate:"required,gte=128" env:"TONNODE_STATEDB_KEEP"`
+		// Backend is the key-value storage engine, either "pebble" or
+		// "leveldb".  If it's not set, pebble is used
+		Backend string `validate:"omitempty,oneof=pebble leveldb" env:"TONNODE_STATEDB_BACKEND"`
 	} `validate:"required"`
+	Database   Database
 	PostgreSQL PostgreSQL `validate:"required"`
 	Web3       struct {
 		// URL is the URL of the web3 ethereum-node RPC server.  Only
 		// geth is officially supported.
 		URL string `validate:"required,url" env:"TONNODE_WEB3_URL"`
+		// URLs are the URLs of more web3 ethereum-node RPC servers.
+		// The calls are balanced between all the nodes, failing over
+		// to the healthy ones.
+		URLs []string `validate:"dive,url" env:"TONNODE_WEB3_URLS" envSeparator:","`
+		// Attempts is the number of attempts of a failed call to
+		// the nodes before giving up
+		Attempts int `validate:"gte=0" env:"TONNODE_WEB3_ATTEMPTS"`
+		// AttemptsDelay is the delay before the first retry of a
+		// failed call, doubled on each attempt
+		AttemptsDelay Duration `env:"TONNODE_WEB3_ATTEMPTSDELAY"`
+		// MaxAttemptsDelay is the maximum delay between retries
+		MaxAttemptsDelay Duration `env:"TONNODE_WEB3_MAXATTEMPTSDELAY"`
+		// FailureThreshold is the number of consecutive failures of a
+		// node after which it's not used for CircuitOpenTimeout
+		FailureThreshold int `validate:"gte=0" env:"TONNODE_WEB3_FAILURETHRESHOLD"`
+		// CircuitOpenTimeout is the time a failing node is not used
+		CircuitOpenTimeout Duration `env:"TONNODE_WEB3_CIRCUITOPENTIMEOUT"`
+		// MaxBlockLag is the number of blocks a node can be behind the
+		// most advanced node before it's not used
+		MaxBlockLag int64 `validate:"gte=0" env:"TONNODE_WEB3_MAXBLOCKLAG"`
+		// HealthCheckInterval is the interval between the checks of
+		// the last block of the nodes
+		HealthCheckInterval Duration `env:"TONNODE_WEB3_HEALTHCHECKINTERVAL"`
 	} `validate:"required"`
 	Synchronizer struct {
 		// SyncLoopInterval is the interval between attempts to
@@ -304,6 +373,38 @@ type Node struct {
 		// defined number of blocks. This value only affects the reported % of
 		// synchronization of blocks and batches, nothing else.
 		StatsUpdateFrequencyDivider uint16 `validate:"required,gt=1" env:"TONNODE_SYNCHRONIZER_STATSUPDATEFREQUENCYDIVIDER"`
+		// CatchUpBlocks is the size of the ranges of blocks synchronized
+		// at once while the synchronizer is at least this number of
+		// blocks behind the ethereum node.  The blocks with Rollup
+		// events in a range are found with a single query, instead of
+		// fetching every block.  If it's 0 the catch-up mode is
+		// disabled.
+		CatchUpBlocks int64 `validate:"gte=0" env:"TONNODE_SYNCHRONIZER_CATCHUPBLOCKS"`
+		// CatchUpWorkers is the number of blocks whose events and forge
+		// calldata are fetched concurrently in the catch-up mode.  If
+		// it's 0, a default value is used
+		CatchUpWorkers int `validate:"gte=0" env:"TONNODE_SYNCHRONIZER_CATCHUPWORKERS"`
+		// FinalityDepth is the number of blocks behind the last
+		// ethereum block after which a synced block is final.  The API
+		// only serves the state of finalized batches, so that it
+		// doesn't change after a reorg of less than FinalityDepth
+		// blocks.  If it's 0, every synced block is final.
+		FinalityDepth int64 `validate:"gte=0" env:"TONNODE_SYNCHRONIZER_FINALITYDEPTH"`
+		// WebSocketURL is the URL of the websocket RPC server of an
+		// ethereum node.  When set, the new heads and the logs of the
+		// Rollup contract are subscribed to synchronize a new block as
+		// soon as it's mined.  The synchronizer keeps polling every
+		// SyncLoopInterval, so it only relies on polling while the
+		// subscriptions are lost.
+		WebSocketURL string `validate:"omitempty,url" env:"TONNODE_SYNCHRONIZER_WEBSOCKETURL"`
+		// WebSocketReconnectDelay is the delay before reconnecting a
+		// lost subscription, doubled on each failed attempt
+		WebSocketReconnectDelay Duration `env:"TONNODE_SYNCHRONIZER_WEBSOCKETRECONNECTDELAY"`
+		// L1QueueCheckInterval is the interval between the checks that
+		// the L1 user tx queues synced in the HistoryDB match the ones
+		// in the Rollup Smart Contract, which are only done in
+		// coordinator mode
+		L1QueueCheckInterval Duration `env:"TONNODE_SYNCHRONIZER_L1QUEUECHECKINTERVAL"`
 	} `validate:"required"`
 	SmartContracts struct {
 		// Rollup is the address of the Hermez.sol smart contract
@@ -418,7 +519,15 @@ func LoadNode(path string, coordinator bool) (*Node, error) {
 		log.Println(err.Error())
 	}
 	validate := validator.New()
-	if err := validate.Struct(cfg); err != nil {
+	if cfg.Database.Driver == DatabaseDriverSQLite {
+		if cfg.Database.SQLitePath == "" {
+			return nil, common.Wrap(fmt.Errorf(
+				"error validating configuration file: Database.SQLitePath is required with the sqlite driver"))
+		}
+		if err := validate.StructExcept(cfg, "PostgreSQL"); err != nil {
+			return nil, common.Wrap(fmt.Errorf("error validating configuration file: %w", err))
+		}
+	} else if err := validate.Struct(cfg); err != nil {
 		return nil, common.Wrap(fmt.Errorf("error validating configuration file: %w", err))
 	}
 	if coordinator {
@@ -430,6 +539,35 @@ func LoadNode(path string, coordinator bool) (*Node, error) {
 	return &cfg, nil
 }
 
+// DB is the subset of the node configuration needed to reach the SQL DB
+type DB struct {
+	Database   Database
+	PostgreSQL PostgreSQL
+}
+
+// LoadDB loads and validates only the Database and PostgreSQL sections of the
+// node configuration, so that the commands that only need the SQL DB don't
+// require a full node configuration
+func LoadDB(path string) (*DB, error) {
+	var cfg DB
+	if err := LoadConfig(path, DefaultValues, &cfg); err != nil {
+		return nil, common.Wrap(fmt.Errorf("error loading configuration: %w", err))
+	}
+	validate := validator.New()
+	if cfg.Database.Driver == DatabaseDriverSQLite {
+		if cfg.Database.SQLitePath == "" {
+			return nil, common.Wrap(fmt.Errorf(
+				"error validating configuration file: Database.SQLitePath is required with the sqlite driver"))
+		}
+		if err := validate.Struct(cfg.Database); err != nil {
+			return nil, common.Wrap(fmt.Errorf("error validating configuration file: %w", err))
+		}
+	} else if err := validate.Struct(cfg); err != nil {
+		return nil, common.Wrap(fmt.Errorf("error validating configuration file: %w", err))
+	}
+	return &cfg, nil
+}
+
 func (d *Duration) UnmarshalText(text []byte) error {
 	var err error
 	d.Duration, err = time.ParseDuration(string(text))
@@ -55,6 +55,7 @@ import (
 	"tokamak-sybil-resistance/database/l2db"
 	"tokamak-sybil-resistance/eth"
 	"tokamak-sybil-resistance/etherscan"
+	"tokamak-sybil-resistance/log"
 	"tokamak-sybil-resistance/synchronizer"
 	"tokamak-sybil-resistance/txprocessor"
 	"tokamak-sybil-resistance/txselector"
@@ -62,7 +63,10 @@ import (
 	ethCommon "github.com/ethereum/go-ethereum/common"
 )
 
-var errSkipBatchByPolicy = fmt.Errorf("skip batch by policy")
+var (
+	errSkipBatchByPolicy = fmt.Errorf("skip batch by policy")
+	errForgingPaused     = fmt.Errorf("forging paused")
+)
 
 const (
 	queueLen         = 16
@@ -156,8 +160,12 @@ type Config struct {
 	MinGasPrice int64
 	// GasPriceIncPerc is the percentage increase of gas price set in an
 	// ethereum transaction from the suggested gas price by the ehtereum
-	// node
+	// node.  It's also the minimum increase over the fees of a transaction
+	// replaced by a resend, which is never lower than the 10% required by
+	// geth.
 	GasPriceIncPerc int64
+	// GasPricer selects how the gas of the transactions is priced
+	GasPricer eth.GasPricerConfig
 	// TxManagerCheckInterval is the waiting interval between receipt
 	// checks of ethereum transactions in the TxManager
 	TxManagerCheckInterval time.Duration
@@ -166,7 +174,9 @@ type Config struct {
 	DebugBatchPath string
 	Purger         PurgerCfg
 	// VerifierIdx is the index of the verifier contract registered in the
-	// smart contract
+	// smart contract.  It's the initial value: the verifier that matches
+	// the circuit of TxProcessorConfig is selected again when the verifiers
+	// change.
 	VerifierIdx uint8
 	// ForgeBatchGasCost contains the cost of each action in the
 	// ForgeBatch transaction.
@@ -218,6 +228,15 @@ type Coordinator struct {
 
 	purger    *Purger
 	txManager *TxManager
+
+	// rw protects verifierIdx and pauseReason, which are read by the
+	// TxManager
+	rw sync.RWMutex
+	// verifierIdx is the index of the verifier that matches the circuit,
+	// nil if there's none
+	verifierIdx *uint8
+	// pauseReason is the reason why forging is paused, empty if it's not
+	pauseReason string
 }
 
 // MsgSyncBlock indicates an update to the Synchronizer stats
@@ -306,5 +325,139 @@ func NewCoordinator(cfg Config,
 	// Set Eth LastBlockNum to -1 in stats so that stats.Synced() is
 	// guaranteed to return false before it's updated with a real stats
 	c.stats.Eth.LastBlock.Num = -1
+	verifierIdx := cfg.VerifierIdx
+	c.verifierIdx = &verifierIdx
+	c.updateForgeState()
 	return &c, nil
 }
+
+// SendMsg is a thread safe method to pass a message to the Coordinator
+func (c *Coordinator) SendMsg(ctx context.Context, msg interface{}) {
+	select {
+	case c.msgCh <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// Start the coordinator
+func (c *Coordinator) Start() {
+	if c.started {
+		log.Fatal("Coordinator already started")
+	}
+	c.started = true
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			select {
+			case <-c.ctx.Done():
+				log.Info("Coordinator done")
+				return
+			case msg := <-c.msgCh:
+				c.handleMsg(msg)
+			}
+		}
+	}()
+}
+
+// Stop the coordinator
+func (c *Coordinator) Stop() {
+	if !c.started {
+		log.Fatal("Coordinator already stopped")
+	}
+	c.started = false
+	log.Infow("Stopping Coordinator...")
+	c.cancel()
+	c.wg.Wait()
+}
+
+func (c *Coordinator) handleMsg(msg interface{}) {
+	switch msg := msg.(type) {
+	case MsgSyncBlock:
+		c.syncStatsVars(&msg.Stats, &msg.Vars)
+	case MsgSyncReorg:
+		c.syncStatsVars(&msg.Stats, &msg.Vars)
+	case MsgStopPipeline:
+		log.Infow("Coordinator received MsgStopPipeline", "reason", msg.Reason,
+			"failedBatchNum", msg.FailedBatchNum)
+	default:
+		log.Errorw("Coordinator unexpected msg", "type", fmt.Sprintf("%T", msg))
+	}
+}
+
+// syncStatsVars updates the synchronizer stats and the smart contract
+// variables that have changed, and adapts the forging to the new governance
+// state
+func (c *Coordinator) syncStatsVars(stats *synchronizer.Stats, vars *common.SCVariablesPtr) {
+	c.stats = *stats
+	if vars.Rollup != nil {
+		c.vars.Rollup = *vars.Rollup
+	}
+	c.updateForgeState()
+}
+
+// updateForgeState selects again the verifier that matches the circuit if the
+// current one doesn't anymore, and pauses forging while the governance state is
+// unknown or doesn't allow it: the synchronizer is not synced, the owner of the
+// smart contract is unknown, the smart contract is in safe mode, or no verifier
+// matches the circuit.
+func (c *Coordinator) updateForgeState() {
+	maxTx, nLevels := int64(c.cfg.TxProcessorConfig.MaxTx), int64(c.cfg.TxProcessorConfig.NLevels)
+	verifiers := c.vars.Rollup.GetVerifiers(&c.consts.Rollup)
+	// verifierIdx is only written by the Coordinator goroutine
+	verifierIdx := c.verifierIdx
+	if verifierIdx == nil || int(*verifierIdx) >= len(verifiers) ||
+		verifiers[*verifierIdx].MaxTx != maxTx || verifiers[*verifierIdx].NLevels != nLevels {
+		verifierIdx = nil
+		idx, err := c.vars.Rollup.FindVerifierIdx(&c.consts.Rollup, maxTx, nLevels)
+		if err == nil {
+			_idx := uint8(idx)
+			verifierIdx = &_idx
+		}
+	}
+	var pauseReason string
+	switch {
+	case !c.stats.Synced():
+		pauseReason = "synchronizer not synced"
+	case c.vars.Rollup.Owner == nil:
+		pauseReason = "smart contract owner unknown"
+	case c.vars.Rollup.SafeMode:
+		pauseReason = "smart contract in safe mode"
+	case verifierIdx == nil:
+		pauseReason = "no verifier matches the circuit"
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+	if (verifierIdx == nil) != (c.verifierIdx == nil) ||
+		(verifierIdx != nil && *verifierIdx != *c.verifierIdx) {
+		if verifierIdx == nil {
+			log.Errorw("Coordinator: no verifier matches the circuit",
+				"maxTx", maxTx, "nLevels", nLevels)
+		} else {
+			log.Infow("Coordinator: selected verifier that matches the circuit",
+				"verifierIdx", *verifierIdx)
+		}
+	}
+	if pauseReason != c.pauseReason {
+		if pauseReason != "" {
+			log.Warnw("Coordinator: forging paused", "reason", pauseReason)
+		} else {
+			log.Infow("Coordinator: forging resumed")
+		}
+	}
+	c.verifierIdx = verifierIdx
+	c.pauseReason = pauseReason
+}
+
+// canForge returns the verifier index to use in a new batch, or an error if
+// forging is paused
+func (c *Coordinator) canForge() (uint8, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	if c.pauseReason != "" {
+		return 0, common.Wrap(fmt.Errorf("%w: %v", errForgingPaused, c.pauseReason))
+	}
+	return *c.verifierIdx, nil
+}
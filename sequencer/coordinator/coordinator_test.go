@@ -0,0 +1,97 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/synchronizer"
+	"tokamak-sybil-resistance/txprocessor"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinatorForgeState(t *testing.T) {
+	cfg := Config{
+		MinGasPrice:       1,
+		MaxGasPrice:       100,
+		EthClientAttempts: 1,
+		TxProcessorConfig: txprocessor.Config{MaxTx: 512, NLevels: 32},
+	}
+	txManager, client := newTestTxManager(t, &cfg)
+	coord := &Coordinator{
+		cfg: cfg,
+		consts: common.SCConsts{Rollup: common.RollupConstants{
+			Verifiers: []common.RollupVerifierStruct{{MaxTx: 2048, NLevels: 32},
+				{MaxTx: 512, NLevels: 32}},
+		}},
+		txManager: txManager,
+	}
+	txManager.coord = coord
+	coord.stats.Eth.LastBlock.Num = -1
+	coord.updateForgeState()
+	ctx := context.Background()
+
+	assertPaused := func(reason string) {
+		_, err := coord.canForge()
+		require.Error(t, err)
+		assert.True(t, errors.Is(common.Unwrap(err), errForgingPaused))
+		assert.Contains(t, err.Error(), reason)
+		batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: &eth.RollupForgeBatchArgs{}}
+		err = txManager.sendRollupForgeBatch(ctx, batchInfo, false)
+		require.Error(t, err)
+		assert.Empty(t, batchInfo.EthTxs)
+	}
+	syncBlock := func(blockNum int64, rollupVars *common.RollupVariables) {
+		var stats synchronizer.Stats
+		stats.Eth.LastBlock.Num = blockNum
+		stats.Sync.LastBlock.Num = blockNum
+		coord.handleMsg(MsgSyncBlock{Stats: stats,
+			Vars: common.SCVariablesPtr{Rollup: rollupVars}})
+	}
+
+	// Forging is paused until the synchronizer is synced and the owner is
+	// known
+	assertPaused("synchronizer not synced")
+	syncBlock(1, nil)
+	assertPaused("smart contract owner unknown")
+	syncBlock(2, &common.RollupVariables{EthBlockNum: 2, Owner: &ethCommon.Address{}})
+	verifierIdx, err := coord.canForge()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), verifierIdx)
+
+	// A batch must use the selected verifier
+	batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: &eth.RollupForgeBatchArgs{}}
+	require.Error(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	batchInfo.ForgeBatchArgs.VerifierIdx = 1
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	require.Equal(t, 1, len(batchInfo.EthTxs))
+
+	// The verifier is selected again when the verifiers change, and
+	// forging is paused while none matches the circuit
+	syncBlock(3, &common.RollupVariables{EthBlockNum: 3, Owner: &ethCommon.Address{},
+		Verifiers: []common.RollupVerifierStruct{{MaxTx: 512, NLevels: 32}}})
+	verifierIdx, err = coord.canForge()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), verifierIdx)
+	syncBlock(4, &common.RollupVariables{EthBlockNum: 4, Owner: &ethCommon.Address{},
+		Verifiers: []common.RollupVerifierStruct{{MaxTx: 1024, NLevels: 32}}})
+	assertPaused("no verifier matches the circuit")
+	// A pending batch can still be resent
+	client.nonce = 0
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+
+	syncBlock(5, &common.RollupVariables{EthBlockNum: 5, Owner: &ethCommon.Address{},
+		SafeMode: true})
+	assertPaused("smart contract in safe mode")
+	// A block behind the head pauses forging, and the vars are kept
+	var stats synchronizer.Stats
+	stats.Eth.LastBlock.Num = 7
+	stats.Sync.LastBlock.Num = 6
+	coord.handleMsg(MsgSyncReorg{Stats: stats})
+	assertPaused("synchronizer not synced")
+	assert.True(t, coord.vars.Rollup.SafeMode)
+}
@@ -2,7 +2,10 @@ package coordinator
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"strings"
+	"time"
 	"tokamak-sybil-resistance/common"
 	"tokamak-sybil-resistance/database/l2db"
 	"tokamak-sybil-resistance/eth"
@@ -11,21 +14,25 @@ import (
 	"tokamak-sybil-resistance/synchronizer"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // TxManager handles everything related to ethereum transactions:  It makes the
 // call to forge, waits for transaction confirmation, and keeps checking them
 // until a number of confirmed blocks have passed.
 type TxManager struct {
-	cfg Config
-	// ethClient        eth.ClientInterface
-	// etherscanService *etherscan.Service
-	l2DB    *l2db.L2DB   // Used only to mark forged txs as forged in the L2DB
-	coord   *Coordinator // Used only to send messages to stop the pipeline
-	batchCh chan *BatchInfo
-	chainID *big.Int
-	account accounts.Account
-	consts  common.SCConsts
+	cfg       Config
+	ethClient eth.ClientInterface
+	gasPricer eth.GasPricer
+	l2DB      *l2db.L2DB   // Used only to mark forged txs as forged in the L2DB
+	coord     *Coordinator // Used only to send messages to stop the pipeline
+	batchCh   chan *BatchInfo
+	chainID   *big.Int
+	account   accounts.Account
+	consts    common.SCConsts
 
 	stats       synchronizer.Stats
 	vars        common.SCVariables
@@ -79,11 +86,19 @@ func NewTxManager(ctx context.Context, cfg *Config, ethClient eth.ClientInterfac
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
+	var etherscanClient etherscan.Client
+	if etherscanService != nil {
+		etherscanClient = etherscanService
+	}
+	gasPricer, err := eth.NewGasPricer(&cfg.GasPricer, ethClient, etherscanClient)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
 	log.Infow("TxManager started", "nonce", accNonce)
 	return &TxManager{
-		cfg: *cfg,
-		// ethClient:         ethClient,
-		// etherscanService:  etherscanService,
+		cfg:               *cfg,
+		ethClient:         ethClient,
+		gasPricer:         gasPricer,
 		l2DB:              l2DB,
 		coord:             coord,
 		batchCh:           make(chan *BatchInfo, queueLen),
@@ -103,3 +118,156 @@ func NewTxManager(ctx context.Context, cfg *Config, ethClient eth.ClientInterfac
 		accNextNonce:   accNonce,
 	}, nil
 }
+
+// NewAuth generates a new auth object for the forgeBatch ethereum transaction
+// of the batch, signed with the account of the ethereum client.  The gas limit
+// is estimated with ForgeBatchGasCost from the txs of the batch.  The fees
+// suggested by the gas pricer are increased by GasPriceIncPerc and bounded by
+// MinGasPrice and MaxGasPrice.
+func (t *TxManager) NewAuth(ctx context.Context, batchInfo *BatchInfo) (*bind.TransactOpts, error) {
+	gasFeeCap, gasTipCap, err := t.gasPricer.SuggestGasFees(ctx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	gasFeeCap = increasePerc(gasFeeCap, t.cfg.GasPriceIncPerc)
+	gasTipCap = increasePerc(gasTipCap, t.cfg.GasPriceIncPerc)
+	minGasPrice := new(big.Int).Mul(big.NewInt(t.cfg.MinGasPrice), big.NewInt(params.GWei))
+	if gasFeeCap.Cmp(minGasPrice) < 0 {
+		gasFeeCap.Set(minGasPrice)
+	}
+	t.capFees(gasFeeCap, gasTipCap)
+
+	auth, err := eth.NewTransactor(t.ethClient.EthSigner(), t.chainID)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	auth.Value = big.NewInt(0) // in wei
+	auth.GasLimit = t.cfg.ForgeBatchGasCost.Fixed +
+		uint64(len(batchInfo.L1UserTxs))*t.cfg.ForgeBatchGasCost.L1UserTx +
+		uint64(len(batchInfo.L1CoordTxs))*t.cfg.ForgeBatchGasCost.L1CoordTx +
+		uint64(len(batchInfo.L2Txs))*t.cfg.ForgeBatchGasCost.L2Tx
+	auth.GasFeeCap = gasFeeCap
+	auth.GasTipCap = gasTipCap
+	auth.Nonce = nil
+	return auth, nil
+}
+
+// increasePerc returns v increased by perc percent
+func increasePerc(v *big.Int, perc int64) *big.Int {
+	inc := new(big.Int).Mul(v, big.NewInt(perc))
+	// nolint reason: to calculate percentages we use 100
+	inc.Div(inc, big.NewInt(100)) //nolint:gomnd
+	return inc.Add(inc, v)
+}
+
+// capFees bounds the fee cap by MaxGasPrice, and the tip by the fee cap
+func (t *TxManager) capFees(gasFeeCap, gasTipCap *big.Int) {
+	maxGasPrice := new(big.Int).Mul(big.NewInt(t.cfg.MaxGasPrice), big.NewInt(params.GWei))
+	if gasFeeCap.Cmp(maxGasPrice) > 0 {
+		log.Warnw("TxManager: gas fee cap limited to MaxGasPrice",
+			"gasFeeCap", gasFeeCap, "maxGasPrice", maxGasPrice)
+		gasFeeCap.Set(maxGasPrice)
+	}
+	if gasTipCap.Cmp(gasFeeCap) > 0 {
+		gasTipCap.Set(gasFeeCap)
+	}
+}
+
+// minReplacementIncPerc is the minimum percentage increase of both fees that
+// geth requires to replace a pending tx
+const minReplacementIncPerc = 10
+
+// bumpFees raises the fees of the auth to replace the tx: a node only accepts
+// a replacement with both fees increased by minReplacementIncPerc, so they are
+// at least the fees of the tx increased by GasPriceIncPerc, or by
+// minReplacementIncPerc if GasPriceIncPerc is lower.
+func (t *TxManager) bumpFees(auth *bind.TransactOpts, tx *types.Transaction) {
+	incPerc := t.cfg.GasPriceIncPerc
+	if incPerc < minReplacementIncPerc {
+		incPerc = minReplacementIncPerc
+	}
+	minGasFeeCap := increasePerc(tx.GasFeeCap(), incPerc)
+	if auth.GasFeeCap.Cmp(minGasFeeCap) < 0 {
+		auth.GasFeeCap = minGasFeeCap
+	}
+	minGasTipCap := increasePerc(tx.GasTipCap(), incPerc)
+	if auth.GasTipCap.Cmp(minGasTipCap) < 0 {
+		auth.GasTipCap = minGasTipCap
+	}
+	t.capFees(auth.GasFeeCap, auth.GasTipCap)
+}
+
+// sendRollupForgeBatch sends the forgeBatch ethereum transaction of the batch.
+// A new transaction uses the nonce accNextNonce, and a resend reuses the nonce
+// of the last transaction sent for the batch so that it replaces it.  A new
+// transaction fails while the Coordinator has paused forging, or if the batch
+// doesn't use the verifier selected for the circuit.
+func (t *TxManager) sendRollupForgeBatch(ctx context.Context, batchInfo *BatchInfo,
+	resend bool) error {
+	// A resend replaces a tx that was already sent, so only the new
+	// batches are held while forging is paused
+	if t.coord != nil && !resend {
+		verifierIdx, err := t.coord.canForge()
+		if err != nil {
+			return common.Wrap(err)
+		}
+		if verifierIdx != batchInfo.ForgeBatchArgs.VerifierIdx {
+			return common.Wrap(fmt.Errorf("batch %v uses verifier %v, but the circuit "+
+				"matches verifier %v", batchInfo.BatchNum,
+				batchInfo.ForgeBatchArgs.VerifierIdx, verifierIdx))
+		}
+	}
+	auth, err := t.NewAuth(ctx, batchInfo)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	auth.Nonce = new(big.Int).SetUint64(t.accNextNonce)
+	if resend {
+		lastTx := batchInfo.EthTxs[len(batchInfo.EthTxs)-1]
+		auth.Nonce = new(big.Int).SetUint64(lastTx.Nonce())
+		t.bumpFees(auth, lastTx)
+	}
+	var ethTx *types.Transaction
+	for attempt := 0; attempt < t.cfg.EthClientAttempts; attempt++ {
+		ethTx, err = t.ethClient.RollupForgeBatch(batchInfo.ForgeBatchArgs, auth)
+		// The errors are compared as strings because the ones of geth
+		// are received via RPC
+		if err == nil {
+			break
+		} else if !resend && strings.Contains(err.Error(), core.ErrNonceTooLow.Error()) {
+			log.Warnw("TxManager ethClient.RollupForgeBatch incrementing nonce",
+				"err", err, "nonce", auth.Nonce, "batchNum", batchInfo.BatchNum)
+			auth.Nonce.Add(auth.Nonce, big.NewInt(1))
+			attempt--
+		} else {
+			log.Errorw("TxManager ethClient.RollupForgeBatch",
+				"attempt", attempt, "err", err, "block", t.stats.Eth.LastBlock.Num+1,
+				"batchNum", batchInfo.BatchNum)
+		}
+		select {
+		case <-ctx.Done():
+			return common.Wrap(common.ErrDone)
+		case <-time.After(t.cfg.EthClientAttemptsDelay):
+		}
+	}
+	if err != nil {
+		return common.Wrap(fmt.Errorf("reached max attempts for ethClient.RollupForgeBatch: %w", err))
+	}
+	if !resend {
+		t.accNextNonce = auth.Nonce.Uint64() + 1
+	}
+	batchInfo.EthTxs = append(batchInfo.EthTxs, ethTx)
+	batchInfo.EthTxsErrs = append(batchInfo.EthTxsErrs, nil)
+	now := time.Now()
+	batchInfo.SendTimestamp = now
+	batchInfo.Debug.Status = StatusSent
+	batchInfo.Debug.SendBlockNum = t.stats.Eth.LastBlock.Num + 1
+	batchInfo.Debug.SendTimestamp = now
+	batchInfo.Debug.StartToSendDelay = now.Sub(batchInfo.Debug.StartTimestamp).Seconds()
+	if resend {
+		batchInfo.Debug.ResendNum++
+	}
+	log.Infow("TxManager ethClient.RollupForgeBatch", "batch", batchInfo.BatchNum,
+		"tx", ethTx.Hash(), "nonce", ethTx.Nonce(), "resend", resend)
+	return nil
+}
@@ -0,0 +1,311 @@
+package coordinator
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/config"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/etherscan"
+	"tokamak-sybil-resistance/test"
+	"tokamak-sybil-resistance/test/sim"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timer struct {
+	time int64
+}
+
+func (t *timer) Time() int64 {
+	currentTime := t.time
+	t.time++
+	return currentTime
+}
+
+// forgeClient is a test client with a fixed suggested gas price that keeps
+// the account nonce, and rejects the forgeBatch txs with lower nonces
+type forgeClient struct {
+	*test.Client
+	gasPrice  *big.Int
+	gasTipCap *big.Int
+	nonce     uint64
+}
+
+func (c *forgeClient) EthSuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(c.gasPrice), nil
+}
+
+func (c *forgeClient) EthSuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(c.gasTipCap), nil
+}
+
+func (c *forgeClient) RollupForgeBatch(args *eth.RollupForgeBatchArgs,
+	auth *bind.TransactOpts) (*types.Transaction, error) {
+	if auth.Nonce.Uint64() < c.nonce {
+		return nil, core.ErrNonceTooLow
+	}
+	c.nonce = auth.Nonce.Uint64() + 1
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     auth.Nonce.Uint64(),
+		GasTipCap: auth.GasTipCap,
+		GasFeeCap: auth.GasFeeCap,
+		Gas:       auth.GasLimit,
+	}), nil
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei))
+}
+
+func newTestTxManager(t *testing.T, cfg *Config) (*TxManager, *forgeClient) {
+	return newTestTxManagerEtherscan(t, cfg, nil)
+}
+
+func newTestTxManagerEtherscan(t *testing.T, cfg *Config,
+	etherscanService *etherscan.Service) (*TxManager, *forgeClient) {
+	var timer timer
+	clientSetup := test.NewClientSetupExample()
+	clientSetup.ChainID = big.NewInt(0)
+	client := &forgeClient{
+		Client:    test.NewClient(true, &timer, &ethCommon.Address{}, clientSetup),
+		gasPrice:  gwei(30),
+		gasTipCap: gwei(2),
+	}
+	txManager, err := NewTxManager(context.Background(), cfg, client, nil, nil,
+		&common.SCConsts{}, &common.SCVariables{}, etherscanService)
+	require.NoError(t, err)
+	return txManager, client
+}
+
+func TestTxManagerNewAuth(t *testing.T) {
+	cfg := Config{
+		MinGasPrice:     1,
+		MaxGasPrice:     100,
+		GasPriceIncPerc: 10,
+		ForgeBatchGasCost: config.ForgeBatchGasCost{
+			Fixed:     500000,
+			L1UserTx:  8000,
+			L1CoordTx: 9000,
+			L2Tx:      1000,
+		},
+	}
+	txManager, _ := newTestTxManager(t, &cfg)
+	batchInfo := &BatchInfo{
+		L1UserTxs:  make([]common.L1Tx, 2),
+		L1CoordTxs: make([]common.L1Tx, 1),
+		L2Txs:      make([]common.L2Tx, 5),
+	}
+
+	auth, err := txManager.NewAuth(context.Background(), batchInfo)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(500000+2*8000+9000+5*1000), auth.GasLimit)
+	// (30 gwei + 28 gwei of base fee) + 10%
+	assert.Equal(t, gwei(638), new(big.Int).Mul(auth.GasFeeCap, big.NewInt(10)))
+	assert.Equal(t, gwei(22), new(big.Int).Mul(auth.GasTipCap, big.NewInt(10)))
+	assert.Equal(t, big.NewInt(0), auth.Value)
+	assert.Nil(t, auth.GasPrice)
+
+	// The fee cap is bounded by MaxGasPrice, and the tip by the fee cap
+	txManager.cfg.MaxGasPrice = 50
+	auth, err = txManager.NewAuth(context.Background(), batchInfo)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(50), auth.GasFeeCap)
+	txManager.cfg.MaxGasPrice = 1
+	auth, err = txManager.NewAuth(context.Background(), batchInfo)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(1), auth.GasFeeCap)
+	assert.Equal(t, gwei(1), auth.GasTipCap)
+
+	// And by MinGasPrice
+	txManager.cfg.MaxGasPrice = 500
+	txManager.cfg.MinGasPrice = 200
+	auth, err = txManager.NewAuth(context.Background(), batchInfo)
+	require.NoError(t, err)
+	assert.Equal(t, gwei(200), auth.GasFeeCap)
+}
+
+func TestTxManagerSendRollupForgeBatch(t *testing.T) {
+	cfg := Config{
+		MinGasPrice:       1,
+		MaxGasPrice:       100,
+		EthClientAttempts: 2,
+	}
+	txManager, client := newTestTxManager(t, &cfg)
+	ctx := context.Background()
+
+	batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: &eth.RollupForgeBatchArgs{}}
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	require.Equal(t, 1, len(batchInfo.EthTxs))
+	assert.Equal(t, uint64(0), batchInfo.EthTxs[0].Nonce())
+	assert.Equal(t, uint64(1), txManager.accNextNonce)
+	assert.Equal(t, StatusSent, batchInfo.Debug.Status)
+
+	// A resend replaces the last tx of the batch
+	client.nonce = 0
+	client.gasPrice = gwei(40)
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	require.Equal(t, 2, len(batchInfo.EthTxs))
+	assert.Equal(t, uint64(0), batchInfo.EthTxs[1].Nonce())
+	assert.Equal(t, 1, batchInfo.Debug.ResendNum)
+	assert.Equal(t, uint64(1), txManager.accNextNonce)
+
+	// The nonce is increased when the account has sent txs out of the
+	// TxManager
+	client.nonce = 4
+	batchInfo = &BatchInfo{BatchNum: 2, ForgeBatchArgs: &eth.RollupForgeBatchArgs{}}
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	assert.Equal(t, uint64(4), batchInfo.EthTxs[0].Nonce())
+	assert.Equal(t, uint64(5), txManager.accNextNonce)
+}
+
+// TestTxManagerSim forges a batch in the Sybil contract running in a simulated
+// chain
+func TestTxManagerSim(t *testing.T) {
+	h := sim.NewTest(t, sim.Config{Accounts: 1, MaxTx: 512, NLevels: 32, AutoMine: true})
+	client, err := h.NewClient(h.Accounts[0])
+	require.NoError(t, err)
+	cfg := Config{
+		MinGasPrice:       1,
+		MaxGasPrice:       100,
+		EthClientAttempts: 1,
+		ForgeBatchGasCost: config.ForgeBatchGasCost{Fixed: 1000000},
+	}
+	txManager, err := NewTxManager(context.Background(), &cfg, client, nil, nil,
+		&common.SCConsts{}, &common.SCVariables{}, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: sim.ForgeBatchArgs(255, true)}
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	require.Equal(t, 1, len(batchInfo.EthTxs))
+	receipt, err := client.EthTransactionReceipt(ctx, batchInfo.EthTxs[0].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, types.DynamicFeeTxType, int(batchInfo.EthTxs[0].Type()))
+	lastForgedBatch, err := client.RollupLastForgedBatch()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lastForgedBatch)
+	assert.Equal(t, uint64(1), txManager.accNextNonce)
+}
+
+func TestTxManagerEtherscanGasPricer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":{` +
+			`"LastBlock":"100","SafeGasPrice":"20","ProposeGasPrice":"22",` +
+			`"FastGasPrice":"25","suggestBaseFee":"20"}}`))
+	}))
+	defer server.Close()
+	etherscanService, err := etherscan.NewEtherscanService(server.URL, "key")
+	require.NoError(t, err)
+
+	cfg := Config{
+		MinGasPrice: 1,
+		MaxGasPrice: 100,
+		GasPricer:   eth.GasPricerConfig{Strategy: eth.GasPricerStrategyEtherscan},
+	}
+	_, err = NewTxManager(context.Background(), &cfg, &forgeClient{
+		Client: test.NewClient(true, &timer{}, &ethCommon.Address{},
+			test.NewClientSetupExample()),
+	}, nil, nil, &common.SCConsts{}, &common.SCVariables{}, nil)
+	assert.Error(t, err)
+
+	txManager, _ := newTestTxManagerEtherscan(t, &cfg, etherscanService)
+	auth, err := txManager.NewAuth(context.Background(), &BatchInfo{})
+	require.NoError(t, err)
+	// 2 * 20 gwei of base fee + 2 gwei of tip
+	assert.Equal(t, gwei(42), auth.GasFeeCap)
+	assert.Equal(t, gwei(2), auth.GasTipCap)
+}
+
+func TestTxManagerResendBumpsFees(t *testing.T) {
+	cfg := Config{
+		MinGasPrice:       1,
+		MaxGasPrice:       100,
+		GasPriceIncPerc:   10,
+		EthClientAttempts: 1,
+	}
+	txManager, client := newTestTxManager(t, &cfg)
+	ctx := context.Background()
+
+	batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: &eth.RollupForgeBatchArgs{}}
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	tx := batchInfo.EthTxs[0]
+	// (30 gwei + 28 gwei of base fee) + 10%
+	assert.Equal(t, gwei(638), new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(10)))
+	assert.Equal(t, gwei(22), new(big.Int).Mul(tx.GasTipCap(), big.NewInt(10)))
+
+	// With the same suggested fees, the replacement raises the fees of the
+	// replaced tx by GasPriceIncPerc
+	client.nonce = 0
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	tx = batchInfo.EthTxs[1]
+	assert.Equal(t, gwei(7018), new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(100)))
+	assert.Equal(t, gwei(242), new(big.Int).Mul(tx.GasTipCap(), big.NewInt(100)))
+
+	// Higher suggested fees are used as they are, up to MaxGasPrice
+	client.nonce = 0
+	client.gasPrice = gwei(40)
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	tx = batchInfo.EthTxs[2]
+	// (40 gwei + 38 gwei of base fee) + 10%
+	assert.Equal(t, gwei(858), new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(10)))
+	client.nonce = 0
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	assert.Equal(t, gwei(9438), new(big.Int).Mul(batchInfo.EthTxs[3].GasFeeCap(),
+		big.NewInt(100)))
+	client.nonce = 0
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	assert.Equal(t, gwei(100), batchInfo.EthTxs[4].GasFeeCap())
+
+	// The replacement raises the fees by 10% even if GasPriceIncPerc is
+	// lower
+	txManager.cfg.GasPriceIncPerc = 0
+	txManager.cfg.MaxGasPrice = 1000
+	client.nonce = 0
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	assert.Equal(t, gwei(110), batchInfo.EthTxs[5].GasFeeCap())
+}
+
+// TestTxManagerSimResend replaces a pending forgeBatch tx in a simulated chain
+// with GasPriceIncPerc 0, which geth would reject without the minimum 10%
+// increase of the fees
+func TestTxManagerSimResend(t *testing.T) {
+	h := sim.NewTest(t, sim.Config{Accounts: 1, MaxTx: 512, NLevels: 32})
+	client, err := h.NewClient(h.Accounts[0])
+	require.NoError(t, err)
+	cfg := Config{
+		MinGasPrice:       1,
+		MaxGasPrice:       100,
+		EthClientAttempts: 1,
+		ForgeBatchGasCost: config.ForgeBatchGasCost{Fixed: 1000000},
+	}
+	txManager, err := NewTxManager(context.Background(), &cfg, client, nil, nil,
+		&common.SCConsts{}, &common.SCVariables{}, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	batchInfo := &BatchInfo{BatchNum: 1, ForgeBatchArgs: sim.ForgeBatchArgs(255, true)}
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, false))
+	require.NoError(t, txManager.sendRollupForgeBatch(ctx, batchInfo, true))
+	require.Equal(t, 2, len(batchInfo.EthTxs))
+	assert.Equal(t, batchInfo.EthTxs[0].Nonce(), batchInfo.EthTxs[1].Nonce())
+	h.Backend.Commit()
+
+	receipt, err := client.EthTransactionReceipt(ctx, batchInfo.EthTxs[1].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	lastForgedBatch, err := client.RollupLastForgedBatch()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lastForgedBatch)
+	assert.Equal(t, uint64(1), txManager.accNextNonce)
+}
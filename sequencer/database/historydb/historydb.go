@@ -2,6 +2,7 @@ package historydb
 
 import (
 	"math/big"
+	"sync"
 	"tokamak-sybil-resistance/common"
 	"tokamak-sybil-resistance/database"
 
@@ -11,11 +12,16 @@ import (
 	"github.com/russross/meddler"
 )
 
-// HistoryDB persist the historic of the rollup
+// HistoryDB persist the historic of the rollup.  All the reads required by the
+// synchronizer and the coordinator for correctness are done against dbWrite,
+// while the explorer reads go to dbRead only when the replica lag is under the
+// configured threshold (see replica.go).
 type HistoryDB struct {
 	dbRead     *sqlx.DB
 	dbWrite    *sqlx.DB
 	apiConnCon *database.APIConnectionController
+	replica    replicaState
+	replicaRw  sync.RWMutex
 }
 
 // NewHistoryDB initialize the DB
@@ -24,6 +30,9 @@ func NewHistoryDB(dbRead, dbWrite *sqlx.DB, apiConnCon *database.APIConnectionCo
 		dbRead:     dbRead,
 		dbWrite:    dbWrite,
 		apiConnCon: apiConnCon,
+		replica: replicaState{
+			maxLag: DefaultMaxReplicaLag,
+		},
 	}
 }
 
@@ -43,7 +52,7 @@ func (hdb *HistoryDB) addBlock(d meddler.DB, block *common.Block) error {
 func (hdb *HistoryDB) GetBlock(blockNum int64) (*common.Block, error) {
 	block := &common.Block{}
 	err := meddler.QueryRow(
-		hdb.dbRead, block,
+		hdb.dbWrite, block,
 		"SELECT * FROM block WHERE eth_block_num = $1;", blockNum,
 	)
 	return block, common.Wrap(err)
@@ -53,7 +62,7 @@ func (hdb *HistoryDB) GetBlock(blockNum int64) (*common.Block, error) {
 func (hdb *HistoryDB) GetAllBlocks() ([]common.Block, error) {
 	var blocks []*common.Block
 	err := meddler.QueryAll(
-		hdb.dbRead, &blocks,
+		hdb.dbAPI(), &blocks,
 		"SELECT * FROM block ORDER BY eth_block_num;",
 	)
 	return database.SlicePtrsToSlice(blocks).([]common.Block), common.Wrap(err)
@@ -63,7 +72,7 @@ func (hdb *HistoryDB) GetAllBlocks() ([]common.Block, error) {
 func (hdb *HistoryDB) GetLastBlock() (*common.Block, error) {
 	block := &common.Block{}
 	err := meddler.QueryRow(
-		hdb.dbRead, block, "SELECT * FROM block ORDER BY eth_block_num DESC LIMIT 1;",
+		hdb.dbWrite, block, "SELECT * FROM block ORDER BY eth_block_num DESC LIMIT 1;",
 	)
 	return block, common.Wrap(err)
 }
@@ -72,7 +81,7 @@ func (hdb *HistoryDB) GetLastBlock() (*common.Block, error) {
 func (hdb *HistoryDB) getBlocks(from, to int64) ([]common.Block, error) {
 	var blocks []*common.Block
 	err := meddler.QueryAll(
-		hdb.dbRead, &blocks,
+		hdb.dbWrite, &blocks,
 		"SELECT * FROM block WHERE $1 <= eth_block_num AND eth_block_num < $2 ORDER BY eth_block_num;",
 		from, to,
 	)
@@ -125,7 +134,7 @@ func (hdb *HistoryDB) addCoordinators(d meddler.DB, coordinators []common.Coordi
 func (hdb *HistoryDB) GetLastBatch() (*common.Batch, error) {
 	var batch common.Batch
 	err := meddler.QueryRow(
-		hdb.dbRead, &batch, `SELECT batch.batch_num, batch.eth_block_num, batch.forger_addr,
+		hdb.dbWrite, &batch, `SELECT batch.batch_num, batch.eth_block_num, batch.forger_addr,
 		batch.fees_collected, batch.fee_idxs_coordinator, batch.state_root,
 		batch.num_accounts, batch.last_idx, batch.exit_root, batch.forge_l1_txs_num,
 		batch.slot_num, batch.total_fees_usd, batch.gas_price, batch.gas_used, batch.ether_price_usd
@@ -136,7 +145,7 @@ func (hdb *HistoryDB) GetLastBatch() (*common.Batch, error) {
 
 // GetLastL1BatchBlockNum returns the blockNum of the latest forged l1Batch
 func (hdb *HistoryDB) GetLastL1BatchBlockNum() (int64, error) {
-	row := hdb.dbRead.QueryRow(`SELECT eth_block_num FROM batch
+	row := hdb.dbWrite.QueryRow(`SELECT eth_block_num FROM batch
 		WHERE forge_l1_txs_num IS NOT NULL
 		ORDER BY batch_num DESC LIMIT 1;`)
 	var blockNum int64
@@ -146,7 +155,7 @@ func (hdb *HistoryDB) GetLastL1BatchBlockNum() (int64, error) {
 // GetLastL1TxsNum returns the greatest ForgeL1TxsNum in the DB from forged
 // batches.  If there's no batch in the DB (nil, nil) is returned.
 func (hdb *HistoryDB) GetLastL1TxsNum() (*int64, error) {
-	row := hdb.dbRead.QueryRow("SELECT MAX(forge_l1_txs_num) FROM batch;")
+	row := hdb.dbWrite.QueryRow("SELECT MAX(forge_l1_txs_num) FROM batch;")
 	lastL1TxsNum := new(int64)
 	return lastL1TxsNum, common.Wrap(row.Scan(&lastL1TxsNum))
 }
@@ -168,7 +177,13 @@ func (hdb *HistoryDB) Reorg(lastValidBlock int64) error {
 func (hdb *HistoryDB) AddBatch(batch *common.Batch) error { return hdb.addBatch(hdb.dbWrite, batch) }
 func (hdb *HistoryDB) addBatch(d meddler.DB, batch *common.Batch) error {
 	// Insert to DB
-	return common.Wrap(meddler.Insert(d, "batch", batch))
+	if err := meddler.Insert(d, "batch", batch); err != nil {
+		return common.Wrap(err)
+	}
+	if hdb.isSQLite() {
+		return common.Wrap(forgeL1UserTxsSQLite(d, batch))
+	}
+	return nil
 }
 
 // AddBatches insert Bids into the DB
@@ -188,7 +203,7 @@ func (hdb *HistoryDB) addBatches(d meddler.DB, batches []common.Batch) error {
 func (hdb *HistoryDB) GetAllBatches() ([]common.Batch, error) {
 	var batches []*common.Batch
 	err := meddler.QueryAll(
-		hdb.dbRead, &batches,
+		hdb.dbAPI(), &batches,
 		`SELECT batch.batch_num, batch.eth_block_num, batch.forger_addr, batch.fees_collected,
 		 batch.fee_idxs_coordinator, batch.state_root, batch.num_accounts, batch.last_idx, batch.exit_root,
 		 batch.forge_l1_txs_num, batch.slot_num, batch.total_fees_usd, batch.eth_tx_hash FROM batch
@@ -201,7 +216,7 @@ func (hdb *HistoryDB) GetAllBatches() ([]common.Batch, error) {
 func (hdb *HistoryDB) GetBatches(from, to common.BatchNum) ([]common.Batch, error) {
 	var batches []*common.Batch
 	err := meddler.QueryAll(
-		hdb.dbRead, &batches,
+		hdb.dbAPI(), &batches,
 		`SELECT batch_num, eth_block_num, forger_addr, fees_collected, fee_idxs_coordinator, 
 		state_root, num_accounts, last_idx, exit_root, forge_l1_txs_num, slot_num, total_fees_usd, gas_price, gas_used, ether_price_usd 
 		FROM batch WHERE $1 <= batch_num AND batch_num < $2 ORDER BY batch_num;`,
@@ -212,7 +227,16 @@ func (hdb *HistoryDB) GetBatches(from, to common.BatchNum) ([]common.Batch, erro
 
 // GetLastBatchNum returns the BatchNum of the latest forged batch
 func (hdb *HistoryDB) GetLastBatchNum() (common.BatchNum, error) {
-	row := hdb.dbRead.QueryRow("SELECT batch_num FROM batch ORDER BY batch_num DESC LIMIT 1;")
+	row := hdb.dbWrite.QueryRow("SELECT batch_num FROM batch ORDER BY batch_num DESC LIMIT 1;")
+	var batchNum common.BatchNum
+	return batchNum, common.Wrap(row.Scan(&batchNum))
+}
+
+// GetLastBatchNumByBlock returns the BatchNum of the latest batch forged in a
+// block up to blockNum (included), or 0 if there is none
+func (hdb *HistoryDB) GetLastBatchNumByBlock(blockNum int64) (common.BatchNum, error) {
+	row := hdb.dbWrite.QueryRow(
+		"SELECT COALESCE(MAX(batch_num), 0) FROM batch WHERE eth_block_num <= $1;", blockNum)
 	var batchNum common.BatchNum
 	return batchNum, common.Wrap(row.Scan(&batchNum))
 }
@@ -221,7 +245,7 @@ func (hdb *HistoryDB) GetLastBatchNum() (common.BatchNum, error) {
 func (hdb *HistoryDB) GetBatch(batchNum common.BatchNum) (*common.Batch, error) {
 	var batch common.Batch
 	err := meddler.QueryRow(
-		hdb.dbRead, &batch, `SELECT batch.batch_num, batch.eth_block_num, batch.forger_addr,
+		hdb.dbWrite, &batch, `SELECT batch.batch_num, batch.eth_block_num, batch.forger_addr,
 		batch.fees_collected, batch.fee_idxs_coordinator, batch.state_root,
 		batch.num_accounts, batch.last_idx, batch.exit_root, batch.forge_l1_txs_num,
 		batch.slot_num, batch.total_fees_usd, batch.gas_price, batch.gas_used, batch.ether_price_usd
@@ -295,7 +319,7 @@ func (hdb *HistoryDB) addAccounts(d meddler.DB, accounts []common.Account) error
 func (hdb *HistoryDB) GetAllAccounts() ([]common.Account, error) {
 	var accs []*common.Account
 	err := meddler.QueryAll(
-		hdb.dbRead, &accs,
+		hdb.dbAPI(), &accs,
 		"SELECT idx, batch_num, bjj, eth_addr FROM account ORDER BY idx;",
 	)
 	return database.SlicePtrsToSlice(accs).([]common.Account), common.Wrap(err)
@@ -418,7 +442,12 @@ func (hdb *HistoryDB) addTxs(d meddler.DB, txs []txWrite) error {
 	if len(txs) == 0 {
 		return nil
 	}
-	return common.Wrap(database.BulkInsert(
+	if hdb.isSQLite() {
+		if err := beforeInsertTxsSQLite(txs); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	if err := database.BulkInsert(
 		d,
 		`INSERT INTO tx (
 			is_l1,
@@ -444,14 +473,20 @@ func (hdb *HistoryDB) addTxs(d meddler.DB, txs []txWrite) error {
 			nonce
 		) VALUES %s;`,
 		txs,
-	))
+	); err != nil {
+		return common.Wrap(err)
+	}
+	if hdb.isSQLite() {
+		return common.Wrap(afterInsertTxsSQLite(d, txs))
+	}
+	return nil
 }
 
 // GetAllExits returns all exit from the DB
 func (hdb *HistoryDB) GetAllExits() ([]common.ExitInfo, error) {
 	var exits []*common.ExitInfo
 	err := meddler.QueryAll(
-		hdb.dbRead, &exits,
+		hdb.dbAPI(), &exits,
 		`SELECT exit_tree.batch_num, exit_tree.account_idx, exit_tree.merkle_proof,
 		exit_tree.balance, exit_tree.instant_withdrawn, exit_tree.delayed_withdraw_request,
 		exit_tree.delayed_withdrawn FROM exit_tree ORDER BY item_id;`,
@@ -463,7 +498,7 @@ func (hdb *HistoryDB) GetAllExits() ([]common.ExitInfo, error) {
 func (hdb *HistoryDB) GetAllL1UserTxs() ([]common.L1Tx, error) {
 	var txs []*common.L1Tx
 	err := meddler.QueryAll(
-		hdb.dbRead, &txs,
+		hdb.dbAPI(), &txs,
 		`SELECT tx.id, tx.to_forge_l1_txs_num, tx.position, tx.user_origin,
 		tx.from_idx, tx.effective_from_idx, tx.from_eth_addr, tx.from_bjj, tx.to_idx,
 		tx.amount, (CASE WHEN tx.batch_num IS NULL THEN NULL WHEN tx.amount_success THEN tx.amount ELSE 0 END) AS effective_amount,
@@ -480,7 +515,7 @@ func (hdb *HistoryDB) GetAllL1CoordinatorTxs() ([]common.L1Tx, error) {
 	// Since the query specifies that only coordinator txs are returned, it's safe to assume
 	// that returned txs will always have effective amounts
 	err := meddler.QueryAll(
-		hdb.dbRead, &txs,
+		hdb.dbAPI(), &txs,
 		`SELECT tx.id, tx.to_forge_l1_txs_num, tx.position, tx.user_origin,
 		tx.from_idx, tx.effective_from_idx, tx.from_eth_addr, tx.from_bjj, tx.to_idx,
 		tx.amount, tx.amount AS effective_amount,
@@ -495,7 +530,7 @@ func (hdb *HistoryDB) GetAllL1CoordinatorTxs() ([]common.L1Tx, error) {
 func (hdb *HistoryDB) GetAllL2Txs() ([]common.L2Tx, error) {
 	var txs []*common.L2Tx
 	err := meddler.QueryAll(
-		hdb.dbRead, &txs,
+		hdb.dbAPI(), &txs,
 		`SELECT tx.id, tx.batch_num, tx.position,
 		tx.from_idx, tx.to_idx, tx.amount,
 		tx.nonce, tx.type, tx.eth_block_num
@@ -504,11 +539,62 @@ func (hdb *HistoryDB) GetAllL2Txs() ([]common.L2Tx, error) {
 	return database.SlicePtrsToSlice(txs).([]common.L2Tx), common.Wrap(err)
 }
 
+// GetBatchL1UserTxs returns the L1UserTxs forged in the given batch, sorted by
+// position.  The effective amounts are not returned, so that the txs can be
+// processed again.
+func (hdb *HistoryDB) GetBatchL1UserTxs(batchNum common.BatchNum) ([]common.L1Tx, error) {
+	var txs []*common.L1Tx
+	err := meddler.QueryAll(
+		hdb.dbWrite, &txs,
+		`SELECT tx.id, tx.to_forge_l1_txs_num, tx.position, tx.user_origin,
+		tx.from_idx, tx.from_eth_addr, tx.from_bjj, tx.to_idx,
+		tx.amount, NULL AS effective_amount,
+		tx.deposit_amount, NULL AS effective_deposit_amount,
+		tx.eth_block_num, tx.type, tx.batch_num
+		FROM tx WHERE batch_num = $1 AND is_l1 = TRUE AND user_origin = TRUE
+		ORDER BY position;`,
+		batchNum,
+	)
+	return database.SlicePtrsToSlice(txs).([]common.L1Tx), common.Wrap(err)
+}
+
+// GetBatchL1CoordinatorTxs returns the L1CoordinatorTxs forged in the given
+// batch, sorted by position
+func (hdb *HistoryDB) GetBatchL1CoordinatorTxs(batchNum common.BatchNum) ([]common.L1Tx, error) {
+	var txs []*common.L1Tx
+	err := meddler.QueryAll(
+		hdb.dbWrite, &txs,
+		`SELECT tx.id, tx.to_forge_l1_txs_num, tx.position, tx.user_origin,
+		tx.from_idx, tx.effective_from_idx, tx.from_eth_addr, tx.from_bjj, tx.to_idx,
+		tx.amount, tx.amount AS effective_amount,
+		tx.deposit_amount, tx.deposit_amount AS effective_deposit_amount,
+		tx.eth_block_num, tx.type, tx.batch_num
+		FROM tx WHERE batch_num = $1 AND is_l1 = TRUE AND user_origin = FALSE
+		ORDER BY position;`,
+		batchNum,
+	)
+	return database.SlicePtrsToSlice(txs).([]common.L1Tx), common.Wrap(err)
+}
+
+// GetBatchL2Txs returns the L2Txs forged in the given batch, sorted by position
+func (hdb *HistoryDB) GetBatchL2Txs(batchNum common.BatchNum) ([]common.L2Tx, error) {
+	var txs []*common.L2Tx
+	err := meddler.QueryAll(
+		hdb.dbWrite, &txs,
+		`SELECT tx.id, tx.batch_num, tx.position,
+		tx.from_idx, tx.to_idx, tx.amount,
+		tx.nonce, tx.type, tx.eth_block_num
+		FROM tx WHERE batch_num = $1 AND is_l1 = FALSE ORDER BY position;`,
+		batchNum,
+	)
+	return database.SlicePtrsToSlice(txs).([]common.L2Tx), common.Wrap(err)
+}
+
 // GetUnforgedL1UserTxs gets L1 User Txs to be forged in the L1Batch with toForgeL1TxsNum.
 func (hdb *HistoryDB) GetUnforgedL1UserTxs(toForgeL1TxsNum int64) ([]common.L1Tx, error) {
 	var txs []*common.L1Tx
 	err := meddler.QueryAll(
-		hdb.dbRead, &txs, // only L1 user txs can have batch_num set to null
+		hdb.dbWrite, &txs, // only L1 user txs can have batch_num set to null
 		`SELECT tx.id, tx.to_forge_l1_txs_num, tx.position, tx.user_origin,
 		tx.from_idx, tx.from_eth_addr, tx.from_bjj, tx.to_idx,
 		tx.amount, NULL AS effective_amount,
@@ -524,7 +610,7 @@ func (hdb *HistoryDB) GetUnforgedL1UserTxs(toForgeL1TxsNum int64) ([]common.L1Tx
 // GetSCVars returns the rollup, auction and wdelayer smart contracts variables at their last update.
 func (hdb *HistoryDB) GetSCVars() (*common.RollupVariables, error) {
 	var rollup common.RollupVariables
-	if err := meddler.QueryRow(hdb.dbRead, &rollup,
+	if err := meddler.QueryRow(hdb.dbWrite, &rollup,
 		"SELECT * FROM rollup_vars ORDER BY eth_block_num DESC LIMIT 1;"); err != nil {
 		return nil, common.Wrap(err)
 	}
@@ -574,6 +660,15 @@ func (hdb *HistoryDB) setExtraInfoForgedL1UserTxs(d sqlx.Ext, txs []common.L1Tx)
 		) as tx_update (id, amount_success, deposit_amount_success, effective_from_idx)
 		WHERE tx.id = tx_update.id;
 	`
+	if len(txUpdates) > 0 && hdb.isSQLite() {
+		for i := range txUpdates {
+			if _, err := sqlx.NamedExec(d, querySetExtraInfoForgedL1UserTxSQLite,
+				txUpdates[i]); err != nil {
+				return common.Wrap(err)
+			}
+		}
+		return nil
+	}
 	if len(txUpdates) > 0 {
 		if _, err := sqlx.NamedExec(d, query, txUpdates); err != nil {
 			return common.Wrap(err)
@@ -607,11 +702,6 @@ func (hdb *HistoryDB) AddBlockSCData(blockData *common.BlockData) (err error) {
 	// 	return common.Wrap(err)
 	// }
 
-	// // Add Bids
-	// if err := hdb.addBids(txn, blockData.Auction.Bids); err != nil {
-	// 	return common.Wrap(err)
-	// }
-
 	// // Add Tokens
 	// if err := hdb.addTokens(txn, blockData.Rollup.AddedTokens); err != nil {
 	// 	return common.Wrap(err)
@@ -713,16 +803,5 @@ func (hdb *HistoryDB) AddBlockSCData(blockData *common.BlockData) (err error) {
 	// 	return common.Wrap(err)
 	// }
 
-	// // Add Escape Hatch Withdrawals
-	// if err := hdb.addEscapeHatchWithdrawals(txn,
-	// 	blockData.WDelayer.EscapeHatchWithdrawals); err != nil {
-	// 	return common.Wrap(err)
-	// }
-
-	// // Add Buckets withdrawals updates
-	// if err := hdb.addBucketUpdates(txn, blockData.Rollup.UpdateBucketWithdraw); err != nil {
-	// 	return common.Wrap(err)
-	// }
-
 	return common.Wrap(txn.Commit())
 }
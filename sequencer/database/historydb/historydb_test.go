@@ -13,6 +13,7 @@ import (
 	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/jmoiron/sqlx"
+	"github.com/russross/meddler"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -203,6 +204,109 @@ func TestBatches(t *testing.T) {
 	assert.Equal(t, sql.ErrNoRows, common.Unwrap(err))
 }
 
+func TestL1UserTxsEffectiveAmounts(t *testing.T) {
+	// Reset DB
+	WipeDB(historyDB.DB())
+	set := `
+		Type: Blockchain
+
+		CreateAccountDeposit A: 2000
+		CreateAccountDeposit B: 1000
+		> batchL1
+		> batchL1
+		> block
+	`
+	tc := til.NewContext(uint16(0), common.RollupConstMaxL1UserTx)
+	blocks, err := tc.GenerateBlocks(set)
+	require.NoError(t, err)
+	require.NoError(t, tc.FillBlocksExtra(blocks, &til.ConfigExtra{}))
+	require.NoError(t, tc.FillBlocksForgedL1UserTxs(blocks))
+	for i := range blocks {
+		require.NoError(t, historyDB.AddBlockSCData(&blocks[i]))
+	}
+
+	// The deposit of B is not effective
+	forgedTxs := blocks[0].Rollup.Batches[1].L1UserTxs
+	require.Equal(t, 2, len(forgedTxs))
+	forgedTxs[1].EffectiveDepositAmount = big.NewInt(0)
+	require.NoError(t, historyDB.setExtraInfoForgedL1UserTxs(historyDB.dbWrite, forgedTxs))
+
+	dbTxs, err := historyDB.GetAllL1UserTxs()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(dbTxs))
+	for i := range dbTxs {
+		assert.Equal(t, forgedTxs[i].TxID, dbTxs[i].TxID)
+		assert.Equal(t, forgedTxs[i].EffectiveFromIdx, dbTxs[i].EffectiveFromIdx)
+		assert.Equal(t, big.NewInt(0), dbTxs[i].EffectiveAmount)
+		assert.Equal(t, forgedTxs[i].EffectiveDepositAmount, dbTxs[i].EffectiveDepositAmount)
+	}
+	assert.Equal(t, big.NewInt(2000), dbTxs[0].EffectiveDepositAmount)
+}
+
+func TestReplicaLag(t *testing.T) {
+	// Use two independent in-memory DBs as write server and read replica
+	defaultMeddler := meddler.Default
+	defer func() { meddler.Default = defaultMeddler }()
+	dbWrite, err := database.InitSQLiteDB(database.SQLiteMemory)
+	require.NoError(t, err)
+	defer dbWrite.Close() //nolint:errcheck
+	dbRead, err := database.InitSQLiteDB(database.SQLiteMemory)
+	require.NoError(t, err)
+	hdb := NewHistoryDB(dbRead, dbWrite, nil)
+	hdb.SetMaxReplicaLag(1)
+
+	// Until the lag is measured the explorer reads go to the write server
+	assert.Equal(t, dbWrite, hdb.dbAPI())
+	lag, err := hdb.UpdateReplicaLag()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), lag)
+	assert.Equal(t, dbRead, hdb.dbAPI())
+
+	addBlock := func(db *sqlx.DB, blockNum int64) {
+		_, err := db.Exec("INSERT INTO block (eth_block_num, timestamp, hash) VALUES ($1, $2, $3);",
+			blockNum, time.Now(), ethCommon.BigToHash(big.NewInt(blockNum)))
+		require.NoError(t, err)
+	}
+	// A lag under the threshold keeps using the replica
+	addBlock(dbWrite, 1)
+	lag, err = hdb.UpdateReplicaLag()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lag)
+	assert.Equal(t, dbRead, hdb.dbAPI())
+
+	// A lag over the threshold falls back to the write server, which is
+	// used for the explorer reads
+	addBlock(dbWrite, 2)
+	lag, err = hdb.UpdateReplicaLag()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lag)
+	assert.Equal(t, dbWrite, hdb.dbAPI())
+	blocks, err := hdb.GetAllBlocks()
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(blocks))
+	fetchedLag, healthy := hdb.ReplicaLag()
+	assert.Equal(t, int64(2), fetchedLag)
+	assert.False(t, healthy)
+
+	// The replica is used again once it catches up
+	addBlock(dbRead, 1)
+	addBlock(dbRead, 2)
+	_, err = hdb.UpdateReplicaLag()
+	require.NoError(t, err)
+	assert.Equal(t, dbRead, hdb.dbAPI())
+	blocks, err = hdb.GetAllBlocks()
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(blocks))
+
+	// If the lag can't be measured the reads fall back to the write server
+	require.NoError(t, dbRead.Close())
+	_, err = hdb.UpdateReplicaLag()
+	require.Error(t, err)
+	assert.Equal(t, dbWrite, hdb.dbAPI())
+	_, healthy = hdb.ReplicaLag()
+	assert.False(t, healthy)
+}
+
 func assertEqualBlock(t *testing.T, expected *common.Block, actual *common.Block) {
 	assert.Equal(t, expected.Num, actual.Num)
 	assert.Equal(t, expected.Hash, actual.Hash)
@@ -5,6 +5,7 @@ import (
 	"tokamak-sybil-resistance/common"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
 	"github.com/russross/meddler"
 )
 
@@ -91,6 +92,10 @@ func (hdb *HistoryDB) SetNodeConfig(nodeConfig *NodeConfig) error {
 
 // SetConstants sets the Constants
 func (hdb *HistoryDB) SetConstants(constants *Constants) error {
+	return setConstants(hdb.dbWrite, constants)
+}
+
+func setConstants(d sqlx.Execer, constants *Constants) error {
 	_constants := struct {
 		Constants *Constants `meddler:"constants,json"`
 	}{constants}
@@ -98,7 +103,7 @@ func (hdb *HistoryDB) SetConstants(constants *Constants) error {
 	if err != nil {
 		return common.Wrap(err)
 	}
-	_, err = hdb.dbWrite.Exec(
+	_, err = d.Exec(
 		"UPDATE node_info SET constants = $1 WHERE item_id = 1;",
 		values[0],
 	)
@@ -109,7 +114,7 @@ func (hdb *HistoryDB) SetConstants(constants *Constants) error {
 func (hdb *HistoryDB) GetConstants() (*Constants, error) {
 	var nodeInfo NodeInfo
 	err := meddler.QueryRow(
-		hdb.dbRead, &nodeInfo,
+		hdb.dbAPI(), &nodeInfo,
 		"SELECT constants FROM node_info WHERE item_id = 1;",
 	)
 	return nodeInfo.Constants, common.Wrap(err)
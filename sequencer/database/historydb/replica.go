@@ -0,0 +1,119 @@
+package historydb
+
+import (
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/log"
+	"tokamak-sybil-resistance/metric"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultMaxReplicaLag is the default maximum number of blocks the read
+// replica can be behind the write server before the explorer reads fall back
+// to the write server
+const DefaultMaxReplicaLag = 0
+
+// replicaState keeps the last measured lag of the read replica
+type replicaState struct {
+	// maxLag is the maximum number of blocks the replica can be behind the
+	// writer to be used for explorer reads
+	maxLag int64
+	// lag is the last measured lag in blocks
+	lag int64
+	// healthy is true when the last lag check succeeded and the lag was
+	// under maxLag
+	healthy bool
+}
+
+// SetMaxReplicaLag sets the maximum number of blocks the read replica can be
+// behind the write server to be used for explorer reads
+func (hdb *HistoryDB) SetMaxReplicaLag(maxLag int64) {
+	hdb.replicaRw.Lock()
+	defer hdb.replicaRw.Unlock()
+	hdb.replica.maxLag = maxLag
+}
+
+// hasReplica returns true if the read and write connections are different
+func (hdb *HistoryDB) hasReplica() bool {
+	return hdb.dbRead != hdb.dbWrite
+}
+
+// dbAPI returns the connection that must be used for explorer reads: the read
+// replica if it's in sync with the write server, and the write server
+// otherwise
+func (hdb *HistoryDB) dbAPI() *sqlx.DB {
+	if !hdb.hasReplica() {
+		return hdb.dbWrite
+	}
+	hdb.replicaRw.RLock()
+	defer hdb.replicaRw.RUnlock()
+	if hdb.replica.healthy {
+		return hdb.dbRead
+	}
+	return hdb.dbWrite
+}
+
+// lastBlockNum returns the highest block number stored in the given DB, or 0
+// if there are no blocks
+func lastBlockNum(db *sqlx.DB) (int64, error) {
+	var blockNum int64
+	row := db.QueryRow("SELECT COALESCE(MAX(eth_block_num), 0) FROM block;")
+	return blockNum, common.Wrap(row.Scan(&blockNum))
+}
+
+// UpdateReplicaLag measures the lag of the read replica by comparing its last
+// block with the last block of the write server, and updates the connection
+// used for explorer reads accordingly.  If the lag can't be measured, explorer
+// reads fall back to the write server.  Returns the measured lag in blocks.
+func (hdb *HistoryDB) UpdateReplicaLag() (int64, error) {
+	if !hdb.hasReplica() {
+		metric.ReplicaLagBlocks.Set(0)
+		return 0, nil
+	}
+	writeBlockNum, err := lastBlockNum(hdb.dbWrite)
+	if err != nil {
+		hdb.setReplicaUnhealthy()
+		return 0, common.Wrap(err)
+	}
+	readBlockNum, err := lastBlockNum(hdb.dbRead)
+	if err != nil {
+		hdb.setReplicaUnhealthy()
+		return 0, common.Wrap(err)
+	}
+	lag := writeBlockNum - readBlockNum
+	if lag < 0 {
+		// The replica can momentarily be ahead of the writer after a
+		// reorg deletes blocks in the writer
+		lag = 0
+	}
+	metric.ReplicaLagBlocks.Set(float64(lag))
+
+	hdb.replicaRw.Lock()
+	defer hdb.replicaRw.Unlock()
+	healthy := lag <= hdb.replica.maxLag
+	if hdb.replica.healthy != healthy {
+		log.Infow("HistoryDB read replica status changed",
+			"healthy", healthy, "lag", lag, "maxLag", hdb.replica.maxLag)
+	}
+	hdb.replica.lag = lag
+	hdb.replica.healthy = healthy
+	return lag, nil
+}
+
+// setReplicaUnhealthy makes the explorer reads fall back to the write server
+func (hdb *HistoryDB) setReplicaUnhealthy() {
+	hdb.replicaRw.Lock()
+	defer hdb.replicaRw.Unlock()
+	if hdb.replica.healthy {
+		log.Warn("HistoryDB read replica unavailable, falling back to write server")
+	}
+	hdb.replica.healthy = false
+}
+
+// ReplicaLag returns the last measured lag in blocks of the read replica and
+// whether it's currently used for explorer reads
+func (hdb *HistoryDB) ReplicaLag() (int64, bool) {
+	hdb.replicaRw.RLock()
+	defer hdb.replicaRw.RUnlock()
+	return hdb.replica.lag, hdb.replica.healthy
+}
@@ -0,0 +1,289 @@
+package historydb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrInvalidSnapshotTable is returned when a Snapshot contains a table that
+// is not exported in snapshots, or a column that the table doesn't have
+var ErrInvalidSnapshotTable = errors.New("invalid snapshot table")
+
+// Snapshot contains the HistoryDB rows up to the block where a batch was
+// forged, which can be imported in an empty HistoryDB to resume the sync from
+// that block
+type Snapshot struct {
+	BatchNum    common.BatchNum
+	EthBlockNum int64
+	Constants   *Constants
+	Tables      []SnapshotTable
+}
+
+// SnapshotTable contains the exported rows of a HistoryDB table
+type SnapshotTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// snapshotTable describes how to export a table.  In the where clause, $1 is
+// the batch num of the snapshot if byBatch is set, or the eth block num where
+// it was forged otherwise.  The item_id column
+// is not exported, so that the importing DB assigns it; the rows are exported
+// in item_id order to keep their relative order.
+type snapshotTable struct {
+	name    string
+	where   string
+	byBatch bool
+	// fix updates the columns of an exported row that reference data after
+	// the snapshot
+	fix func(row map[string]*interface{}, ethBlockNum int64, batchNum common.BatchNum)
+}
+
+// snapshotTables are the tables exported in a Snapshot, in an order that
+// respects the foreign keys.  The genesis block and token are inserted by the
+// migrations, so they are not exported.
+var snapshotTables = []snapshotTable{
+	{name: "block", where: "eth_block_num > 0 AND eth_block_num <= $1"},
+	{name: "coordinator", where: "eth_block_num <= $1"},
+	{name: "token", where: "eth_block_num > 0 AND eth_block_num <= $1"},
+	{name: "rollup_vars", where: "eth_block_num <= $1"},
+	{name: "batch", where: "batch_num <= $1", byBatch: true},
+	{name: "account", where: "batch_num <= $1", byBatch: true},
+	{name: "account_update", where: "batch_num <= $1", byBatch: true},
+	{
+		name:    "exit_tree",
+		where:   "batch_num <= $1",
+		byBatch: true,
+		fix: func(row map[string]*interface{}, ethBlockNum int64, _ common.BatchNum) {
+			for _, col := range []string{"instant_withdrawn", "delayed_withdraw_request",
+				"delayed_withdrawn"} {
+				if blockNum, ok := (*row[col]).(int64); ok && blockNum > ethBlockNum {
+					*row[col] = nil
+				}
+			}
+		},
+	},
+	{
+		name:  "tx",
+		where: "eth_block_num <= $1",
+		fix: func(row map[string]*interface{}, _ int64, batchNum common.BatchNum) {
+			// L1 user txs added before the snapshot but forged after
+			// it go back to the queue
+			if bn, ok := (*row["batch_num"]).(int64); ok && bn > int64(batchNum) {
+				*row["batch_num"] = nil
+				*row["effective_from_idx"] = nil
+				*row["amount_success"] = true
+				*row["deposit_amount_success"] = true
+			}
+		},
+	},
+}
+
+// ExportSnapshot returns the rows of the HistoryDB up to the block where
+// batchNum was forged.  batchNum must be the last batch forged in its block,
+// since the sync can only be resumed from the end of a block.
+func (hdb *HistoryDB) ExportSnapshot(batchNum common.BatchNum) (*Snapshot, error) {
+	batch, err := hdb.GetBatch(batchNum)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("GetBatch(%v): %w", batchNum, err))
+	}
+	var lastBatchNum common.BatchNum
+	if err := hdb.dbWrite.Get(&lastBatchNum,
+		"SELECT MAX(batch_num) FROM batch WHERE eth_block_num = $1;",
+		batch.EthBlockNum); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if lastBatchNum != batchNum {
+		return nil, common.Wrap(fmt.Errorf(
+			"batch %v is not the last batch of block %v, use batch %v instead",
+			batchNum, batch.EthBlockNum, lastBatchNum))
+	}
+	constants, err := hdb.GetConstants()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	snapshot := &Snapshot{
+		BatchNum:    batchNum,
+		EthBlockNum: batch.EthBlockNum,
+		Constants:   constants,
+	}
+	for _, t := range snapshotTables {
+		table, err := hdb.exportTable(t, batch.EthBlockNum, batchNum)
+		if err != nil {
+			return nil, common.Wrap(fmt.Errorf("table %v: %w", t.name, err))
+		}
+		snapshot.Tables = append(snapshot.Tables, *table)
+	}
+	return snapshot, nil
+}
+
+func (hdb *HistoryDB) exportTable(t snapshotTable, ethBlockNum int64,
+	batchNum common.BatchNum) (*SnapshotTable, error) {
+	order := "item_id"
+	if t.name == "block" || t.name == "rollup_vars" {
+		order = "eth_block_num"
+	}
+	var arg interface{} = ethBlockNum
+	if t.byBatch {
+		arg = batchNum
+	}
+	rows, err := hdb.dbWrite.Queryx(fmt.Sprintf("SELECT * FROM %s WHERE %s ORDER BY %s;",
+		t.name, t.where, order), arg)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer rows.Close() //nolint:errcheck
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	table := &SnapshotTable{Name: t.name}
+	for _, colType := range colTypes {
+		if colType.Name() != "item_id" {
+			table.Columns = append(table.Columns, colType.Name())
+		}
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(colTypes))
+		dest := make([]interface{}, len(colTypes))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, common.Wrap(err)
+		}
+		row := make(map[string]*interface{}, len(colTypes))
+		var out []interface{}
+		for i, colType := range colTypes {
+			// The numeric values are read as text, which must not be
+			// inserted back as binary
+			if b, ok := values[i].([]byte); ok && !isBinaryColumn(colType) {
+				values[i] = string(b)
+			}
+			row[colType.Name()] = &values[i]
+		}
+		if t.fix != nil {
+			t.fix(row, ethBlockNum, batchNum)
+		}
+		for i, colType := range colTypes {
+			if colType.Name() != "item_id" {
+				out = append(out, values[i])
+			}
+		}
+		table.Rows = append(table.Rows, out)
+	}
+	return table, common.Wrap(rows.Err())
+}
+
+func isBinaryColumn(colType *sql.ColumnType) bool {
+	typeName := strings.ToUpper(colType.DatabaseTypeName())
+	return typeName == "BYTEA" || typeName == "BLOB"
+}
+
+// ImportSnapshot inserts the rows of the snapshot in the HistoryDB, which
+// must not have any block synced yet
+func (hdb *HistoryDB) ImportSnapshot(snapshot *Snapshot) (err error) {
+	var nBlocks int
+	if err := hdb.dbWrite.Get(&nBlocks,
+		"SELECT COUNT(*) FROM block WHERE eth_block_num > 0;"); err != nil {
+		return common.Wrap(err)
+	}
+	if nBlocks > 0 {
+		return common.Wrap(fmt.Errorf("can't import a snapshot into a HistoryDB with %v blocks",
+			nBlocks))
+	}
+	// The table and column names are used to build the insert queries
+	if err := hdb.CheckSnapshotTables(snapshot); err != nil {
+		return common.Wrap(err)
+	}
+	txn, err := hdb.dbWrite.Beginx()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer func() {
+		if err != nil {
+			database.Rollback(txn)
+		}
+	}()
+	for _, table := range snapshot.Tables {
+		if err := importTable(txn, &table); err != nil {
+			return common.Wrap(fmt.Errorf("table %v: %w", table.Name, err))
+		}
+	}
+	if err := setConstants(txn, snapshot.Constants); err != nil {
+		return common.Wrap(err)
+	}
+	return common.Wrap(txn.Commit())
+}
+
+// CheckSnapshotTables returns ErrInvalidSnapshotTable if the snapshot contains
+// a table that is not exported in snapshots, or a column that is not in the
+// schema of its table
+func (hdb *HistoryDB) CheckSnapshotTables(snapshot *Snapshot) error {
+	for _, table := range snapshot.Tables {
+		known := false
+		for _, t := range snapshotTables {
+			if t.name == table.Name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return common.Wrap(fmt.Errorf("%w: unknown table %q",
+				ErrInvalidSnapshotTable, table.Name))
+		}
+		// table.Name is one of snapshotTables
+		rows, err := hdb.dbWrite.Query(fmt.Sprintf("SELECT * FROM %s LIMIT 0;", table.Name))
+		if err != nil {
+			return common.Wrap(err)
+		}
+		columns, err := rows.Columns()
+		if errClose := rows.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			return common.Wrap(err)
+		}
+		schema := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			schema[column] = true
+		}
+		for _, column := range table.Columns {
+			if !schema[column] {
+				return common.Wrap(fmt.Errorf("%w: unknown column %q of table %v",
+					ErrInvalidSnapshotTable, column, table.Name))
+			}
+		}
+	}
+	return nil
+}
+
+func importTable(txn *sqlx.Tx, table *SnapshotTable) error {
+	if len(table.Rows) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(table.Columns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table.Name,
+		strings.Join(table.Columns, ", "), strings.Join(placeholders, ", "))
+	stmt, err := txn.Prepare(query)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer stmt.Close() //nolint:errcheck
+	for _, row := range table.Rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	return nil
+}
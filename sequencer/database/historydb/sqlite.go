@@ -0,0 +1,113 @@
+package historydb
+
+import (
+	"fmt"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database"
+
+	"github.com/russross/meddler"
+)
+
+// This file contains the Go equivalents of the plpgsql triggers of the
+// PostgreSQL schema, which are only used with the SQLite backend.
+
+// isSQLite returns true if the HistoryDB uses the SQLite backend
+func (hdb *HistoryDB) isSQLite() bool {
+	return database.IsSQLite(hdb.dbWrite)
+}
+
+// beforeInsertTxsSQLite validates the txs and sets their default values
+// before they are inserted.  Equivalent to the first part of the `set_tx`
+// trigger.
+func beforeInsertTxsSQLite(txs []txWrite) error {
+	for i := range txs {
+		tx := &txs[i]
+		if tx.IsL1 {
+			// If is Coordinator L1, must include batch_num
+			if tx.UserOrigin == nil || tx.FromEthAddr == nil || tx.FromBJJ == nil ||
+				tx.DepositAmount == nil || tx.DepositAmountFloat == nil ||
+				(!*tx.UserOrigin && tx.BatchNum == nil) {
+				return common.Wrap(fmt.Errorf("Invalid L1 tx: %+v", *tx))
+			}
+		} else {
+			if tx.BatchNum == nil || tx.Nonce == nil {
+				return common.Wrap(fmt.Errorf("Invalid L2 tx: %+v", *tx))
+			}
+			if tx.Fee == nil {
+				fee := common.FeeSelector(0)
+				tx.Fee = &fee
+			}
+		}
+	}
+	return nil
+}
+
+// afterInsertTxsSQLite sets the from_{eth_addr,bjj} of the L2 txs and the
+// to_{eth_addr,bjj} of the txs sent to an account from the account table.
+// Equivalent to the second part of the `set_tx` trigger.
+func afterInsertTxsSQLite(d meddler.DB, txs []txWrite) error {
+	for i := range txs {
+		if !txs[i].IsL1 {
+			if _, err := d.Exec(`UPDATE tx SET (from_eth_addr, from_bjj) =
+				(SELECT eth_addr, bjj FROM account WHERE idx = tx.from_idx)
+				WHERE id = $1;`, txs[i].TxID); err != nil {
+				return common.Wrap(err)
+			}
+		}
+		if txs[i].ToIdx >= common.UserThreshold {
+			if _, err := d.Exec(`UPDATE tx SET (to_eth_addr, to_bjj) =
+				(SELECT eth_addr, bjj FROM account WHERE idx = tx.to_idx)
+				WHERE id = $1;`, txs[i].TxID); err != nil {
+				return common.Wrap(err)
+			}
+		}
+	}
+	return nil
+}
+
+// forgeL1UserTxsSQLite sets the batch_num of the L1 user txs forged in the
+// batch, and updates their item_id so that they are ordered by position after
+// the txs that were already in the DB.  Equivalent to the `forge_l1_user_txs`
+// trigger.
+func forgeL1UserTxsSQLite(d meddler.DB, batch *common.Batch) error {
+	if batch.ForgeL1TxsNum == nil {
+		return nil
+	}
+	rows, err := d.Query(`SELECT id FROM tx
+		WHERE user_origin AND to_forge_l1_txs_num = $1
+		ORDER BY position;`, *batch.ForgeL1TxsNum)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	var ids []common.TxID
+	for rows.Next() {
+		var id common.TxID
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return common.Wrap(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Close(); err != nil {
+		return common.Wrap(err)
+	}
+	for _, id := range ids {
+		if _, err := d.Exec(`UPDATE tx SET
+			item_id = (SELECT MAX(item_id) FROM tx) + 1, batch_num = $1
+			WHERE id = $2;`, batch.BatchNum, id); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// querySetExtraInfoForgedL1UserTxSQLite is the SQLite version of the query
+// used by setExtraInfoForgedL1UserTxs, which relies on PostgreSQL casts.  It
+// updates a single tx.
+const querySetExtraInfoForgedL1UserTxSQLite = `
+	UPDATE tx SET
+		amount_success = :amount_success,
+		deposit_amount_success = :deposit_amount_success,
+		effective_from_idx = :effective_from_idx
+	WHERE tx.id = :id;
+`
@@ -0,0 +1,213 @@
+package kvdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/iden3/go-merkletree/db"
+	"github.com/iden3/go-merkletree/db/leveldb"
+	"github.com/iden3/go-merkletree/db/pebble"
+)
+
+// Storage is a db.Storage opened by a Backend
+type Storage interface {
+	db.Storage
+	// Write atomically puts the given key-values and deletes the given
+	// keys.  The keys of puts and dels must not overlap.
+	Write(puts []db.KV, dels [][]byte) error
+}
+
+// Backend is the key-value storage engine of the KVDB.  The current state,
+// the last batch and every checkpoint are separate storages identified by a
+// path, which for the on-disk backends is a directory.
+type Backend interface {
+	// Open opens the storage at path, creating it if it doesn't exist
+	Open(path string) (Storage, error)
+	// Checkpoint copies the content of sto into a new storage at dest,
+	// replacing dest if it exists
+	Checkpoint(sto db.Storage, dest string) error
+	// Exists returns true if there is a storage at path
+	Exists(path string) (bool, error)
+	// Remove deletes the storage at path and all the storages under it.
+	// The storages must be closed.  It's not an error if path doesn't
+	// exist.
+	Remove(path string) error
+	// List returns the names of the storages and subpaths directly under
+	// dir
+	List(dir string) ([]string, error)
+}
+
+// copyStorage writes all the key-values of src into dst
+func copyStorage(src db.Storage, dst db.Storage) error {
+	tx, err := dst.NewTx()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	if err := src.Iterate(func(k, v []byte) (bool, error) {
+		return true, tx.Put(db.Clone(k), db.Clone(v))
+	}); err != nil {
+		tx.Close()
+		return common.Wrap(err)
+	}
+	return common.Wrap(tx.Commit())
+}
+
+// dirBackend implements the path methods of a Backend that stores each
+// storage in a directory
+type dirBackend struct{}
+
+// Exists implements the method Exists of the interface Backend
+func (dirBackend) Exists(path string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, common.Wrap(err)
+	}
+	return true, nil
+}
+
+// Remove implements the method Remove of the interface Backend
+func (dirBackend) Remove(path string) error {
+	return common.Wrap(os.RemoveAll(path))
+}
+
+// List implements the method List of the interface Backend
+func (dirBackend) List(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	names := []string{}
+	for _, file := range files {
+		if file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+// PebbleBackend is the Backend that stores the KVDB in pebble databases.  It's
+// the default Backend.
+type PebbleBackend struct {
+	dirBackend
+}
+
+type pebbleStorage struct {
+	*pebble.Storage
+}
+
+// Write implements the method Write of the interface Storage
+func (p pebbleStorage) Write(puts []db.KV, dels [][]byte) error {
+	batch := p.Pebble().NewBatch()
+	defer batch.Close() //nolint:errcheck
+	for _, kv := range puts {
+		if err := batch.Set(kv.K, kv.V, nil); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	for _, k := range dels {
+		if err := batch.Delete(k, nil); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	return common.Wrap(batch.Commit(nil))
+}
+
+// Open implements the method Open of the interface Backend
+func (PebbleBackend) Open(path string) (Storage, error) {
+	sto, err := pebble.NewPebbleStorage(path, false)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return pebbleStorage{sto}, nil
+}
+
+// Checkpoint implements the method Checkpoint of the interface Backend.  A
+// pebble storage is copied with a native pebble checkpoint, which hard links
+// the files instead of copying them.
+func (b PebbleBackend) Checkpoint(sto db.Storage, dest string) error {
+	if err := b.Remove(dest); err != nil {
+		return common.Wrap(err)
+	}
+	if p, ok := sto.(pebbleStorage); ok {
+		sto = p.Storage
+	}
+	if p, ok := sto.(*pebble.Storage); ok {
+		return common.Wrap(p.Pebble().Checkpoint(dest))
+	}
+	dst, err := pebble.NewPebbleStorage(dest, false)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer dst.Close()
+	return copyStorage(sto, dst)
+}
+
+// LevelDBBackend is the Backend that stores the KVDB in LevelDB databases.
+// LevelDB doesn't support checkpoints, so they are done by copying all the
+// key-values.
+type LevelDBBackend struct {
+	dirBackend
+}
+
+type levelDBStorage struct {
+	*leveldb.Storage
+}
+
+// Write implements the method Write of the interface Storage
+func (l levelDBStorage) Write(puts []db.KV, dels [][]byte) error {
+	tx, err := l.LevelDB().OpenTransaction()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	for _, kv := range puts {
+		if err := tx.Put(kv.K, kv.V, nil); err != nil {
+			tx.Discard()
+			return common.Wrap(err)
+		}
+	}
+	for _, k := range dels {
+		if err := tx.Delete(k, nil); err != nil {
+			tx.Discard()
+			return common.Wrap(err)
+		}
+	}
+	return common.Wrap(tx.Commit())
+}
+
+// Open implements the method Open of the interface Backend
+func (LevelDBBackend) Open(path string) (Storage, error) {
+	sto, err := leveldb.NewLevelDbStorage(path, false)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return levelDBStorage{sto}, nil
+}
+
+// Checkpoint implements the method Checkpoint of the interface Backend
+func (b LevelDBBackend) Checkpoint(sto db.Storage, dest string) error {
+	if err := b.Remove(dest); err != nil {
+		return common.Wrap(err)
+	}
+	dst, err := leveldb.NewLevelDbStorage(dest, false)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer dst.Close()
+	return copyStorage(sto, dst)
+}
+
+// BackendByName returns the Backend with the given name, which can be
+// "pebble" or "leveldb".  An empty name selects pebble.
+func BackendByName(name string) (Backend, error) {
+	switch name {
+	case "", "pebble":
+		return PebbleBackend{}, nil
+	case "leveldb":
+		return LevelDBBackend{}, nil
+	default:
+		return nil, common.Wrap(fmt.Errorf("unknown kvdb backend %q", name))
+	}
+}
@@ -0,0 +1,175 @@
+package kvdb
+
+import (
+	"fmt"
+	"sync"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/iden3/go-merkletree/db"
+)
+
+// ErrInvalidSnapshot is returned when RevertToSnapshot is called with a
+// snapshot that doesn't exist or that has been invalidated by a checkpoint or
+// a reset
+var ErrInvalidSnapshot = fmt.Errorf("invalid snapshot")
+
+// journalEntry holds the value that a key had before being written
+type journalEntry struct {
+	key     []byte
+	value   []byte
+	existed bool
+}
+
+// journal records the previous value of the keys written to the KVDB since
+// the first snapshot, so that the writes can be undone in reverse order
+type journal struct {
+	// mutex allows recording the writes of the merkle trees updated
+	// concurrently
+	mutex   sync.Mutex
+	active  bool
+	entries []journalEntry
+}
+
+// reset discards the journal
+func (j *journal) reset() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.active = false
+	j.entries = nil
+}
+
+// record appends to the journal the current value of the given keys, which
+// are about to be overwritten
+func (j *journal) record(sto db.Storage, keys [][]byte) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if !j.active {
+		return nil
+	}
+	for _, key := range keys {
+		entry := journalEntry{key: key}
+		v, err := sto.Get(key)
+		if err == nil {
+			entry.value, entry.existed = db.Clone(v), true
+		} else if common.Unwrap(err) != db.ErrNotFound {
+			return common.Wrap(err)
+		}
+		j.entries = append(j.entries, entry)
+	}
+	return nil
+}
+
+// journaledStorage is a db.Storage that records in the journal of the KVDB
+// the previous value of every key written through it
+type journaledStorage struct {
+	db.Storage
+	kvdb   *KVDB
+	prefix []byte
+}
+
+// WithPrefix implements the method WithPrefix of the interface db.Storage
+func (s *journaledStorage) WithPrefix(prefix []byte) db.Storage {
+	return &journaledStorage{
+		Storage: s.Storage.WithPrefix(prefix),
+		kvdb:    s.kvdb,
+		prefix:  db.Concat(s.prefix, prefix),
+	}
+}
+
+// NewTx implements the method NewTx of the interface db.Storage
+func (s *journaledStorage) NewTx() (db.Tx, error) {
+	tx, err := s.Storage.NewTx()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &journaledTx{Tx: tx, sto: s}, nil
+}
+
+// journaledTx is a db.Tx that keeps the keys written in it to record their
+// previous values in the journal when it's committed
+type journaledTx struct {
+	db.Tx
+	sto  *journaledStorage
+	keys [][]byte
+}
+
+// Put implements the method Put of the interface db.Tx
+func (tx *journaledTx) Put(k, v []byte) error {
+	tx.keys = append(tx.keys, db.Concat(tx.sto.prefix, k))
+	return tx.Tx.Put(k, v)
+}
+
+// Add implements the method Add of the interface db.Tx
+func (tx *journaledTx) Add(atx db.Tx) error {
+	if jatx, ok := atx.(*journaledTx); ok {
+		tx.keys = append(tx.keys, jatx.keys...)
+		atx = jatx.Tx
+	}
+	return tx.Tx.Add(atx)
+}
+
+// Commit implements the method Commit of the interface db.Tx
+func (tx *journaledTx) Commit() error {
+	if err := tx.sto.kvdb.journal.record(tx.sto.kvdb.db, tx.keys); err != nil {
+		return common.Wrap(err)
+	}
+	return tx.Tx.Commit()
+}
+
+// Write atomically puts the given key-values and deletes the given keys of the
+// current state, recording their previous values in the journal.  The keys of
+// puts and dels must not overlap.
+func (k *KVDB) Write(puts []db.KV, dels [][]byte) error {
+	keys := make([][]byte, 0, len(puts)+len(dels))
+	for _, kv := range puts {
+		keys = append(keys, kv.K)
+	}
+	keys = append(keys, dels...)
+	if err := k.journal.record(k.db, keys); err != nil {
+		return common.Wrap(err)
+	}
+	return common.Wrap(k.db.Write(puts, dels))
+}
+
+// Snapshot starts journaling the writes done to the KVDB, if it wasn't
+// already, and returns an identifier of the current state that can be passed
+// to RevertToSnapshot.  The journal is discarded when a checkpoint is made or
+// the KVDB is reset, which invalidates all the snapshot identifiers.
+func (k *KVDB) Snapshot() int {
+	k.journal.active = true
+	return len(k.journal.entries)
+}
+
+// RevertToSnapshot undoes all the writes done to the KVDB after the given
+// snapshot was taken.  Snapshots taken after the given one are invalidated.
+func (k *KVDB) RevertToSnapshot(id int) error {
+	if !k.journal.active || id < 0 || id > len(k.journal.entries) {
+		return common.Wrap(fmt.Errorf("%w: %d", ErrInvalidSnapshot, id))
+	}
+	// the oldest entry of each key holds the value it had at the snapshot
+	var puts []db.KV
+	var dels [][]byte
+	reverted := make(map[string]bool)
+	for _, entry := range k.journal.entries[id:] {
+		if reverted[string(entry.key)] {
+			continue
+		}
+		reverted[string(entry.key)] = true
+		if entry.existed {
+			puts = append(puts, db.KV{K: entry.key, V: entry.value})
+		} else {
+			dels = append(dels, entry.key)
+		}
+	}
+	if err := k.db.Write(puts, dels); err != nil {
+		return common.Wrap(err)
+	}
+	k.journal.entries = k.journal.entries[:id]
+
+	var err error
+	k.CurrentAccountIdx, err = k.GetCurrentAccountIdx()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	return nil
+}
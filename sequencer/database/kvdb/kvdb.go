@@ -2,8 +2,6 @@ package kvdb
 
 import (
 	"fmt"
-	"io/ioutil"
-	"os"
 	"path"
 	"sort"
 	"strings"
@@ -25,6 +23,9 @@ const (
 	// PathLast defines the subpath of the last Batch in the subpath
 	// of the StateDB
 	PathLast = "last"
+	// PathVerify defines the subpath of the KVDB where the checkpoints
+	// being verified are copied
+	PathVerify = "verify"
 	// DefaultKeep is the default value for the Keep parameter
 	DefaultKeep = 128
 )
@@ -37,12 +38,16 @@ var (
 	// ErrNoLast is returned when the KVDB has been configured to not have
 	// a Last checkpoint but a Last method is used
 	ErrNoLast = fmt.Errorf("no last checkpoint")
+	// ErrCorruptCheckpoint is returned when the data stored in a checkpoint
+	// is not consistent
+	ErrCorruptCheckpoint = fmt.Errorf("corrupt checkpoint")
 )
 
 // KVDB represents the Key-Value DB object
 type KVDB struct {
-	cfg Config
-	db  *pebble.Storage
+	cfg     Config
+	backend Backend
+	db      Storage
 	// CurrentIdx holds the current Idx that the BatchBuilder is using
 	CurrentAccountIdx common.AccountIdx
 	CurrentBatch      common.BatchNum
@@ -50,14 +55,17 @@ type KVDB struct {
 	mutexDelOld       sync.Mutex
 	wg                sync.WaitGroup
 	last              *Last
+	journal           journal
+	openAt            *checkpointCache
 }
 
 // Last is a consistent view to the last batch of the stateDB that can
 // be queried concurrently.
 type Last struct {
-	db   *pebble.Storage
-	path string
-	rw   sync.RWMutex
+	db      Storage
+	backend Backend
+	path    string
+	rw      sync.RWMutex
 }
 
 // Config of the KVDB
@@ -73,6 +81,12 @@ type Config struct {
 	// NoLast skips having an opened DB with a checkpoint to the last
 	// batchNum for thread-safe reads.
 	NoLast bool
+	// OpenAtCache is the number of checkpoints opened with OpenAt that are
+	// kept open.  If 0, DefaultOpenAtCache is used.
+	OpenAtCache int
+	// Backend is the storage engine of the KVDB.  If nil, PebbleBackend is
+	// used.
+	Backend Backend
 }
 
 func (k *Last) setNew() error {
@@ -83,10 +97,10 @@ func (k *Last) setNew() error {
 		k.db = nil
 	}
 	lastPath := path.Join(k.path, PathLast)
-	if err := os.RemoveAll(lastPath); err != nil {
+	if err := k.backend.Remove(lastPath); err != nil {
 		return common.Wrap(err)
 	}
-	db, err := pebble.NewPebbleStorage(lastPath, false)
+	db, err := k.backend.Open(lastPath)
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -105,7 +119,7 @@ func (k *Last) set(kvdb *KVDB, batchNum common.BatchNum) error {
 	if err := kvdb.MakeCheckpointFromTo(batchNum, lastPath); err != nil {
 		return common.Wrap(err)
 	}
-	db, err := pebble.NewPebbleStorage(lastPath, false)
+	db, err := k.backend.Open(lastPath)
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -113,6 +127,23 @@ func (k *Last) set(kvdb *KVDB, batchNum common.BatchNum) error {
 	return nil
 }
 
+// DB returns the db.Storage of the last checkpoint
+func (k *Last) DB() db.Storage {
+	return k.db
+}
+
+// GetCurrentBatch returns the current BatchNum stored in the last checkpoint
+func (k *Last) GetCurrentBatch() (common.BatchNum, error) {
+	cbBytes, err := k.db.Get(KeyCurrentBatch)
+	if common.Unwrap(err) == db.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, common.Wrap(err)
+	}
+	return common.BatchNumFromBytes(cbBytes)
+}
+
 func (k *Last) close() {
 	k.rw.Lock()
 	defer k.rw.Unlock()
@@ -125,22 +156,35 @@ func (k *Last) close() {
 // NewKVDB creates a new KVDB, allowing to use an in-memory or in-disk storage.
 // Checkpoints older than the value defined by `keep` will be deleted.
 func NewKVDB(cfg Config) (*KVDB, error) {
-	var sto *pebble.Storage
-	var err error
-	sto, err = pebble.NewPebbleStorage(path.Join(cfg.Path, PathCurrent), false)
+	backend := cfg.Backend
+	if backend == nil {
+		backend = PebbleBackend{}
+	}
+	sto, err := backend.Open(path.Join(cfg.Path, PathCurrent))
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
 	var last *Last
 	if !cfg.NoLast {
 		last = &Last{
-			path: cfg.Path,
+			backend: backend,
+			path:    cfg.Path,
 		}
 	}
+	openAt, err := newCheckpointCache(backend, cfg.Path, cfg.OpenAtCache)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	// remove the copies left by an interrupted VerifyCheckpoint
+	if err := backend.Remove(path.Join(cfg.Path, PathVerify)); err != nil {
+		return nil, common.Wrap(err)
+	}
 	kvdb := &KVDB{
-		cfg:  cfg,
-		db:   sto,
-		last: last,
+		cfg:     cfg,
+		backend: backend,
+		db:      sto,
+		last:    last,
+		openAt:  openAt,
 	}
 	// load currentBatch
 	kvdb.CurrentBatch, err = kvdb.GetCurrentBatch()
@@ -157,15 +201,41 @@ func NewKVDB(cfg Config) (*KVDB, error) {
 	return kvdb, nil
 }
 
-// DB returns the *pebble.Storage from the KVDB
-func (k *KVDB) DB() *pebble.Storage {
-	return k.db
+// DB returns the db.Storage from the KVDB.  The writes done through it are
+// journaled after a Snapshot, so that they can be reverted.
+func (k *KVDB) DB() db.Storage {
+	return &journaledStorage{Storage: k.db, kvdb: k}
+}
+
+// LastRead is a thread-safe method to query the last checkpoint of the KVDB.
+// The checkpoint can't be replaced while fn runs.
+func (k *KVDB) LastRead(fn func(last *Last) error) error {
+	if k.last == nil {
+		return common.Wrap(ErrNoLast)
+	}
+	k.last.rw.RLock()
+	defer k.last.rw.RUnlock()
+	return fn(k.last)
+}
+
+// LastGetCurrentBatch is a thread-safe method to get the BatchNum of the last
+// checkpoint of the KVDB
+func (k *KVDB) LastGetCurrentBatch() (common.BatchNum, error) {
+	var batchNum common.BatchNum
+	if err := k.LastRead(func(last *Last) error {
+		var err error
+		batchNum, err = last.GetCurrentBatch()
+		return err
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return batchNum, nil
 }
 
 // StorageWithPrefix returns the db.Storage with the given prefix from the
 // current KVDB
 func (k *KVDB) StorageWithPrefix(prefix []byte) db.Storage {
-	return k.db.WithPrefix(prefix)
+	return k.DB().WithPrefix(prefix)
 }
 
 // Reset resets the KVDB to the checkpoint at the given batchNum. Reset does
@@ -183,13 +253,15 @@ func (k *KVDB) Reset(batchNum common.BatchNum) error {
 // opened db before doing the reset.
 func (k *KVDB) reset(batchNum common.BatchNum, closeCurrent bool) error {
 	currentPath := path.Join(k.cfg.Path, PathCurrent)
+	k.journal.reset()
+	k.openAt.invalidateFrom(batchNum + 1)
 
 	if closeCurrent && k.db != nil {
 		k.db.Close()
 		k.db = nil
 	}
 	// remove 'current'
-	if err := os.RemoveAll(currentPath); err != nil {
+	if err := k.backend.Remove(currentPath); err != nil {
 		return common.Wrap(err)
 	}
 	// remove all checkpoints > batchNum
@@ -213,7 +285,7 @@ func (k *KVDB) reset(batchNum common.BatchNum, closeCurrent bool) error {
 
 	if batchNum == 0 {
 		// if batchNum == 0, open the new fresh 'current'
-		sto, err := pebble.NewPebbleStorage(currentPath, false)
+		sto, err := k.backend.Open(currentPath)
 		if err != nil {
 			return common.Wrap(err)
 		}
@@ -241,7 +313,7 @@ func (k *KVDB) reset(batchNum common.BatchNum, closeCurrent bool) error {
 	}
 
 	// open the new 'current'
-	sto, err := pebble.NewPebbleStorage(currentPath, false)
+	sto, err := k.backend.Open(currentPath)
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -306,7 +378,7 @@ func (k *KVDB) setCurrentBatch() error {
 func (k *KVDB) SetCurrentAccountIdx(idx common.AccountIdx) error {
 	k.CurrentAccountIdx = idx
 
-	tx, err := k.db.NewTx()
+	tx, err := k.DB().NewTx()
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -327,16 +399,15 @@ func (k *KVDB) SetCurrentAccountIdx(idx common.AccountIdx) error {
 // ListCheckpoints returns the list of batchNums of the checkpoints, sorted.
 // If there's a gap between the list of checkpoints, an error is returned.
 func (k *KVDB) ListCheckpoints() ([]int, error) {
-	files, err := ioutil.ReadDir(k.cfg.Path)
+	names, err := k.backend.List(k.cfg.Path)
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
 	checkpoints := []int{}
 	var checkpoint int
 	pattern := fmt.Sprintf("%s%%d", PathBatchNum)
-	for _, file := range files {
-		fileName := file.Name()
-		if file.IsDir() && strings.HasPrefix(fileName, PathBatchNum) {
+	for _, fileName := range names {
+		if strings.HasPrefix(fileName, PathBatchNum) {
 			if _, err := fmt.Sscanf(fileName, pattern, &checkpoint); err != nil {
 				return nil, common.Wrap(err)
 			}
@@ -361,25 +432,38 @@ func (k *KVDB) ListCheckpoints() ([]int, error) {
 func (k *KVDB) DeleteCheckpoint(batchNum common.BatchNum) error {
 	checkpointPath := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, batchNum))
 
-	if _, err := os.Stat(checkpointPath); os.IsNotExist(err) {
-		return common.Wrap(fmt.Errorf("Checkpoint with batchNum %d does not exist in DB", batchNum))
-	} else if err != nil {
+	if exists, err := k.backend.Exists(checkpointPath); err != nil {
 		return common.Wrap(err)
+	} else if !exists {
+		return common.Wrap(fmt.Errorf("Checkpoint with batchNum %d does not exist in DB", batchNum))
 	}
 
-	return os.RemoveAll(checkpointPath)
+	return k.backend.Remove(checkpointPath)
 }
 
 // MakeCheckpointFromTo makes a checkpoint from the current db at fromBatchNum
 // to the dest folder.  This method is locking, so it can be called from
 // multiple places at the same time.
 func (k *KVDB) MakeCheckpointFromTo(fromBatchNum common.BatchNum, dest string) error {
+	return k.makeCheckpointFromTo(fromBatchNum, k.backend, dest)
+}
+
+// ExportCheckpoint copies the checkpoint at batchNum to a pebble db at dest,
+// whatever the Backend of the KVDB is
+func (k *KVDB) ExportCheckpoint(batchNum common.BatchNum, dest string) error {
+	return k.makeCheckpointFromTo(batchNum, PebbleBackend{}, dest)
+}
+
+// makeCheckpointFromTo copies the checkpoint at fromBatchNum to dest in the
+// given Backend
+func (k *KVDB) makeCheckpointFromTo(fromBatchNum common.BatchNum, backend Backend,
+	dest string) error {
 	source := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, fromBatchNum))
-	if _, err := os.Stat(source); os.IsNotExist(err) {
+	if exists, err := k.backend.Exists(source); err != nil {
+		return common.Wrap(err)
+	} else if !exists {
 		// if kvdb does not have checkpoint at batchNum, return err
 		return common.Wrap(fmt.Errorf("Checkpoint \"%v\" does not exist", source))
-	} else if err != nil {
-		return common.Wrap(err)
 	}
 	// By locking we allow calling MakeCheckpointFromTo from multiple
 	// places at the same time for the same stateDB.  This allows the
@@ -388,35 +472,23 @@ func (k *KVDB) MakeCheckpointFromTo(fromBatchNum common.BatchNum, dest string) e
 	// synchronizer to the same batchNum
 	k.mutexCheckpoint.Lock()
 	defer k.mutexCheckpoint.Unlock()
-	return PebbleMakeCheckpoint(source, dest)
+	sto, err := k.backend.Open(source)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer sto.Close()
+	return backend.Checkpoint(sto, dest)
 }
 
 // PebbleMakeCheckpoint is a hepler function to make a pebble checkpoint from
 // source to dest.
 func PebbleMakeCheckpoint(source, dest string) error {
-	// Remove dest folder (if it exists) before doing the checkpoint
-	if _, err := os.Stat(dest); os.IsNotExist(err) {
-	} else if err != nil {
-		return common.Wrap(err)
-	} else {
-		if err := os.RemoveAll(dest); err != nil {
-			return common.Wrap(err)
-		}
-	}
-
 	sto, err := pebble.NewPebbleStorage(source, false)
 	if err != nil {
 		return common.Wrap(err)
 	}
 	defer sto.Close()
-
-	// execute Checkpoint
-	err = sto.Pebble().Checkpoint(dest)
-	if err != nil {
-		return common.Wrap(err)
-	}
-
-	return nil
+	return PebbleBackend{}.Checkpoint(sto, dest)
 }
 
 // MakeCheckpoint does a checkpoint at the given batchNum in the defined path.
@@ -425,6 +497,9 @@ func PebbleMakeCheckpoint(source, dest string) error {
 func (k *KVDB) MakeCheckpoint() error {
 	// advance currentBatch
 	k.CurrentBatch++
+	// the changes of the batch can't be reverted after the checkpoint
+	k.journal.reset()
+	k.openAt.invalidateFrom(k.CurrentBatch)
 
 	checkpointPath := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, k.CurrentBatch))
 
@@ -432,17 +507,9 @@ func (k *KVDB) MakeCheckpoint() error {
 		return common.Wrap(err)
 	}
 
-	// if checkpoint BatchNum already exist in disk, delete it
-	if _, err := os.Stat(checkpointPath); os.IsNotExist(err) {
-	} else if err != nil {
-		return common.Wrap(err)
-	} else {
-		if err := os.RemoveAll(checkpointPath); err != nil {
-			return common.Wrap(err)
-		}
-	}
-	// execute Checkpoint
-	if err := k.db.Pebble().Checkpoint(checkpointPath); err != nil {
+	// execute Checkpoint, which replaces the checkpoint BatchNum if it
+	// already exists
+	if err := k.backend.Checkpoint(k.db, checkpointPath); err != nil {
 		return common.Wrap(err)
 	}
 	// copy 'CurrentBatch' to 'last'
@@ -493,6 +560,114 @@ func (k *KVDB) Close() {
 	if k.last != nil {
 		k.last.close()
 	}
+	k.openAt.close()
 	// wait for deletion of old checkpoints
 	k.wg.Wait()
 }
+
+// UpdateCheckpoints calls update with the storage of every checkpoint in
+// ascending order, and then resets the KVDB to its current batch so that the
+// current state and the last checkpoint are copies of the updated one.  It's
+// meant for one-time migrations of the data written by older versions, so it
+// must not run while the checkpoints are read.
+func (k *KVDB) UpdateCheckpoints(update func(batchNum common.BatchNum, sto Storage) error) error {
+	list, err := k.ListCheckpoints()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	for _, bn := range list {
+		checkpointPath := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, bn))
+		sto, err := k.backend.Open(checkpointPath)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		err = update(common.BatchNum(bn), sto)
+		sto.Close()
+		if err != nil {
+			return common.Wrap(err)
+		}
+	}
+	k.openAt.invalidateFrom(0)
+	return k.reset(k.CurrentBatch, true)
+}
+
+// VerifyCheckpoint opens a temporary copy of the checkpoint at the given
+// batchNum, checks that the BatchNum stored in it matches the checkpoint and
+// calls verify with the storage of the copy.  The checkpoint itself is never
+// modified, so verify can freely write to the given storage.
+func (k *KVDB) VerifyCheckpoint(batchNum common.BatchNum,
+	verify func(sto Storage) error) error {
+	checkpointPath := path.Join(k.cfg.Path, PathVerify,
+		fmt.Sprintf("%s%d", PathBatchNum, batchNum))
+	if err := k.MakeCheckpointFromTo(batchNum, checkpointPath); err != nil {
+		return common.Wrap(err)
+	}
+	sto, err := k.backend.Open(checkpointPath)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer func() {
+		sto.Close()
+		if err := k.backend.Remove(checkpointPath); err != nil {
+			log.Errorw("remove verify checkpoint", "path", checkpointPath, "err", err)
+		}
+	}()
+	return verifyStorage(sto, batchNum, verify)
+}
+
+// verifyStorage checks that the current batch stored in sto is batchNum and
+// then calls verify
+func verifyStorage(sto Storage, batchNum common.BatchNum,
+	verify func(sto Storage) error) error {
+	cbBytes, err := sto.Get(KeyCurrentBatch)
+	if err != nil {
+		return common.Wrap(fmt.Errorf("%w %d: current batch: %v",
+			ErrCorruptCheckpoint, batchNum, err))
+	}
+	storedBatchNum, err := common.BatchNumFromBytes(cbBytes)
+	if err != nil {
+		return common.Wrap(fmt.Errorf("%w %d: current batch: %v",
+			ErrCorruptCheckpoint, batchNum, err))
+	}
+	if storedBatchNum != batchNum {
+		return common.Wrap(fmt.Errorf("%w %d: stored current batch is %d",
+			ErrCorruptCheckpoint, batchNum, storedBatchNum))
+	}
+	return verify(sto)
+}
+
+// ImportCheckpoint copies the pebble db at source as the checkpoint of
+// batchNum and resets the KVDB to it.  The copy is checked with verify before
+// the reset, and it's discarded if verify returns an error.  verify can also
+// write to the copy to migrate the data of older versions.  Only a KVDB
+// without batches can import a checkpoint.
+func (k *KVDB) ImportCheckpoint(batchNum common.BatchNum, source string,
+	verify func(sto Storage) error) error {
+	if k.CurrentBatch != 0 {
+		return common.Wrap(fmt.Errorf("can't import a checkpoint into a KVDB at batch %d",
+			k.CurrentBatch))
+	}
+	src, err := pebble.NewPebbleStorage(source, true)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	checkpointPath := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, batchNum))
+	err = k.backend.Checkpoint(src, checkpointPath)
+	src.Close()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	sto, err := k.backend.Open(checkpointPath)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	err = verifyStorage(sto, batchNum, verify)
+	sto.Close()
+	if err != nil {
+		if err := k.backend.Remove(checkpointPath); err != nil {
+			log.Errorw("remove imported checkpoint", "path", checkpointPath, "err", err)
+		}
+		return common.Wrap(err)
+	}
+	return k.reset(batchNum, true)
+}
@@ -0,0 +1,133 @@
+package kvdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/iden3/go-merkletree/db"
+	"github.com/iden3/go-merkletree/db/pebble"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testBackends = []struct {
+	name    string
+	backend func() Backend
+}{
+	{"pebble", func() Backend { return PebbleBackend{} }},
+	{"leveldb", func() Backend { return LevelDBBackend{} }},
+	{"memory", func() Backend { return NewMemoryBackend() }},
+}
+
+func put(t *testing.T, sto db.Storage, k, v string) {
+	tx, err := sto.NewTx()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put([]byte(k), []byte(v)))
+	require.NoError(t, tx.Commit())
+}
+
+func get(t *testing.T, sto db.Storage, k string) string {
+	v, err := sto.Get([]byte(k))
+	if common.Unwrap(err) == db.ErrNotFound {
+		return ""
+	}
+	require.NoError(t, err)
+	return string(v)
+}
+
+func TestBackends(t *testing.T) {
+	for _, tc := range testBackends {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tmpdb")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir) //nolint:errcheck
+			backend := tc.backend()
+
+			kv, err := NewKVDB(Config{Path: dir, Keep: 2, Backend: backend})
+			require.NoError(t, err)
+			for batchNum := 1; batchNum <= 4; batchNum++ {
+				put(t, kv.DB(), "batch", fmt.Sprint(batchNum))
+				put(t, kv.StorageWithPrefix([]byte("p:")), fmt.Sprint(batchNum), "v")
+				require.NoError(t, kv.MakeCheckpoint())
+			}
+			kv.wg.Wait()
+			// only the last 2 checkpoints are kept
+			list, err := kv.ListCheckpoints()
+			require.NoError(t, err)
+			assert.Equal(t, []int{3, 4}, list)
+			require.NoError(t, kv.LastRead(func(last *Last) error {
+				assert.Equal(t, "4", get(t, last.DB(), "batch"))
+				return nil
+			}))
+
+			checkpoint, err := kv.OpenAt(3)
+			require.NoError(t, err)
+			assert.Equal(t, "3", get(t, checkpoint.DB(), "batch"))
+			assert.Equal(t, "", get(t, checkpoint.DB().WithPrefix([]byte("p:")), "4"))
+			checkpoint.Close()
+			_, err = kv.OpenAt(1)
+			assert.ErrorIs(t, err, ErrCheckpointNotFound)
+			require.NoError(t, kv.VerifyCheckpoint(3, func(sto Storage) error {
+				assert.Equal(t, "3", get(t, sto, "batch"))
+				return nil
+			}))
+
+			// revert the writes done after a snapshot
+			snapshot := kv.Snapshot()
+			put(t, kv.DB(), "batch", "5")
+			put(t, kv.DB(), "new", "v")
+			require.NoError(t, kv.RevertToSnapshot(snapshot))
+			assert.Equal(t, "4", get(t, kv.DB(), "batch"))
+			assert.Equal(t, "", get(t, kv.DB(), "new"))
+
+			require.NoError(t, kv.Reset(3))
+			assert.Equal(t, common.BatchNum(3), kv.CurrentBatch)
+			assert.Equal(t, "3", get(t, kv.DB(), "batch"))
+			list, err = kv.ListCheckpoints()
+			require.NoError(t, err)
+			assert.Equal(t, []int{3}, list)
+			kv.Close()
+
+			// the state is kept when the KVDB is opened again
+			kv, err = NewKVDB(Config{Path: dir, Keep: 2, Backend: backend})
+			require.NoError(t, err)
+			defer kv.Close()
+			assert.Equal(t, common.BatchNum(3), kv.CurrentBatch)
+			assert.Equal(t, "3", get(t, kv.DB(), "batch"))
+			assert.Equal(t, "v", get(t, kv.StorageWithPrefix([]byte("p:")), "3"))
+
+			// the exported checkpoint is a pebble db
+			dest := path.Join(dir, "export")
+			require.NoError(t, kv.ExportCheckpoint(3, dest))
+			sto, err := pebble.NewPebbleStorage(dest, true)
+			require.NoError(t, err)
+			assert.Equal(t, "3", get(t, sto, "batch"))
+			sto.Close()
+		})
+	}
+}
+
+func TestImportCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmpdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint:errcheck
+	source := path.Join(dir, "source")
+	sto, err := pebble.NewPebbleStorage(source, false)
+	require.NoError(t, err)
+	put(t, sto, string(KeyCurrentBatch), string(common.BatchNum(7).Bytes()))
+	put(t, sto, "k", "v")
+	sto.Close()
+
+	kv, err := NewKVDB(Config{Path: path.Join(dir, "kvdb"), Backend: NewMemoryBackend()})
+	require.NoError(t, err)
+	defer kv.Close()
+	require.NoError(t, kv.ImportCheckpoint(7, source, func(sto Storage) error {
+		return nil
+	}))
+	assert.Equal(t, common.BatchNum(7), kv.CurrentBatch)
+	assert.Equal(t, "v", get(t, kv.DB(), "k"))
+}
@@ -0,0 +1,243 @@
+package kvdb
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/iden3/go-merkletree/db"
+)
+
+// MemoryBackend is a Backend that keeps all the storages in memory, so that
+// the KVDB doesn't do any disk I/O.  The data is lost when the MemoryBackend
+// is garbage collected, so it's meant for tests, simulations and temporary
+// trees.  The same MemoryBackend must be used to reopen a KVDB.
+type MemoryBackend struct {
+	mutex    sync.Mutex
+	storages map[string]*memoryData
+}
+
+// NewMemoryBackend returns a new empty MemoryBackend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{storages: make(map[string]*memoryData)}
+}
+
+// memoryData holds the key-values of a memory storage, shared by all the
+// storages opened at the same path and all their prefixed storages
+type memoryData struct {
+	rw sync.RWMutex
+	kv map[string][]byte
+}
+
+// NewMemoryStorage returns a new Storage that is not part of any Backend
+func NewMemoryStorage() Storage {
+	return &memoryStorage{data: &memoryData{kv: make(map[string][]byte)}}
+}
+
+// Open implements the method Open of the interface Backend
+func (b *MemoryBackend) Open(p string) (Storage, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p = path.Clean(p)
+	data, ok := b.storages[p]
+	if !ok {
+		data = &memoryData{kv: make(map[string][]byte)}
+		b.storages[p] = data
+	}
+	return &memoryStorage{data: data}, nil
+}
+
+// Checkpoint implements the method Checkpoint of the interface Backend
+func (b *MemoryBackend) Checkpoint(sto db.Storage, dest string) error {
+	data := &memoryData{kv: make(map[string][]byte)}
+	if m, ok := sto.(*memoryStorage); ok && len(m.prefix) == 0 {
+		m.data.rw.RLock()
+		for k, v := range m.data.kv {
+			data.kv[k] = v
+		}
+		m.data.rw.RUnlock()
+	} else if err := sto.Iterate(func(k, v []byte) (bool, error) {
+		data.kv[string(k)] = db.Clone(v)
+		return true, nil
+	}); err != nil {
+		return common.Wrap(err)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.storages[path.Clean(dest)] = data
+	return nil
+}
+
+// Exists implements the method Exists of the interface Backend
+func (b *MemoryBackend) Exists(p string) (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	_, ok := b.storages[path.Clean(p)]
+	return ok, nil
+}
+
+// Remove implements the method Remove of the interface Backend
+func (b *MemoryBackend) Remove(p string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p = path.Clean(p)
+	for storagePath := range b.storages {
+		if storagePath == p || strings.HasPrefix(storagePath, p+"/") {
+			delete(b.storages, storagePath)
+		}
+	}
+	return nil
+}
+
+// List implements the method List of the interface Backend
+func (b *MemoryBackend) List(dir string) ([]string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	dir = path.Clean(dir) + "/"
+	found := make(map[string]bool)
+	names := []string{}
+	for storagePath := range b.storages {
+		if !strings.HasPrefix(storagePath, dir) {
+			continue
+		}
+		name := strings.SplitN(storagePath[len(dir):], "/", 2)[0]
+		if !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// memoryStorage implements the interface Storage in memory
+type memoryStorage struct {
+	data   *memoryData
+	prefix []byte
+}
+
+// WithPrefix implements the method WithPrefix of the interface db.Storage
+func (m *memoryStorage) WithPrefix(prefix []byte) db.Storage {
+	return &memoryStorage{data: m.data, prefix: db.Concat(m.prefix, prefix)}
+}
+
+// NewTx implements the method NewTx of the interface db.Storage
+func (m *memoryStorage) NewTx() (db.Tx, error) {
+	return &memoryTx{sto: m, puts: make(map[string][]byte)}, nil
+}
+
+// Get implements the method Get of the interface db.Storage
+func (m *memoryStorage) Get(key []byte) ([]byte, error) {
+	m.data.rw.RLock()
+	defer m.data.rw.RUnlock()
+	if v, ok := m.data.kv[string(db.Concat(m.prefix, key))]; ok {
+		return v, nil
+	}
+	return nil, db.ErrNotFound
+}
+
+// Iterate implements the method Iterate of the interface db.Storage.  The
+// key-values are iterated in key order over a copy taken when it's called, so
+// f can write to the storage.
+func (m *memoryStorage) Iterate(f func([]byte, []byte) (bool, error)) error {
+	m.data.rw.RLock()
+	kvs := []db.KV{}
+	for k, v := range m.data.kv {
+		if strings.HasPrefix(k, string(m.prefix)) {
+			kvs = append(kvs, db.KV{K: []byte(k[len(m.prefix):]), V: v})
+		}
+	}
+	m.data.rw.RUnlock()
+	sort.Slice(kvs, func(i, j int) bool {
+		return bytes.Compare(kvs[i].K, kvs[j].K) < 0
+	})
+	for _, kv := range kvs {
+		if cont, err := f(kv.K, kv.V); err != nil {
+			return err
+		} else if !cont {
+			break
+		}
+	}
+	return nil
+}
+
+// List implements the method List of the interface db.Storage
+func (m *memoryStorage) List(limit int) ([]db.KV, error) {
+	ret := []db.KV{}
+	err := m.Iterate(func(key []byte, value []byte) (bool, error) {
+		ret = append(ret, db.KV{K: db.Clone(key), V: db.Clone(value)})
+		if len(ret) == limit {
+			return false, nil
+		}
+		return true, nil
+	})
+	return ret, err
+}
+
+// Close implements the method Close of the interface db.Storage
+func (m *memoryStorage) Close() {}
+
+// Write implements the method Write of the interface Storage
+func (m *memoryStorage) Write(puts []db.KV, dels [][]byte) error {
+	m.data.rw.Lock()
+	defer m.data.rw.Unlock()
+	for _, kv := range puts {
+		m.data.kv[string(db.Concat(m.prefix, kv.K))] = db.Clone(kv.V)
+	}
+	for _, k := range dels {
+		delete(m.data.kv, string(db.Concat(m.prefix, k)))
+	}
+	return nil
+}
+
+// memoryTx implements the interface db.Tx of a memoryStorage
+type memoryTx struct {
+	sto  *memoryStorage
+	puts map[string][]byte
+}
+
+// Get implements the method Get of the interface db.Tx
+func (tx *memoryTx) Get(key []byte) ([]byte, error) {
+	if v, ok := tx.puts[string(db.Concat(tx.sto.prefix, key))]; ok {
+		return v, nil
+	}
+	return tx.sto.Get(key)
+}
+
+// Put implements the method Put of the interface db.Tx
+func (tx *memoryTx) Put(k, v []byte) error {
+	tx.puts[string(db.Concat(tx.sto.prefix, k))] = db.Clone(v)
+	return nil
+}
+
+// Add implements the method Add of the interface db.Tx
+func (tx *memoryTx) Add(atx db.Tx) error {
+	mtx, ok := atx.(*memoryTx)
+	if !ok {
+		return common.Wrap(fmt.Errorf("can't add a %T to a memory tx", atx))
+	}
+	for k, v := range mtx.puts {
+		tx.puts[k] = v
+	}
+	return nil
+}
+
+// Commit implements the method Commit of the interface db.Tx
+func (tx *memoryTx) Commit() error {
+	tx.sto.data.rw.Lock()
+	defer tx.sto.data.rw.Unlock()
+	for k, v := range tx.puts {
+		tx.sto.data.kv[k] = v
+	}
+	tx.puts = nil
+	return nil
+}
+
+// Close implements the method Close of the interface db.Tx
+func (tx *memoryTx) Close() {
+	tx.puts = nil
+}
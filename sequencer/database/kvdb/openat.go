@@ -0,0 +1,178 @@
+package kvdb
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/log"
+
+	"github.com/iden3/go-merkletree/db"
+)
+
+const (
+	// PathOpenAt defines the subpath of the KVDB where the checkpoints
+	// opened with OpenAt are copied
+	PathOpenAt = "openat"
+	// DefaultOpenAtCache is the default value for the OpenAtCache
+	// parameter
+	DefaultOpenAtCache = 8
+)
+
+// ErrCheckpointNotFound is returned by OpenAt when the KVDB doesn't keep the
+// checkpoint of the requested batch
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is a read-only view to the checkpoint of a past batch of the
+// KVDB.  It must be closed after use.
+type Checkpoint struct {
+	db       Storage
+	path     string
+	batchNum common.BatchNum
+	cache    *checkpointCache
+	// refs is the number of users of the Checkpoint
+	refs int
+	// evicted is true when the Checkpoint has been removed from the cache,
+	// so it will be deleted when the last user closes it
+	evicted bool
+}
+
+// DB returns the db.Storage of the checkpoint
+func (c *Checkpoint) DB() db.Storage {
+	return c.db
+}
+
+// BatchNum returns the BatchNum of the checkpoint
+func (c *Checkpoint) BatchNum() common.BatchNum {
+	return c.batchNum
+}
+
+// Close releases the Checkpoint
+func (c *Checkpoint) Close() {
+	c.cache.mutex.Lock()
+	defer c.cache.mutex.Unlock()
+	c.refs--
+	if c.refs == 0 && c.evicted {
+		c.delete()
+	}
+}
+
+// delete closes the storage of the Checkpoint and removes its files
+func (c *Checkpoint) delete() {
+	c.db.Close()
+	if err := c.cache.backend.Remove(c.path); err != nil {
+		log.Errorw("remove checkpoint copy", "path", c.path, "err", err)
+	}
+}
+
+// checkpointCache is a LRU cache of the checkpoints opened with OpenAt.  Each
+// opened checkpoint is a copy of the original one, so that the KVDB can
+// keep using and deleting the original while the copy is being read.
+type checkpointCache struct {
+	mutex   sync.Mutex
+	backend Backend
+	size    int
+	path    string
+	// entries is sorted from the least to the most recently used
+	entries []*Checkpoint
+	// nextID is used to give a unique path to each copy, as an evicted
+	// copy may still be in use when the same batch is opened again
+	nextID int
+}
+
+func newCheckpointCache(backend Backend, kvdbPath string,
+	size int) (*checkpointCache, error) {
+	if size <= 0 {
+		size = DefaultOpenAtCache
+	}
+	cachePath := path.Join(kvdbPath, PathOpenAt)
+	// remove the copies left by a previous run
+	if err := backend.Remove(cachePath); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &checkpointCache{backend: backend, size: size, path: cachePath}, nil
+}
+
+// close deletes all the checkpoints of the cache that are not in use
+func (c *checkpointCache) close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, checkpoint := range c.entries {
+		checkpoint.evicted = true
+		if checkpoint.refs == 0 {
+			checkpoint.delete()
+		}
+	}
+	c.entries = nil
+}
+
+// invalidateFrom removes from the cache the checkpoints of batchNum or
+// later, because they are going to be deleted or overwritten
+func (c *checkpointCache) invalidateFrom(batchNum common.BatchNum) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entries := c.entries[:0]
+	for _, checkpoint := range c.entries {
+		if checkpoint.batchNum < batchNum {
+			entries = append(entries, checkpoint)
+			continue
+		}
+		checkpoint.evicted = true
+		if checkpoint.refs == 0 {
+			checkpoint.delete()
+		}
+	}
+	c.entries = entries
+}
+
+// OpenAt returns a read-only view to the checkpoint of the given batchNum.
+// The last opened checkpoints are kept open in a cache, so that successive
+// reads of the same batches are fast.  The returned Checkpoint must be closed
+// after use.
+func (k *KVDB) OpenAt(batchNum common.BatchNum) (*Checkpoint, error) {
+	c := k.openAt
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, checkpoint := range c.entries {
+		if checkpoint.batchNum == batchNum {
+			// move to the most recently used position
+			c.entries = append(append(c.entries[:i:i], c.entries[i+1:]...), checkpoint)
+			checkpoint.refs++
+			return checkpoint, nil
+		}
+	}
+
+	source := path.Join(k.cfg.Path, fmt.Sprintf("%s%d", PathBatchNum, batchNum))
+	if exists, err := k.backend.Exists(source); err != nil {
+		return nil, common.Wrap(err)
+	} else if !exists {
+		return nil, common.Wrap(fmt.Errorf("%w: batch %d", ErrCheckpointNotFound, batchNum))
+	}
+	checkpointPath := path.Join(c.path, fmt.Sprintf("%s%d-%d", PathBatchNum, batchNum, c.nextID))
+	c.nextID++
+	if err := k.MakeCheckpointFromTo(batchNum, checkpointPath); err != nil {
+		return nil, common.Wrap(err)
+	}
+	sto, err := k.backend.Open(checkpointPath)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	checkpoint := &Checkpoint{
+		db:       sto,
+		path:     checkpointPath,
+		batchNum: batchNum,
+		cache:    c,
+		refs:     1,
+	}
+	c.entries = append(c.entries, checkpoint)
+	if len(c.entries) > c.size {
+		evicted := c.entries[0]
+		c.entries = c.entries[1:]
+		evicted.evicted = true
+		if evicted.refs == 0 {
+			evicted.delete()
+		}
+	}
+	return checkpoint, nil
+}
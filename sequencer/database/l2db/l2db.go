@@ -199,11 +199,15 @@ func (l2db *L2DB) addTxs(txs []common.PoolL2Tx, checkPoolIsFull bool) error {
 		?::NUMERIC, ?::SMALLINT, ?::BIGINT, ?::CHAR(4), ?::VARCHAR, ?::BYTEA, ?::BIGINT,
 		?::BIGINT, ?::BYTEA, ?::BYTEA, ?::INT, ?::NUMERIC, ?::SMALLINT, ?::BIGINT,
 		?::VARCHAR(40), ?::NUMERIC, ?::VARCHAR, ?::SMALLINT, ?::BYTEA, ?::BIGINT)`
+		valuesPart := queryVarsPartPerTx
+		if l2db.isSQLite() {
+			valuesPart = queryVarsPartPerTxSQLite
+		}
 		if i == 0 {
-			queryVarsPart += queryVarsPartPerTx
+			queryVarsPart += valuesPart
 		} else {
 			// Add coma before next tx values.
-			queryVarsPart += ", " + queryVarsPartPerTx
+			queryVarsPart += ", " + valuesPart
 		}
 		// Add values that will replace the ?
 		// caution: hardcoded tokenID and amount as 0
@@ -236,6 +240,9 @@ func (l2db *L2DB) addTxs(txs []common.PoolL2Tx, checkPoolIsFull bool) error {
 			return common.Wrap(errPoolFull)
 		}
 	}
+	if err == nil && l2db.isSQLite() {
+		err = afterInsertPoolTxsSQLite(l2db.dbWrite, txs)
+	}
 	return common.Wrap(err)
 }
 
@@ -254,7 +261,7 @@ func (l2db *L2DB) GetTx(txID common.TxID) (*common.PoolL2Tx, error) {
 	tx := new(common.PoolL2Tx)
 	return tx, common.Wrap(meddler.QueryRow(
 		l2db.dbRead, tx,
-		selectPoolTxCommon+"WHERE tx_id = $1;",
+		l2db.selectPoolTx()+"WHERE tx_id = $1;",
 		txID,
 	))
 }
@@ -277,5 +284,44 @@ func (l2db *L2DB) updateTx(tx common.PoolL2Tx) error {
 
 	query = l2db.dbWrite.Rebind(query)
 	_, err = l2db.dbWrite.Exec(query, args...)
+	if err == nil && l2db.isSQLite() {
+		err = afterUpdatePoolTxSQLite(l2db.dbWrite, tx.TxID)
+	}
+	return common.Wrap(err)
+}
+
+// DoneForging sets the state of the txs referenced by txIDs to Forged in the
+// batch batchNum.  The txIDs that are not in the pool are ignored.
+func (l2db *L2DB) DoneForging(txIDs []common.TxID, batchNum common.BatchNum) error {
+	if len(txIDs) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(
+		`UPDATE tx_pool SET state = ?, batch_num = ? WHERE tx_id IN (?);`,
+		common.PoolL2TxStateForged,
+		batchNum,
+		txIDs,
+	)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	query = l2db.dbWrite.Rebind(query)
+	_, err = l2db.dbWrite.Exec(query, args...)
+	return common.Wrap(err)
+}
+
+// Reorg sets back to Pending the txs that were forged or invalidated in a
+// batch discarded due to a blockchain reorg, that is, a batch after
+// lastValidBatch
+func (l2db *L2DB) Reorg(lastValidBatch common.BatchNum) error {
+	_, err := l2db.dbWrite.Exec(
+		`UPDATE tx_pool SET batch_num = NULL, state = $1, info = NULL
+		WHERE (state = $2 OR state = $3 OR state = $4) AND batch_num > $5;`,
+		common.PoolL2TxStatePending,
+		common.PoolL2TxStateForging,
+		common.PoolL2TxStateForged,
+		common.PoolL2TxStateInvalid,
+		lastValidBatch,
+	)
 	return common.Wrap(err)
 }
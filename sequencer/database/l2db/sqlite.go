@@ -0,0 +1,71 @@
+package l2db
+
+import (
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database"
+
+	"github.com/russross/meddler"
+)
+
+// This file contains the Go equivalents of the plpgsql triggers of the
+// PostgreSQL schema, which are only used with the SQLite backend.
+
+// queryVarsPartPerTxSQLite is the SQLite version of the values inserted per tx
+// in addTxs, which in PostgreSQL are casted to the column types
+const queryVarsPartPerTxSQLite = `(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+	?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// selectPoolTxCommonSQLite is the SQLite version of selectPoolTxCommon
+const selectPoolTxCommonSQLite = `SELECT  tx_pool.tx_id, from_idx, to_idx, tx_pool.to_eth_addr,
+tx_pool.to_bjj, tx_pool.token_id, tx_pool.amount, tx_pool.fee, tx_pool.nonce,
+tx_pool.state, tx_pool.info, tx_pool.signature, tx_pool.timestamp, rq_from_idx,
+rq_to_idx, tx_pool.rq_to_eth_addr, tx_pool.rq_to_bjj, tx_pool.rq_token_id, tx_pool.rq_amount,
+tx_pool.rq_fee, tx_pool.rq_nonce, tx_pool.tx_type, tx_pool.rq_offset, tx_pool.atomic_group_id, tx_pool.max_num_batch,
+(fee_percentage(tx_pool.fee) * token.usd * tx_pool.amount_f) /
+	power(10.0, token.decimals) AS fee_usd, token.usd_update
+FROM tx_pool INNER JOIN token ON tx_pool.token_id = token.token_id `
+
+// isSQLite returns true if the L2DB uses the SQLite backend
+func (l2db *L2DB) isSQLite() bool {
+	return database.IsSQLite(l2db.dbWrite)
+}
+
+// selectPoolTx returns the select part of the queries to get common.PoolL2Tx
+// for the backend in use
+func (l2db *L2DB) selectPoolTx() string {
+	if l2db.isSQLite() {
+		return selectPoolTxCommonSQLite
+	}
+	return selectPoolTxCommon
+}
+
+// afterInsertPoolTxsSQLite sets the effective_{from,to}_{eth_addr,bjj} of the
+// inserted txs.  Equivalent to the `set_pool_tx` trigger.
+func afterInsertPoolTxsSQLite(d meddler.DB, txs []common.PoolL2Tx) error {
+	for i := range txs {
+		if _, err := d.Exec(`UPDATE tx_pool SET (effective_from_eth_addr, effective_from_bjj) =
+			(SELECT eth_addr, bjj FROM account WHERE idx = tx_pool.from_idx)
+			WHERE tx_id = $1;`, txs[i].TxID); err != nil {
+			return common.Wrap(err)
+		}
+		query := `UPDATE tx_pool SET effective_to_eth_addr = to_eth_addr,
+			effective_to_bjj = to_bjj WHERE tx_id = $1;`
+		if txs[i].ToIdx >= common.UserThreshold {
+			query = `UPDATE tx_pool SET (effective_to_eth_addr, effective_to_bjj) =
+				(SELECT eth_addr, bjj FROM account WHERE idx = tx_pool.to_idx)
+				WHERE tx_id = $1;`
+		}
+		if _, err := d.Exec(query, txs[i].TxID); err != nil {
+			return common.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// afterUpdatePoolTxSQLite sets the effective_to_{eth_addr,bjj} of an updated
+// tx.  Equivalent to the `update_pool_tx` trigger.
+func afterUpdatePoolTxSQLite(d meddler.DB, txID common.TxID) error {
+	_, err := d.Exec(`UPDATE tx_pool SET effective_to_eth_addr = to_eth_addr,
+		effective_to_bjj = to_bjj WHERE tx_id = $1;`, txID)
+	return common.Wrap(err)
+}
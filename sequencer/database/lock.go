@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"syscall"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NodeLockID is the key of the PostgreSQL advisory lock held by a running node
+const NodeLockID int64 = 0x746f6b616d616b // "tokamak"
+
+// ErrNodeLockHeld is returned when the node lock is held by another process
+var ErrNodeLockHeld = errors.New("the DB is in use by a running node")
+
+// NodeLock is an exclusive lock on a SQL DB held by a running node, which
+// prevents destructive operations (such as running migrations down) while the
+// node is using the DB.  In PostgreSQL it's a session advisory lock, and in
+// SQLite an flock on a file next to the DB file.
+type NodeLock struct {
+	conn *sql.Conn
+	file *os.File
+}
+
+// TryNodeLock tries to acquire the node lock of db without blocking.  If the
+// lock is held by another process ErrNodeLockHeld is returned.
+func TryNodeLock(db *sqlx.DB) (*NodeLock, error) {
+	if IsSQLite(db) {
+		return trySQLiteNodeLock(db)
+	}
+	// Session advisory locks belong to a connection, so a connection is
+	// taken out of the pool until the lock is released
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	var locked bool
+	if err := conn.QueryRowContext(context.Background(),
+		"SELECT pg_try_advisory_lock($1);", NodeLockID).Scan(&locked); err != nil {
+		_ = conn.Close()
+		return nil, common.Wrap(err)
+	}
+	if !locked {
+		_ = conn.Close()
+		return nil, common.Wrap(ErrNodeLockHeld)
+	}
+	return &NodeLock{conn: conn}, nil
+}
+
+// trySQLiteNodeLock locks the file `<DB path>.lock`.  In-memory DBs can't be
+// shared between processes, so there is nothing to lock for them.
+func trySQLiteNodeLock(db *sqlx.DB) (*NodeLock, error) {
+	var seq int
+	var name, path string
+	if err := db.QueryRow("PRAGMA database_list;").Scan(&seq, &name, &path); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if path == "" {
+		return &NodeLock{}, nil
+	}
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600) //nolint:gomnd
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, common.Wrap(ErrNodeLockHeld)
+		}
+		return nil, common.Wrap(err)
+	}
+	return &NodeLock{file: file}, nil
+}
+
+// Release releases the node lock
+func (l *NodeLock) Release() error {
+	if l.conn != nil {
+		_, err := l.conn.ExecContext(context.Background(),
+			"SELECT pg_advisory_unlock($1);", NodeLockID)
+		if cerr := l.conn.Close(); err == nil {
+			err = cerr
+		}
+		l.conn = nil
+		return common.Wrap(err)
+	}
+	if l.file != nil {
+		err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+		if cerr := l.file.Close(); err == nil {
+			err = cerr
+		}
+		l.file = nil
+		return common.Wrap(err)
+	}
+	return nil
+}
@@ -112,6 +112,7 @@ func (m migrationTest0005) RunAssertsAfterMigrationDown(t *testing.T, db *sqlx.D
 }
 
 func runMigration0005TestWithLongName(t *testing.T, migrationNumber int) {
+	skipIfSQLite(t)
 	miter := migrationTest0005{}
 	// Initialize an empty DB
 	db, err := initCleanSQLDB()
@@ -0,0 +1,99 @@
+-- +migrate Up
+-- Remove the Hermez tables and columns that are not used by the Sybil
+-- contracts: auction, withdrawal delayer, token exchange, buckets and fiat
+DROP TABLE IF EXISTS bid;
+DROP TABLE IF EXISTS auction_vars;
+DROP TABLE IF EXISTS wdelayer_vars;
+DROP TABLE IF EXISTS token_exchange;
+DROP TABLE IF EXISTS escape_hatch_withdrawal;
+DROP TABLE IF EXISTS bucket_update;
+DROP TRIGGER IF EXISTS trigger_fiat_price_update ON fiat;
+DROP FUNCTION IF EXISTS set_fiat_last_update;
+DROP TABLE IF EXISTS fiat;
+ALTER TABLE rollup_vars DROP COLUMN buckets;
+
+-- +migrate Down
+ALTER TABLE rollup_vars ADD COLUMN buckets BYTEA NOT NULL DEFAULT '\x5b5d';
+ALTER TABLE rollup_vars ALTER COLUMN buckets DROP DEFAULT;
+
+CREATE TABLE bid (
+    item_id SERIAL PRIMARY KEY,
+    slot_num BIGINT NOT NULL,
+    bid_value DECIMAL(78,0) NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    bidder_addr BYTEA NOT NULL -- fake foreign key for coordinator
+);
+
+CREATE TABLE bucket_update (
+    item_id SERIAL PRIMARY KEY,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    num_bucket BIGINT NOT NULL,
+    block_stamp BIGINT NOT NULL,
+    withdrawals DECIMAL(78,0) NOT NULL
+);
+
+CREATE TABLE token_exchange (
+    item_id SERIAL PRIMARY KEY,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    eth_addr BYTEA NOT NULL,
+    value_usd BIGINT NOT NULL
+);
+
+CREATE TABLE escape_hatch_withdrawal (
+    item_id SERIAL PRIMARY KEY,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    who_addr BYTEA NOT NULL,
+    to_addr BYTEA NOT NULL,
+    token_addr BYTEA NOT NULL,
+    amount DECIMAL(78,0) NOT NULL
+);
+
+CREATE TABLE auction_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    donation_address BYTEA NOT NULL,
+    boot_coordinator BYTEA NOT NULL,
+    boot_coordinator_url BYTEA NOT NULL,
+    default_slot_set_bid BYTEA NOT NULL,
+    default_slot_set_bid_slot_num BIGINT NOT NULL, -- slot_num after which the new default_slot_set_bid applies
+    closed_auction_slots INT NOT NULL,
+    open_auction_slots INT NOT NULL,
+    allocation_ratio VARCHAR(200),
+    outbidding INT NOT NULL,
+    slot_deadline INT NOT NULL
+);
+
+CREATE TABLE wdelayer_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    gov_address BYTEA NOT NULL,
+    emg_address BYTEA NOT NULL,
+    withdrawal_delay BIGINT NOT NULL,
+    emergency_start_block BIGINT NOT NULL,
+    emergency_mode BOOLEAN NOT NULL
+);
+
+CREATE TABLE fiat (
+    item_id SERIAL NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    base_currency VARCHAR(10) NOT NULL,
+    price NUMERIC NOT NULL,
+    last_update TIMESTAMP WITHOUT TIME ZONE DEFAULT timezone('utc', now()) NOT NULL,
+    PRIMARY KEY(currency, base_currency)
+);
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION set_fiat_last_update()
+    RETURNS TRIGGER
+AS
+$BODY$
+BEGIN
+	IF tg_op = 'INSERT' THEN
+        NEW."last_update" = timezone('utc', now());
+    ELSIF tg_op = 'UPDATE' then
+        NEW."last_update" = timezone('utc', now());
+    END IF;
+    RETURN NEW;
+END;
+$BODY$
+LANGUAGE plpgsql;
+-- +migrate StatementEnd
+CREATE TRIGGER trigger_fiat_price_update BEFORE UPDATE OR INSERT ON fiat
+FOR EACH ROW EXECUTE PROCEDURE set_fiat_last_update();
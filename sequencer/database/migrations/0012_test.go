@@ -0,0 +1,78 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// This migration drops the legacy Hermez tables (bid, auction_vars,
+// wdelayer_vars, token_exchange, escape_hatch_withdrawal, bucket_update and
+// fiat) and the `buckets` column of the `rollup_vars` table
+
+type migrationTest0012 struct{}
+
+func (m migrationTest0012) InsertData(db *sqlx.DB) error {
+	const queryInsert = `
+	INSERT INTO block
+	(eth_block_num, "timestamp", hash)
+	VALUES(48295, '2021-09-13 08:28:39.000', decode('2AB24E7021318D6CF0686E8F8FBFB0A63CB79A9FB5CDECE7C09FD4438E67242F','hex'));
+
+	INSERT INTO rollup_vars
+	(eth_block_num, forge_l1_timeout, buckets, safe_mode)
+	VALUES(48295, 10, decode('5B5D','hex'), false);
+
+	INSERT INTO bid
+	(slot_num, bid_value, eth_block_num, bidder_addr)
+	VALUES(1205, 10, 48295, decode('DCC5DD922FB1D0FD0C450A0636A8CE827521F0ED','hex'));
+
+	INSERT INTO fiat(currency, base_currency, price) VALUES ('EUR','USD',0.82);
+	`
+	_, err := db.Exec(queryInsert)
+	return err
+}
+
+func (m migrationTest0012) RunAssertsAfterMigrationUp(t *testing.T, db *sqlx.DB) {
+	// check that the rollup vars are persisted
+	const queryGetRollupVars = `SELECT COUNT(*) FROM rollup_vars WHERE forge_l1_timeout = 10;`
+	row := db.QueryRow(queryGetRollupVars)
+	var result int
+	assert.NoError(t, row.Scan(&result))
+	assert.Equal(t, 1, result)
+
+	// check that the buckets column and the legacy tables don't exist anymore
+	const queryCheckBuckets = `SELECT COUNT(*) FROM rollup_vars WHERE buckets IS NULL;`
+	row = db.QueryRow(queryCheckBuckets)
+	assert.Equal(t, `pq: column "buckets" does not exist`, row.Scan(&result).Error())
+	for _, table := range []string{"bid", "auction_vars", "wdelayer_vars", "token_exchange",
+		"escape_hatch_withdrawal", "bucket_update", "fiat"} {
+		row = db.QueryRow("SELECT COUNT(*) FROM " + table + ";")
+		assert.Equal(t, `pq: relation "`+table+`" does not exist`, row.Scan(&result).Error())
+	}
+}
+
+func (m migrationTest0012) RunAssertsAfterMigrationDown(t *testing.T, db *sqlx.DB) {
+	// check that the rollup vars are persisted with an empty list of buckets
+	const queryGetRollupVars = `SELECT COUNT(*) FROM rollup_vars
+	WHERE forge_l1_timeout = 10 AND buckets = decode('5B5D','hex');`
+	row := db.QueryRow(queryGetRollupVars)
+	var result int
+	assert.NoError(t, row.Scan(&result))
+	assert.Equal(t, 1, result)
+
+	// check that the legacy tables are created again
+	for _, table := range []string{"bid", "auction_vars", "wdelayer_vars", "token_exchange",
+		"escape_hatch_withdrawal", "bucket_update", "fiat"} {
+		row = db.QueryRow("SELECT COUNT(*) FROM " + table + ";")
+		assert.NoError(t, row.Scan(&result))
+		assert.Equal(t, 0, result)
+	}
+	const queryInsertFiat = `INSERT INTO fiat(currency, base_currency, price) VALUES ('CNY','USD',6.4306);`
+	_, err := db.Exec(queryInsertFiat)
+	assert.NoError(t, err)
+}
+
+func TestMigration0012(t *testing.T) {
+	runMigrationTest(t, 12, migrationTest0012{})
+}
@@ -0,0 +1,11 @@
+-- +migrate Up
+-- Track the owner of the Sybil contract and the verifiers set on each
+-- (re)initialization.  The owner of the vars synced before is unknown (NULL)
+-- and their verifiers are the ones in the constants (empty list).
+ALTER TABLE rollup_vars ADD COLUMN owner BYTEA;
+ALTER TABLE rollup_vars ADD COLUMN verifiers BYTEA NOT NULL DEFAULT '\x5b5d';
+ALTER TABLE rollup_vars ALTER COLUMN verifiers DROP DEFAULT;
+
+-- +migrate Down
+ALTER TABLE rollup_vars DROP COLUMN owner;
+ALTER TABLE rollup_vars DROP COLUMN verifiers;
@@ -0,0 +1,70 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// This migration adds the `owner` and `verifiers` columns to the
+// `rollup_vars` table
+
+type migrationTest0013 struct{}
+
+func (m migrationTest0013) InsertData(db *sqlx.DB) error {
+	const queryInsert = `
+	INSERT INTO block
+	(eth_block_num, "timestamp", hash)
+	VALUES(48295, '2021-09-13 08:28:39.000', decode('2AB24E7021318D6CF0686E8F8FBFB0A63CB79A9FB5CDECE7C09FD4438E67242F','hex'));
+
+	INSERT INTO rollup_vars
+	(eth_block_num, forge_l1_timeout, safe_mode)
+	VALUES(48295, 10, false);
+	`
+	_, err := db.Exec(queryInsert)
+	return err
+}
+
+func (m migrationTest0013) RunAssertsAfterMigrationUp(t *testing.T, db *sqlx.DB) {
+	// check that the owner of the existing vars is unknown and that their
+	// verifiers are an empty list
+	const queryGetRollupVars = `SELECT COUNT(*) FROM rollup_vars
+	WHERE forge_l1_timeout = 10 AND owner IS NULL AND verifiers = decode('5B5D','hex');`
+	row := db.QueryRow(queryGetRollupVars)
+	var result int
+	assert.NoError(t, row.Scan(&result))
+	assert.Equal(t, 1, result)
+
+	// check that the new vars can be inserted
+	const queryInsert = `
+	INSERT INTO block
+	(eth_block_num, "timestamp", hash)
+	VALUES(48296, '2021-09-13 08:28:54.000', decode('3AB24E7021318D6CF0686E8F8FBFB0A63CB79A9FB5CDECE7C09FD4438E67242F','hex'));
+
+	INSERT INTO rollup_vars
+	(eth_block_num, forge_l1_timeout, safe_mode, owner, verifiers)
+	VALUES(48296, 10, false, decode('DCC5DD922FB1D0FD0C450A0636A8CE827521F0ED','hex'),
+	decode('5B7B226D61785478223A3531322C226E6C6576656C73223A33327D5D','hex'));
+	`
+	_, err := db.Exec(queryInsert)
+	assert.NoError(t, err)
+}
+
+func (m migrationTest0013) RunAssertsAfterMigrationDown(t *testing.T, db *sqlx.DB) {
+	// check that the rollup vars are persisted
+	const queryGetRollupVars = `SELECT COUNT(*) FROM rollup_vars WHERE forge_l1_timeout = 10;`
+	row := db.QueryRow(queryGetRollupVars)
+	var result int
+	assert.NoError(t, row.Scan(&result))
+	assert.Equal(t, 2, result)
+
+	// check that the new columns don't exist anymore
+	const queryCheckOwner = `SELECT COUNT(*) FROM rollup_vars WHERE owner IS NULL;`
+	row = db.QueryRow(queryCheckOwner)
+	assert.Equal(t, `pq: column "owner" does not exist`, row.Scan(&result).Error())
+}
+
+func TestMigration0013(t *testing.T) {
+	runMigrationTest(t, 13, migrationTest0013{})
+}
@@ -0,0 +1,358 @@
+-- +migrate Up
+
+-- NOTE: This is the SQLite schema, equivalent to the PostgreSQL schema that
+-- results of applying the migrations 0001 to 0011 found in the parent
+-- directory.  New PostgreSQL migrations must be mirrored here with the same
+-- number.
+--
+-- SQLite doesn't support plpgsql, so the logic of the following PostgreSQL
+-- functions is implemented in Go and only runs when using this backend:
+-- * set_tx: historydb/sqlite.go
+-- * forge_l1_user_txs: historydb/sqlite.go
+-- * set_pool_tx and update_pool_tx: l2db/sqlite.go
+-- * fee_percentage: registered as an SQLite function in database/sqlite.go
+--
+-- The go *big.Int types that PostgreSQL stores as "DECIMAL(78,0)" are stored as
+-- TEXT, since the SQLite NUMERIC affinity would convert the numbers that don't
+-- fit in 64 bits to REAL, losing precision.
+
+-- History
+CREATE TABLE block (
+    eth_block_num BIGINT PRIMARY KEY,
+    timestamp TIMESTAMP NOT NULL,
+    hash BLOB NOT NULL
+);
+
+CREATE TABLE coordinator (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bidder_addr BLOB NOT NULL,
+    forger_addr BLOB NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    url BLOB NOT NULL
+);
+
+CREATE TABLE batch (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_num BIGINT UNIQUE NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    forger_addr BLOB NOT NULL, -- fake foreign key for coordinator
+    fees_collected BLOB NOT NULL,
+    fee_idxs_coordinator BLOB NOT NULL,
+    state_root TEXT NOT NULL,
+    num_accounts BIGINT NOT NULL,
+    last_idx BIGINT NOT NULL,
+    exit_root TEXT NOT NULL,
+    forge_l1_txs_num BIGINT,
+    slot_num BIGINT NOT NULL,
+    total_fees_usd REAL,
+    eth_tx_hash BLOB DEFAULT (X'0000000000000000000000000000000000000000000000000000000000000000'),
+    gas_price TEXT DEFAULT '0',
+    gas_used TEXT DEFAULT '0',
+    ether_price_usd REAL DEFAULT 0
+);
+
+CREATE TABLE bid (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slot_num BIGINT NOT NULL,
+    bid_value TEXT NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    bidder_addr BLOB NOT NULL -- fake foreign key for coordinator
+);
+
+CREATE TABLE token (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_id INT UNIQUE NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    eth_addr BLOB UNIQUE NOT NULL,
+    name VARCHAR NOT NULL,
+    symbol VARCHAR(10) NOT NULL,
+    decimals INT NOT NULL,
+    usd REAL, -- value of a normalized token (1 token = 10^decimals units)
+    usd_update TIMESTAMP
+);
+
+-- Add ETH as TokenID 0
+INSERT INTO block (
+    eth_block_num,
+    timestamp,
+    hash
+) VALUES (
+    0,
+    '2015-07-30 03:26:13',
+    X'd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3'
+); -- info from https://etherscan.io/block/0
+
+INSERT INTO token (
+    token_id,
+    eth_block_num,
+    eth_addr,
+    name,
+    symbol,
+    decimals
+) VALUES (
+    0,
+    0,
+    X'0000000000000000000000000000000000000000',
+    'Ether',
+    'ETH',
+    18
+);
+
+-- +migrate StatementBegin
+CREATE TRIGGER trigger_token_usd_insert AFTER INSERT ON token
+FOR EACH ROW WHEN NEW.usd IS NOT NULL AND NEW.usd_update IS NULL
+BEGIN
+    UPDATE token SET usd_update = CURRENT_TIMESTAMP WHERE item_id = NEW.item_id;
+END;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TRIGGER trigger_token_usd_update AFTER UPDATE OF usd ON token
+FOR EACH ROW
+BEGIN
+    UPDATE token SET usd_update = CURRENT_TIMESTAMP WHERE item_id = NEW.item_id;
+END;
+-- +migrate StatementEnd
+
+CREATE TABLE account (
+    item_id INTEGER,
+    idx BIGINT PRIMARY KEY,
+    batch_num BIGINT NOT NULL REFERENCES batch (batch_num) ON DELETE CASCADE,
+    bjj BLOB NOT NULL,
+    eth_addr BLOB NOT NULL,
+    nonce BIGINT NOT NULL,
+    balance TEXT NOT NULL
+);
+
+-- Emulate the PostgreSQL SERIAL item_id, which is not the primary key
+-- +migrate StatementBegin
+CREATE TRIGGER trigger_account_item_id AFTER INSERT ON account
+FOR EACH ROW WHEN NEW.item_id IS NULL
+BEGIN
+    UPDATE account SET item_id = NEW.rowid WHERE idx = NEW.idx;
+END;
+-- +migrate StatementEnd
+
+CREATE TABLE account_update (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    batch_num BIGINT NOT NULL REFERENCES batch (batch_num) ON DELETE CASCADE,
+    idx BIGINT NOT NULL REFERENCES account (idx) ON DELETE CASCADE,
+    nonce BIGINT NOT NULL,
+    balance TEXT NOT NULL
+);
+
+CREATE TABLE exit_tree (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    batch_num BIGINT REFERENCES batch (batch_num) ON DELETE CASCADE,
+    account_idx BIGINT REFERENCES account (idx) ON DELETE CASCADE,
+    merkle_proof BLOB NOT NULL,
+    balance TEXT NOT NULL,
+    instant_withdrawn BIGINT REFERENCES block (eth_block_num) ON DELETE SET NULL,
+    delayed_withdraw_request BIGINT REFERENCES block (eth_block_num) ON DELETE SET NULL,
+    owner BLOB,
+    token BLOB,
+    delayed_withdrawn BIGINT REFERENCES block (eth_block_num) ON DELETE SET NULL
+);
+
+-- See the PostgreSQL schema for the semantics of "amount_success" and
+-- "deposit_amount_success".  The item_id of forged L1 user txs is updated
+-- when the batch that forges them is inserted (forge_l1_user_txs).
+CREATE TABLE tx (
+    -- Generic TX
+    item_id INTEGER PRIMARY KEY,
+    is_l1 BOOLEAN NOT NULL,
+    id BLOB,
+    type VARCHAR(40) NOT NULL,
+    position INT NOT NULL,
+    from_idx BIGINT,
+    effective_from_idx BIGINT REFERENCES account (idx) ON DELETE SET NULL,
+    from_eth_addr BLOB,
+    from_bjj BLOB,
+    to_idx BIGINT NOT NULL,
+    to_eth_addr BLOB,
+    to_bjj BLOB,
+    amount TEXT NOT NULL,
+    amount_success BOOLEAN NOT NULL DEFAULT true,
+    amount_f REAL NOT NULL,
+    amount_usd REAL, -- Value of the amount in USD at the moment the tx was inserted in the DB
+    batch_num BIGINT REFERENCES batch (batch_num) ON DELETE SET NULL, -- Can be NULL in the case of L1 txs that are on the queue but not forged yet.
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    -- L1
+    to_forge_l1_txs_num BIGINT,
+    user_origin BOOLEAN,
+    deposit_amount TEXT,
+    deposit_amount_success BOOLEAN NOT NULL DEFAULT true,
+    deposit_amount_f REAL,
+    deposit_amount_usd REAL,
+    -- L2
+    fee INT,
+    fee_usd REAL,
+    nonce BIGINT,
+    eth_tx_hash BLOB,
+    l1_fee TEXT
+);
+
+CREATE TABLE rollup_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    forge_l1_timeout BIGINT NOT NULL,
+    buckets BLOB NOT NULL,
+    safe_mode BOOLEAN NOT NULL
+);
+
+CREATE TABLE bucket_update (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    num_bucket BIGINT NOT NULL,
+    block_stamp BIGINT NOT NULL,
+    withdrawals TEXT NOT NULL
+);
+
+CREATE TABLE token_exchange (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    eth_addr BLOB NOT NULL,
+    value_usd BIGINT NOT NULL
+);
+
+CREATE TABLE escape_hatch_withdrawal (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    who_addr BLOB NOT NULL,
+    to_addr BLOB NOT NULL,
+    token_addr BLOB NOT NULL,
+    amount TEXT NOT NULL
+);
+
+CREATE TABLE auction_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    donation_address BLOB NOT NULL,
+    boot_coordinator BLOB NOT NULL,
+    boot_coordinator_url BLOB NOT NULL,
+    default_slot_set_bid BLOB NOT NULL,
+    default_slot_set_bid_slot_num BIGINT NOT NULL, -- slot_num after which the new default_slot_set_bid applies
+    closed_auction_slots INT NOT NULL,
+    open_auction_slots INT NOT NULL,
+    allocation_ratio VARCHAR(200),
+    outbidding INT NOT NULL,
+    slot_deadline INT NOT NULL
+);
+
+CREATE TABLE wdelayer_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    gov_address BLOB NOT NULL,
+    emg_address BLOB NOT NULL,
+    withdrawal_delay BIGINT NOT NULL,
+    emergency_start_block BIGINT NOT NULL,
+    emergency_mode BOOLEAN NOT NULL
+);
+
+-- L2
+CREATE TABLE tx_pool (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tx_id BLOB NOT NULL CONSTRAINT tx_id_unique UNIQUE,
+    from_idx BIGINT NOT NULL,
+    effective_from_eth_addr BLOB,
+    effective_from_bjj BLOB,
+    to_idx BIGINT,
+    to_eth_addr BLOB,
+    to_bjj BLOB,
+    effective_to_eth_addr BLOB,
+    effective_to_bjj BLOB,
+    token_id INT NOT NULL REFERENCES token (token_id) ON DELETE CASCADE,
+    amount TEXT NOT NULL,
+    amount_f REAL NOT NULL,
+    fee SMALLINT NOT NULL,
+    nonce BIGINT NOT NULL,
+    state CHAR(4) NOT NULL,
+    info VARCHAR,
+    signature BLOB NOT NULL,
+    timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    batch_num BIGINT,
+    rq_from_idx BIGINT,
+    rq_to_idx BIGINT,
+    rq_to_eth_addr BLOB,
+    rq_to_bjj BLOB,
+    rq_token_id INT,
+    rq_amount TEXT,
+    rq_fee SMALLINT,
+    rq_nonce BIGINT,
+    tx_type VARCHAR(40) NOT NULL,
+    client_ip VARCHAR,
+    external_delete BOOLEAN NOT NULL DEFAULT false,
+    rq_offset SMALLINT DEFAULT NULL,
+    atomic_group_id BLOB DEFAULT NULL,
+    max_num_batch BIGINT DEFAULT NULL,
+    error_code NUMERIC,
+    error_type VARCHAR
+);
+
+CREATE TABLE account_creation_auth (
+    eth_addr BLOB PRIMARY KEY,
+    bjj BLOB NOT NULL,
+    signature BLOB NOT NULL,
+    timestamp TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE node_info (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    state BLOB,            -- object returned by GET /state
+    config BLOB,           -- Node config
+    constants BLOB         -- info of the network that is constant
+);
+INSERT INTO node_info(item_id) VALUES (1); -- Always have a single row that we will update
+
+CREATE VIEW account_state AS SELECT DISTINCT idx,
+first_value(nonce) OVER w AS nonce,
+first_value(balance) OVER w AS balance,
+first_value(eth_block_num) OVER w AS eth_block_num,
+first_value(batch_num) OVER w AS batch_num
+FROM account_update
+window w AS (partition by idx ORDER BY item_id desc);
+
+CREATE TABLE fiat (
+    item_id INTEGER NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    base_currency VARCHAR(10) NOT NULL,
+    price REAL NOT NULL,
+    last_update TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY(currency, base_currency)
+);
+
+-- +migrate StatementBegin
+CREATE TRIGGER trigger_fiat_price_update AFTER UPDATE OF price ON fiat
+FOR EACH ROW
+BEGIN
+    UPDATE fiat SET last_update = CURRENT_TIMESTAMP
+    WHERE currency = NEW.currency AND base_currency = NEW.base_currency;
+END;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- triggers
+DROP TRIGGER IF EXISTS trigger_fiat_price_update;
+DROP TRIGGER IF EXISTS trigger_account_item_id;
+DROP TRIGGER IF EXISTS trigger_token_usd_update;
+DROP TRIGGER IF EXISTS trigger_token_usd_insert;
+-- drop views IF EXISTS
+DROP VIEW IF EXISTS account_state;
+-- drop tables IF EXISTS
+DROP TABLE IF EXISTS fiat;
+DROP TABLE IF EXISTS node_info;
+DROP TABLE IF EXISTS account_creation_auth;
+DROP TABLE IF EXISTS tx_pool;
+DROP TABLE IF EXISTS auction_vars;
+DROP TABLE IF EXISTS rollup_vars;
+DROP TABLE IF EXISTS escape_hatch_withdrawal;
+DROP TABLE IF EXISTS bucket_update;
+DROP TABLE IF EXISTS token_exchange;
+DROP TABLE IF EXISTS wdelayer_vars;
+DROP TABLE IF EXISTS tx;
+DROP TABLE IF EXISTS exit_tree;
+DROP TABLE IF EXISTS account_update;
+DROP TABLE IF EXISTS account;
+DROP TABLE IF EXISTS token;
+DROP TABLE IF EXISTS bid;
+DROP TABLE IF EXISTS batch;
+DROP TABLE IF EXISTS coordinator;
+DROP TABLE IF EXISTS block;
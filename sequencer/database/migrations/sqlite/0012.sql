@@ -0,0 +1,88 @@
+-- +migrate Up
+-- Remove the Hermez tables and columns that are not used by the Sybil
+-- contracts: auction, withdrawal delayer, token exchange, buckets and fiat
+DROP TABLE IF EXISTS bid;
+DROP TABLE IF EXISTS auction_vars;
+DROP TABLE IF EXISTS wdelayer_vars;
+DROP TABLE IF EXISTS token_exchange;
+DROP TABLE IF EXISTS escape_hatch_withdrawal;
+DROP TABLE IF EXISTS bucket_update;
+DROP TRIGGER IF EXISTS trigger_fiat_price_update;
+DROP TABLE IF EXISTS fiat;
+ALTER TABLE rollup_vars DROP COLUMN buckets;
+
+-- +migrate Down
+ALTER TABLE rollup_vars ADD COLUMN buckets BLOB NOT NULL DEFAULT X'5b5d';
+
+CREATE TABLE bid (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slot_num BIGINT NOT NULL,
+    bid_value TEXT NOT NULL,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    bidder_addr BLOB NOT NULL -- fake foreign key for coordinator
+);
+
+CREATE TABLE bucket_update (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    num_bucket BIGINT NOT NULL,
+    block_stamp BIGINT NOT NULL,
+    withdrawals TEXT NOT NULL
+);
+
+CREATE TABLE token_exchange (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    eth_addr BLOB NOT NULL,
+    value_usd BIGINT NOT NULL
+);
+
+CREATE TABLE escape_hatch_withdrawal (
+    item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    eth_block_num BIGINT NOT NULL REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    who_addr BLOB NOT NULL,
+    to_addr BLOB NOT NULL,
+    token_addr BLOB NOT NULL,
+    amount TEXT NOT NULL
+);
+
+CREATE TABLE auction_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    donation_address BLOB NOT NULL,
+    boot_coordinator BLOB NOT NULL,
+    boot_coordinator_url BLOB NOT NULL,
+    default_slot_set_bid BLOB NOT NULL,
+    default_slot_set_bid_slot_num BIGINT NOT NULL, -- slot_num after which the new default_slot_set_bid applies
+    closed_auction_slots INT NOT NULL,
+    open_auction_slots INT NOT NULL,
+    allocation_ratio VARCHAR(200),
+    outbidding INT NOT NULL,
+    slot_deadline INT NOT NULL
+);
+
+CREATE TABLE wdelayer_vars (
+    eth_block_num BIGINT PRIMARY KEY REFERENCES block (eth_block_num) ON DELETE CASCADE,
+    gov_address BLOB NOT NULL,
+    emg_address BLOB NOT NULL,
+    withdrawal_delay BIGINT NOT NULL,
+    emergency_start_block BIGINT NOT NULL,
+    emergency_mode BOOLEAN NOT NULL
+);
+
+CREATE TABLE fiat (
+    item_id INTEGER NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    base_currency VARCHAR(10) NOT NULL,
+    price REAL NOT NULL,
+    last_update TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY(currency, base_currency)
+);
+
+-- +migrate StatementBegin
+CREATE TRIGGER trigger_fiat_price_update AFTER UPDATE OF price ON fiat
+FOR EACH ROW
+BEGIN
+    UPDATE fiat SET last_update = CURRENT_TIMESTAMP
+    WHERE currency = NEW.currency AND base_currency = NEW.base_currency;
+END;
+-- +migrate StatementEnd
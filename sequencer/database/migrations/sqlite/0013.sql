@@ -0,0 +1,10 @@
+-- +migrate Up
+-- Track the owner of the Sybil contract and the verifiers set on each
+-- (re)initialization.  The owner of the vars synced before is unknown (NULL)
+-- and their verifiers are the ones in the constants (empty list).
+ALTER TABLE rollup_vars ADD COLUMN owner BLOB;
+ALTER TABLE rollup_vars ADD COLUMN verifiers BLOB NOT NULL DEFAULT X'5b5d';
+
+-- +migrate Down
+ALTER TABLE rollup_vars DROP COLUMN owner;
+ALTER TABLE rollup_vars DROP COLUMN verifiers;
@@ -40,7 +40,16 @@ type migrationTester interface {
 	RunAssertsAfterMigrationDown(*testing.T, *sqlx.DB)
 }
 
+// skipIfSQLite skips the migration tests when the tests run against SQLite,
+// since the migrations in this directory are the PostgreSQL ones
+func skipIfSQLite(t *testing.T) {
+	if os.Getenv(dbUtils.TestDBDriverEnv) == dbUtils.DriverSQLite {
+		t.Skip("migration tests run only against PostgreSQL")
+	}
+}
+
 func runMigrationTest(t *testing.T, migrationNumber int, miter migrationTester) {
+	skipIfSQLite(t)
 	// Initialize an empty DB
 	db, err := initCleanSQLDB()
 	require.NoError(t, err)
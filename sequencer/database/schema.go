@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// migrationsTable is the table where sql-migrate stores the applied migrations
+const migrationsTable = "gorp_migrations"
+
+// postgresSchemaQueries are the queries used to describe the schema of a
+// PostgreSQL DB.  Each row of each query describes a schema object.
+var postgresSchemaQueries = []string{
+	`SELECT 'column', table_name || '.' || column_name, data_type, is_nullable,
+		COALESCE(column_default, '')
+	FROM information_schema.columns
+	WHERE table_schema = current_schema() AND table_name <> '` + migrationsTable + `';`,
+	`SELECT 'index', indexname, indexdef FROM pg_indexes
+	WHERE schemaname = current_schema() AND tablename <> '` + migrationsTable + `';`,
+	`SELECT 'constraint', conrelid::regclass::text || '.' || conname,
+		pg_get_constraintdef(oid)
+	FROM pg_constraint
+	WHERE connamespace = current_schema()::regnamespace
+		AND conrelid::regclass::text <> '` + migrationsTable + `';`,
+	`SELECT 'trigger', event_object_table || '.' || trigger_name,
+		action_timing, event_manipulation, action_statement
+	FROM information_schema.triggers WHERE trigger_schema = current_schema();`,
+	`SELECT 'function', p.proname, md5(pg_get_functiondef(p.oid))
+	FROM pg_proc p INNER JOIN pg_namespace n ON n.oid = p.pronamespace
+	WHERE n.nspname = current_schema() AND p.prokind IN ('f', 'p');`,
+	`SELECT 'view', table_name, md5(view_definition) FROM information_schema.views
+	WHERE table_schema = current_schema();`,
+	`SELECT 'type', t.typname, string_agg(e.enumlabel, ',' ORDER BY e.enumsortorder)
+	FROM pg_type t INNER JOIN pg_enum e ON e.enumtypid = t.oid
+	INNER JOIN pg_namespace n ON n.oid = t.typnamespace
+	WHERE n.nspname = current_schema() GROUP BY t.typname;`,
+}
+
+// sqliteSchemaQueries are the queries used to describe the schema of a SQLite
+// DB.  Each row of each query describes a schema object.
+var sqliteSchemaQueries = []string{
+	`SELECT type, name, COALESCE(sql, '') FROM sqlite_master
+	WHERE name NOT LIKE 'sqlite_%' AND tbl_name <> '` + migrationsTable + `';`,
+}
+
+// DumpSchema returns a sorted list of the objects (tables, columns, indexes,
+// triggers, etc.) of the schema of db, one line per object, excluding the
+// table used to track the migrations.
+func DumpSchema(db *sqlx.DB) ([]string, error) {
+	queries := postgresSchemaQueries
+	if IsSQLite(db) {
+		queries = sqliteSchemaQueries
+	}
+	var schema []string
+	for _, query := range queries {
+		lines, err := querySchemaLines(db, query)
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		schema = append(schema, lines...)
+	}
+	sort.Strings(schema)
+	return schema, nil
+}
+
+// querySchemaLines runs query and joins the columns of every row in a line
+// with normalized whitespace
+func querySchemaLines(db *sqlx.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer rows.Close() //nolint:errcheck
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	var lines []string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, common.Wrap(err)
+		}
+		fields := make([]string, len(values))
+		for i := range values {
+			fields[i] = strings.Join(strings.Fields(values[i].String), " ")
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	return lines, common.Wrap(rows.Err())
+}
+
+// SchemaDiff is the difference between an expected and an actual schema
+type SchemaDiff struct {
+	// Missing are the objects of the expected schema that are not in the
+	// actual schema
+	Missing []string
+	// Unexpected are the objects of the actual schema that are not in the
+	// expected schema
+	Unexpected []string
+}
+
+// Empty returns true if both schemas are equal
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0
+}
+
+// DiffSchema compares two schemas returned by DumpSchema
+func DiffSchema(expected, actual []string) *SchemaDiff {
+	inActual := make(map[string]bool, len(actual))
+	for _, line := range actual {
+		inActual[line] = true
+	}
+	inExpected := make(map[string]bool, len(expected))
+	var diff SchemaDiff
+	for _, line := range expected {
+		inExpected[line] = true
+		if !inActual[line] {
+			diff.Missing = append(diff.Missing, line)
+		}
+	}
+	for _, line := range actual {
+		if !inExpected[line] {
+			diff.Unexpected = append(diff.Unexpected, line)
+		}
+	}
+	return &diff
+}
+
+// VerifySchema runs all the migrations Up in the empty DB scratch, and
+// returns the difference between the resulting schema and the schema of db
+func VerifySchema(db, scratch *sqlx.DB) (*SchemaDiff, error) {
+	if err := MigrationsUp(scratch.DB); err != nil {
+		return nil, common.Wrap(err)
+	}
+	expected, err := DumpSchema(scratch)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	actual, err := DumpSchema(db)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return DiffSchema(expected, actual), nil
+}
+
+// CreateScratchSQLDB creates an empty PostgreSQL DB named name using the
+// connection db, and connects to it with the given credentials.  The returned
+// function closes the connection and drops the DB.
+func CreateScratchSQLDB(db *sqlx.DB, port int, host, user, password,
+	name string) (*sqlx.DB, func() error, error) {
+	if _, err := db.Exec(fmt.Sprintf("CREATE DATABASE %s;", pq.QuoteIdentifier(name))); err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	drop := func() error {
+		_, err := db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s;", pq.QuoteIdentifier(name)))
+		return common.Wrap(err)
+	}
+	scratch, err := ConnectSQLDB(port, host, user, password, name)
+	if err != nil {
+		return nil, nil, common.Wrap(fmt.Errorf("%w (drop: %v)", err, drop()))
+	}
+	return scratch, func() error {
+		if err := scratch.Close(); err != nil {
+			return common.Wrap(err)
+		}
+		return drop()
+	}, nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySchema(t *testing.T) {
+	db, err := InitSQLiteDB(SQLiteMemory)
+	require.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+	scratch, err := ConnectSQLiteDB(SQLiteMemory)
+	require.NoError(t, err)
+	defer scratch.Close() //nolint:errcheck
+
+	diff, err := VerifySchema(db, scratch)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	_, err = db.Exec("DROP VIEW account_state;")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE foo (bar INT);")
+	require.NoError(t, err)
+	expected, err := DumpSchema(scratch)
+	require.NoError(t, err)
+	actual, err := DumpSchema(db)
+	require.NoError(t, err)
+	diff = DiffSchema(expected, actual)
+	require.Equal(t, 1, len(diff.Missing))
+	assert.Contains(t, diff.Missing[0], "view account_state")
+	assert.Equal(t, []string{"table foo CREATE TABLE foo (bar INT)"}, diff.Unexpected)
+}
+
+func TestNodeLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.db")
+	db1, err := InitSQLiteDB(path)
+	require.NoError(t, err)
+	defer db1.Close() //nolint:errcheck
+	db2, err := ConnectSQLiteDB(path)
+	require.NoError(t, err)
+	defer db2.Close() //nolint:errcheck
+
+	lock, err := TryNodeLock(db1)
+	require.NoError(t, err)
+	_, err = TryNodeLock(db2)
+	assert.ErrorIs(t, err, ErrNodeLockHeld)
+
+	require.NoError(t, lock.Release())
+	lock, err = TryNodeLock(db2)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
@@ -0,0 +1,358 @@
+/*
+Package snapshot exports and imports the state of a synchronizer at a batch, so
+that a new node can be bootstrapped without syncing every block from the
+genesis.
+
+A snapshot is a gzipped tar archive with the following files:
+
+	statedb/...      the files of the StateDB checkpoint of the batch
+	historydb.gob    the HistoryDB rows up to the block of the batch
+	manifest.json    the Manifest, written last
+
+The Manifest contains the format version, the batch, the roots of the StateDB
+merkle trees and the SHA-256 checksum of every other file of the archive.  On
+import the checksums and the StateDB trees are verified, and the roots are
+compared with the ones forged in the Rollup Smart Contract before anything is
+written to the node databases.
+*/
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/log"
+)
+
+const (
+	// Version is the version of the snapshot format
+	Version = 1
+
+	fileManifest  = "manifest.json"
+	fileHistoryDB = "historydb.gob"
+	dirStateDB    = "statedb"
+)
+
+// ErrInvalidSnapshot is returned when a snapshot archive is malformed, fails
+// the checksums or doesn't match the chain
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+func init() {
+	// The HistoryDB rows contain the values scanned from the SQL DB
+	gob.Register(time.Time{})
+}
+
+// Manifest describes the contents of a snapshot
+type Manifest struct {
+	Version     int             `json:"version"`
+	ChainID     uint16          `json:"chainId"`
+	BatchNum    common.BatchNum `json:"batchNum"`
+	EthBlockNum int64           `json:"ethBlockNum"`
+	StateRoot   *big.Int        `json:"stateRoot"`
+	VouchRoot   *big.Int        `json:"vouchRoot"`
+	ScoreRoot   *big.Int        `json:"scoreRoot"`
+	Files       []File          `json:"files"`
+}
+
+// File is an entry of the Manifest
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Chain is the subset of the eth client used to verify a snapshot
+type Chain interface {
+	EthChainID() (*big.Int, error)
+	RollupBatchRoots(batchNum int64) (*eth.RollupBatchRoots, error)
+}
+
+// Export writes to w the snapshot of the state at batchNum
+func Export(w io.Writer, stateDB *statedb.StateDB, historyDB *historydb.HistoryDB,
+	batchNum common.BatchNum) (*Manifest, error) {
+	hdbSnapshot, err := historyDB.ExportSnapshot(batchNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if hdbSnapshot.Constants == nil {
+		return nil, common.Wrap(fmt.Errorf("no constants in the HistoryDB"))
+	}
+	tmpPath, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer removeAll(tmpPath)
+	checkpointPath := path.Join(tmpPath, dirStateDB)
+	roots, err := stateDB.ExportCheckpoint(batchNum, checkpointPath)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	manifest := &Manifest{
+		Version:     Version,
+		ChainID:     hdbSnapshot.Constants.ChainID,
+		BatchNum:    batchNum,
+		EthBlockNum: hdbSnapshot.EthBlockNum,
+		StateRoot:   roots.Account,
+		VouchRoot:   roots.Vouch,
+		ScoreRoot:   roots.Score,
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	if err := filepath.Walk(checkpointPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(tmpPath, p)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		f, err := os.Open(filepath.Clean(p))
+		if err != nil {
+			return common.Wrap(err)
+		}
+		defer f.Close() //nolint:errcheck
+		file, err := writeFile(tw, filepath.ToSlash(rel), info.Size(), f)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		manifest.Files = append(manifest.Files, *file)
+		return nil
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+
+	// The gob is encoded to a file first since the tar header needs its
+	// size
+	hdbFile, err := ioutil.TempFile(tmpPath, fileHistoryDB)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer hdbFile.Close() //nolint:errcheck
+	if err := gob.NewEncoder(hdbFile).Encode(hdbSnapshot); err != nil {
+		return nil, common.Wrap(err)
+	}
+	hdbSize, err := hdbFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if _, err := hdbFile.Seek(0, io.SeekStart); err != nil {
+		return nil, common.Wrap(err)
+	}
+	file, err := writeFile(tw, fileHistoryDB, hdbSize, hdbFile)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	manifest.Files = append(manifest.Files, *file)
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if _, err := writeFile(tw, fileManifest, int64(len(manifestBytes)),
+		strings.NewReader(string(manifestBytes))); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return manifest, nil
+}
+
+// writeFile adds a file to the tar archive and returns its manifest entry
+func writeFile(tw *tar.Writer, name string, size int64, r io.Reader) (*File, error) {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0600, //nolint:gomnd
+		Size:     size,
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(tw, h), r, size); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &File{Name: name, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// Import reads the snapshot from r and sets it as the state of stateDB and
+// historyDB, which must be empty.  The synchronizer resumes the sync from the
+// block of the snapshot batch.  Since the StateDB only has the checkpoint of
+// the snapshot batch, reorgs to a previous batch require syncing from scratch.
+func Import(r io.Reader, stateDB *statedb.StateDB, historyDB *historydb.HistoryDB,
+	chain Chain) (*Manifest, error) {
+	tmpPath, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer removeAll(tmpPath)
+	manifest, err := extract(r, tmpPath)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+
+	chainID, err := chain.EthChainID()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if chainID.Cmp(big.NewInt(int64(manifest.ChainID))) != 0 {
+		return nil, common.Wrap(fmt.Errorf("%w: the snapshot is of chain %v, but the node is on chain %v",
+			ErrInvalidSnapshot, manifest.ChainID, chainID))
+	}
+	// The state must match the roots forged on chain
+	onChain, err := chain.RollupBatchRoots(int64(manifest.BatchNum))
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	for _, root := range []struct {
+		name              string
+		snapshot, onChain *big.Int
+	}{
+		{"state", manifest.StateRoot, onChain.StateRoot},
+		{"vouch", manifest.VouchRoot, onChain.VouchRoot},
+		{"score", manifest.ScoreRoot, onChain.ScoreRoot},
+	} {
+		if root.snapshot == nil || root.onChain == nil || root.snapshot.Cmp(root.onChain) != 0 {
+			return nil, common.Wrap(fmt.Errorf("%w: %v root of batch %v is %v, but %v on chain",
+				ErrInvalidSnapshot, root.name, manifest.BatchNum, root.snapshot, root.onChain))
+		}
+	}
+
+	f, err := os.Open(filepath.Clean(path.Join(tmpPath, fileHistoryDB)))
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	defer f.Close() //nolint:errcheck
+	var hdbSnapshot historydb.Snapshot
+	if err := gob.NewDecoder(f).Decode(&hdbSnapshot); err != nil {
+		return nil, common.Wrap(fmt.Errorf("%w: %v: %v", ErrInvalidSnapshot, fileHistoryDB, err))
+	}
+	if hdbSnapshot.BatchNum != manifest.BatchNum ||
+		hdbSnapshot.EthBlockNum != manifest.EthBlockNum {
+		return nil, common.Wrap(fmt.Errorf("%w: the HistoryDB rows are at batch %v, block %v",
+			ErrInvalidSnapshot, hdbSnapshot.BatchNum, hdbSnapshot.EthBlockNum))
+	}
+	if err := historyDB.CheckSnapshotTables(&hdbSnapshot); err != nil {
+		return nil, common.Wrap(fmt.Errorf("%w: %v: %v", ErrInvalidSnapshot, fileHistoryDB, err))
+	}
+
+	if err := stateDB.ImportCheckpoint(manifest.BatchNum, path.Join(tmpPath, dirStateDB),
+		&statedb.TreeRoots{
+			Account: manifest.StateRoot,
+			Vouch:   manifest.VouchRoot,
+			Score:   manifest.ScoreRoot,
+		}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := historyDB.ImportSnapshot(&hdbSnapshot); err != nil {
+		// Leave the StateDB empty again
+		if err := stateDB.Reset(0); err != nil {
+			log.Errorw("stateDB.Reset", "err", err)
+		}
+		return nil, common.Wrap(err)
+	}
+	return manifest, nil
+}
+
+// extract extracts the snapshot archive into dir and returns its Manifest
+// after checking the version and the checksums of the files
+func extract(r io.Reader, dir string) (*Manifest, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("%w: %v", ErrInvalidSnapshot, err))
+	}
+	defer gzr.Close() //nolint:errcheck
+	tr := tar.NewReader(gzr)
+	files := map[string]File{}
+	var manifestBytes []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, common.Wrap(fmt.Errorf("%w: %v", ErrInvalidSnapshot, err))
+		}
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || path.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return nil, common.Wrap(fmt.Errorf("%w: unexpected entry %q", ErrInvalidSnapshot,
+				hdr.Name))
+		}
+		if name == fileManifest {
+			if manifestBytes, err = ioutil.ReadAll(tr); err != nil {
+				return nil, common.Wrap(err)
+			}
+			continue
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil { //nolint:gomnd
+			return nil, common.Wrap(err)
+		}
+		f, err := os.OpenFile(filepath.Clean(dest), os.O_CREATE|os.O_WRONLY|os.O_EXCL,
+			0600) //nolint:gomnd
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		h := sha256.New()
+		size, err := io.Copy(io.MultiWriter(f, h), tr)
+		if errClose := f.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		files[name] = File{Name: name, Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if manifestBytes == nil {
+		return nil, common.Wrap(fmt.Errorf("%w: no %v", ErrInvalidSnapshot, fileManifest))
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, common.Wrap(fmt.Errorf("%w: %v: %v", ErrInvalidSnapshot, fileManifest, err))
+	}
+	if manifest.Version != Version {
+		return nil, common.Wrap(fmt.Errorf("%w: unsupported version %v, expected %v",
+			ErrInvalidSnapshot, manifest.Version, Version))
+	}
+	if len(manifest.Files) != len(files) {
+		return nil, common.Wrap(fmt.Errorf("%w: the manifest lists %v files, but the archive has %v",
+			ErrInvalidSnapshot, len(manifest.Files), len(files)))
+	}
+	for _, expected := range manifest.Files {
+		if file, ok := files[expected.Name]; !ok || file != expected {
+			return nil, common.Wrap(fmt.Errorf("%w: checksum mismatch of %v",
+				ErrInvalidSnapshot, expected.Name))
+		}
+	}
+	if _, ok := files[fileHistoryDB]; !ok {
+		return nil, common.Wrap(fmt.Errorf("%w: no %v", ErrInvalidSnapshot, fileHistoryDB))
+	}
+	return &manifest, nil
+}
+
+func removeAll(p string) {
+	if err := os.RemoveAll(p); err != nil {
+		log.Errorw("os.RemoveAll", "path", p, "err", err)
+	}
+}
@@ -0,0 +1,258 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database"
+	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/eth"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chainID = 5
+
+type testChain struct {
+	roots map[int64]*eth.RollupBatchRoots
+}
+
+func (c *testChain) EthChainID() (*big.Int, error) {
+	return big.NewInt(chainID), nil
+}
+
+func (c *testChain) RollupBatchRoots(batchNum int64) (*eth.RollupBatchRoots, error) {
+	if roots, ok := c.roots[batchNum]; ok {
+		return roots, nil
+	}
+	return &eth.RollupBatchRoots{StateRoot: big.NewInt(0), VouchRoot: big.NewInt(0),
+		ScoreRoot: big.NewInt(0)}, nil
+}
+
+func newHistoryDB(t *testing.T) *historydb.HistoryDB {
+	db, err := database.InitSQLiteDB(database.SQLiteMemory)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck
+	return historydb.NewHistoryDB(db, db, nil)
+}
+
+func newStateDB(t *testing.T) *statedb.StateDB {
+	dir, err := ioutil.TempDir("", "tmpdb")
+	require.NoError(t, err)
+	sdb, err := statedb.NewStateDB(statedb.Config{Path: dir, Keep: 128,
+		Type: statedb.TypeSynchronizer, NLevels: statedb.MaxNLevels})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sdb.Close()
+		os.RemoveAll(dir) //nolint:errcheck
+	})
+	return sdb
+}
+
+// newSource returns a StateDB and a HistoryDB with 4 batches: batch 1 is
+// forged in block 1, batches 2 and 3 in block 2 and batch 4 in block 3, which
+// forges an L1 user tx added in block 2
+func newSource(t *testing.T) (*statedb.StateDB, *historydb.HistoryDB, *testChain) {
+	hdb := newHistoryDB(t)
+	sdb := newStateDB(t)
+	chain := &testChain{roots: map[int64]*eth.RollupBatchRoots{}}
+	require.NoError(t, hdb.SetConstants(&historydb.Constants{ChainID: chainID}))
+
+	var sk babyjub.PrivateKey
+	bjj := sk.Public().Compress()
+	ethAddr := ethCommon.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	forgeL1TxsNum := int64(1)
+	for blockNum := int64(1); blockNum <= 3; blockNum++ {
+		require.NoError(t, hdb.AddBlock(&common.Block{
+			Num:       blockNum,
+			Timestamp: time.Unix(blockNum*15, 0).UTC(),
+			Hash:      ethCommon.BigToHash(big.NewInt(blockNum)),
+		}))
+	}
+	l1Tx, err := common.NewL1Tx(&common.L1Tx{
+		ToForgeL1TxsNum: &forgeL1TxsNum,
+		Position:        0,
+		UserOrigin:      true,
+		FromEthAddr:     ethAddr,
+		FromBJJ:         bjj,
+		Amount:          big.NewInt(0),
+		DepositAmount:   big.NewInt(10),
+		EthBlockNum:     2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, hdb.AddL1Txs([]common.L1Tx{*l1Tx}))
+
+	for batchNum, blockNum := range []int64{0, 1, 2, 2, 3} {
+		if batchNum == 0 {
+			continue
+		}
+		account := common.Account{
+			Idx:      common.AccountIdx(255 + batchNum),
+			BatchNum: common.BatchNum(batchNum),
+			BJJ:      bjj,
+			EthAddr:  ethAddr,
+			Balance:  big.NewInt(int64(100 * batchNum)),
+		}
+		_, err := sdb.CreateAccount(account.Idx, &account)
+		require.NoError(t, err)
+		_, err = sdb.CreateScore(account.Idx, &common.Score{Idx: account.Idx,
+			Value: uint32(batchNum)})
+		require.NoError(t, err)
+		require.NoError(t, sdb.MakeCheckpoint())
+		chain.roots[int64(batchNum)] = &eth.RollupBatchRoots{
+			StateRoot: sdb.GetMTRootAccount(),
+			VouchRoot: sdb.GetMTRootVouch(),
+			ScoreRoot: sdb.GetMTRootScore(),
+		}
+
+		batch := common.Batch{
+			BatchNum:           common.BatchNum(batchNum),
+			EthBlockNum:        blockNum,
+			CollectedFees:      map[common.TokenID]*big.Int{},
+			FeeIdxsCoordinator: []common.AccountIdx{},
+			StateRoot:          sdb.GetMTRootAccount(),
+			LastIdx:            int64(account.Idx),
+			ExitRoot:           big.NewInt(0),
+			GasPrice:           big.NewInt(0),
+		}
+		if batchNum == 4 {
+			batch.ForgeL1TxsNum = &forgeL1TxsNum
+		}
+		require.NoError(t, hdb.AddBatch(&batch))
+		require.NoError(t, hdb.AddAccounts([]common.Account{account}))
+	}
+	return sdb, hdb, chain
+}
+
+func TestExportImport(t *testing.T) {
+	sdb, hdb, chain := newSource(t)
+
+	// batch 2 is not the last batch of its block
+	var archive bytes.Buffer
+	_, err := Export(&archive, sdb, hdb, 2)
+	require.Error(t, err)
+
+	archive.Reset()
+	manifest, err := Export(&archive, sdb, hdb, 3)
+	require.NoError(t, err)
+	assert.Equal(t, Version, manifest.Version)
+	assert.Equal(t, common.BatchNum(3), manifest.BatchNum)
+	assert.Equal(t, int64(2), manifest.EthBlockNum)
+	assert.Equal(t, chain.roots[3].StateRoot, manifest.StateRoot)
+
+	// The roots must match the chain
+	sdb2, hdb2 := newStateDB(t), newHistoryDB(t)
+	badChain := &testChain{roots: map[int64]*eth.RollupBatchRoots{3: chain.roots[2]}}
+	_, err = Import(bytes.NewReader(archive.Bytes()), sdb2, hdb2, badChain)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	assert.Equal(t, common.BatchNum(0), sdb2.CurrentBatch())
+
+	_, err = Import(bytes.NewReader(archive.Bytes()), sdb2, hdb2, chain)
+	require.NoError(t, err)
+	assert.Equal(t, common.BatchNum(3), sdb2.CurrentBatch())
+	assert.Equal(t, chain.roots[3].StateRoot, sdb2.GetMTRootAccount())
+	assert.Equal(t, chain.roots[3].ScoreRoot, sdb2.GetMTRootScore())
+	account, err := sdb2.GetAccount(258)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(300), account.Balance)
+
+	lastBlock, err := hdb2.GetLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lastBlock.Num)
+	lastBatchNum, err := hdb2.GetLastBatchNum()
+	require.NoError(t, err)
+	assert.Equal(t, common.BatchNum(3), lastBatchNum)
+	accounts, err := hdb2.GetAllAccounts()
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(accounts))
+	constants, err := hdb2.GetConstants()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(chainID), constants.ChainID)
+	// The L1 user tx forged after the snapshot is back in the queue
+	unforged, err := hdb.GetUnforgedL1UserTxs(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(unforged))
+	unforged, err = hdb2.GetUnforgedL1UserTxs(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(unforged))
+
+	// A second import is rejected
+	_, err = Import(bytes.NewReader(archive.Bytes()), sdb2, hdb2, chain)
+	require.Error(t, err)
+}
+
+func TestImportChecksum(t *testing.T) {
+	sdb, hdb, chain := newSource(t)
+	var archive bytes.Buffer
+	_, err := Export(&archive, sdb, hdb, 3)
+	require.NoError(t, err)
+
+	// Rewrite the archive changing a byte of the HistoryDB rows
+	gzr, err := gzip.NewReader(&archive)
+	require.NoError(t, err)
+	tr := tar.NewReader(gzr)
+	var tampered bytes.Buffer
+	gzw := gzip.NewWriter(&tampered)
+	tw := tar.NewWriter(gzw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		if hdr.Name == fileHistoryDB {
+			data[len(data)-1] ^= 1
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	sdb2, hdb2 := newStateDB(t), newHistoryDB(t)
+	_, err = Import(&tampered, sdb2, hdb2, chain)
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+	assert.Equal(t, common.BatchNum(0), sdb2.CurrentBatch())
+}
+
+func TestImportUnknownTable(t *testing.T) {
+	_, hdb, _ := newSource(t)
+	for _, tamper := range []func(snapshot *historydb.Snapshot){
+		func(snapshot *historydb.Snapshot) {
+			snapshot.Tables[0].Name = "block (eth_block_num) VALUES (1); DROP TABLE tx; --"
+		},
+		func(snapshot *historydb.Snapshot) {
+			snapshot.Tables[0].Name = "node_info"
+		},
+		func(snapshot *historydb.Snapshot) {
+			snapshot.Tables[0].Columns[0] = "eth_block_num) VALUES (1); DROP TABLE tx; --"
+		},
+	} {
+		snapshot, err := hdb.ExportSnapshot(3)
+		require.NoError(t, err)
+		tamper(snapshot)
+		hdb2 := newHistoryDB(t)
+		err = hdb2.ImportSnapshot(snapshot)
+		assert.ErrorIs(t, err, historydb.ErrInvalidSnapshotTable)
+		// Nothing was imported
+		lastBlock, err := hdb2.GetLastBlock()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), lastBlock.Num)
+		_, err = hdb2.GetUnforgedL1UserTxs(1)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"tokamak-sybil-resistance/common"
+
+	"github.com/jmoiron/sqlx"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/russross/meddler"
+)
+
+const (
+	// DriverPostgres is the name of the PostgreSQL driver
+	DriverPostgres = "postgres"
+	// DriverSQLite is the name under which the SQLite driver is registered.
+	// It's a custom name because the driver registers Go equivalents of the
+	// functions defined in the PostgreSQL schema on every connection.
+	DriverSQLite = "sqlite3_tokamak"
+	// SQLiteMemory is the path that opens an SQLite in-memory database
+	SQLiteMemory = ":memory:"
+)
+
+func init() {
+	sql.Register(DriverSQLite, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("fee_percentage", FeePercentage, true); err != nil {
+				return err
+			}
+			// The exponent is always an integer column (token.decimals)
+			return conn.RegisterFunc("power", func(base float64, exp int64) float64 {
+				return math.Pow(base, float64(exp))
+			}, true)
+		},
+	})
+}
+
+// FeePercentage returns the percentage of the amount that is payed as fee for
+// the given fee selector.  It's the Go equivalent of the `fee_percentage`
+// PostgreSQL function.
+func FeePercentage(fee int64) float64 {
+	switch {
+	case fee <= 0:
+		return 0
+	case fee < 32: //nolint:gomnd
+		return math.Pow(2, -60+1.625*float64(fee)) //nolint:gomnd
+	case fee <= 192: //nolint:gomnd
+		return math.Pow(2, -8+0.05*float64(fee-32)) //nolint:gomnd
+	default:
+		return math.Pow(2, float64(fee-192)) //nolint:gomnd
+	}
+}
+
+// sqlDriverNamer is implemented by sqlx.DB and sqlx.Tx
+type sqlDriverNamer interface {
+	DriverName() string
+}
+
+// IsSQLite returns true if the given connection uses the SQLite backend
+func IsSQLite(db sqlDriverNamer) bool {
+	return db.DriverName() == DriverSQLite
+}
+
+// isSQLiteDB returns true if the given connection uses the SQLite backend
+func isSQLiteDB(db *sql.DB) bool {
+	_, ok := db.Driver().(*sqlite3.SQLiteDriver)
+	return ok
+}
+
+// ConnectSQLiteDB opens the SQLite DB stored at path.  Use SQLiteMemory as
+// path to open an in-memory DB.
+func ConnectSQLiteDB(path string) (*sqlx.DB, error) {
+	// Init meddler
+	initMeddler()
+	meddler.Default = meddler.SQLite
+	// Foreign keys are disabled by default in SQLite, but the schema relies on
+	// them to delete all the data of reorged blocks
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path)
+	db, err := sqlx.Connect(DriverSQLite, dsn)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	// SQLite only allows a single writer, and each connection to an
+	// in-memory DB opens a different DB
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// InitSQLiteDB opens the SQLite DB stored at path and runs the migrations
+func InitSQLiteDB(path string) (*sqlx.DB, error) {
+	db, err := ConnectSQLiteDB(path)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	// Run DB migrations
+	if err := MigrationsUp(db.DB); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return db, nil
+}
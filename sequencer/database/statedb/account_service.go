@@ -21,6 +21,8 @@ var (
 	PrefixKeyAddr = []byte("a:")
 	// PrefixKeyAddrBJJ is the key prefix for address-babyjubjub in the db
 	PrefixKeyAddrBJJ = []byte("ab:")
+	// PrefixKeyBJJ is the key prefix for babyjubjub in the db
+	PrefixKeyBJJ = []byte("b:")
 )
 
 // CreateAccount creates a new Account in the StateDB for the given Idx.  If
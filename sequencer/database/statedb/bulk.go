@@ -0,0 +1,384 @@
+package statedb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"tokamak-sybil-resistance/common"
+
+	cryptoUtils "github.com/iden3/go-iden3-crypto/utils"
+	"github.com/iden3/go-merkletree"
+	"github.com/iden3/go-merkletree/db"
+)
+
+// BulkUpdate is a set of leaves of the Account, Vouch and Score trees that
+// are written at once with ApplyBulkUpdate.  The Accounts must already
+// exist, since creating an account also assigns its Idx (use CreateAccount
+// for that); the Vouches and Scores are created if they don't exist.  If the
+// same leaf appears more than once, the last value is the one kept.
+type BulkUpdate struct {
+	Accounts []common.Account
+	Vouches  []common.Vouch
+	Scores   []common.Score
+}
+
+// BulkProofs contains the CircomProcessorProofs of the leaves of a
+// BulkUpdate, in the same order
+type BulkProofs struct {
+	Accounts []*merkletree.CircomProcessorProof
+	Vouches  []*merkletree.CircomProcessorProof
+	Scores   []*merkletree.CircomProcessorProof
+}
+
+// treeOp is the update of a merkle tree leaf
+type treeOp struct {
+	key    *big.Int
+	value  *big.Int
+	create bool
+}
+
+// ApplyBulkUpdate writes all the leaves of the update to the db in a single
+// transaction, and then updates the Account, Vouch and Score trees
+// concurrently.  The proofs are only generated, and a BulkProofs returned,
+// when the StateDB is of TypeBatchBuilder, which needs them for the ZKInputs;
+// otherwise nil is returned.  If a tree update fails the leaves remain
+// written, so the StateDB must be reset to the last checkpoint.
+func (s *StateDB) ApplyBulkUpdate(update *BulkUpdate) (*BulkProofs, error) {
+	sto := s.db.DB()
+	tx, err := sto.NewTx()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	accountOps, err := putAccounts(sto, tx, update.Accounts)
+	if err != nil {
+		tx.Close()
+		return nil, common.Wrap(err)
+	}
+	vouchOps, err := putVouches(sto, tx, update.Vouches)
+	if err != nil {
+		tx.Close()
+		return nil, common.Wrap(err)
+	}
+	scoreOps, err := putScores(sto, tx, update.Scores)
+	if err != nil {
+		tx.Close()
+		return nil, common.Wrap(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, common.Wrap(err)
+	}
+
+	withProofs := s.cfg.Type == TypeBatchBuilder
+	var proofs BulkProofs
+	var errs [3]error
+	var wg sync.WaitGroup
+	for i, t := range []struct {
+		mt     *merkletree.MerkleTree
+		ops    []treeOp
+		proofs *[]*merkletree.CircomProcessorProof
+	}{
+		{s.AccountTree, accountOps, &proofs.Accounts},
+		{s.VouchTree, vouchOps, &proofs.Vouches},
+		{s.ScoreTree, scoreOps, &proofs.Scores},
+	} {
+		if t.mt == nil || len(t.ops) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, mt *merkletree.MerkleTree, ops []treeOp,
+			proofs *[]*merkletree.CircomProcessorProof) {
+			defer wg.Done()
+			*proofs, errs[i] = applyTreeOps(mt, ops, withProofs)
+		}(i, t.mt, t.ops, t.proofs)
+	}
+	wg.Wait()
+	if !withProofs {
+		// updateLeaves writes the new roots directly to the db, so the
+		// trees are reopened to load them, even if an update failed
+		if err := s.reopenTrees(); err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	if !withProofs {
+		return nil, nil
+	}
+	return &proofs, nil
+}
+
+// applyTreeOps applies the ops to the merkle tree in order, returning their
+// proofs if withProofs is set.  Without proofs the new leaves are added in
+// order and then the existing ones are updated at once with updateLeaves,
+// which gives the same root since it only depends on the final leaves.
+func applyTreeOps(mt *merkletree.MerkleTree, ops []treeOp,
+	withProofs bool) ([]*merkletree.CircomProcessorProof, error) {
+	if !withProofs {
+		var updates []treeOp
+		for _, op := range ops {
+			if !op.create {
+				updates = append(updates, op)
+			} else if err := mt.Add(op.key, op.value); err != nil {
+				return nil, common.Wrap(fmt.Errorf("leaf %v: %w", op.key, err))
+			}
+		}
+		return nil, common.Wrap(updateLeaves(mt, updates))
+	}
+	proofs := make([]*merkletree.CircomProcessorProof, len(ops))
+	for i, op := range ops {
+		var err error
+		if op.create {
+			proofs[i], err = mt.AddAndGetCircomProof(op.key, op.value)
+		} else {
+			proofs[i], err = mt.Update(op.key, op.value)
+		}
+		if err != nil {
+			return nil, common.Wrap(fmt.Errorf("leaf %v: %w", op.key, err))
+		}
+	}
+	return proofs, nil
+}
+
+// dbKeyRootNode is the key where the merkletree stores its current root
+var dbKeyRootNode = []byte("currentroot")
+
+// updateLeaves sets the values of existing leaves of mt without generating
+// their proofs, which is what makes mt.Update slow.  The nodes in the paths
+// of the leaves are recomputed once, even the ones shared by several leaves,
+// and written with the new root in a single db transaction.  If a key appears
+// more than once the last value is the one kept.  The in-memory root of mt is
+// not updated, so the tree must be reopened afterwards.
+func updateLeaves(mt *merkletree.MerkleTree, ops []treeOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	values := make(map[merkletree.Hash]*merkletree.Hash, len(ops))
+	keys := make([]*merkletree.Hash, 0, len(ops))
+	for _, op := range ops {
+		if !cryptoUtils.CheckBigIntInField(op.key) ||
+			!cryptoUtils.CheckBigIntInField(op.value) {
+			return common.Wrap(fmt.Errorf("leaf %v: key or value not inside the Finite Field",
+				op.key))
+		}
+		k := merkletree.NewHashFromBigInt(op.key)
+		if _, ok := values[*k]; !ok {
+			keys = append(keys, k)
+		}
+		values[*k] = merkletree.NewHashFromBigInt(op.value)
+	}
+	tx, err := mt.DB().NewTx()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	root, err := updateSubtree(mt, tx, mt.Root(), 0, keys, values)
+	if err != nil {
+		tx.Close()
+		return common.Wrap(err)
+	}
+	if err := tx.Put(dbKeyRootNode,
+		append([]byte{byte(merkletree.DBEntryTypeRoot)}, root[:]...)); err != nil {
+		tx.Close()
+		return common.Wrap(err)
+	}
+	return common.Wrap(tx.Commit())
+}
+
+// updateSubtree sets the values of the leaves with the given keys in the
+// subtree whose root is the node nodeKey at level lvl, writing the new nodes
+// to tx, and returns the key of the new root of the subtree
+func updateSubtree(mt *merkletree.MerkleTree, tx db.Tx, nodeKey *merkletree.Hash, lvl int,
+	keys []*merkletree.Hash, values map[merkletree.Hash]*merkletree.Hash) (*merkletree.Hash, error) {
+	n, err := mt.GetNode(nodeKey)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	switch n.Type {
+	case merkletree.NodeTypeLeaf:
+		if len(keys) != 1 || !bytes.Equal(keys[0][:], n.Entry[0][:]) {
+			return nil, common.Wrap(fmt.Errorf("leaf %v: %w", keys[0].BigInt(),
+				merkletree.ErrKeyNotFound))
+		}
+		return putNode(tx, merkletree.NewNodeLeaf(keys[0], values[*keys[0]]))
+	case merkletree.NodeTypeMiddle:
+		if lvl >= mt.MaxLevels() {
+			return nil, common.Wrap(merkletree.ErrReachedMaxLevel)
+		}
+		var left, right []*merkletree.Hash
+		for _, k := range keys {
+			if merkletree.TestBit(k[:], uint(lvl)) {
+				right = append(right, k)
+			} else {
+				left = append(left, k)
+			}
+		}
+		childL, childR := n.ChildL, n.ChildR
+		if len(left) > 0 {
+			if childL, err = updateSubtree(mt, tx, n.ChildL, lvl+1, left, values); err != nil {
+				return nil, common.Wrap(err)
+			}
+		}
+		if len(right) > 0 {
+			if childR, err = updateSubtree(mt, tx, n.ChildR, lvl+1, right, values); err != nil {
+				return nil, common.Wrap(err)
+			}
+		}
+		return putNode(tx, merkletree.NewNodeMiddle(childL, childR))
+	default:
+		return nil, common.Wrap(fmt.Errorf("leaf %v: %w", keys[0].BigInt(),
+			merkletree.ErrKeyNotFound))
+	}
+}
+
+// putNode writes the node n to tx and returns its key
+func putNode(tx db.Tx, n *merkletree.Node) (*merkletree.Hash, error) {
+	k, err := n.Key()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := tx.Put(k[:], n.Value()); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return k, nil
+}
+
+// exists returns true if key is stored in sto
+func exists(sto db.Storage, key []byte) (bool, error) {
+	_, err := sto.Get(key)
+	if common.Unwrap(err) == db.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, common.Wrap(err)
+	}
+	return true, nil
+}
+
+func putAccounts(sto db.Storage, tx db.Tx, accounts []common.Account) ([]treeOp, error) {
+	ops := make([]treeOp, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		idxBytes, err := account.Idx.Bytes()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		if ok, err := exists(sto, db.Concat(PrefixKeyAccIdx, idxBytes[:])); err != nil {
+			return nil, common.Wrap(err)
+		} else if !ok {
+			return nil, common.Wrap(fmt.Errorf("account %v: %w", account.Idx, db.ErrNotFound))
+		}
+		v, err := account.HashValue()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		accountBytes, err := account.Bytes()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		if err := tx.Put(db.Concat(PrefixKeyAccHash, v.Bytes()), accountBytes[:]); err != nil {
+			return nil, common.Wrap(err)
+		}
+		if err := tx.Put(db.Concat(PrefixKeyAccIdx, idxBytes[:]), v.Bytes()); err != nil {
+			return nil, common.Wrap(err)
+		}
+		ops[i] = treeOp{key: account.Idx.BigInt(), value: v}
+	}
+	return ops, nil
+}
+
+func putVouches(sto db.Storage, tx db.Tx, vouches []common.Vouch) ([]treeOp, error) {
+	ops := make([]treeOp, len(vouches))
+	created := make(map[common.VouchIdx]bool)
+	for i := range vouches {
+		vouch := &vouches[i]
+		idxBytes, err := vouch.Idx.Bytes()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		key := db.Concat(PrefixKeyVocIdx, idxBytes[:])
+		ok, err := exists(sto, key)
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		create := !ok && !created[vouch.Idx]
+		created[vouch.Idx] = true
+		if err := tx.Put(key, vouch.BytesFromBool()); err != nil {
+			return nil, common.Wrap(err)
+		}
+		ops[i] = treeOp{key: vouch.Idx.BigInt(), value: common.BigIntFromBool(vouch.Value),
+			create: create}
+	}
+	return ops, nil
+}
+
+func putScores(sto db.Storage, tx db.Tx, scores []common.Score) ([]treeOp, error) {
+	ops := make([]treeOp, len(scores))
+	created := make(map[common.AccountIdx]bool)
+	for i := range scores {
+		score := &scores[i]
+		idxBytes, err := score.Idx.Bytes()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		scoreBytes, err := score.Bytes()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		key := db.Concat(PrefixKeyScoIdx, idxBytes[:])
+		ok, err := exists(sto, key)
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		create := !ok && !created[score.Idx]
+		created[score.Idx] = true
+		if err := tx.Put(key, scoreBytes[:]); err != nil {
+			return nil, common.Wrap(err)
+		}
+		ops[i] = treeOp{key: score.Idx.BigInt(), value: score.BigInt(), create: create}
+	}
+	return ops, nil
+}
+
+// UpdateScores sets the given scores, creating the ones that don't exist,
+// with a single db transaction.  The scores are applied in Idx order, and
+// their proofs are only returned when the StateDB is of TypeBatchBuilder.
+func (s *StateDB) UpdateScores(scores map[common.AccountIdx]*common.Score) (
+	map[common.AccountIdx]*merkletree.CircomProcessorProof, error) {
+	update := &BulkUpdate{Scores: make([]common.Score, 0, len(scores))}
+	for idx, score := range scores {
+		update.Scores = append(update.Scores, *score)
+		update.Scores[len(update.Scores)-1].Idx = idx
+	}
+	sort.Slice(update.Scores, func(i, j int) bool {
+		return update.Scores[i].Idx < update.Scores[j].Idx
+	})
+	proofs, err := s.ApplyBulkUpdate(update)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if proofs == nil {
+		return nil, nil
+	}
+	scoreProofs := make(map[common.AccountIdx]*merkletree.CircomProcessorProof, len(scores))
+	for i, score := range update.Scores {
+		scoreProofs[score.Idx] = proofs.Scores[i]
+	}
+	return scoreProofs, nil
+}
+
+// ApplyVouches sets the given vouches in order, creating the ones that don't
+// exist, with a single db transaction.  Their proofs are only returned when
+// the StateDB is of TypeBatchBuilder.
+func (s *StateDB) ApplyVouches(vouches []common.Vouch) ([]*merkletree.CircomProcessorProof, error) {
+	proofs, err := s.ApplyBulkUpdate(&BulkUpdate{Vouches: vouches})
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if proofs == nil {
+		return nil, nil
+	}
+	return proofs.Vouches, nil
+}
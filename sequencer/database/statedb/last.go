@@ -0,0 +1,314 @@
+package statedb
+
+import (
+	"math/big"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-merkletree"
+	"github.com/iden3/go-merkletree/db"
+)
+
+// Last offers a subset of view methods of the StateDB that can be called via
+// the LastRead method of the StateDB in a thread-safe manner to obtain a
+// consistent view to the last batch of the StateDB.
+type Last struct {
+	db      db.Storage
+	nLevels int
+}
+
+// DB returns the underlying storage of Last
+func (s *Last) DB() db.Storage {
+	return s.db
+}
+
+// GetCurrentBatch returns the current BatchNum of the last checkpoint
+func (s *Last) GetCurrentBatch() (common.BatchNum, error) {
+	cbBytes, err := s.db.Get(kvdb.KeyCurrentBatch)
+	if common.Unwrap(err) == db.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, common.Wrap(err)
+	}
+	return common.BatchNumFromBytes(cbBytes)
+}
+
+// GetAccount returns the account for the given Idx
+func (s *Last) GetAccount(idx common.AccountIdx) (*common.Account, error) {
+	return GetAccountInTreeDB(s.db, idx)
+}
+
+// GetAccounts returns all the accounts in the db.  Use for debugging purposes
+// only.
+func (s *Last) GetAccounts() ([]common.Account, error) {
+	return getAccounts(s.db)
+}
+
+// GetVouch returns the vouch for the given Idx
+func (s *Last) GetVouch(idx common.VouchIdx) (*common.Vouch, error) {
+	return GetVouchInTreeDB(s.db, idx)
+}
+
+// GetScore returns the score for the given Idx
+func (s *Last) GetScore(idx common.AccountIdx) (*common.Score, error) {
+	return GetScoreInTreeDB(s.db, idx)
+}
+
+// GetIdxByEthAddr returns the smallest Idx for the given Ethereum Address
+func (s *Last) GetIdxByEthAddr(addr ethCommon.Address) (common.AccountIdx, error) {
+	return getIdxByEthAddr(s.db, addr)
+}
+
+// GetIdxByEthAddrBJJ returns the smallest Idx for the given Ethereum Address
+// and BabyJubJub PublicKey.  See StateDB.GetIdxByEthAddrBJJ.
+func (s *Last) GetIdxByEthAddrBJJ(addr ethCommon.Address,
+	pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	return getIdxByEthAddrBJJ(s.db, addr, pk)
+}
+
+// GetIdxByBJJ returns the smallest Idx for the given BabyJubJub PublicKey.
+// See StateDB.GetIdxByBJJ.
+func (s *Last) GetIdxByBJJ(pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	return getIdxByBJJ(s.db, pk)
+}
+
+// tree opens the merkle tree stored with the given prefix
+func (s *Last) tree(prefix []byte) (*merkletree.MerkleTree, error) {
+	mt, err := merkletree.NewMerkleTree(s.db.WithPrefix(prefix), s.nLevels)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return mt, nil
+}
+
+// MTGetRoots returns the roots of the Account, Vouch and Score Merkle Trees
+func (s *Last) MTGetRoots() (*TreeRoots, error) {
+	roots := &TreeRoots{}
+	for _, t := range []struct {
+		prefix []byte
+		root   **big.Int
+	}{
+		{PrefixKeyMTAcc, &roots.Account},
+		{PrefixKeyMTVoc, &roots.Vouch},
+		{PrefixKeyMTSco, &roots.Score},
+	} {
+		mt, err := s.tree(t.prefix)
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		*t.root = mt.Root().BigInt()
+	}
+	return roots, nil
+}
+
+// mtGetProof returns the CircomVerifierProof of the given key in the merkle
+// tree stored with the given prefix
+func (s *Last) mtGetProof(prefix []byte, k *big.Int) (*merkletree.CircomVerifierProof, error) {
+	mt, err := s.tree(prefix)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	p, err := mt.GenerateSCVerifierProof(k, mt.Root())
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return p, nil
+}
+
+// MTGetAccountProof returns the CircomVerifierProof for a given accountIdx
+func (s *Last) MTGetAccountProof(idx common.AccountIdx) (*merkletree.CircomVerifierProof, error) {
+	return s.mtGetProof(PrefixKeyMTAcc, idx.BigInt())
+}
+
+// MTGetVouchProof returns the CircomVerifierProof for a given vouchIdx
+func (s *Last) MTGetVouchProof(idx common.VouchIdx) (*merkletree.CircomVerifierProof, error) {
+	return s.mtGetProof(PrefixKeyMTVoc, idx.BigInt())
+}
+
+// MTGetScoreProof returns the CircomVerifierProof for a given accountIdx in
+// the Score Merkle Tree
+func (s *Last) MTGetScoreProof(idx common.AccountIdx) (*merkletree.CircomVerifierProof, error) {
+	return s.mtGetProof(PrefixKeyMTSco, idx.BigInt())
+}
+
+// LastRead is a thread-safe method to query the last checkpoint of the StateDB
+// via the Last type methods.  The synchronizer can't replace the last
+// checkpoint while fn runs, so all the reads done in fn see the same batch.
+func (s *StateDB) LastRead(fn func(sdbLast *Last) error) error {
+	return s.db.LastRead(
+		func(kvdbLast *kvdb.Last) error {
+			return fn(&Last{db: kvdbLast.DB(), nLevels: s.AccountTree.MaxLevels()})
+		},
+	)
+}
+
+// LastGetCurrentBatch is a thread-safe method to get the current BatchNum of
+// the last checkpoint of the StateDB
+func (s *StateDB) LastGetCurrentBatch() (common.BatchNum, error) {
+	return s.db.LastGetCurrentBatch()
+}
+
+// LastGetAccount is a thread-safe method to query an account in the last
+// checkpoint of the StateDB
+func (s *StateDB) LastGetAccount(idx common.AccountIdx) (*common.Account, error) {
+	var account *common.Account
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		account, err = sdb.GetAccount(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return account, nil
+}
+
+// LastGetVouch is a thread-safe method to query a vouch in the last
+// checkpoint of the StateDB
+func (s *StateDB) LastGetVouch(idx common.VouchIdx) (*common.Vouch, error) {
+	var vouch *common.Vouch
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		vouch, err = sdb.GetVouch(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return vouch, nil
+}
+
+// LastGetScore is a thread-safe method to query a score in the last
+// checkpoint of the StateDB
+func (s *StateDB) LastGetScore(idx common.AccountIdx) (*common.Score, error) {
+	var score *common.Score
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		score, err = sdb.GetScore(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return score, nil
+}
+
+// LastGetIdxByEthAddrBJJ is a thread-safe method to query the Idx of the
+// given Ethereum Address and BabyJubJub PublicKey in the last checkpoint of
+// the StateDB
+func (s *StateDB) LastGetIdxByEthAddrBJJ(addr ethCommon.Address,
+	pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	var idx common.AccountIdx
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		idx, err = sdb.GetIdxByEthAddrBJJ(addr, pk)
+		return err
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return idx, nil
+}
+
+// LastGetIdxByBJJ is a thread-safe method to query the Idx of the given
+// BabyJubJub PublicKey in the last checkpoint of the StateDB
+func (s *StateDB) LastGetIdxByBJJ(pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	var idx common.AccountIdx
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		idx, err = sdb.GetIdxByBJJ(pk)
+		return err
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return idx, nil
+}
+
+// LastMTGetRoots is a thread-safe method to get the roots of the Merkle Trees
+// in the last checkpoint of the StateDB
+func (s *StateDB) LastMTGetRoots() (*TreeRoots, error) {
+	var roots *TreeRoots
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		roots, err = sdb.MTGetRoots()
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return roots, nil
+}
+
+// LastMTGetAccountProof is a thread-safe method to get the proof of an
+// account in the last checkpoint of the StateDB
+func (s *StateDB) LastMTGetAccountProof(idx common.AccountIdx) (
+	*merkletree.CircomVerifierProof, error) {
+	var p *merkletree.CircomVerifierProof
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		p, err = sdb.MTGetAccountProof(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return p, nil
+}
+
+// LastMTGetVouchProof is a thread-safe method to get the proof of a vouch in
+// the last checkpoint of the StateDB
+func (s *StateDB) LastMTGetVouchProof(idx common.VouchIdx) (
+	*merkletree.CircomVerifierProof, error) {
+	var p *merkletree.CircomVerifierProof
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		p, err = sdb.MTGetVouchProof(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return p, nil
+}
+
+// LastMTGetScoreProof is a thread-safe method to get the proof of a score in
+// the last checkpoint of the StateDB
+func (s *StateDB) LastMTGetScoreProof(idx common.AccountIdx) (
+	*merkletree.CircomVerifierProof, error) {
+	var p *merkletree.CircomVerifierProof
+	if err := s.LastRead(func(sdb *Last) error {
+		var err error
+		p, err = sdb.MTGetScoreProof(idx)
+		return err
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return p, nil
+}
+
+// CheckpointView is a read-only view of the StateDB at the checkpoint of a
+// past batch, which offers the same view methods as Last
+type CheckpointView struct {
+	*Last
+	checkpoint *kvdb.Checkpoint
+}
+
+// BatchNum returns the BatchNum of the checkpoint
+func (v *CheckpointView) BatchNum() common.BatchNum {
+	return v.checkpoint.BatchNum()
+}
+
+// Close releases the CheckpointView
+func (v *CheckpointView) Close() {
+	v.checkpoint.Close()
+}
+
+// OpenAt returns a read-only view of the StateDB at the checkpoint of the
+// given batchNum, which must still be kept in the StateDB.  The view is
+// thread-safe and must be closed after use.
+func (s *StateDB) OpenAt(batchNum common.BatchNum) (*CheckpointView, error) {
+	checkpoint, err := s.db.OpenAt(batchNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &CheckpointView{
+		Last:       &Last{db: checkpoint.DB(), nLevels: s.AccountTree.MaxLevels()},
+		checkpoint: checkpoint,
+	}, nil
+}
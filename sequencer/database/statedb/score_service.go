@@ -129,9 +129,9 @@ func UpdateScoreInTreeDB(sto db.Storage, mt *merkletree.MerkleTree, idx common.A
 	return nil, nil
 }
 
-// GetMTRoot returns the root of the Merkle Tree
+// GetMTRootScore returns the root of the Score Merkle Tree
 func (s *StateDB) GetMTRootScore() *big.Int {
-	return s.AccountTree.Root().BigInt()
+	return s.ScoreTree.Root().BigInt()
 }
 
 func performTxScore(sto db.Storage, idx common.AccountIdx,
@@ -40,6 +40,12 @@ type Config struct {
 	// merkle tree.  If the Type doesn't use a merkle tree, NLevels should
 	// be 0.
 	NLevels int
+	// OpenAtCache is the number of checkpoints opened with OpenAt that are
+	// kept open.  If 0, kvdb.DefaultOpenAtCache is used.
+	OpenAtCache int
+	// Backend is the storage engine of the StateDB.  If nil,
+	// kvdb.PebbleBackend is used.
+	Backend kvdb.Backend
 	// At every checkpoint, check that there are no gaps between the
 	// checkpoints
 	noGapsCheck bool
@@ -101,7 +107,8 @@ func NewStateDB(cfg Config) (*StateDB, error) {
 	var err error
 
 	kv, err = kvdb.NewKVDB(kvdb.Config{Path: cfg.Path, Keep: cfg.Keep,
-		NoGapsCheck: cfg.noGapsCheck, NoLast: cfg.NoLast})
+		NoGapsCheck: cfg.noGapsCheck, NoLast: cfg.NoLast, OpenAtCache: cfg.OpenAtCache,
+		Backend: cfg.Backend})
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
@@ -109,13 +116,18 @@ func NewStateDB(cfg Config) (*StateDB, error) {
 	mtAccount, _ := merkletree.NewMerkleTree(kv.StorageWithPrefix(PrefixKeyMTAcc), 24)
 	mtVouch, _ := merkletree.NewMerkleTree(kv.StorageWithPrefix(PrefixKeyMTVoc), 24)
 	mtScore, _ := merkletree.NewMerkleTree(kv.StorageWithPrefix(PrefixKeyMTSco), 24)
-	return &StateDB{
+	s := &StateDB{
 		cfg:         cfg,
 		db:          kv,
 		AccountTree: mtAccount,
 		VouchTree:   mtVouch,
 		ScoreTree:   mtScore,
-	}, nil
+	}
+	if err := s.backfillCheckpointsIdxIndexes(); err != nil {
+		kv.Close()
+		return nil, common.Wrap(err)
+	}
+	return s, nil
 }
 
 // Type returns the StateDB configured Type
@@ -153,6 +165,12 @@ func (s *StateDB) Reset(batchNum common.BatchNum) error {
 	if err := s.db.Reset(batchNum); err != nil {
 		return common.Wrap(err)
 	}
+	return s.reopenTrees()
+}
+
+// reopenTrees opens again the merkle trees from the current s.db, so that
+// their in-memory roots match the stored ones
+func (s *StateDB) reopenTrees() error {
 	if s.AccountTree != nil {
 		// open the Account MT for the current s.db
 		accountTree, err := merkletree.NewMerkleTree(s.db.StorageWithPrefix(PrefixKeyMTAcc), s.AccountTree.MaxLevels())
@@ -180,6 +198,24 @@ func (s *StateDB) Reset(batchNum common.BatchNum) error {
 	return nil
 }
 
+// Snapshot returns an identifier of the current state of the StateDB that can
+// be passed to RevertToSnapshot to undo the changes done after it, such as the
+// ones of a single tx.  The identifiers are invalidated by MakeCheckpoint and
+// Reset.
+func (s *StateDB) Snapshot() int {
+	return s.db.Snapshot()
+}
+
+// RevertToSnapshot undoes all the changes done to the StateDB, including the
+// merkle trees, after the given snapshot was taken
+func (s *StateDB) RevertToSnapshot(id int) error {
+	log.Debugw("Reverting StateDB to snapshot", "snapshot", id, "type", s.cfg.Type)
+	if err := s.db.RevertToSnapshot(id); err != nil {
+		return common.Wrap(err)
+	}
+	return s.reopenTrees()
+}
+
 // MakeCheckpoint does a checkpoint at the given batchNum in the defined path.
 // Internally this advances & stores the current BatchNum, and then stores a
 // Checkpoint of the current state of the StateDB.
@@ -192,3 +228,8 @@ func (s *StateDB) MakeCheckpoint() error {
 func (s *StateDB) CurrentBatch() common.BatchNum {
 	return s.db.CurrentBatch
 }
+
+// ListCheckpoints returns the list of batchNums of the checkpoints, sorted.
+func (s *StateDB) ListCheckpoints() ([]int, error) {
+	return s.db.ListCheckpoints()
+}
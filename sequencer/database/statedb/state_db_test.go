@@ -5,10 +5,12 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
 	"tokamak-sybil-resistance/log"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/iden3/go-merkletree/db"
+	"github.com/iden3/go-merkletree/db/pebble"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -454,3 +457,637 @@ func TestCheckAccountsTreeTestVectors(t *testing.T) {
 // 	defer stateDB.Close()
 // 	printExamples(stateDB)
 // }
+
+func TestVerifyCheckpoint(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	defer func() { sdb.Close() }()
+
+	for i := 0; i < 4; i++ {
+		_, err = sdb.CreateAccount(common.AccountIdx(256+i), newAccount(t, i))
+		require.NoError(t, err)
+		_, err = sdb.CreateVouch(common.VouchIdx(256257+i), newVouch(i))
+		require.NoError(t, err)
+		_, err = sdb.CreateScore(common.AccountIdx(256+i), newScore(i))
+		require.NoError(t, err)
+	}
+	require.NoError(t, sdb.MakeCheckpoint())
+
+	report, err := sdb.VerifyCheckpoint(1)
+	require.NoError(t, err)
+	assert.True(t, report.Consistent())
+	assert.Equal(t, sdb.GetMTRootAccount(), report.Computed.Account)
+	assert.Equal(t, sdb.GetMTRootVouch(), report.Computed.Vouch)
+	assert.Equal(t, sdb.GetMTRootScore(), report.Computed.Score)
+
+	// unexisting checkpoint
+	_, err = sdb.VerifyCheckpoint(2)
+	require.Error(t, err)
+
+	// corrupt the checkpoint by changing a score leaf without updating
+	// the score merkle tree
+	sdb.Close()
+	sto, err := pebble.NewPebbleStorage(filepath.Join(dir, "BatchNum1"), false)
+	require.NoError(t, err)
+	idxBytes, err := common.AccountIdx(257).Bytes()
+	require.NoError(t, err)
+	tx, err := sto.NewTx()
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(append(PrefixKeyScoIdx, idxBytes[:]...), []byte{0, 0, 0, 42}))
+	require.NoError(t, tx.Commit())
+	sto.Close()
+
+	sdb, err = NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	report, err = sdb.VerifyCheckpoint(1)
+	require.NoError(t, err)
+	assert.False(t, report.Consistent())
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, report.Stored.Account, report.Computed.Account)
+	assert.Equal(t, report.Stored.Vouch, report.Computed.Vouch)
+	assert.NotEqual(t, report.Stored.Score, report.Computed.Score)
+}
+
+func TestIdxIndexes(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	var sks [3]babyjub.PrivateKey
+	for i := range sks {
+		sks[i][0] = byte(i + 1)
+	}
+	bjjA, bjjB, bjjC := sks[0].Public().Compress(), sks[1].Public().Compress(),
+		sks[2].Public().Compress()
+	addrX := ethCommon.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	addrY := ethCommon.HexToAddress("0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF")
+	create := func(i int, addr ethCommon.Address, pk babyjub.PublicKeyComp) {
+		account := newAccount(t, i)
+		account.EthAddr, account.BJJ = addr, pk
+		_, err := sdb.CreateAccount(account.Idx, account)
+		require.NoError(t, err)
+	}
+	create(0, addrX, bjjA)
+	create(1, addrX, bjjB)
+	create(2, addrY, bjjA)
+	require.NoError(t, sdb.MakeCheckpoint())
+
+	// every index keeps the smallest idx
+	idx, err := sdb.GetIdxByBJJ(bjjA)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(256), idx)
+	idx, err = sdb.GetIdxByBJJ(bjjB)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(257), idx)
+	idx, err = sdb.GetIdxByEthAddr(addrX)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(256), idx)
+	idx, err = sdb.GetIdxByEthAddrBJJ(addrY, bjjA)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(258), idx)
+	idx, err = sdb.LastGetIdxByBJJ(bjjB)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(257), idx)
+	_, err = sdb.GetIdxByBJJ(bjjC)
+	assert.Equal(t, ErrIdxNotFound, common.Unwrap(err))
+	issues, err := sdb.CheckIdxIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	// the indexes are reverted by a Reset
+	create(3, addrY, bjjC)
+	require.NoError(t, sdb.MakeCheckpoint())
+	idx, err = sdb.GetIdxByBJJ(bjjC)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(259), idx)
+	require.NoError(t, sdb.Reset(1))
+	_, err = sdb.GetIdxByBJJ(bjjC)
+	assert.Equal(t, ErrIdxNotFound, common.Unwrap(err))
+	_, err = sdb.LastGetIdxByBJJ(bjjC)
+	assert.Equal(t, ErrIdxNotFound, common.Unwrap(err))
+	issues, err = sdb.CheckIdxIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	// an index that points to a wrong idx and a missing index are found
+	// and fixed by RebuildIdxIndexes
+	idxBytes, err := common.AccountIdx(258).Bytes()
+	require.NoError(t, err)
+	require.NoError(t, sdb.db.Write(
+		[]db.KV{{K: db.Concat(PrefixKeyBJJ, bjjB[:]), V: idxBytes[:]}},
+		[][]byte{db.Concat(PrefixKeyAddr, addrX.Bytes())}))
+	issues, err = sdb.CheckIdxIndexes()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(issues))
+	require.NoError(t, sdb.MakeCheckpoint())
+	report, err := sdb.VerifyCheckpoint(2)
+	require.NoError(t, err)
+	assert.False(t, report.Consistent())
+	assert.Equal(t, issues, report.Issues)
+
+	require.NoError(t, sdb.RebuildIdxIndexes())
+	issues, err = sdb.CheckIdxIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+	idx, err = sdb.GetIdxByBJJ(bjjB)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(257), idx)
+	idx, err = sdb.GetIdxByEthAddr(addrX)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(256), idx)
+	require.NoError(t, sdb.MakeCheckpoint())
+	report, err = sdb.VerifyCheckpoint(3)
+	require.NoError(t, err)
+	assert.True(t, report.Consistent())
+}
+
+// TestIdxIndexesBackfill opens a StateDB written with the Idx indexes of the
+// versions before the BJJ index existed: no BJJ index, and an EthAddr index
+// that keeps the last Idx created for the address
+func TestIdxIndexesBackfill(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+
+	var sks [2]babyjub.PrivateKey
+	for i := range sks {
+		sks[i][0] = byte(i + 1)
+	}
+	bjjA, bjjB := sks[0].Public().Compress(), sks[1].Public().Compress()
+	addrX := ethCommon.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	addrY := ethCommon.HexToAddress("0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF")
+	create := func(i int, addr ethCommon.Address, pk babyjub.PublicKeyComp) {
+		account := newAccount(t, i)
+		account.EthAddr, account.BJJ = addr, pk
+		_, err := sdb.CreateAccount(account.Idx, account)
+		require.NoError(t, err)
+	}
+	// oldIndexes replaces the indexes of the current state with the ones
+	// of the old versions
+	oldIndexes := func(lastIdxX common.AccountIdx) {
+		var dels [][]byte
+		require.NoError(t, sdb.db.DB().WithPrefix(PrefixKeyBJJ).Iterate(
+			func(k, v []byte) (bool, error) {
+				dels = append(dels, db.Concat(PrefixKeyBJJ, k))
+				return true, nil
+			}))
+		idxBytes, err := lastIdxX.Bytes()
+		require.NoError(t, err)
+		require.NoError(t, sdb.db.Write(
+			[]db.KV{{K: db.Concat(PrefixKeyAddr, addrX.Bytes()), V: idxBytes[:]}}, dels))
+	}
+	create(0, addrX, bjjA)
+	create(1, addrX, bjjB)
+	oldIndexes(257)
+	require.NoError(t, sdb.MakeCheckpoint())
+	create(2, addrY, bjjA)
+	oldIndexes(257)
+	require.NoError(t, sdb.MakeCheckpoint())
+	report, err := sdb.VerifyCheckpoint(1)
+	require.NoError(t, err)
+	require.False(t, report.Consistent())
+	sdb.Close()
+
+	// the indexes of the current state and of every checkpoint are
+	// rebuilt when the StateDB is opened
+	sdb, err = NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	defer sdb.Close()
+	assert.Equal(t, common.BatchNum(2), sdb.CurrentBatch())
+	issues, err := sdb.CheckIdxIndexes()
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+	for batchNum := common.BatchNum(1); batchNum <= 2; batchNum++ {
+		report, err := sdb.VerifyCheckpoint(batchNum)
+		require.NoError(t, err)
+		assert.True(t, report.Consistent(), report.Issues)
+	}
+	idx, err := sdb.GetIdxByBJJ(bjjA)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(256), idx)
+	idx, err = sdb.GetIdxByEthAddr(addrX)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(256), idx)
+	idx, err = sdb.LastGetIdxByBJJ(bjjB)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(257), idx)
+	view, err := sdb.OpenAt(1)
+	require.NoError(t, err)
+	idx, err = view.GetIdxByBJJ(bjjB)
+	require.NoError(t, err)
+	assert.Equal(t, common.AccountIdx(257), idx)
+	view.Close()
+}
+
+func TestLastRead(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	account := newAccount(t, 0)
+	_, err = sdb.CreateAccount(account.Idx, account)
+	require.NoError(t, err)
+	vouch := newVouch(0)
+	_, err = sdb.CreateVouch(vouch.Idx, vouch)
+	require.NoError(t, err)
+	score := newScore(0)
+	_, err = sdb.CreateScore(score.Idx, score)
+	require.NoError(t, err)
+
+	// the changes are not visible until the checkpoint is made
+	_, err = sdb.LastGetAccount(account.Idx)
+	assert.Equal(t, db.ErrNotFound, common.Unwrap(err))
+	batchNum, err := sdb.LastGetCurrentBatch()
+	require.NoError(t, err)
+	assert.Equal(t, common.BatchNum(0), batchNum)
+
+	require.NoError(t, sdb.MakeCheckpoint())
+	batchNum, err = sdb.LastGetCurrentBatch()
+	require.NoError(t, err)
+	assert.Equal(t, common.BatchNum(1), batchNum)
+	lastAccount, err := sdb.LastGetAccount(account.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, account, lastAccount)
+	lastVouch, err := sdb.LastGetVouch(vouch.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, vouch.Value, lastVouch.Value)
+	lastScore, err := sdb.LastGetScore(score.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, score.Value, lastScore.Value)
+	idx, err := sdb.LastGetIdxByEthAddrBJJ(account.EthAddr, account.BJJ)
+	require.NoError(t, err)
+	assert.Equal(t, account.Idx, idx)
+
+	roots, err := sdb.LastMTGetRoots()
+	require.NoError(t, err)
+	assert.Equal(t, sdb.GetMTRootAccount(), roots.Account)
+	assert.Equal(t, sdb.GetMTRootVouch(), roots.Vouch)
+	assert.Equal(t, sdb.GetMTRootScore(), roots.Score)
+	proof, err := sdb.LastMTGetAccountProof(account.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, roots.Account, proof.Root.BigInt())
+
+	// updates in the current batch don't change the last checkpoint
+	account.Balance = big.NewInt(42)
+	_, err = sdb.UpdateAccount(account.Idx, account)
+	require.NoError(t, err)
+	require.NoError(t, sdb.LastRead(func(last *Last) error {
+		acc, err := last.GetAccount(account.Idx)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1000), acc.Balance)
+		lastRoots, err := last.MTGetRoots()
+		require.NoError(t, err)
+		assert.Equal(t, roots, lastRoots)
+		return nil
+	}))
+
+	require.NoError(t, sdb.MakeCheckpoint())
+	lastAccount, err = sdb.LastGetAccount(account.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), lastAccount.Balance)
+}
+
+func TestOpenAt(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0,
+		OpenAtCache: 2})
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	// the score is increased at every batch
+	score := newScore(0)
+	_, err = sdb.CreateScore(score.Idx, score)
+	require.NoError(t, err)
+	require.NoError(t, sdb.MakeCheckpoint())
+	roots := map[common.BatchNum]*big.Int{1: sdb.GetMTRootScore()}
+	for batchNum := common.BatchNum(2); batchNum <= 4; batchNum++ {
+		score.Value++
+		_, err = sdb.UpdateScore(score.Idx, score)
+		require.NoError(t, err)
+		require.NoError(t, sdb.MakeCheckpoint())
+		roots[batchNum] = sdb.GetMTRootScore()
+	}
+
+	for batchNum := common.BatchNum(1); batchNum <= 4; batchNum++ {
+		view, err := sdb.OpenAt(batchNum)
+		require.NoError(t, err)
+		assert.Equal(t, batchNum, view.BatchNum())
+		current, err := view.GetCurrentBatch()
+		require.NoError(t, err)
+		assert.Equal(t, batchNum, current)
+		s, err := view.GetScore(score.Idx)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(batchNum), s.Value)
+		proof, err := view.MTGetScoreProof(score.Idx)
+		require.NoError(t, err)
+		assert.Equal(t, roots[batchNum], proof.Root.BigInt())
+		view.Close()
+	}
+	// only the 2 most recently used checkpoints are kept open
+	entries, err := os.ReadDir(filepath.Join(dir, kvdb.PathOpenAt))
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(entries))
+
+	// an evicted view in use can still be read
+	view, err := sdb.OpenAt(1)
+	require.NoError(t, err)
+	for batchNum := common.BatchNum(2); batchNum <= 4; batchNum++ {
+		other, err := sdb.OpenAt(batchNum)
+		require.NoError(t, err)
+		other.Close()
+	}
+	s, err := view.GetScore(score.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), s.Value)
+	view.Close()
+
+	// the views of the batches discarded by a reset are not served
+	require.NoError(t, sdb.Reset(2))
+	_, err = sdb.OpenAt(4)
+	assert.ErrorIs(t, err, kvdb.ErrCheckpointNotFound)
+	view, err = sdb.OpenAt(2)
+	require.NoError(t, err)
+	s, err = view.GetScore(score.Idx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), s.Value)
+	view.Close()
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(t, err)
+	deleteme = append(deleteme, dir)
+
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: TypeSynchronizer, NLevels: 0})
+	require.NoError(t, err)
+	defer sdb.Close()
+
+	accounts := []*common.Account{newAccount(t, 0), newAccount(t, 1), newAccount(t, 2)}
+	_, err = sdb.CreateAccount(accounts[0].Idx, accounts[0])
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetCurrentAccountIdx(accounts[0].Idx))
+	root := sdb.GetMTRootAccount()
+
+	// revert a created account and an updated account
+	snapshot := sdb.Snapshot()
+	_, err = sdb.CreateAccount(accounts[1].Idx, accounts[1])
+	require.NoError(t, err)
+	require.NoError(t, sdb.SetCurrentAccountIdx(accounts[1].Idx))
+	updated := *accounts[0]
+	updated.Balance = big.NewInt(1)
+	_, err = sdb.UpdateAccount(updated.Idx, &updated)
+	require.NoError(t, err)
+	_, err = sdb.CreateScore(accounts[1].Idx, newScore(1))
+	require.NoError(t, err)
+	assert.NotEqual(t, root, sdb.GetMTRootAccount())
+
+	require.NoError(t, sdb.RevertToSnapshot(snapshot))
+	assert.Equal(t, root, sdb.GetMTRootAccount())
+	assert.Equal(t, accounts[0].Idx, sdb.CurrentAccountIdx())
+	acc, err := sdb.GetAccount(accounts[0].Idx)
+	require.NoError(t, err)
+	assert.Equal(t, accounts[0], acc)
+	_, err = sdb.GetAccount(accounts[1].Idx)
+	assert.Equal(t, db.ErrNotFound, common.Unwrap(err))
+	_, err = sdb.GetScore(accounts[1].Idx)
+	assert.Equal(t, db.ErrNotFound, common.Unwrap(err))
+	_, err = sdb.GetIdxByEthAddrBJJ(accounts[1].EthAddr, accounts[1].BJJ)
+	assert.Equal(t, ErrIdxNotFound, common.Unwrap(err))
+	// the reverted trees can still be modified
+	_, err = sdb.MTGetAccountProof(accounts[0].Idx)
+	require.NoError(t, err)
+
+	// nested snapshots
+	snapshot = sdb.Snapshot()
+	_, err = sdb.CreateAccount(accounts[1].Idx, accounts[1])
+	require.NoError(t, err)
+	rootNested := sdb.GetMTRootAccount()
+	snapshotNested := sdb.Snapshot()
+	_, err = sdb.CreateAccount(accounts[2].Idx, accounts[2])
+	require.NoError(t, err)
+	require.NoError(t, sdb.RevertToSnapshot(snapshotNested))
+	assert.Equal(t, rootNested, sdb.GetMTRootAccount())
+	_, err = sdb.GetAccount(accounts[1].Idx)
+	require.NoError(t, err)
+	_, err = sdb.GetAccount(accounts[2].Idx)
+	assert.Equal(t, db.ErrNotFound, common.Unwrap(err))
+	require.NoError(t, sdb.RevertToSnapshot(snapshot))
+	assert.Equal(t, root, sdb.GetMTRootAccount())
+
+	// the same state is obtained after reverting and redoing the changes
+	_, err = sdb.CreateAccount(accounts[1].Idx, accounts[1])
+	require.NoError(t, err)
+	assert.Equal(t, rootNested, sdb.GetMTRootAccount())
+
+	// snapshots are invalidated by checkpoints
+	snapshot = sdb.Snapshot()
+	require.NoError(t, sdb.MakeCheckpoint())
+	err = sdb.RevertToSnapshot(snapshot)
+	assert.ErrorIs(t, err, kvdb.ErrInvalidSnapshot)
+}
+
+func TestBulkUpdate(t *testing.T) {
+	newDB := func(typ TypeStateDB) *StateDB {
+		sdb, err := NewStateDB(Config{Path: "sdb", Keep: 128, Type: typ, NLevels: 0,
+			Backend: kvdb.NewMemoryBackend()})
+		require.NoError(t, err)
+		return sdb
+	}
+	bulk, seq := newDB(TypeSynchronizer), newDB(TypeBatchBuilder)
+	defer bulk.Close()
+	defer seq.Close()
+
+	accounts := []*common.Account{newAccount(t, 0), newAccount(t, 1)}
+	for _, sdb := range []*StateDB{bulk, seq} {
+		for _, account := range accounts {
+			_, err := sdb.CreateAccount(account.Idx, account)
+			require.NoError(t, err)
+		}
+		_, err := sdb.CreateScore(0x100, newScore(0))
+		require.NoError(t, err)
+	}
+
+	// the bulk update gives the same state as the individual updates
+	update := &BulkUpdate{
+		Accounts: []common.Account{*accounts[1]},
+		Vouches: []common.Vouch{{Idx: common.VouchIdx(256257), Value: true},
+			{Idx: common.VouchIdx(257256), Value: true},
+			{Idx: common.VouchIdx(256257), Value: false}},
+		Scores: []common.Score{{Idx: 0x100, Value: 7}, {Idx: 0x101, Value: 3}},
+	}
+	update.Accounts[0].Balance = big.NewInt(5)
+	proofs, err := bulk.ApplyBulkUpdate(update)
+	require.NoError(t, err)
+	assert.Nil(t, proofs)
+
+	_, err = seq.UpdateAccount(accounts[1].Idx, &update.Accounts[0])
+	require.NoError(t, err)
+	_, err = seq.CreateVouch(256257, &update.Vouches[0])
+	require.NoError(t, err)
+	_, err = seq.CreateVouch(257256, &update.Vouches[1])
+	require.NoError(t, err)
+	_, err = seq.UpdateVouch(256257, &update.Vouches[2])
+	require.NoError(t, err)
+	_, err = seq.UpdateScore(0x100, &update.Scores[0])
+	require.NoError(t, err)
+	_, err = seq.CreateScore(0x101, &update.Scores[1])
+	require.NoError(t, err)
+
+	assert.Equal(t, seq.GetMTRootAccount(), bulk.GetMTRootAccount())
+	assert.Equal(t, seq.GetMTRootVouch(), bulk.GetMTRootVouch())
+	assert.Equal(t, seq.GetMTRootScore(), bulk.GetMTRootScore())
+	vouch, err := bulk.GetVouch(256257)
+	require.NoError(t, err)
+	assert.False(t, vouch.Value)
+	score, err := bulk.GetScore(0x101)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), score.Value)
+
+	// the BatchBuilder gets the proofs
+	scoreProofs, err := seq.UpdateScores(map[common.AccountIdx]*common.Score{
+		0x100: {Value: 8}, 0x102: {Value: 1}})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(scoreProofs))
+	assert.Equal(t, seq.GetMTRootScore(), scoreProofs[0x102].NewRoot.BigInt())
+	assert.Equal(t, big.NewInt(0x100), scoreProofs[0x100].OldKey.BigInt())
+	vouchProofs, err := seq.ApplyVouches([]common.Vouch{{Idx: 256257, Value: true}})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(vouchProofs))
+	assert.Equal(t, seq.GetMTRootVouch(), vouchProofs[0].NewRoot.BigInt())
+	scoreProofs, err = bulk.UpdateScores(map[common.AccountIdx]*common.Score{
+		0x100: {Value: 8}, 0x102: {Value: 1}})
+	require.NoError(t, err)
+	assert.Nil(t, scoreProofs)
+	assert.Equal(t, seq.GetMTRootScore(), bulk.GetMTRootScore())
+
+	// updating many existing leaves, with shared paths, without proofs gives
+	// the same roots as updating them one by one
+	for _, sdb := range []*StateDB{bulk, seq} {
+		scores := make(map[common.AccountIdx]*common.Score)
+		for i := 0; i < 64; i++ {
+			scores[common.AccountIdx(0x100+i)] = &common.Score{Value: uint32(i)}
+		}
+		for round := 0; round < 2; round++ {
+			_, err = sdb.UpdateScores(scores)
+			require.NoError(t, err)
+			for _, score := range scores {
+				score.Value++
+			}
+		}
+	}
+	assert.Equal(t, seq.GetMTRootScore(), bulk.GetMTRootScore())
+	score, err = bulk.GetScore(0x13f)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x3f+1), score.Value)
+
+	// only existing accounts can be updated
+	_, err = bulk.ApplyBulkUpdate(&BulkUpdate{Accounts: []common.Account{*newAccount(t, 5)}})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func newBenchStateDB(b *testing.B, typ TypeStateDB) *StateDB {
+	dir, err := os.MkdirTemp("", "tmpdb")
+	require.NoError(b, err)
+	sdb, err := NewStateDB(Config{Path: dir, Keep: 128, Type: typ, NLevels: 0})
+	require.NoError(b, err)
+	b.Cleanup(func() {
+		sdb.Close()
+		os.RemoveAll(dir) //nolint:errcheck
+	})
+	return sdb
+}
+
+const benchScores = 1000
+
+// BenchmarkUpdateScores compares updating the scores of benchScores accounts
+// one by one with UpdateScore and at once with UpdateScores
+func BenchmarkUpdateScores(b *testing.B) {
+	setup := func(b *testing.B, typ TypeStateDB) (*StateDB,
+		map[common.AccountIdx]*common.Score) {
+		sdb := newBenchStateDB(b, typ)
+		scores := make(map[common.AccountIdx]*common.Score, benchScores)
+		for i := 0; i < benchScores; i++ {
+			scores[common.AccountIdx(256+i)] = &common.Score{Idx: common.AccountIdx(256 + i)}
+		}
+		_, err := sdb.UpdateScores(scores)
+		require.NoError(b, err)
+		return sdb, scores
+	}
+	b.Run("UpdateScore", func(b *testing.B) {
+		sdb, scores := setup(b, TypeSynchronizer)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for idx, score := range scores {
+				score.Value++
+				_, err := sdb.UpdateScore(idx, score)
+				require.NoError(b, err)
+			}
+		}
+	})
+	for _, typ := range []TypeStateDB{TypeSynchronizer, TypeBatchBuilder} {
+		b.Run("UpdateScores/"+string(typ), func(b *testing.B) {
+			sdb, scores := setup(b, typ)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, score := range scores {
+					score.Value++
+				}
+				_, err := sdb.UpdateScores(scores)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// BenchmarkBulkUpdate compares creating benchScores scores and vouches one
+// by one and with a single ApplyBulkUpdate, which updates both trees
+// concurrently
+func BenchmarkBulkUpdate(b *testing.B) {
+	update := &BulkUpdate{}
+	for i := 0; i < benchScores; i++ {
+		update.Scores = append(update.Scores, common.Score{Idx: common.AccountIdx(256 + i),
+			Value: 1})
+		update.Vouches = append(update.Vouches, common.Vouch{
+			Idx: common.GenerateVouchIdx(256, common.AccountIdx(257+i)), Value: true})
+	}
+	b.Run("Create", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			sdb := newBenchStateDB(b, TypeSynchronizer)
+			b.StartTimer()
+			for j := range update.Scores {
+				_, err := sdb.CreateScore(update.Scores[j].Idx, &update.Scores[j])
+				require.NoError(b, err)
+				_, err = sdb.CreateVouch(update.Vouches[j].Idx, &update.Vouches[j])
+				require.NoError(b, err)
+			}
+		}
+	})
+	b.Run("ApplyBulkUpdate", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			sdb := newBenchStateDB(b, TypeSynchronizer)
+			b.StartTimer()
+			_, err := sdb.ApplyBulkUpdate(update)
+			require.NoError(b, err)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package statedb
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
 	"tokamak-sybil-resistance/log"
 
 	ethCommon "github.com/ethereum/go-ethereum/common"
@@ -28,9 +30,14 @@ func concatEthAddrBJJ(addr ethCommon.Address, pk babyjub.PublicKeyComp) []byte {
 // Ethereum Address. Will return common.Idx(0) and error in case that Idx is
 // not found in the StateDB.
 func (s *StateDB) GetIdxByEthAddr(addr ethCommon.Address) (common.AccountIdx,
+	error) {
+	return getIdxByEthAddr(s.db.DB(), addr)
+}
+
+func getIdxByEthAddr(sto db.Storage, addr ethCommon.Address) (common.AccountIdx,
 	error) {
 	k := concatEthAddr(addr)
-	b, err := s.db.DB().Get(append(PrefixKeyAddr, k...))
+	b, err := sto.Get(append(PrefixKeyAddr, k...))
 	if err != nil {
 		return common.AccountIdx(0), common.Wrap(fmt.Errorf("GetIdxByEthAddr: %s: ToEthAddr: %s",
 			ErrIdxNotFound, addr.Hex()))
@@ -49,15 +56,20 @@ func (s *StateDB) GetIdxByEthAddr(addr ethCommon.Address) (common.AccountIdx,
 // query.  Will return common.Idx(0) and error in case that Idx is not found in
 // the StateDB.
 func (s *StateDB) GetIdxByEthAddrBJJ(addr ethCommon.Address, pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	return getIdxByEthAddrBJJ(s.db.DB(), addr, pk)
+}
+
+func getIdxByEthAddrBJJ(sto db.Storage, addr ethCommon.Address,
+	pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
 	if !bytes.Equal(addr.Bytes(), common.EmptyAddr.Bytes()) && pk == common.EmptyBJJComp {
 		// ToEthAddr
 		// case ToEthAddr!=0 && ToBJJ=0
-		return s.GetIdxByEthAddr(addr)
+		return getIdxByEthAddr(sto, addr)
 	} else if !bytes.Equal(addr.Bytes(), common.EmptyAddr.Bytes()) &&
 		pk != common.EmptyBJJComp {
 		// case ToEthAddr!=0 && ToBJJ!=0
 		k := concatEthAddrBJJ(addr, pk)
-		b, err := s.db.DB().Get(append(PrefixKeyAddrBJJ, k...))
+		b, err := sto.Get(append(PrefixKeyAddrBJJ, k...))
 		if common.Unwrap(err) == db.ErrNotFound {
 			// return the error (ErrNotFound), so can be traced at upper layers
 			return common.AccountIdx(0), common.Wrap(ErrIdxNotFound)
@@ -81,54 +93,254 @@ func (s *StateDB) GetIdxByEthAddrBJJ(addr ethCommon.Address, pk babyjub.PublicKe
 				ErrGetIdxNoCase, addr.Hex(), pk))
 }
 
+// GetIdxByBJJ returns the smallest Idx in the StateDB for the given
+// BabyJubJub PublicKey.  Will return common.Idx(0) and error in case that Idx
+// is not found in the StateDB.
+func (s *StateDB) GetIdxByBJJ(pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	return getIdxByBJJ(s.db.DB(), pk)
+}
+
+func getIdxByBJJ(sto db.Storage, pk babyjub.PublicKeyComp) (common.AccountIdx, error) {
+	if pk == common.EmptyBJJComp {
+		return common.AccountIdx(0),
+			common.Wrap(fmt.Errorf("GetIdxByBJJ: %s: ToBJJ: %s", ErrGetIdxNoCase, pk))
+	}
+	b, err := sto.Get(db.Concat(PrefixKeyBJJ, pk[:]))
+	if common.Unwrap(err) == db.ErrNotFound {
+		return common.AccountIdx(0), common.Wrap(ErrIdxNotFound)
+	} else if err != nil {
+		return common.AccountIdx(0),
+			common.Wrap(fmt.Errorf("GetIdxByBJJ: %s: ToBJJ: %s", err, pk))
+	}
+	idx, err := common.AccountIdxFromBytes(b)
+	if err != nil {
+		return common.AccountIdx(0),
+			common.Wrap(fmt.Errorf("GetIdxByBJJ: %s: ToBJJ: %s", err, pk))
+	}
+	return idx, nil
+}
+
+// idxIndexKeys returns the keys of the secondary indexes that map to the Idx
+// of an account with the given EthAddr & BJJ:
+// - Eth Address
+// - EthAddr & BabyJubJub PublicKey Compressed
+// - BabyJubJub PublicKey Compressed
+func idxIndexKeys(addr ethCommon.Address, pk babyjub.PublicKeyComp) [][]byte {
+	return [][]byte{
+		db.Concat(PrefixKeyAddr, concatEthAddr(addr)),
+		db.Concat(PrefixKeyAddrBJJ, concatEthAddrBJJ(addr, pk)),
+		db.Concat(PrefixKeyBJJ, pk[:]),
+	}
+}
+
 // setIdxByEthAddrBJJ stores the given Idx in the StateDB as follows:
 // - key: Eth Address, value: idx
 // - key: EthAddr & BabyJubJub PublicKey Compressed, value: idx
-// If Idx already exist for the given EthAddr & BJJ, the remaining Idx will be
+// - key: BabyJubJub PublicKey Compressed, value: idx
+// If an Idx already exists for any of the keys, the remaining Idx will be
 // always the smallest one.
 func (s *StateDB) setIdxByEthAddrBJJ(idx common.AccountIdx, addr ethCommon.Address,
 	pk babyjub.PublicKeyComp) error {
-	oldIdx, err := s.GetIdxByEthAddrBJJ(addr, pk)
-	if err == nil {
-		// EthAddr & BJJ already have an Idx
-		// check which Idx is smaller
-		// if new idx is smaller, store the new one
-		// if new idx is bigger, don't store and return, as the used one will be the old
-		if idx >= oldIdx {
-			log.Debug("StateDB.setIdxByEthAddrBJJ: Idx not stored because there " +
-				"already exist a smaller Idx for the given EthAddr & BJJ")
-			return nil
+	sto := s.db.DB()
+	idxBytes, err := idx.Bytes()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	tx, err := sto.NewTx()
+	if err != nil {
+		return common.Wrap(err)
+	}
+	for _, k := range idxIndexKeys(addr, pk) {
+		b, err := sto.Get(k)
+		if err == nil {
+			// the key already has an Idx: if the new idx is bigger,
+			// don't store it, as the used one will be the old
+			oldIdx, err := common.AccountIdxFromBytes(b)
+			if err != nil {
+				tx.Close()
+				return common.Wrap(err)
+			}
+			if idx >= oldIdx {
+				log.Debugw("StateDB.setIdxByEthAddrBJJ: Idx not stored because there "+
+					"already exist a smaller Idx", "key", k, "idx", idx, "oldIdx", oldIdx)
+				continue
+			}
+		} else if common.Unwrap(err) != db.ErrNotFound {
+			tx.Close()
+			return common.Wrap(err)
+		}
+		if err := tx.Put(k, idxBytes[:]); err != nil {
+			tx.Close()
+			return common.Wrap(err)
+		}
+	}
+	return common.Wrap(tx.Commit())
+}
+
+// idxIndexes returns the content of the secondary indexes that map the EthAddr
+// and BJJ of the accounts to their Idx computed from the account leaves stored
+// in sto, with the keys as strings
+func idxIndexes(sto db.Storage) (map[string]common.AccountIdx, error) {
+	indexes := make(map[string]common.AccountIdx)
+	// accountsIter goes through the accounts in ascending Idx order, so the
+	// first Idx of each key is the smallest
+	if err := accountsIter(sto, func(a *common.Account) (bool, error) {
+		for _, k := range idxIndexKeys(a.EthAddr, a.BJJ) {
+			if _, ok := indexes[string(k)]; !ok {
+				indexes[string(k)] = a.Idx
+			}
 		}
+		return true, nil
+	}); err != nil {
+		return nil, common.Wrap(err)
 	}
+	return indexes, nil
+}
 
-	// store idx for EthAddr & BJJ assuming that EthAddr & BJJ still don't
-	// have an Idx stored in the DB, and if so, the already stored Idx is
-	// bigger than the given one, so should be updated to the new one
-	// (smaller)
-	tx, err := s.db.DB().NewTx()
+// verifyIdxIndexes compares the secondary indexes stored in sto with the ones
+// computed from the account leaves, and returns the inconsistencies found
+func verifyIdxIndexes(sto db.Storage) ([]string, error) {
+	indexes, err := idxIndexes(sto)
 	if err != nil {
-		return common.Wrap(err)
+		return nil, common.Wrap(err)
 	}
-	idxBytes, err := idx.Bytes()
+	issues := []string{}
+	found := make(map[string]bool)
+	for _, prefix := range [][]byte{PrefixKeyAddr, PrefixKeyAddrBJJ, PrefixKeyBJJ} {
+		if err := sto.WithPrefix(prefix).Iterate(func(k, v []byte) (bool, error) {
+			key := string(db.Concat(prefix, k))
+			found[key] = true
+			idx, err := common.AccountIdxFromBytes(v)
+			if err != nil {
+				issues = append(issues, fmt.Sprintf("index %x: %v", key, err))
+			} else if expected, ok := indexes[key]; !ok {
+				issues = append(issues, fmt.Sprintf("index %x: idx %d has no account", key, idx))
+			} else if idx != expected {
+				issues = append(issues, fmt.Sprintf("index %x: idx %d, expected %d",
+					key, idx, expected))
+			}
+			return true, nil
+		}); err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	missing := []string{}
+	for key, idx := range indexes {
+		if !found[key] {
+			missing = append(missing, fmt.Sprintf("index %x: missing idx %d", key, idx))
+		}
+	}
+	sort.Strings(missing)
+	return append(issues, missing...), nil
+}
+
+// CheckIdxIndexes checks that the secondary indexes used by GetIdxByEthAddr,
+// GetIdxByEthAddrBJJ and GetIdxByBJJ match the account leaves of the current
+// state, and returns the inconsistencies found.  RebuildIdxIndexes fixes them.
+func (s *StateDB) CheckIdxIndexes() ([]string, error) {
+	return verifyIdxIndexes(s.db.DB())
+}
+
+// RebuildIdxIndexes replaces the secondary indexes used by GetIdxByEthAddr,
+// GetIdxByEthAddrBJJ and GetIdxByBJJ in the current state with the ones
+// computed from the account leaves.  The rebuilt indexes are persisted at the
+// next checkpoint.
+func (s *StateDB) RebuildIdxIndexes() error {
+	puts, dels, err := idxIndexesWrites(s.db.DB())
 	if err != nil {
 		return common.Wrap(err)
 	}
-	// store Addr&BJJ-idx
-	k := concatEthAddrBJJ(addr, pk)
-	err = tx.Put(append(PrefixKeyAddrBJJ, k...), idxBytes[:])
+	return common.Wrap(s.db.Write(puts, dels))
+}
+
+// idxIndexesWrites returns the writes that replace the secondary indexes
+// stored in sto with the ones computed from the account leaves
+func idxIndexesWrites(sto db.Storage) ([]db.KV, [][]byte, error) {
+	indexes, err := idxIndexes(sto)
 	if err != nil {
-		return common.Wrap(err)
+		return nil, nil, common.Wrap(err)
+	}
+	var dels [][]byte
+	for _, prefix := range [][]byte{PrefixKeyAddr, PrefixKeyAddrBJJ, PrefixKeyBJJ} {
+		if err := sto.WithPrefix(prefix).Iterate(func(k, v []byte) (bool, error) {
+			key := db.Concat(prefix, k)
+			if _, ok := indexes[string(key)]; !ok {
+				dels = append(dels, key)
+			}
+			return true, nil
+		}); err != nil {
+			return nil, nil, common.Wrap(err)
+		}
+	}
+	puts := make([]db.KV, 0, len(indexes))
+	for key, idx := range indexes {
+		idxBytes, err := idx.Bytes()
+		if err != nil {
+			return nil, nil, common.Wrap(err)
+		}
+		puts = append(puts, db.KV{K: []byte(key), V: idxBytes[:]})
 	}
+	return puts, dels, nil
+}
 
-	// store Addr-idx
-	k = concatEthAddr(addr)
-	err = tx.Put(append(PrefixKeyAddr, k...), idxBytes[:])
+// needsIdxIndexesBackfill returns true if sto was written before the BJJ
+// index existed: it has account leaves but no BJJ index entries.  The EthAddr
+// index of those states also keeps the last Idx created for the address
+// instead of the smallest one.
+func needsIdxIndexesBackfill(sto db.Storage) (bool, error) {
+	hasAccounts, err := hasPrefix(sto, PrefixKeyAccIdx)
+	if err != nil || !hasAccounts {
+		return false, common.Wrap(err)
+	}
+	hasBJJIndex, err := hasPrefix(sto, PrefixKeyBJJ)
 	if err != nil {
-		return common.Wrap(err)
+		return false, common.Wrap(err)
 	}
-	err = tx.Commit()
+	return !hasBJJIndex, nil
+}
+
+// backfillIdxIndexes rebuilds the secondary indexes of sto if it was written
+// before the BJJ index existed, and returns true if it did
+func backfillIdxIndexes(sto kvdb.Storage) (bool, error) {
+	backfill, err := needsIdxIndexesBackfill(sto)
+	if err != nil || !backfill {
+		return false, common.Wrap(err)
+	}
+	puts, dels, err := idxIndexesWrites(sto)
 	if err != nil {
+		return false, common.Wrap(err)
+	}
+	return true, common.Wrap(sto.Write(puts, dels))
+}
+
+// backfillCheckpointsIdxIndexes rebuilds once the secondary indexes of every
+// checkpoint, and of the current state, of a StateDB written before the BJJ
+// index existed, so that GetIdxByBJJ finds the accounts created by it and
+// CheckIdxIndexes doesn't reject its checkpoints
+func (s *StateDB) backfillCheckpointsIdxIndexes() error {
+	backfill, err := needsIdxIndexesBackfill(s.db.DB())
+	if err != nil || !backfill {
+		return common.Wrap(err)
+	}
+	log.Infow("Backfilling the StateDB Idx indexes", "batch", s.CurrentBatch(),
+		"type", s.cfg.Type)
+	if err := s.db.UpdateCheckpoints(func(batchNum common.BatchNum, sto kvdb.Storage) error {
+		_, err := backfillIdxIndexes(sto)
 		return common.Wrap(err)
+	}); err != nil {
+		return common.Wrap(err)
+	}
+	return s.reopenTrees()
+}
+
+// hasPrefix returns true if sto has any key with the given prefix
+func hasPrefix(sto db.Storage, prefix []byte) (bool, error) {
+	found := false
+	if err := sto.WithPrefix(prefix).Iterate(func(k, v []byte) (bool, error) {
+		found = true
+		return false, nil
+	}); err != nil {
+		return false, common.Wrap(err)
 	}
-	return nil
+	return found, nil
 }
@@ -0,0 +1,231 @@
+package statedb
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
+
+	"github.com/iden3/go-merkletree"
+	"github.com/iden3/go-merkletree/db"
+	"github.com/iden3/go-merkletree/db/memory"
+)
+
+// TreeRoots contains the roots of the Account, Vouch and Score merkle trees
+type TreeRoots struct {
+	Account *big.Int
+	Vouch   *big.Int
+	Score   *big.Int
+}
+
+// CheckpointReport is the result of verifying a StateDB checkpoint
+type CheckpointReport struct {
+	BatchNum common.BatchNum
+	// Stored are the roots of the merkle trees stored in the checkpoint
+	Stored TreeRoots
+	// Computed are the roots of the merkle trees recomputed from the leaves
+	// stored in the checkpoint
+	Computed TreeRoots
+	// Issues contains the inconsistencies found in the checkpoint leaves
+	Issues []string
+}
+
+// Consistent returns true if no issues were found in the checkpoint and the
+// stored roots match the roots recomputed from the leaves
+func (r *CheckpointReport) Consistent() bool {
+	return len(r.Issues) == 0 &&
+		r.Stored.Account.Cmp(r.Computed.Account) == 0 &&
+		r.Stored.Vouch.Cmp(r.Computed.Vouch) == 0 &&
+		r.Stored.Score.Cmp(r.Computed.Score) == 0
+}
+
+// VerifyCheckpoint checks the integrity of the checkpoint at the given
+// batchNum: the Account, Vouch and Score merkle trees are rebuilt from the
+// leaves stored in the checkpoint and their roots are compared with the
+// stored ones, and the Idx secondary indexes are checked against the account
+// leaves.  An error is only returned if the checkpoint can't be read;
+// inconsistencies are reported in the returned CheckpointReport.
+func (s *StateDB) VerifyCheckpoint(batchNum common.BatchNum) (*CheckpointReport, error) {
+	report := &CheckpointReport{BatchNum: batchNum}
+	if err := s.db.VerifyCheckpoint(batchNum, func(sto kvdb.Storage) error {
+		return verifyTrees(sto, s.AccountTree.MaxLevels(), report)
+	}); errors.Is(err, kvdb.ErrCorruptCheckpoint) {
+		report.Issues = append(report.Issues, err.Error())
+	} else if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return report, nil
+}
+
+// verifyTrees fills the roots of the report with the roots of the trees stored
+// in sto and the roots of the trees recomputed from the leaves stored in sto,
+// and reports the secondary indexes that don't match the account leaves
+func verifyTrees(sto db.Storage, nLevels int, report *CheckpointReport) error {
+	var err error
+	if report.Stored.Account, err = storedRoot(sto, PrefixKeyMTAcc, nLevels); err != nil {
+		return common.Wrap(err)
+	}
+	if report.Stored.Vouch, err = storedRoot(sto, PrefixKeyMTVoc, nLevels); err != nil {
+		return common.Wrap(err)
+	}
+	if report.Stored.Score, err = storedRoot(sto, PrefixKeyMTSco, nLevels); err != nil {
+		return common.Wrap(err)
+	}
+
+	issuef := func(format string, args ...interface{}) {
+		report.Issues = append(report.Issues, fmt.Sprintf(format, args...))
+	}
+	// Account leaves: idx -> hash, hash -> account
+	if report.Computed.Account, err = computedRoot(sto, PrefixKeyAccIdx, nLevels,
+		func(k, v []byte) (*big.Int, *big.Int) {
+			idx, err := common.AccountIdxFromBytes(k)
+			if err != nil {
+				issuef("account key %x: %v", k, err)
+				return nil, nil
+			}
+			acc, err := GetAccountInTreeDB(sto, idx)
+			if err != nil {
+				issuef("account %d: %v", idx, err)
+				return nil, nil
+			}
+			hash, err := acc.HashValue()
+			if err != nil {
+				issuef("account %d: %v", idx, err)
+				return nil, nil
+			}
+			if hash.Cmp(new(big.Int).SetBytes(v)) != 0 {
+				issuef("account %d: stored hash %x doesn't match the account data", idx, v)
+			}
+			return idx.BigInt(), hash
+		}); err != nil {
+		return common.Wrap(err)
+	}
+	// Vouch leaves: idx -> value
+	if report.Computed.Vouch, err = computedRoot(sto, PrefixKeyVocIdx, nLevels,
+		func(k, v []byte) (*big.Int, *big.Int) {
+			idx, err := common.VouchIdxFromBytes(k)
+			if err != nil {
+				issuef("vouch key %x: %v", k, err)
+				return nil, nil
+			}
+			if len(v) != 1 || v[0] > 1 {
+				issuef("vouch %d: invalid value %x", idx, v)
+			}
+			var b [1]byte
+			copy(b[:], v)
+			vouch, _ := common.VouchFromBytes(b)
+			return idx.BigInt(), common.BigIntFromBool(vouch.Value)
+		}); err != nil {
+		return common.Wrap(err)
+	}
+	// Score leaves: idx -> score
+	if report.Computed.Score, err = computedRoot(sto, PrefixKeyScoIdx, nLevels,
+		func(k, v []byte) (*big.Int, *big.Int) {
+			idx, err := common.AccountIdxFromBytes(k)
+			if err != nil {
+				issuef("score key %x: %v", k, err)
+				return nil, nil
+			}
+			if len(v) != 4 { //nolint:gomnd
+				issuef("score %d: invalid value %x", idx, v)
+			}
+			score, err := GetScoreInTreeDB(sto, idx)
+			if err != nil {
+				issuef("score %d: %v", idx, err)
+				return nil, nil
+			}
+			return idx.BigInt(), score.BigInt()
+		}); err != nil {
+		return common.Wrap(err)
+	}
+	// Secondary indexes: EthAddr, EthAddr & BJJ and BJJ -> idx
+	issues, err := verifyIdxIndexes(sto)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	report.Issues = append(report.Issues, issues...)
+	return nil
+}
+
+// storedRoot returns the root of the merkle tree stored in sto under prefix
+func storedRoot(sto db.Storage, prefix []byte, nLevels int) (*big.Int, error) {
+	mt, err := merkletree.NewMerkleTree(sto.WithPrefix(prefix), nLevels)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return mt.Root().BigInt(), nil
+}
+
+// computedRoot builds an in-memory merkle tree with the leaves stored in sto
+// under prefix and returns its root.  leaf returns the key and value of the
+// merkle tree leaf for each stored key-value, or nil if it must be skipped.
+func computedRoot(sto db.Storage, prefix []byte, nLevels int,
+	leaf func(k, v []byte) (*big.Int, *big.Int)) (*big.Int, error) {
+	mt, err := merkletree.NewMerkleTree(memory.NewMemoryStorage(), nLevels)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if err := sto.WithPrefix(prefix).Iterate(func(k, v []byte) (bool, error) {
+		key, value := leaf(k, v)
+		if key == nil {
+			return true, nil
+		}
+		return true, common.Wrap(mt.Add(key, value))
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return mt.Root().BigInt(), nil
+}
+
+// ImportCheckpoint sets the pebble db at source as the checkpoint of batchNum
+// and resets the StateDB to it.  The checkpoint is only imported if it's
+// consistent and, when roots is not nil, its roots match roots.  Only a
+// StateDB without batches can import a checkpoint.
+func (s *StateDB) ImportCheckpoint(batchNum common.BatchNum, source string,
+	roots *TreeRoots) error {
+	if err := s.db.ImportCheckpoint(batchNum, source, func(sto kvdb.Storage) error {
+		// The checkpoints exported before the BJJ index existed don't
+		// have it
+		if _, err := backfillIdxIndexes(sto); err != nil {
+			return common.Wrap(err)
+		}
+		report := &CheckpointReport{BatchNum: batchNum}
+		if err := verifyTrees(sto, s.AccountTree.MaxLevels(), report); err != nil {
+			return common.Wrap(err)
+		}
+		if !report.Consistent() {
+			return common.Wrap(fmt.Errorf("%w %d: %d issues, stored roots %+v, computed roots %+v",
+				kvdb.ErrCorruptCheckpoint, batchNum, len(report.Issues),
+				report.Stored, report.Computed))
+		}
+		if roots != nil && (roots.Account.Cmp(report.Stored.Account) != 0 ||
+			roots.Vouch.Cmp(report.Stored.Vouch) != 0 ||
+			roots.Score.Cmp(report.Stored.Score) != 0) {
+			return common.Wrap(fmt.Errorf("%w %d: roots %+v, expected %+v",
+				kvdb.ErrCorruptCheckpoint, batchNum, report.Stored, *roots))
+		}
+		return nil
+	}); err != nil {
+		return common.Wrap(err)
+	}
+	return s.reopenTrees()
+}
+
+// ExportCheckpoint copies the checkpoint of batchNum to dest after checking
+// its integrity, and returns its roots
+func (s *StateDB) ExportCheckpoint(batchNum common.BatchNum, dest string) (*TreeRoots, error) {
+	report, err := s.VerifyCheckpoint(batchNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if !report.Consistent() {
+		return nil, common.Wrap(fmt.Errorf("%w %d: %d issues, stored roots %+v, computed roots %+v",
+			kvdb.ErrCorruptCheckpoint, batchNum, len(report.Issues),
+			report.Stored, report.Computed))
+	}
+	if err := s.db.ExportCheckpoint(batchNum, dest); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &report.Stored, nil
+}
@@ -109,9 +109,9 @@ func UpdateVouchInTreeDB(sto db.Storage, mt *merkletree.MerkleTree, idx common.V
 	return nil, nil
 }
 
-// GetMTRoot returns the root of the Merkle Tree
+// GetMTRootVouch returns the root of the Vouch Merkle Tree
 func (s *StateDB) GetMTRootVouch() *big.Int {
-	return s.AccountTree.Root().BigInt()
+	return s.VouchTree.Root().BigInt()
 }
 
 func performTxVouch(sto db.Storage, idx common.VouchIdx,
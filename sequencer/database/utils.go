@@ -22,24 +22,44 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-var migrations *migrate.PackrMigrationSource
+var (
+	migrations       *migrate.PackrMigrationSource
+	sqliteMigrations *migrate.PackrMigrationSource
+)
 
 func init() {
+	box := packr.New("hermez-db-migrations", "./migrations")
 	migrations = &migrate.PackrMigrationSource{
-		Box: packr.New("hermez-db-migrations", "./migrations"),
+		Box: box,
 	}
-	ms, err := migrations.FindMigrations()
-	if err != nil {
-		panic(err)
+	sqliteMigrations = &migrate.PackrMigrationSource{
+		Box: box,
+		Dir: "sqlite",
 	}
-	if len(ms) == 0 {
-		panic(fmt.Errorf("no SQL migrations found"))
+	for _, source := range []*migrate.PackrMigrationSource{migrations, sqliteMigrations} {
+		ms, err := source.FindMigrations()
+		if err != nil {
+			panic(err)
+		}
+		if len(ms) == 0 {
+			panic(fmt.Errorf("no SQL migrations found"))
+		}
 	}
 }
 
+// migrationSource returns the migrations and the sql-migrate dialect that
+// correspond to the backend of db
+func migrationSource(db *sql.DB) (*migrate.PackrMigrationSource, string) {
+	if isSQLiteDB(db) {
+		return sqliteMigrations, "sqlite3"
+	}
+	return migrations, DriverPostgres
+}
+
 // MigrationsUp runs the SQL migrations Up
 func MigrationsUp(db *sql.DB) error {
-	nMigrations, err := migrate.Exec(db, "postgres", migrations, migrate.Up)
+	source, dialect := migrationSource(db)
+	nMigrations, err := migrate.Exec(db, dialect, source, migrate.Up)
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -50,7 +70,8 @@ func MigrationsUp(db *sql.DB) error {
 // MigrationsDown runs the SQL migrations Down,
 // migrationsToRun specifies how many migrations will be run, 0 means any.
 func MigrationsDown(db *sql.DB, migrationsToRun uint) error {
-	nMigrations, err := migrate.ExecMax(db, "postgres", migrations, migrate.Down, int(migrationsToRun))
+	source, dialect := migrationSource(db)
+	nMigrations, err := migrate.ExecMax(db, dialect, source, migrate.Down, int(migrationsToRun))
 	if err != nil {
 		return common.Wrap(err)
 	}
@@ -63,6 +84,52 @@ func MigrationsDown(db *sql.DB, migrationsToRun uint) error {
 	return nil
 }
 
+// MigrationStatus is the status of a SQL migration
+type MigrationStatus struct {
+	ID string
+	// AppliedAt is nil if the migration has not been applied
+	AppliedAt *time.Time
+	// Unknown is true if the migration has been applied but is not part of
+	// the migrations of this version of the node
+	Unknown bool
+}
+
+// MigrationsStatus returns the status of all the known and applied SQL
+// migrations, sorted by ID
+func MigrationsStatus(db *sql.DB) ([]MigrationStatus, error) {
+	source, dialect := migrationSource(db)
+	ms, err := source.FindMigrations()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	records, err := migrate.GetMigrationRecords(db, dialect)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	applied := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		applied[record.Id] = record.AppliedAt
+	}
+	status := make([]MigrationStatus, 0, len(ms))
+	for _, m := range ms {
+		s := MigrationStatus{ID: m.Id}
+		if appliedAt, ok := applied[m.Id]; ok {
+			s.AppliedAt = &appliedAt
+			delete(applied, m.Id)
+		}
+		status = append(status, s)
+	}
+	for _, record := range records {
+		if _, ok := applied[record.Id]; ok {
+			appliedAt := record.AppliedAt
+			status = append(status, MigrationStatus{
+				ID: record.Id, AppliedAt: &appliedAt, Unknown: true,
+			})
+		}
+	}
+	return status, nil
+}
+
 // ConnectSQLDB connects to the SQL DB
 func ConnectSQLDB(port int, host, user, password, name string) (*sqlx.DB, error) {
 	// Init meddler
@@ -77,7 +144,7 @@ func ConnectSQLDB(port int, host, user, password, name string) (*sqlx.DB, error)
 		password,
 		name,
 	)
-	db, err := sqlx.Connect("postgres", psqlconn)
+	db, err := sqlx.Connect(DriverPostgres, psqlconn)
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
@@ -97,12 +164,25 @@ func InitSQLDB(port int, host, user, password, name string) (*sqlx.DB, error) {
 	return db, nil
 }
 
-// InitTestSQLDB opens test PostgreSQL database
+// TestDBDriverEnv is the envvar that selects the backend of the test
+// databases.  Set it to DriverSQLite to run the tests against an in-memory
+// SQLite database instead of PostgreSQL.
+const TestDBDriverEnv = "TESTDB_DRIVER"
+
+// InitTestSQLDB opens test PostgreSQL database, or an in-memory SQLite database
+// if the TESTDB_DRIVER envvar is set to DriverSQLite.
 func InitTestSQLDB() (*sqlx.DB, error) {
 	err := godotenv.Load("../.env")
 	if err != nil {
 		log.Info("Error loading .env file")
 	}
+	switch driver := os.Getenv(TestDBDriverEnv); driver {
+	case "", "postgres":
+	case DriverSQLite:
+		return InitSQLiteDB(SQLiteMemory)
+	default:
+		return nil, common.Wrap(fmt.Errorf("unknown %s: %s", TestDBDriverEnv, driver))
+	}
 	host := os.Getenv("PGHOST")
 	if host == "" {
 		host = "localhost"
@@ -207,8 +287,18 @@ func (b BigIntNullMeddler) PostRead(fieldPtr, scanTarget interface{}) error {
 		*field = nil
 		return nil
 	}
-	// not null
-	ptr := (*ptrPtr).([]byte)
+	// not null.  PostgreSQL returns the numeric as []byte, while SQLite
+	// returns the text as string, and integer literals (such as the 0 of
+	// the effective amounts) as int64
+	var ptr []byte
+	switch v := (*ptrPtr).(type) {
+	case []byte:
+		ptr = v
+	case string:
+		ptr = []byte(v)
+	case int64:
+		ptr = []byte(strconv.FormatInt(v, 10))
+	}
 	if ptr == nil {
 		return common.Wrap(fmt.Errorf("BigIntMeddler.PostRead: nil pointer"))
 	}
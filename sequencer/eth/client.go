@@ -3,10 +3,8 @@ package eth
 import (
 	"tokamak-sybil-resistance/common"
 
-	"github.com/ethereum/go-ethereum/accounts"
-	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	ethCommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // ClientInterface is the eth Client interface used by hermez-node modules to
@@ -38,9 +36,8 @@ const (
 )
 
 // NewClient creates a new Client to interact with Ethereum and the Hermez smart contracts.
-func NewClient(client *ethclient.Client, account *accounts.Account, ks *ethKeystore.KeyStore,
-	cfg *ClientConfig) (*Client, error) {
-	ethereumClient, err := NewEthereumClient(client, account, ks, &cfg.Ethereum)
+func NewClient(client *rpc.Client, signer Signer, cfg *ClientConfig) (*Client, error) {
+	ethereumClient, err := NewEthereumClient(client, signer, &cfg.Ethereum)
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
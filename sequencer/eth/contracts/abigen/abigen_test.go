@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"tokamak-sybil-resistance/eth/contracts/tokamak"
+	"tokamak-sybil-resistance/test/sim"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractsDir is the Foundry project of the contracts, relative to this
+// directory
+const contractsDir = "../../../../contracts"
+
+// TestABIMatchesArtifacts checks that the committed ABI of each binding is
+// the one of the contracts built by forge build.  It's skipped when the
+// contracts haven't been built, unless sim.RequireArtifactsEnv is set.
+func TestABIMatchesArtifacts(t *testing.T) {
+	outDir := filepath.Join(contractsDir, "out")
+	for i := range Targets {
+		target := &Targets[i]
+		t.Run(target.Contract, func(t *testing.T) {
+			artifact, err := os.ReadFile(filepath.Join(outDir, target.ArtifactPath()))
+			if os.IsNotExist(err) && os.Getenv(sim.RequireArtifactsEnv) == "" {
+				t.Skipf("contracts not built, run forge build in %v", contractsDir)
+			}
+			require.NoError(t, err)
+			artifactABI, err := ArtifactABI(artifact)
+			require.NoError(t, err)
+			abiJSON, err := os.ReadFile(filepath.Join("..", target.ABIPath()))
+			require.NoError(t, err)
+			assert.JSONEq(t, string(artifactABI), string(abiJSON),
+				"%v doesn't match the artifact, run go generate", target.ABIPath())
+		})
+	}
+}
+
+func TestBindingsUpToDate(t *testing.T) {
+	for i := range Targets {
+		target := &Targets[i]
+		abiJSON, err := os.ReadFile(filepath.Join("..", target.ABIPath()))
+		require.NoError(t, err)
+		code, err := Bind(target, abiJSON)
+		require.NoError(t, err)
+		committed, err := os.ReadFile(filepath.Join("..", target.Pkg, target.Pkg+".go"))
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(code, committed),
+			"the binding of %v is outdated, run go generate", target.Contract)
+	}
+}
+
+func TestUnpackError(t *testing.T) {
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err)
+	args, err := abi.Arguments{{Type: stringType}}.Pack("poseidon2Elements")
+	require.NoError(t, err)
+	data := append(crypto.Keccak256([]byte("InvalidPoseidonAddress(string)"))[:4], args...)
+	revertErr, err := tokamak.UnpackTokamakError(data)
+	require.NoError(t, err)
+	assert.Equal(t, &tokamak.TokamakError{Name: "InvalidPoseidonAddress",
+		Args: []interface{}{"poseidon2Elements"}}, revertErr)
+
+	revertErr, err = tokamak.UnpackTokamakError(crypto.Keccak256([]byte("InvalidProof()"))[:4])
+	require.NoError(t, err)
+	assert.Equal(t, "InvalidProof[]", revertErr.Error())
+
+	// Error(string) of require isn't a custom error
+	revertErr, err = tokamak.UnpackTokamakError(crypto.Keccak256([]byte("Error(string)"))[:4])
+	require.NoError(t, err)
+	assert.Nil(t, revertErr)
+}
@@ -0,0 +1,236 @@
+// Command abigen generates the Go bindings of the Smart Contracts in
+// eth/contracts.  It is run with go generate from eth/contracts:
+//
+//	go generate ./eth/contracts
+//
+// When the Foundry artifacts are present (forge build in the contracts
+// directory), the ABI of each contract is copied from them into the .abi file
+// committed next to its binding, so that the bindings can be regenerated
+// without compiling the contracts.  The custom errors are left out of the ABI
+// passed to go-ethereum, which doesn't parse them, and are generated apart.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Target is a contract for which a binding is generated
+type Target struct {
+	// Contract is the name of the Solidity contract
+	Contract string
+	// Source is the Solidity file that declares Contract, relative to the
+	// sources directory of the contracts
+	Source string
+	// Pkg is the Go package of the binding, which is also its directory
+	// and the name of the generated file
+	Pkg string
+	// Type is the Go type of the binding
+	Type string
+}
+
+// Targets are the contracts that have a binding
+var Targets = []Target{
+	{Contract: "Sybil", Source: "sybil.sol", Pkg: "tokamak", Type: "Tokamak"},
+	{Contract: "VerifierRollupInterface", Source: "interfaces/IVerifierRollup.sol",
+		Pkg: "verifier", Type: "VerifierRollup"},
+}
+
+// ABIPath is the path of the ABI file of the target, relative to eth/contracts
+func (t *Target) ABIPath() string {
+	return filepath.Join(t.Pkg, t.Contract+".abi")
+}
+
+// ArtifactPath is the path of the Foundry artifact of the target, relative to
+// the out directory of the contracts
+func (t *Target) ArtifactPath() string {
+	return filepath.Join(filepath.Base(t.Source), t.Contract+".json")
+}
+
+func main() {
+	outDir := flag.String("out", "../../../contracts/out",
+		"out directory of the Foundry project of the contracts")
+	flag.Parse()
+
+	for i := range Targets {
+		if err := generate(&Targets[i], *outDir); err != nil {
+			log.Fatalf("%v: %v", Targets[i].Contract, err)
+		}
+	}
+}
+
+func generate(t *Target, outDir string) error {
+	artifact, err := os.ReadFile(filepath.Join(outDir, t.ArtifactPath()))
+	if err == nil {
+		abiJSON, err := ArtifactABI(artifact)
+		if err != nil {
+			return fmt.Errorf("artifact: %w", err)
+		}
+		if err := os.WriteFile(t.ABIPath(), abiJSON, 0644); err != nil { //nolint:gosec
+			return err
+		}
+	} else if os.IsNotExist(err) {
+		log.Printf("%v: no artifact in %v, using %v", t.Contract, outDir, t.ABIPath())
+	} else {
+		return err
+	}
+
+	abiJSON, err := os.ReadFile(t.ABIPath())
+	if err != nil {
+		return err
+	}
+	code, err := Bind(t, abiJSON)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.Pkg, t.Pkg+".go"), code, 0644) //nolint:gosec
+}
+
+// ArtifactABI returns the ABI of a Foundry artifact formatted as the .abi files
+func ArtifactABI(artifact []byte) ([]byte, error) {
+	var a struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(artifact, &a); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, a.ABI, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Bind returns the source of the binding of the target with the given ABI
+func Bind(t *Target, abiJSON []byte) ([]byte, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(abiJSON, &entries); err != nil {
+		return nil, err
+	}
+	var noErrors, errs []json.RawMessage
+	for _, entry := range entries {
+		var e struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(entry, &e); err != nil {
+			return nil, err
+		}
+		if e.Type == "error" {
+			errs = append(errs, entry)
+		} else {
+			noErrors = append(noErrors, entry)
+		}
+	}
+	noErrorsJSON, err := json.Marshal(noErrors)
+	if err != nil {
+		return nil, err
+	}
+	code, err := bind.Bind([]string{t.Type}, []string{string(noErrorsJSON)}, []string{""},
+		nil, t.Pkg, bind.LangGo, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		errorsJSON, err := errorsAsFunctions(errs)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := errorsTmpl.Execute(&buf, map[string]string{
+			"Type": t.Type,
+			"ABI":  strings.ReplaceAll(string(errorsJSON), `"`, `\"`),
+		}); err != nil {
+			return nil, err
+		}
+		code = strings.Replace(code+buf.String(), "\t\"math/big\"\n",
+			"\t\"fmt\"\n\t\"math/big\"\n", 1)
+		code = strings.Replace(code, "\t\"github.com/ethereum/go-ethereum/common\"\n",
+			"\t\"github.com/ethereum/go-ethereum/common\"\n"+
+				"\t\"github.com/ethereum/go-ethereum/common/hexutil\"\n", 1)
+	}
+	return format.Source([]byte(code))
+}
+
+// errorsAsFunctions returns the ABI of the errors declared as functions, whose
+// IDs are the selectors of the errors
+func errorsAsFunctions(errs []json.RawMessage) ([]byte, error) {
+	funcs := make([]map[string]interface{}, len(errs))
+	for i, e := range errs {
+		if err := json.Unmarshal(e, &funcs[i]); err != nil {
+			return nil, err
+		}
+		funcs[i]["type"] = "function"
+	}
+	return json.Marshal(funcs)
+}
+
+var errorsTmpl = template.Must(template.New("errors").Parse(`
+// {{.Type}}ErrorsABI is the input ABI of the custom errors of the contract.
+// The errors are declared as functions, since go-ethereum doesn't parse them,
+// so the ID of each function is the selector of its error.
+const {{.Type}}ErrorsABI = "{{.ABI}}"
+
+// {{.Type}}Error is a custom error of the contract, decoded from the data of
+// a reverted call.
+type {{.Type}}Error struct {
+	Name string
+	Args []interface{}
+}
+
+// Error implements the error interface
+func (e *{{.Type}}Error) Error() string {
+	return fmt.Sprintf("%v%v", e.Name, e.Args)
+}
+
+// Unpack{{.Type}}Error decodes the data of a reverted call into the custom
+// error it encodes.  It returns nil if the data isn't a custom error of the
+// contract.
+func Unpack{{.Type}}Error(data []byte) (*{{.Type}}Error, error) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ErrorsABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil, nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}Error{Name: method.RawName, Args: args}, nil
+}
+
+// {{.Type}}RevertError returns the custom error of the contract in the revert
+// data of the error returned by an RPC call, or nil if there's none.
+func {{.Type}}RevertError(err error) *{{.Type}}Error {
+	dataErr, ok := err.(interface{ ErrorData() interface{} })
+	if !ok {
+		return nil
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil
+	}
+	data, decodeErr := hexutil.Decode(hexData)
+	if decodeErr != nil {
+		return nil
+	}
+	revertErr, _ := Unpack{{.Type}}Error(data)
+	return revertErr
+}
+`))
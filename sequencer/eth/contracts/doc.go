@@ -0,0 +1,6 @@
+// Package contracts contains in its subpackages the Go bindings of the Smart
+// Contracts in the contracts directory of the repository.  Run forge build
+// there before go generate to update the ABIs from the Solidity sources.
+package contracts
+
+//go:generate go run ./abigen
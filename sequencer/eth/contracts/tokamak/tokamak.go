@@ -1,17 +1,37 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
 package tokamak
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
 )
 
-// TODO: Update this
-const TokamakABI = ""
+// TokamakABI is the input ABI used to generate the binding from.
+const TokamakABI = "[{\"type\":\"constructor\",\"inputs\":[{\"name\":\"verifiers\",\"type\":\"address[]\",\"internalType\":\"address[]\"},{\"name\":\"maxTxs\",\"type\":\"uint256[]\",\"internalType\":\"uint256[]\"},{\"name\":\"nLevels\",\"type\":\"uint256[]\",\"internalType\":\"uint256[]\"},{\"name\":\"_forgeL1L2BatchTimeout\",\"type\":\"uint8\",\"internalType\":\"uint8\"},{\"name\":\"_poseidon2Elements\",\"type\":\"address\",\"internalType\":\"address\"},{\"name\":\"_poseidon3Elements\",\"type\":\"address\",\"internalType\":\"address\"},{\"name\":\"_poseidon4Elements\",\"type\":\"address\",\"internalType\":\"address\"}],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"ABSOLUTE_MAX_L1L2BATCHTIMEOUT\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint8\",\"internalType\":\"uint8\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"_hashFinalNode\",\"inputs\":[{\"name\":\"key\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"value\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"_hashNode\",\"inputs\":[{\"name\":\"left\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"right\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"addL1Transaction\",\"inputs\":[{\"name\":\"babyPubKey\",\"type\":\"string\",\"internalType\":\"string\"},{\"name\":\"fromIdx\",\"type\":\"uint48\",\"internalType\":\"uint48\"},{\"name\":\"loadAmountF\",\"type\":\"uint40\",\"internalType\":\"uint40\"},{\"name\":\"amountF\",\"type\":\"uint40\",\"internalType\":\"uint40\"},{\"name\":\"toIdx\",\"type\":\"uint48\",\"internalType\":\"uint48\"}],\"outputs\":[],\"stateMutability\":\"payable\"},{\"type\":\"function\",\"name\":\"exitNullifierMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"},{\"name\":\"\",\"type\":\"uint48\",\"internalType\":\"uint48\"}],\"outputs\":[{\"name\":\"\",\"type\":\"bool\",\"internalType\":\"bool\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"exitRootsMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"forgeBatch\",\"inputs\":[{\"name\":\"newLastIdx\",\"type\":\"uint48\",\"internalType\":\"uint48\"},{\"name\":\"newStRoot\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"newVouchRoot\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"newScoreRoot\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"newExitRoot\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"verifierIdx\",\"type\":\"uint8\",\"internalType\":\"uint8\"},{\"name\":\"l1Batch\",\"type\":\"bool\",\"internalType\":\"bool\"},{\"name\":\"proofA\",\"type\":\"uint256[2]\",\"internalType\":\"uint256[2]\"},{\"name\":\"proofB\",\"type\":\"uint256[2][2]\",\"internalType\":\"uint256[2][2]\"},{\"name\":\"proofC\",\"type\":\"uint256[2]\",\"internalType\":\"uint256[2]\"},{\"name\":\"input\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"forgeL1L2BatchTimeout\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint8\",\"internalType\":\"uint8\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"getL1TransactionQueue\",\"inputs\":[{\"name\":\"queueIndex\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"getLastForgedBatch\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"getQueueLength\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"getStateRoot\",\"inputs\":[{\"name\":\"batchNum\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"initialize\",\"inputs\":[{\"name\":\"verifiers\",\"type\":\"address[]\",\"internalType\":\"address[]\"},{\"name\":\"maxTxs\",\"type\":\"uint256[]\",\"internalType\":\"uint256[]\"},{\"name\":\"nLevels\",\"type\":\"uint256[]\",\"internalType\":\"uint256[]\"},{\"name\":\"_forgeL1L2BatchTimeout\",\"type\":\"uint8\",\"internalType\":\"uint8\"},{\"name\":\"_poseidon2Elements\",\"type\":\"address\",\"internalType\":\"address\"},{\"name\":\"_poseidon3Elements\",\"type\":\"address\",\"internalType\":\"address\"},{\"name\":\"_poseidon4Elements\",\"type\":\"address\",\"internalType\":\"address\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"l1L2TxsDataHashMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"bytes32\",\"internalType\":\"bytes32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"lastForgedBatch\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"lastIdx\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint48\",\"internalType\":\"uint48\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"lastL1L2Batch\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint64\",\"internalType\":\"uint64\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"mapL1TxQueue\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"bytes\",\"internalType\":\"bytes\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"nextL1FillingQueue\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"nextL1ToForgeQueue\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"owner\",\"inputs\":[],\"outputs\":[{\"name\":\"\",\"type\":\"address\",\"internalType\":\"address\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"renounceOwnership\",\"inputs\":[],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"rollupVerifiers\",\"inputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"outputs\":[{\"name\":\"verifierInterface\",\"type\":\"address\",\"internalType\":\"contractVerifierRollupInterface\"},{\"name\":\"maxTxs\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"nLevels\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"scoreRootMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"setForgeL1L2BatchTimeout\",\"inputs\":[{\"name\":\"newTimeout\",\"type\":\"uint8\",\"internalType\":\"uint8\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"stateRootMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"transferOwnership\",\"inputs\":[{\"name\":\"newOwner\",\"type\":\"address\",\"internalType\":\"address\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"function\",\"name\":\"vouchRootMap\",\"inputs\":[{\"name\":\"\",\"type\":\"uint32\",\"internalType\":\"uint32\"}],\"outputs\":[{\"name\":\"\",\"type\":\"uint256\",\"internalType\":\"uint256\"}],\"stateMutability\":\"view\"},{\"type\":\"function\",\"name\":\"withdrawMerkleProof\",\"inputs\":[{\"name\":\"amount\",\"type\":\"uint192\",\"internalType\":\"uint192\"},{\"name\":\"babyPubKey\",\"type\":\"uint256\",\"internalType\":\"uint256\"},{\"name\":\"numExitRoot\",\"type\":\"uint32\",\"internalType\":\"uint32\"},{\"name\":\"siblings\",\"type\":\"uint256[]\",\"internalType\":\"uint256[]\"},{\"name\":\"idx\",\"type\":\"uint48\",\"internalType\":\"uint48\"}],\"outputs\":[],\"stateMutability\":\"nonpayable\"},{\"type\":\"event\",\"name\":\"ForgeBatch\",\"inputs\":[{\"name\":\"batchNum\",\"type\":\"uint32\",\"internalType\":\"uint32\",\"indexed\":true},{\"name\":\"l1UserTxsLen\",\"type\":\"uint16\",\"internalType\":\"uint16\",\"indexed\":false}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"Initialize\",\"inputs\":[{\"name\":\"forgeL1L2BatchTimeout\",\"type\":\"uint8\",\"internalType\":\"uint8\",\"indexed\":false}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"Initialized\",\"inputs\":[{\"name\":\"version\",\"type\":\"uint64\",\"internalType\":\"uint64\",\"indexed\":false}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"L1UserTxEvent\",\"inputs\":[{\"name\":\"queueIndex\",\"type\":\"uint32\",\"internalType\":\"uint32\",\"indexed\":true},{\"name\":\"position\",\"type\":\"uint8\",\"internalType\":\"uint8\",\"indexed\":true},{\"name\":\"l1UserTx\",\"type\":\"bytes\",\"internalType\":\"bytes\",\"indexed\":false}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"OwnershipTransferred\",\"inputs\":[{\"name\":\"previousOwner\",\"type\":\"address\",\"internalType\":\"address\",\"indexed\":true},{\"name\":\"newOwner\",\"type\":\"address\",\"internalType\":\"address\",\"indexed\":true}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"UpdateForgeL1L2BatchTimeout\",\"inputs\":[{\"name\":\"newForgeL1L2BatchTimeout\",\"type\":\"uint8\",\"internalType\":\"uint8\",\"indexed\":false}],\"anonymous\":false},{\"type\":\"event\",\"name\":\"WithdrawEvent\",\"inputs\":[{\"name\":\"idx\",\"type\":\"uint48\",\"internalType\":\"uint48\",\"indexed\":true},{\"name\":\"numExitRoot\",\"type\":\"uint32\",\"internalType\":\"uint32\",\"indexed\":true}],\"anonymous\":false}]"
 
+// Tokamak is an auto generated Go binding around an Ethereum contract.
 type Tokamak struct {
 	TokamakCaller     // Read-only binding to the contract
 	TokamakTransactor // Write-only binding to the contract
@@ -33,6 +53,128 @@ type TokamakFilterer struct {
 	contract *bind.BoundContract // Generic contract wrapper for the low level calls
 }
 
+// TokamakSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type TokamakSession struct {
+	Contract     *Tokamak          // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// TokamakCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type TokamakCallerSession struct {
+	Contract *TokamakCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts  // Call options to use throughout this session
+}
+
+// TokamakTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type TokamakTransactorSession struct {
+	Contract     *TokamakTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts  // Transaction auth options to use throughout this session
+}
+
+// TokamakRaw is an auto generated low-level Go binding around an Ethereum contract.
+type TokamakRaw struct {
+	Contract *Tokamak // Generic contract binding to access the raw methods on
+}
+
+// TokamakCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type TokamakCallerRaw struct {
+	Contract *TokamakCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// TokamakTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type TokamakTransactorRaw struct {
+	Contract *TokamakTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewTokamak creates a new instance of Tokamak, bound to a specific deployed contract.
+func NewTokamak(address common.Address, backend bind.ContractBackend) (*Tokamak, error) {
+	contract, err := bindTokamak(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokamak{TokamakCaller: TokamakCaller{contract: contract}, TokamakTransactor: TokamakTransactor{contract: contract}, TokamakFilterer: TokamakFilterer{contract: contract}}, nil
+}
+
+// NewTokamakCaller creates a new read-only instance of Tokamak, bound to a specific deployed contract.
+func NewTokamakCaller(address common.Address, caller bind.ContractCaller) (*TokamakCaller, error) {
+	contract, err := bindTokamak(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakCaller{contract: contract}, nil
+}
+
+// NewTokamakTransactor creates a new write-only instance of Tokamak, bound to a specific deployed contract.
+func NewTokamakTransactor(address common.Address, transactor bind.ContractTransactor) (*TokamakTransactor, error) {
+	contract, err := bindTokamak(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakTransactor{contract: contract}, nil
+}
+
+// NewTokamakFilterer creates a new log filterer instance of Tokamak, bound to a specific deployed contract.
+func NewTokamakFilterer(address common.Address, filterer bind.ContractFilterer) (*TokamakFilterer, error) {
+	contract, err := bindTokamak(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakFilterer{contract: contract}, nil
+}
+
+// bindTokamak binds a generic wrapper to an already deployed contract.
+func bindTokamak(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(TokamakABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Tokamak *TokamakRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Tokamak.Contract.TokamakCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Tokamak *TokamakRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Tokamak.Contract.TokamakTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Tokamak *TokamakRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Tokamak.Contract.TokamakTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_Tokamak *TokamakCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _Tokamak.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_Tokamak *TokamakTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Tokamak.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_Tokamak *TokamakTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _Tokamak.Contract.contract.Transact(opts, method, params...)
+}
+
+// ABSOLUTEMAXL1L2BATCHTIMEOUT is a free data retrieval call binding the contract method 0x95a09f2a.
+//
 // Solidity: function ABSOLUTE_MAX_L1L2BATCHTIMEOUT() view returns(uint8)
 func (_Tokamak *TokamakCaller) ABSOLUTEMAXL1L2BATCHTIMEOUT(opts *bind.CallOpts) (uint8, error) {
 	var out []interface{}
@@ -48,10 +190,57 @@ func (_Tokamak *TokamakCaller) ABSOLUTEMAXL1L2BATCHTIMEOUT(opts *bind.CallOpts)
 
 }
 
-// Solidity: function rollupVerifiersLength() view returns(uint256)
-func (_Tokamak *TokamakCaller) RollupVerifiersLength(opts *bind.CallOpts) (*big.Int, error) {
+// ABSOLUTEMAXL1L2BATCHTIMEOUT is a free data retrieval call binding the contract method 0x95a09f2a.
+//
+// Solidity: function ABSOLUTE_MAX_L1L2BATCHTIMEOUT() view returns(uint8)
+func (_Tokamak *TokamakSession) ABSOLUTEMAXL1L2BATCHTIMEOUT() (uint8, error) {
+	return _Tokamak.Contract.ABSOLUTEMAXL1L2BATCHTIMEOUT(&_Tokamak.CallOpts)
+}
+
+// ABSOLUTEMAXL1L2BATCHTIMEOUT is a free data retrieval call binding the contract method 0x95a09f2a.
+//
+// Solidity: function ABSOLUTE_MAX_L1L2BATCHTIMEOUT() view returns(uint8)
+func (_Tokamak *TokamakCallerSession) ABSOLUTEMAXL1L2BATCHTIMEOUT() (uint8, error) {
+	return _Tokamak.Contract.ABSOLUTEMAXL1L2BATCHTIMEOUT(&_Tokamak.CallOpts)
+}
+
+// HashFinalNode is a free data retrieval call binding the contract method 0xbbe5a375.
+//
+// Solidity: function _hashFinalNode(uint256 key, uint256 value) view returns(uint256)
+func (_Tokamak *TokamakCaller) HashFinalNode(opts *bind.CallOpts, key *big.Int, value *big.Int) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "_hashFinalNode", key, value)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// HashFinalNode is a free data retrieval call binding the contract method 0xbbe5a375.
+//
+// Solidity: function _hashFinalNode(uint256 key, uint256 value) view returns(uint256)
+func (_Tokamak *TokamakSession) HashFinalNode(key *big.Int, value *big.Int) (*big.Int, error) {
+	return _Tokamak.Contract.HashFinalNode(&_Tokamak.CallOpts, key, value)
+}
+
+// HashFinalNode is a free data retrieval call binding the contract method 0xbbe5a375.
+//
+// Solidity: function _hashFinalNode(uint256 key, uint256 value) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) HashFinalNode(key *big.Int, value *big.Int) (*big.Int, error) {
+	return _Tokamak.Contract.HashFinalNode(&_Tokamak.CallOpts, key, value)
+}
+
+// HashNode is a free data retrieval call binding the contract method 0xc0b55ae4.
+//
+// Solidity: function _hashNode(uint256 left, uint256 right) view returns(uint256)
+func (_Tokamak *TokamakCaller) HashNode(opts *bind.CallOpts, left *big.Int, right *big.Int) (*big.Int, error) {
 	var out []interface{}
-	err := _Tokamak.contract.Call(opts, &out, "rollupVerifiersLength")
+	err := _Tokamak.contract.Call(opts, &out, "_hashNode", left, right)
 
 	if err != nil {
 		return *new(*big.Int), err
@@ -60,40 +249,153 @@ func (_Tokamak *TokamakCaller) RollupVerifiersLength(opts *bind.CallOpts) (*big.
 	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
 
 	return out0, err
+
 }
 
-// Solidity: function rollupVerifiers(uint256 ) view returns(address verifierInterface, uint256 maxTx, uint256 nLevels)
-func (_Tokamak *TokamakCaller) RollupVerifiers(opts *bind.CallOpts, arg0 *big.Int) (struct {
-	VerifierInterface common.Address
-	MaxTx             *big.Int
-	NLevels           *big.Int
-}, error) {
+// HashNode is a free data retrieval call binding the contract method 0xc0b55ae4.
+//
+// Solidity: function _hashNode(uint256 left, uint256 right) view returns(uint256)
+func (_Tokamak *TokamakSession) HashNode(left *big.Int, right *big.Int) (*big.Int, error) {
+	return _Tokamak.Contract.HashNode(&_Tokamak.CallOpts, left, right)
+}
+
+// HashNode is a free data retrieval call binding the contract method 0xc0b55ae4.
+//
+// Solidity: function _hashNode(uint256 left, uint256 right) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) HashNode(left *big.Int, right *big.Int) (*big.Int, error) {
+	return _Tokamak.Contract.HashNode(&_Tokamak.CallOpts, left, right)
+}
+
+// ExitNullifierMap is a free data retrieval call binding the contract method 0xf84f92ee.
+//
+// Solidity: function exitNullifierMap(uint32 , uint48 ) view returns(bool)
+func (_Tokamak *TokamakCaller) ExitNullifierMap(opts *bind.CallOpts, arg0 uint32, arg1 *big.Int) (bool, error) {
 	var out []interface{}
-	err := _Tokamak.contract.Call(opts, &out, "rollupVerifiers", arg0)
+	err := _Tokamak.contract.Call(opts, &out, "exitNullifierMap", arg0, arg1)
 
-	outstruct := new(struct {
-		VerifierInterface common.Address
-		MaxTx             *big.Int
-		NLevels           *big.Int
-	})
 	if err != nil {
-		return *outstruct, err
+		return *new(bool), err
 	}
 
-	outstruct.VerifierInterface = *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
-	outstruct.MaxTx = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
-	outstruct.NLevels = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
 
-	return *outstruct, err
+	return out0, err
 
 }
 
-// LastForgedBatch is a free data retrieval call binding the contract method 0x44e0b2ce.
+// ExitNullifierMap is a free data retrieval call binding the contract method 0xf84f92ee.
 //
-// Solidity: function lastForgedBatch() view returns(uint32)
-func (_Tokamak *TokamakCaller) LastForgedBatch(opts *bind.CallOpts) (uint32, error) {
+// Solidity: function exitNullifierMap(uint32 , uint48 ) view returns(bool)
+func (_Tokamak *TokamakSession) ExitNullifierMap(arg0 uint32, arg1 *big.Int) (bool, error) {
+	return _Tokamak.Contract.ExitNullifierMap(&_Tokamak.CallOpts, arg0, arg1)
+}
+
+// ExitNullifierMap is a free data retrieval call binding the contract method 0xf84f92ee.
+//
+// Solidity: function exitNullifierMap(uint32 , uint48 ) view returns(bool)
+func (_Tokamak *TokamakCallerSession) ExitNullifierMap(arg0 uint32, arg1 *big.Int) (bool, error) {
+	return _Tokamak.Contract.ExitNullifierMap(&_Tokamak.CallOpts, arg0, arg1)
+}
+
+// ExitRootsMap is a free data retrieval call binding the contract method 0x3ee641ea.
+//
+// Solidity: function exitRootsMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCaller) ExitRootsMap(opts *bind.CallOpts, arg0 uint32) (*big.Int, error) {
 	var out []interface{}
-	err := _Tokamak.contract.Call(opts, &out, "lastForgedBatch")
+	err := _Tokamak.contract.Call(opts, &out, "exitRootsMap", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ExitRootsMap is a free data retrieval call binding the contract method 0x3ee641ea.
+//
+// Solidity: function exitRootsMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakSession) ExitRootsMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.ExitRootsMap(&_Tokamak.CallOpts, arg0)
+}
+
+// ExitRootsMap is a free data retrieval call binding the contract method 0x3ee641ea.
+//
+// Solidity: function exitRootsMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) ExitRootsMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.ExitRootsMap(&_Tokamak.CallOpts, arg0)
+}
+
+// ForgeL1L2BatchTimeout is a free data retrieval call binding the contract method 0xa3275838.
+//
+// Solidity: function forgeL1L2BatchTimeout() view returns(uint8)
+func (_Tokamak *TokamakCaller) ForgeL1L2BatchTimeout(opts *bind.CallOpts) (uint8, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "forgeL1L2BatchTimeout")
+
+	if err != nil {
+		return *new(uint8), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint8)).(*uint8)
+
+	return out0, err
+
+}
+
+// ForgeL1L2BatchTimeout is a free data retrieval call binding the contract method 0xa3275838.
+//
+// Solidity: function forgeL1L2BatchTimeout() view returns(uint8)
+func (_Tokamak *TokamakSession) ForgeL1L2BatchTimeout() (uint8, error) {
+	return _Tokamak.Contract.ForgeL1L2BatchTimeout(&_Tokamak.CallOpts)
+}
+
+// ForgeL1L2BatchTimeout is a free data retrieval call binding the contract method 0xa3275838.
+//
+// Solidity: function forgeL1L2BatchTimeout() view returns(uint8)
+func (_Tokamak *TokamakCallerSession) ForgeL1L2BatchTimeout() (uint8, error) {
+	return _Tokamak.Contract.ForgeL1L2BatchTimeout(&_Tokamak.CallOpts)
+}
+
+// GetL1TransactionQueue is a free data retrieval call binding the contract method 0xba2506df.
+//
+// Solidity: function getL1TransactionQueue(uint32 queueIndex) view returns(bytes)
+func (_Tokamak *TokamakCaller) GetL1TransactionQueue(opts *bind.CallOpts, queueIndex uint32) ([]byte, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "getL1TransactionQueue", queueIndex)
+
+	if err != nil {
+		return *new([]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+
+	return out0, err
+
+}
+
+// GetL1TransactionQueue is a free data retrieval call binding the contract method 0xba2506df.
+//
+// Solidity: function getL1TransactionQueue(uint32 queueIndex) view returns(bytes)
+func (_Tokamak *TokamakSession) GetL1TransactionQueue(queueIndex uint32) ([]byte, error) {
+	return _Tokamak.Contract.GetL1TransactionQueue(&_Tokamak.CallOpts, queueIndex)
+}
+
+// GetL1TransactionQueue is a free data retrieval call binding the contract method 0xba2506df.
+//
+// Solidity: function getL1TransactionQueue(uint32 queueIndex) view returns(bytes)
+func (_Tokamak *TokamakCallerSession) GetL1TransactionQueue(queueIndex uint32) ([]byte, error) {
+	return _Tokamak.Contract.GetL1TransactionQueue(&_Tokamak.CallOpts, queueIndex)
+}
+
+// GetLastForgedBatch is a free data retrieval call binding the contract method 0x1b78164b.
+//
+// Solidity: function getLastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakCaller) GetLastForgedBatch(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "getLastForgedBatch")
 
 	if err != nil {
 		return *new(uint32), err
@@ -105,20 +407,1681 @@ func (_Tokamak *TokamakCaller) LastForgedBatch(opts *bind.CallOpts) (uint32, err
 
 }
 
-// NewTokamak creates a new instance of Tokamak, bound to a specific deployed contract.
-func NewTokamak(address common.Address, backend bind.ContractBackend) (*Tokamak, error) {
-	contract, err := bindTokamak(address, backend, backend, backend)
+// GetLastForgedBatch is a free data retrieval call binding the contract method 0x1b78164b.
+//
+// Solidity: function getLastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakSession) GetLastForgedBatch() (uint32, error) {
+	return _Tokamak.Contract.GetLastForgedBatch(&_Tokamak.CallOpts)
+}
+
+// GetLastForgedBatch is a free data retrieval call binding the contract method 0x1b78164b.
+//
+// Solidity: function getLastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakCallerSession) GetLastForgedBatch() (uint32, error) {
+	return _Tokamak.Contract.GetLastForgedBatch(&_Tokamak.CallOpts)
+}
+
+// GetQueueLength is a free data retrieval call binding the contract method 0xb8f77005.
+//
+// Solidity: function getQueueLength() view returns(uint32)
+func (_Tokamak *TokamakCaller) GetQueueLength(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "getQueueLength")
+
 	if err != nil {
-		return nil, err
+		return *new(uint32), err
 	}
-	return &Tokamak{TokamakCaller: TokamakCaller{contract: contract}, TokamakTransactor: TokamakTransactor{contract: contract}, TokamakFilterer: TokamakFilterer{contract: contract}}, nil
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
 }
 
-// bindTokamak binds a generic wrapper to an already deployed contract.
-func bindTokamak(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
-	parsed, err := abi.JSON(strings.NewReader(TokamakABI))
+// GetQueueLength is a free data retrieval call binding the contract method 0xb8f77005.
+//
+// Solidity: function getQueueLength() view returns(uint32)
+func (_Tokamak *TokamakSession) GetQueueLength() (uint32, error) {
+	return _Tokamak.Contract.GetQueueLength(&_Tokamak.CallOpts)
+}
+
+// GetQueueLength is a free data retrieval call binding the contract method 0xb8f77005.
+//
+// Solidity: function getQueueLength() view returns(uint32)
+func (_Tokamak *TokamakCallerSession) GetQueueLength() (uint32, error) {
+	return _Tokamak.Contract.GetQueueLength(&_Tokamak.CallOpts)
+}
+
+// GetStateRoot is a free data retrieval call binding the contract method 0x3009c59f.
+//
+// Solidity: function getStateRoot(uint32 batchNum) view returns(uint256)
+func (_Tokamak *TokamakCaller) GetStateRoot(opts *bind.CallOpts, batchNum uint32) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "getStateRoot", batchNum)
+
 	if err != nil {
-		return nil, err
+		return *new(*big.Int), err
 	}
-	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetStateRoot is a free data retrieval call binding the contract method 0x3009c59f.
+//
+// Solidity: function getStateRoot(uint32 batchNum) view returns(uint256)
+func (_Tokamak *TokamakSession) GetStateRoot(batchNum uint32) (*big.Int, error) {
+	return _Tokamak.Contract.GetStateRoot(&_Tokamak.CallOpts, batchNum)
+}
+
+// GetStateRoot is a free data retrieval call binding the contract method 0x3009c59f.
+//
+// Solidity: function getStateRoot(uint32 batchNum) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) GetStateRoot(batchNum uint32) (*big.Int, error) {
+	return _Tokamak.Contract.GetStateRoot(&_Tokamak.CallOpts, batchNum)
+}
+
+// L1L2TxsDataHashMap is a free data retrieval call binding the contract method 0xce5ec65a.
+//
+// Solidity: function l1L2TxsDataHashMap(uint32 ) view returns(bytes32)
+func (_Tokamak *TokamakCaller) L1L2TxsDataHashMap(opts *bind.CallOpts, arg0 uint32) ([32]byte, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "l1L2TxsDataHashMap", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// L1L2TxsDataHashMap is a free data retrieval call binding the contract method 0xce5ec65a.
+//
+// Solidity: function l1L2TxsDataHashMap(uint32 ) view returns(bytes32)
+func (_Tokamak *TokamakSession) L1L2TxsDataHashMap(arg0 uint32) ([32]byte, error) {
+	return _Tokamak.Contract.L1L2TxsDataHashMap(&_Tokamak.CallOpts, arg0)
+}
+
+// L1L2TxsDataHashMap is a free data retrieval call binding the contract method 0xce5ec65a.
+//
+// Solidity: function l1L2TxsDataHashMap(uint32 ) view returns(bytes32)
+func (_Tokamak *TokamakCallerSession) L1L2TxsDataHashMap(arg0 uint32) ([32]byte, error) {
+	return _Tokamak.Contract.L1L2TxsDataHashMap(&_Tokamak.CallOpts, arg0)
+}
+
+// LastForgedBatch is a free data retrieval call binding the contract method 0x44e0b2ce.
+//
+// Solidity: function lastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakCaller) LastForgedBatch(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "lastForgedBatch")
+
+	if err != nil {
+		return *new(uint32), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
+}
+
+// LastForgedBatch is a free data retrieval call binding the contract method 0x44e0b2ce.
+//
+// Solidity: function lastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakSession) LastForgedBatch() (uint32, error) {
+	return _Tokamak.Contract.LastForgedBatch(&_Tokamak.CallOpts)
+}
+
+// LastForgedBatch is a free data retrieval call binding the contract method 0x44e0b2ce.
+//
+// Solidity: function lastForgedBatch() view returns(uint32)
+func (_Tokamak *TokamakCallerSession) LastForgedBatch() (uint32, error) {
+	return _Tokamak.Contract.LastForgedBatch(&_Tokamak.CallOpts)
+}
+
+// LastIdx is a free data retrieval call binding the contract method 0xd486645c.
+//
+// Solidity: function lastIdx() view returns(uint48)
+func (_Tokamak *TokamakCaller) LastIdx(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "lastIdx")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// LastIdx is a free data retrieval call binding the contract method 0xd486645c.
+//
+// Solidity: function lastIdx() view returns(uint48)
+func (_Tokamak *TokamakSession) LastIdx() (*big.Int, error) {
+	return _Tokamak.Contract.LastIdx(&_Tokamak.CallOpts)
+}
+
+// LastIdx is a free data retrieval call binding the contract method 0xd486645c.
+//
+// Solidity: function lastIdx() view returns(uint48)
+func (_Tokamak *TokamakCallerSession) LastIdx() (*big.Int, error) {
+	return _Tokamak.Contract.LastIdx(&_Tokamak.CallOpts)
+}
+
+// LastL1L2Batch is a free data retrieval call binding the contract method 0x84ef9ed4.
+//
+// Solidity: function lastL1L2Batch() view returns(uint64)
+func (_Tokamak *TokamakCaller) LastL1L2Batch(opts *bind.CallOpts) (uint64, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "lastL1L2Batch")
+
+	if err != nil {
+		return *new(uint64), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint64)).(*uint64)
+
+	return out0, err
+
+}
+
+// LastL1L2Batch is a free data retrieval call binding the contract method 0x84ef9ed4.
+//
+// Solidity: function lastL1L2Batch() view returns(uint64)
+func (_Tokamak *TokamakSession) LastL1L2Batch() (uint64, error) {
+	return _Tokamak.Contract.LastL1L2Batch(&_Tokamak.CallOpts)
+}
+
+// LastL1L2Batch is a free data retrieval call binding the contract method 0x84ef9ed4.
+//
+// Solidity: function lastL1L2Batch() view returns(uint64)
+func (_Tokamak *TokamakCallerSession) LastL1L2Batch() (uint64, error) {
+	return _Tokamak.Contract.LastL1L2Batch(&_Tokamak.CallOpts)
+}
+
+// MapL1TxQueue is a free data retrieval call binding the contract method 0xdc3e718e.
+//
+// Solidity: function mapL1TxQueue(uint32 ) view returns(bytes)
+func (_Tokamak *TokamakCaller) MapL1TxQueue(opts *bind.CallOpts, arg0 uint32) ([]byte, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "mapL1TxQueue", arg0)
+
+	if err != nil {
+		return *new([]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([]byte)).(*[]byte)
+
+	return out0, err
+
+}
+
+// MapL1TxQueue is a free data retrieval call binding the contract method 0xdc3e718e.
+//
+// Solidity: function mapL1TxQueue(uint32 ) view returns(bytes)
+func (_Tokamak *TokamakSession) MapL1TxQueue(arg0 uint32) ([]byte, error) {
+	return _Tokamak.Contract.MapL1TxQueue(&_Tokamak.CallOpts, arg0)
+}
+
+// MapL1TxQueue is a free data retrieval call binding the contract method 0xdc3e718e.
+//
+// Solidity: function mapL1TxQueue(uint32 ) view returns(bytes)
+func (_Tokamak *TokamakCallerSession) MapL1TxQueue(arg0 uint32) ([]byte, error) {
+	return _Tokamak.Contract.MapL1TxQueue(&_Tokamak.CallOpts, arg0)
+}
+
+// NextL1FillingQueue is a free data retrieval call binding the contract method 0x0ee8e52b.
+//
+// Solidity: function nextL1FillingQueue() view returns(uint32)
+func (_Tokamak *TokamakCaller) NextL1FillingQueue(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "nextL1FillingQueue")
+
+	if err != nil {
+		return *new(uint32), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
+}
+
+// NextL1FillingQueue is a free data retrieval call binding the contract method 0x0ee8e52b.
+//
+// Solidity: function nextL1FillingQueue() view returns(uint32)
+func (_Tokamak *TokamakSession) NextL1FillingQueue() (uint32, error) {
+	return _Tokamak.Contract.NextL1FillingQueue(&_Tokamak.CallOpts)
+}
+
+// NextL1FillingQueue is a free data retrieval call binding the contract method 0x0ee8e52b.
+//
+// Solidity: function nextL1FillingQueue() view returns(uint32)
+func (_Tokamak *TokamakCallerSession) NextL1FillingQueue() (uint32, error) {
+	return _Tokamak.Contract.NextL1FillingQueue(&_Tokamak.CallOpts)
+}
+
+// NextL1ToForgeQueue is a free data retrieval call binding the contract method 0xd0f32e67.
+//
+// Solidity: function nextL1ToForgeQueue() view returns(uint32)
+func (_Tokamak *TokamakCaller) NextL1ToForgeQueue(opts *bind.CallOpts) (uint32, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "nextL1ToForgeQueue")
+
+	if err != nil {
+		return *new(uint32), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(uint32)).(*uint32)
+
+	return out0, err
+
+}
+
+// NextL1ToForgeQueue is a free data retrieval call binding the contract method 0xd0f32e67.
+//
+// Solidity: function nextL1ToForgeQueue() view returns(uint32)
+func (_Tokamak *TokamakSession) NextL1ToForgeQueue() (uint32, error) {
+	return _Tokamak.Contract.NextL1ToForgeQueue(&_Tokamak.CallOpts)
+}
+
+// NextL1ToForgeQueue is a free data retrieval call binding the contract method 0xd0f32e67.
+//
+// Solidity: function nextL1ToForgeQueue() view returns(uint32)
+func (_Tokamak *TokamakCallerSession) NextL1ToForgeQueue() (uint32, error) {
+	return _Tokamak.Contract.NextL1ToForgeQueue(&_Tokamak.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Tokamak *TokamakCaller) Owner(opts *bind.CallOpts) (common.Address, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "owner")
+
+	if err != nil {
+		return *new(common.Address), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+
+	return out0, err
+
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Tokamak *TokamakSession) Owner() (common.Address, error) {
+	return _Tokamak.Contract.Owner(&_Tokamak.CallOpts)
+}
+
+// Owner is a free data retrieval call binding the contract method 0x8da5cb5b.
+//
+// Solidity: function owner() view returns(address)
+func (_Tokamak *TokamakCallerSession) Owner() (common.Address, error) {
+	return _Tokamak.Contract.Owner(&_Tokamak.CallOpts)
+}
+
+// RollupVerifiers is a free data retrieval call binding the contract method 0x38330200.
+//
+// Solidity: function rollupVerifiers(uint256 ) view returns(address verifierInterface, uint256 maxTxs, uint256 nLevels)
+func (_Tokamak *TokamakCaller) RollupVerifiers(opts *bind.CallOpts, arg0 *big.Int) (struct {
+	VerifierInterface common.Address
+	MaxTxs            *big.Int
+	NLevels           *big.Int
+}, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "rollupVerifiers", arg0)
+
+	outstruct := new(struct {
+		VerifierInterface common.Address
+		MaxTxs            *big.Int
+		NLevels           *big.Int
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.VerifierInterface = *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	outstruct.MaxTxs = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+	outstruct.NLevels = *abi.ConvertType(out[2], new(*big.Int)).(**big.Int)
+
+	return *outstruct, err
+
+}
+
+// RollupVerifiers is a free data retrieval call binding the contract method 0x38330200.
+//
+// Solidity: function rollupVerifiers(uint256 ) view returns(address verifierInterface, uint256 maxTxs, uint256 nLevels)
+func (_Tokamak *TokamakSession) RollupVerifiers(arg0 *big.Int) (struct {
+	VerifierInterface common.Address
+	MaxTxs            *big.Int
+	NLevels           *big.Int
+}, error) {
+	return _Tokamak.Contract.RollupVerifiers(&_Tokamak.CallOpts, arg0)
+}
+
+// RollupVerifiers is a free data retrieval call binding the contract method 0x38330200.
+//
+// Solidity: function rollupVerifiers(uint256 ) view returns(address verifierInterface, uint256 maxTxs, uint256 nLevels)
+func (_Tokamak *TokamakCallerSession) RollupVerifiers(arg0 *big.Int) (struct {
+	VerifierInterface common.Address
+	MaxTxs            *big.Int
+	NLevels           *big.Int
+}, error) {
+	return _Tokamak.Contract.RollupVerifiers(&_Tokamak.CallOpts, arg0)
+}
+
+// ScoreRootMap is a free data retrieval call binding the contract method 0xbd8a4a61.
+//
+// Solidity: function scoreRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCaller) ScoreRootMap(opts *bind.CallOpts, arg0 uint32) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "scoreRootMap", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// ScoreRootMap is a free data retrieval call binding the contract method 0xbd8a4a61.
+//
+// Solidity: function scoreRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakSession) ScoreRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.ScoreRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// ScoreRootMap is a free data retrieval call binding the contract method 0xbd8a4a61.
+//
+// Solidity: function scoreRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) ScoreRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.ScoreRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// StateRootMap is a free data retrieval call binding the contract method 0x9e00d7ea.
+//
+// Solidity: function stateRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCaller) StateRootMap(opts *bind.CallOpts, arg0 uint32) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "stateRootMap", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// StateRootMap is a free data retrieval call binding the contract method 0x9e00d7ea.
+//
+// Solidity: function stateRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakSession) StateRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.StateRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// StateRootMap is a free data retrieval call binding the contract method 0x9e00d7ea.
+//
+// Solidity: function stateRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) StateRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.StateRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// VouchRootMap is a free data retrieval call binding the contract method 0xadacd33b.
+//
+// Solidity: function vouchRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCaller) VouchRootMap(opts *bind.CallOpts, arg0 uint32) (*big.Int, error) {
+	var out []interface{}
+	err := _Tokamak.contract.Call(opts, &out, "vouchRootMap", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// VouchRootMap is a free data retrieval call binding the contract method 0xadacd33b.
+//
+// Solidity: function vouchRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakSession) VouchRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.VouchRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// VouchRootMap is a free data retrieval call binding the contract method 0xadacd33b.
+//
+// Solidity: function vouchRootMap(uint32 ) view returns(uint256)
+func (_Tokamak *TokamakCallerSession) VouchRootMap(arg0 uint32) (*big.Int, error) {
+	return _Tokamak.Contract.VouchRootMap(&_Tokamak.CallOpts, arg0)
+}
+
+// AddL1Transaction is a paid mutator transaction binding the contract method 0x29b1ac6b.
+//
+// Solidity: function addL1Transaction(string babyPubKey, uint48 fromIdx, uint40 loadAmountF, uint40 amountF, uint48 toIdx) payable returns()
+func (_Tokamak *TokamakTransactor) AddL1Transaction(opts *bind.TransactOpts, babyPubKey string, fromIdx *big.Int, loadAmountF *big.Int, amountF *big.Int, toIdx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "addL1Transaction", babyPubKey, fromIdx, loadAmountF, amountF, toIdx)
+}
+
+// AddL1Transaction is a paid mutator transaction binding the contract method 0x29b1ac6b.
+//
+// Solidity: function addL1Transaction(string babyPubKey, uint48 fromIdx, uint40 loadAmountF, uint40 amountF, uint48 toIdx) payable returns()
+func (_Tokamak *TokamakSession) AddL1Transaction(babyPubKey string, fromIdx *big.Int, loadAmountF *big.Int, amountF *big.Int, toIdx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.AddL1Transaction(&_Tokamak.TransactOpts, babyPubKey, fromIdx, loadAmountF, amountF, toIdx)
+}
+
+// AddL1Transaction is a paid mutator transaction binding the contract method 0x29b1ac6b.
+//
+// Solidity: function addL1Transaction(string babyPubKey, uint48 fromIdx, uint40 loadAmountF, uint40 amountF, uint48 toIdx) payable returns()
+func (_Tokamak *TokamakTransactorSession) AddL1Transaction(babyPubKey string, fromIdx *big.Int, loadAmountF *big.Int, amountF *big.Int, toIdx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.AddL1Transaction(&_Tokamak.TransactOpts, babyPubKey, fromIdx, loadAmountF, amountF, toIdx)
+}
+
+// ForgeBatch is a paid mutator transaction binding the contract method 0x8112639b.
+//
+// Solidity: function forgeBatch(uint48 newLastIdx, uint256 newStRoot, uint256 newVouchRoot, uint256 newScoreRoot, uint256 newExitRoot, uint8 verifierIdx, bool l1Batch, uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256 input) returns()
+func (_Tokamak *TokamakTransactor) ForgeBatch(opts *bind.TransactOpts, newLastIdx *big.Int, newStRoot *big.Int, newVouchRoot *big.Int, newScoreRoot *big.Int, newExitRoot *big.Int, verifierIdx uint8, l1Batch bool, proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input *big.Int) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "forgeBatch", newLastIdx, newStRoot, newVouchRoot, newScoreRoot, newExitRoot, verifierIdx, l1Batch, proofA, proofB, proofC, input)
+}
+
+// ForgeBatch is a paid mutator transaction binding the contract method 0x8112639b.
+//
+// Solidity: function forgeBatch(uint48 newLastIdx, uint256 newStRoot, uint256 newVouchRoot, uint256 newScoreRoot, uint256 newExitRoot, uint8 verifierIdx, bool l1Batch, uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256 input) returns()
+func (_Tokamak *TokamakSession) ForgeBatch(newLastIdx *big.Int, newStRoot *big.Int, newVouchRoot *big.Int, newScoreRoot *big.Int, newExitRoot *big.Int, verifierIdx uint8, l1Batch bool, proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.ForgeBatch(&_Tokamak.TransactOpts, newLastIdx, newStRoot, newVouchRoot, newScoreRoot, newExitRoot, verifierIdx, l1Batch, proofA, proofB, proofC, input)
+}
+
+// ForgeBatch is a paid mutator transaction binding the contract method 0x8112639b.
+//
+// Solidity: function forgeBatch(uint48 newLastIdx, uint256 newStRoot, uint256 newVouchRoot, uint256 newScoreRoot, uint256 newExitRoot, uint8 verifierIdx, bool l1Batch, uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256 input) returns()
+func (_Tokamak *TokamakTransactorSession) ForgeBatch(newLastIdx *big.Int, newStRoot *big.Int, newVouchRoot *big.Int, newScoreRoot *big.Int, newExitRoot *big.Int, verifierIdx uint8, l1Batch bool, proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.ForgeBatch(&_Tokamak.TransactOpts, newLastIdx, newStRoot, newVouchRoot, newScoreRoot, newExitRoot, verifierIdx, l1Batch, proofA, proofB, proofC, input)
+}
+
+// Initialize is a paid mutator transaction binding the contract method 0x0f1c7003.
+//
+// Solidity: function initialize(address[] verifiers, uint256[] maxTxs, uint256[] nLevels, uint8 _forgeL1L2BatchTimeout, address _poseidon2Elements, address _poseidon3Elements, address _poseidon4Elements) returns()
+func (_Tokamak *TokamakTransactor) Initialize(opts *bind.TransactOpts, verifiers []common.Address, maxTxs []*big.Int, nLevels []*big.Int, _forgeL1L2BatchTimeout uint8, _poseidon2Elements common.Address, _poseidon3Elements common.Address, _poseidon4Elements common.Address) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "initialize", verifiers, maxTxs, nLevels, _forgeL1L2BatchTimeout, _poseidon2Elements, _poseidon3Elements, _poseidon4Elements)
+}
+
+// Initialize is a paid mutator transaction binding the contract method 0x0f1c7003.
+//
+// Solidity: function initialize(address[] verifiers, uint256[] maxTxs, uint256[] nLevels, uint8 _forgeL1L2BatchTimeout, address _poseidon2Elements, address _poseidon3Elements, address _poseidon4Elements) returns()
+func (_Tokamak *TokamakSession) Initialize(verifiers []common.Address, maxTxs []*big.Int, nLevels []*big.Int, _forgeL1L2BatchTimeout uint8, _poseidon2Elements common.Address, _poseidon3Elements common.Address, _poseidon4Elements common.Address) (*types.Transaction, error) {
+	return _Tokamak.Contract.Initialize(&_Tokamak.TransactOpts, verifiers, maxTxs, nLevels, _forgeL1L2BatchTimeout, _poseidon2Elements, _poseidon3Elements, _poseidon4Elements)
+}
+
+// Initialize is a paid mutator transaction binding the contract method 0x0f1c7003.
+//
+// Solidity: function initialize(address[] verifiers, uint256[] maxTxs, uint256[] nLevels, uint8 _forgeL1L2BatchTimeout, address _poseidon2Elements, address _poseidon3Elements, address _poseidon4Elements) returns()
+func (_Tokamak *TokamakTransactorSession) Initialize(verifiers []common.Address, maxTxs []*big.Int, nLevels []*big.Int, _forgeL1L2BatchTimeout uint8, _poseidon2Elements common.Address, _poseidon3Elements common.Address, _poseidon4Elements common.Address) (*types.Transaction, error) {
+	return _Tokamak.Contract.Initialize(&_Tokamak.TransactOpts, verifiers, maxTxs, nLevels, _forgeL1L2BatchTimeout, _poseidon2Elements, _poseidon3Elements, _poseidon4Elements)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Tokamak *TokamakTransactor) RenounceOwnership(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "renounceOwnership")
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Tokamak *TokamakSession) RenounceOwnership() (*types.Transaction, error) {
+	return _Tokamak.Contract.RenounceOwnership(&_Tokamak.TransactOpts)
+}
+
+// RenounceOwnership is a paid mutator transaction binding the contract method 0x715018a6.
+//
+// Solidity: function renounceOwnership() returns()
+func (_Tokamak *TokamakTransactorSession) RenounceOwnership() (*types.Transaction, error) {
+	return _Tokamak.Contract.RenounceOwnership(&_Tokamak.TransactOpts)
+}
+
+// SetForgeL1L2BatchTimeout is a paid mutator transaction binding the contract method 0x40105466.
+//
+// Solidity: function setForgeL1L2BatchTimeout(uint8 newTimeout) returns()
+func (_Tokamak *TokamakTransactor) SetForgeL1L2BatchTimeout(opts *bind.TransactOpts, newTimeout uint8) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "setForgeL1L2BatchTimeout", newTimeout)
+}
+
+// SetForgeL1L2BatchTimeout is a paid mutator transaction binding the contract method 0x40105466.
+//
+// Solidity: function setForgeL1L2BatchTimeout(uint8 newTimeout) returns()
+func (_Tokamak *TokamakSession) SetForgeL1L2BatchTimeout(newTimeout uint8) (*types.Transaction, error) {
+	return _Tokamak.Contract.SetForgeL1L2BatchTimeout(&_Tokamak.TransactOpts, newTimeout)
+}
+
+// SetForgeL1L2BatchTimeout is a paid mutator transaction binding the contract method 0x40105466.
+//
+// Solidity: function setForgeL1L2BatchTimeout(uint8 newTimeout) returns()
+func (_Tokamak *TokamakTransactorSession) SetForgeL1L2BatchTimeout(newTimeout uint8) (*types.Transaction, error) {
+	return _Tokamak.Contract.SetForgeL1L2BatchTimeout(&_Tokamak.TransactOpts, newTimeout)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Tokamak *TokamakTransactor) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "transferOwnership", newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Tokamak *TokamakSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _Tokamak.Contract.TransferOwnership(&_Tokamak.TransactOpts, newOwner)
+}
+
+// TransferOwnership is a paid mutator transaction binding the contract method 0xf2fde38b.
+//
+// Solidity: function transferOwnership(address newOwner) returns()
+func (_Tokamak *TokamakTransactorSession) TransferOwnership(newOwner common.Address) (*types.Transaction, error) {
+	return _Tokamak.Contract.TransferOwnership(&_Tokamak.TransactOpts, newOwner)
+}
+
+// WithdrawMerkleProof is a paid mutator transaction binding the contract method 0xc285d345.
+//
+// Solidity: function withdrawMerkleProof(uint192 amount, uint256 babyPubKey, uint32 numExitRoot, uint256[] siblings, uint48 idx) returns()
+func (_Tokamak *TokamakTransactor) WithdrawMerkleProof(opts *bind.TransactOpts, amount *big.Int, babyPubKey *big.Int, numExitRoot uint32, siblings []*big.Int, idx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.contract.Transact(opts, "withdrawMerkleProof", amount, babyPubKey, numExitRoot, siblings, idx)
+}
+
+// WithdrawMerkleProof is a paid mutator transaction binding the contract method 0xc285d345.
+//
+// Solidity: function withdrawMerkleProof(uint192 amount, uint256 babyPubKey, uint32 numExitRoot, uint256[] siblings, uint48 idx) returns()
+func (_Tokamak *TokamakSession) WithdrawMerkleProof(amount *big.Int, babyPubKey *big.Int, numExitRoot uint32, siblings []*big.Int, idx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.WithdrawMerkleProof(&_Tokamak.TransactOpts, amount, babyPubKey, numExitRoot, siblings, idx)
+}
+
+// WithdrawMerkleProof is a paid mutator transaction binding the contract method 0xc285d345.
+//
+// Solidity: function withdrawMerkleProof(uint192 amount, uint256 babyPubKey, uint32 numExitRoot, uint256[] siblings, uint48 idx) returns()
+func (_Tokamak *TokamakTransactorSession) WithdrawMerkleProof(amount *big.Int, babyPubKey *big.Int, numExitRoot uint32, siblings []*big.Int, idx *big.Int) (*types.Transaction, error) {
+	return _Tokamak.Contract.WithdrawMerkleProof(&_Tokamak.TransactOpts, amount, babyPubKey, numExitRoot, siblings, idx)
+}
+
+// TokamakForgeBatchIterator is returned from FilterForgeBatch and is used to iterate over the raw logs and unpacked data for ForgeBatch events raised by the Tokamak contract.
+type TokamakForgeBatchIterator struct {
+	Event *TokamakForgeBatch // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakForgeBatchIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakForgeBatch)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakForgeBatch)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakForgeBatchIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakForgeBatchIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakForgeBatch represents a ForgeBatch event raised by the Tokamak contract.
+type TokamakForgeBatch struct {
+	BatchNum     uint32
+	L1UserTxsLen uint16
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterForgeBatch is a free log retrieval operation binding the contract event 0xe00040c8a3b0bf905636c26924e90520eafc5003324138236fddee2d34588618.
+//
+// Solidity: event ForgeBatch(uint32 indexed batchNum, uint16 l1UserTxsLen)
+func (_Tokamak *TokamakFilterer) FilterForgeBatch(opts *bind.FilterOpts, batchNum []uint32) (*TokamakForgeBatchIterator, error) {
+
+	var batchNumRule []interface{}
+	for _, batchNumItem := range batchNum {
+		batchNumRule = append(batchNumRule, batchNumItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "ForgeBatch", batchNumRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakForgeBatchIterator{contract: _Tokamak.contract, event: "ForgeBatch", logs: logs, sub: sub}, nil
+}
+
+// WatchForgeBatch is a free log subscription operation binding the contract event 0xe00040c8a3b0bf905636c26924e90520eafc5003324138236fddee2d34588618.
+//
+// Solidity: event ForgeBatch(uint32 indexed batchNum, uint16 l1UserTxsLen)
+func (_Tokamak *TokamakFilterer) WatchForgeBatch(opts *bind.WatchOpts, sink chan<- *TokamakForgeBatch, batchNum []uint32) (event.Subscription, error) {
+
+	var batchNumRule []interface{}
+	for _, batchNumItem := range batchNum {
+		batchNumRule = append(batchNumRule, batchNumItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "ForgeBatch", batchNumRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakForgeBatch)
+				if err := _Tokamak.contract.UnpackLog(event, "ForgeBatch", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseForgeBatch is a log parse operation binding the contract event 0xe00040c8a3b0bf905636c26924e90520eafc5003324138236fddee2d34588618.
+//
+// Solidity: event ForgeBatch(uint32 indexed batchNum, uint16 l1UserTxsLen)
+func (_Tokamak *TokamakFilterer) ParseForgeBatch(log types.Log) (*TokamakForgeBatch, error) {
+	event := new(TokamakForgeBatch)
+	if err := _Tokamak.contract.UnpackLog(event, "ForgeBatch", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakInitializeIterator is returned from FilterInitialize and is used to iterate over the raw logs and unpacked data for Initialize events raised by the Tokamak contract.
+type TokamakInitializeIterator struct {
+	Event *TokamakInitialize // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakInitializeIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakInitialize)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakInitialize)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakInitializeIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakInitializeIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakInitialize represents a Initialize event raised by the Tokamak contract.
+type TokamakInitialize struct {
+	ForgeL1L2BatchTimeout uint8
+	Raw                   types.Log // Blockchain specific contextual infos
+}
+
+// FilterInitialize is a free log retrieval operation binding the contract event 0xd2b214d5e2d2f958eb3b30690fa010715ebfdb9438837a496031fd1d0462e593.
+//
+// Solidity: event Initialize(uint8 forgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) FilterInitialize(opts *bind.FilterOpts) (*TokamakInitializeIterator, error) {
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "Initialize")
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakInitializeIterator{contract: _Tokamak.contract, event: "Initialize", logs: logs, sub: sub}, nil
+}
+
+// WatchInitialize is a free log subscription operation binding the contract event 0xd2b214d5e2d2f958eb3b30690fa010715ebfdb9438837a496031fd1d0462e593.
+//
+// Solidity: event Initialize(uint8 forgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) WatchInitialize(opts *bind.WatchOpts, sink chan<- *TokamakInitialize) (event.Subscription, error) {
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "Initialize")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakInitialize)
+				if err := _Tokamak.contract.UnpackLog(event, "Initialize", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseInitialize is a log parse operation binding the contract event 0xd2b214d5e2d2f958eb3b30690fa010715ebfdb9438837a496031fd1d0462e593.
+//
+// Solidity: event Initialize(uint8 forgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) ParseInitialize(log types.Log) (*TokamakInitialize, error) {
+	event := new(TokamakInitialize)
+	if err := _Tokamak.contract.UnpackLog(event, "Initialize", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakInitializedIterator is returned from FilterInitialized and is used to iterate over the raw logs and unpacked data for Initialized events raised by the Tokamak contract.
+type TokamakInitializedIterator struct {
+	Event *TokamakInitialized // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakInitializedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakInitialized)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakInitialized)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakInitializedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakInitializedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakInitialized represents a Initialized event raised by the Tokamak contract.
+type TokamakInitialized struct {
+	Version uint64
+	Raw     types.Log // Blockchain specific contextual infos
+}
+
+// FilterInitialized is a free log retrieval operation binding the contract event 0xc7f505b2f371ae2175ee4913f4499e1f2633a7b5936321eed1cdaeb6115181d2.
+//
+// Solidity: event Initialized(uint64 version)
+func (_Tokamak *TokamakFilterer) FilterInitialized(opts *bind.FilterOpts) (*TokamakInitializedIterator, error) {
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "Initialized")
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakInitializedIterator{contract: _Tokamak.contract, event: "Initialized", logs: logs, sub: sub}, nil
+}
+
+// WatchInitialized is a free log subscription operation binding the contract event 0xc7f505b2f371ae2175ee4913f4499e1f2633a7b5936321eed1cdaeb6115181d2.
+//
+// Solidity: event Initialized(uint64 version)
+func (_Tokamak *TokamakFilterer) WatchInitialized(opts *bind.WatchOpts, sink chan<- *TokamakInitialized) (event.Subscription, error) {
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "Initialized")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakInitialized)
+				if err := _Tokamak.contract.UnpackLog(event, "Initialized", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseInitialized is a log parse operation binding the contract event 0xc7f505b2f371ae2175ee4913f4499e1f2633a7b5936321eed1cdaeb6115181d2.
+//
+// Solidity: event Initialized(uint64 version)
+func (_Tokamak *TokamakFilterer) ParseInitialized(log types.Log) (*TokamakInitialized, error) {
+	event := new(TokamakInitialized)
+	if err := _Tokamak.contract.UnpackLog(event, "Initialized", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakL1UserTxEventIterator is returned from FilterL1UserTxEvent and is used to iterate over the raw logs and unpacked data for L1UserTxEvent events raised by the Tokamak contract.
+type TokamakL1UserTxEventIterator struct {
+	Event *TokamakL1UserTxEvent // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakL1UserTxEventIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakL1UserTxEvent)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakL1UserTxEvent)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakL1UserTxEventIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakL1UserTxEventIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakL1UserTxEvent represents a L1UserTxEvent event raised by the Tokamak contract.
+type TokamakL1UserTxEvent struct {
+	QueueIndex uint32
+	Position   uint8
+	L1UserTx   []byte
+	Raw        types.Log // Blockchain specific contextual infos
+}
+
+// FilterL1UserTxEvent is a free log retrieval operation binding the contract event 0xdd5c7c5ea02d3c5d1621513faa6de53d474ee6f111eda6352a63e3dfe8c40119.
+//
+// Solidity: event L1UserTxEvent(uint32 indexed queueIndex, uint8 indexed position, bytes l1UserTx)
+func (_Tokamak *TokamakFilterer) FilterL1UserTxEvent(opts *bind.FilterOpts, queueIndex []uint32, position []uint8) (*TokamakL1UserTxEventIterator, error) {
+
+	var queueIndexRule []interface{}
+	for _, queueIndexItem := range queueIndex {
+		queueIndexRule = append(queueIndexRule, queueIndexItem)
+	}
+	var positionRule []interface{}
+	for _, positionItem := range position {
+		positionRule = append(positionRule, positionItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "L1UserTxEvent", queueIndexRule, positionRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakL1UserTxEventIterator{contract: _Tokamak.contract, event: "L1UserTxEvent", logs: logs, sub: sub}, nil
+}
+
+// WatchL1UserTxEvent is a free log subscription operation binding the contract event 0xdd5c7c5ea02d3c5d1621513faa6de53d474ee6f111eda6352a63e3dfe8c40119.
+//
+// Solidity: event L1UserTxEvent(uint32 indexed queueIndex, uint8 indexed position, bytes l1UserTx)
+func (_Tokamak *TokamakFilterer) WatchL1UserTxEvent(opts *bind.WatchOpts, sink chan<- *TokamakL1UserTxEvent, queueIndex []uint32, position []uint8) (event.Subscription, error) {
+
+	var queueIndexRule []interface{}
+	for _, queueIndexItem := range queueIndex {
+		queueIndexRule = append(queueIndexRule, queueIndexItem)
+	}
+	var positionRule []interface{}
+	for _, positionItem := range position {
+		positionRule = append(positionRule, positionItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "L1UserTxEvent", queueIndexRule, positionRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakL1UserTxEvent)
+				if err := _Tokamak.contract.UnpackLog(event, "L1UserTxEvent", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseL1UserTxEvent is a log parse operation binding the contract event 0xdd5c7c5ea02d3c5d1621513faa6de53d474ee6f111eda6352a63e3dfe8c40119.
+//
+// Solidity: event L1UserTxEvent(uint32 indexed queueIndex, uint8 indexed position, bytes l1UserTx)
+func (_Tokamak *TokamakFilterer) ParseL1UserTxEvent(log types.Log) (*TokamakL1UserTxEvent, error) {
+	event := new(TokamakL1UserTxEvent)
+	if err := _Tokamak.contract.UnpackLog(event, "L1UserTxEvent", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakOwnershipTransferredIterator is returned from FilterOwnershipTransferred and is used to iterate over the raw logs and unpacked data for OwnershipTransferred events raised by the Tokamak contract.
+type TokamakOwnershipTransferredIterator struct {
+	Event *TokamakOwnershipTransferred // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakOwnershipTransferredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakOwnershipTransferred)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakOwnershipTransferred)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakOwnershipTransferredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakOwnershipTransferredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakOwnershipTransferred represents a OwnershipTransferred event raised by the Tokamak contract.
+type TokamakOwnershipTransferred struct {
+	PreviousOwner common.Address
+	NewOwner      common.Address
+	Raw           types.Log // Blockchain specific contextual infos
+}
+
+// FilterOwnershipTransferred is a free log retrieval operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Tokamak *TokamakFilterer) FilterOwnershipTransferred(opts *bind.FilterOpts, previousOwner []common.Address, newOwner []common.Address) (*TokamakOwnershipTransferredIterator, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakOwnershipTransferredIterator{contract: _Tokamak.contract, event: "OwnershipTransferred", logs: logs, sub: sub}, nil
+}
+
+// WatchOwnershipTransferred is a free log subscription operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Tokamak *TokamakFilterer) WatchOwnershipTransferred(opts *bind.WatchOpts, sink chan<- *TokamakOwnershipTransferred, previousOwner []common.Address, newOwner []common.Address) (event.Subscription, error) {
+
+	var previousOwnerRule []interface{}
+	for _, previousOwnerItem := range previousOwner {
+		previousOwnerRule = append(previousOwnerRule, previousOwnerItem)
+	}
+	var newOwnerRule []interface{}
+	for _, newOwnerItem := range newOwner {
+		newOwnerRule = append(newOwnerRule, newOwnerItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "OwnershipTransferred", previousOwnerRule, newOwnerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakOwnershipTransferred)
+				if err := _Tokamak.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseOwnershipTransferred is a log parse operation binding the contract event 0x8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e0.
+//
+// Solidity: event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+func (_Tokamak *TokamakFilterer) ParseOwnershipTransferred(log types.Log) (*TokamakOwnershipTransferred, error) {
+	event := new(TokamakOwnershipTransferred)
+	if err := _Tokamak.contract.UnpackLog(event, "OwnershipTransferred", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakUpdateForgeL1L2BatchTimeoutIterator is returned from FilterUpdateForgeL1L2BatchTimeout and is used to iterate over the raw logs and unpacked data for UpdateForgeL1L2BatchTimeout events raised by the Tokamak contract.
+type TokamakUpdateForgeL1L2BatchTimeoutIterator struct {
+	Event *TokamakUpdateForgeL1L2BatchTimeout // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakUpdateForgeL1L2BatchTimeoutIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakUpdateForgeL1L2BatchTimeout)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakUpdateForgeL1L2BatchTimeout)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakUpdateForgeL1L2BatchTimeoutIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakUpdateForgeL1L2BatchTimeoutIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakUpdateForgeL1L2BatchTimeout represents a UpdateForgeL1L2BatchTimeout event raised by the Tokamak contract.
+type TokamakUpdateForgeL1L2BatchTimeout struct {
+	NewForgeL1L2BatchTimeout uint8
+	Raw                      types.Log // Blockchain specific contextual infos
+}
+
+// FilterUpdateForgeL1L2BatchTimeout is a free log retrieval operation binding the contract event 0xff6221781ac525b04585dbb55cd2ebd2a92c828ca3e42b23813a1137ac974431.
+//
+// Solidity: event UpdateForgeL1L2BatchTimeout(uint8 newForgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) FilterUpdateForgeL1L2BatchTimeout(opts *bind.FilterOpts) (*TokamakUpdateForgeL1L2BatchTimeoutIterator, error) {
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "UpdateForgeL1L2BatchTimeout")
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakUpdateForgeL1L2BatchTimeoutIterator{contract: _Tokamak.contract, event: "UpdateForgeL1L2BatchTimeout", logs: logs, sub: sub}, nil
+}
+
+// WatchUpdateForgeL1L2BatchTimeout is a free log subscription operation binding the contract event 0xff6221781ac525b04585dbb55cd2ebd2a92c828ca3e42b23813a1137ac974431.
+//
+// Solidity: event UpdateForgeL1L2BatchTimeout(uint8 newForgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) WatchUpdateForgeL1L2BatchTimeout(opts *bind.WatchOpts, sink chan<- *TokamakUpdateForgeL1L2BatchTimeout) (event.Subscription, error) {
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "UpdateForgeL1L2BatchTimeout")
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakUpdateForgeL1L2BatchTimeout)
+				if err := _Tokamak.contract.UnpackLog(event, "UpdateForgeL1L2BatchTimeout", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseUpdateForgeL1L2BatchTimeout is a log parse operation binding the contract event 0xff6221781ac525b04585dbb55cd2ebd2a92c828ca3e42b23813a1137ac974431.
+//
+// Solidity: event UpdateForgeL1L2BatchTimeout(uint8 newForgeL1L2BatchTimeout)
+func (_Tokamak *TokamakFilterer) ParseUpdateForgeL1L2BatchTimeout(log types.Log) (*TokamakUpdateForgeL1L2BatchTimeout, error) {
+	event := new(TokamakUpdateForgeL1L2BatchTimeout)
+	if err := _Tokamak.contract.UnpackLog(event, "UpdateForgeL1L2BatchTimeout", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakWithdrawEventIterator is returned from FilterWithdrawEvent and is used to iterate over the raw logs and unpacked data for WithdrawEvent events raised by the Tokamak contract.
+type TokamakWithdrawEventIterator struct {
+	Event *TokamakWithdrawEvent // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *TokamakWithdrawEventIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(TokamakWithdrawEvent)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(TokamakWithdrawEvent)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *TokamakWithdrawEventIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *TokamakWithdrawEventIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// TokamakWithdrawEvent represents a WithdrawEvent event raised by the Tokamak contract.
+type TokamakWithdrawEvent struct {
+	Idx         *big.Int
+	NumExitRoot uint32
+	Raw         types.Log // Blockchain specific contextual infos
+}
+
+// FilterWithdrawEvent is a free log retrieval operation binding the contract event 0x102db758451b2f65238246a452d00c0c4c8f59d8c623aff254111079418e57ec.
+//
+// Solidity: event WithdrawEvent(uint48 indexed idx, uint32 indexed numExitRoot)
+func (_Tokamak *TokamakFilterer) FilterWithdrawEvent(opts *bind.FilterOpts, idx []*big.Int, numExitRoot []uint32) (*TokamakWithdrawEventIterator, error) {
+
+	var idxRule []interface{}
+	for _, idxItem := range idx {
+		idxRule = append(idxRule, idxItem)
+	}
+	var numExitRootRule []interface{}
+	for _, numExitRootItem := range numExitRoot {
+		numExitRootRule = append(numExitRootRule, numExitRootItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.FilterLogs(opts, "WithdrawEvent", idxRule, numExitRootRule)
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakWithdrawEventIterator{contract: _Tokamak.contract, event: "WithdrawEvent", logs: logs, sub: sub}, nil
+}
+
+// WatchWithdrawEvent is a free log subscription operation binding the contract event 0x102db758451b2f65238246a452d00c0c4c8f59d8c623aff254111079418e57ec.
+//
+// Solidity: event WithdrawEvent(uint48 indexed idx, uint32 indexed numExitRoot)
+func (_Tokamak *TokamakFilterer) WatchWithdrawEvent(opts *bind.WatchOpts, sink chan<- *TokamakWithdrawEvent, idx []*big.Int, numExitRoot []uint32) (event.Subscription, error) {
+
+	var idxRule []interface{}
+	for _, idxItem := range idx {
+		idxRule = append(idxRule, idxItem)
+	}
+	var numExitRootRule []interface{}
+	for _, numExitRootItem := range numExitRoot {
+		numExitRootRule = append(numExitRootRule, numExitRootItem)
+	}
+
+	logs, sub, err := _Tokamak.contract.WatchLogs(opts, "WithdrawEvent", idxRule, numExitRootRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(TokamakWithdrawEvent)
+				if err := _Tokamak.contract.UnpackLog(event, "WithdrawEvent", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseWithdrawEvent is a log parse operation binding the contract event 0x102db758451b2f65238246a452d00c0c4c8f59d8c623aff254111079418e57ec.
+//
+// Solidity: event WithdrawEvent(uint48 indexed idx, uint32 indexed numExitRoot)
+func (_Tokamak *TokamakFilterer) ParseWithdrawEvent(log types.Log) (*TokamakWithdrawEvent, error) {
+	event := new(TokamakWithdrawEvent)
+	if err := _Tokamak.contract.UnpackLog(event, "WithdrawEvent", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// TokamakErrorsABI is the input ABI of the custom errors of the contract.
+// The errors are declared as functions, since go-ethereum doesn't parse them,
+// so the ID of each function is the selector of its error.
+const TokamakErrorsABI = "[{\"inputs\":[],\"name\":\"AmountExceedsLimit\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"BatchTimeoutExceeded\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"EthTransferFailed\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InternalTxNotAllowed\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidCreateAccountTransaction\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidDepositTransaction\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidForceExitTransaction\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidForceExplodeTransaction\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidInitialization\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"elementType\",\"type\":\"string\"}],\"name\":\"InvalidPoseidonAddress\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidProof\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidTransactionParameters\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"InvalidVerifierAddress\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"LoadAmountDoesNotMatch\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"LoadAmountExceedsLimit\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"NotInitializing\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"OwnableInvalidOwner\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"}],\"name\":\"OwnableUnauthorizedAccount\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"SmtProofInvalid\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"WithdrawAlreadyDone\",\"type\":\"function\"}]"
+
+// TokamakError is a custom error of the contract, decoded from the data of
+// a reverted call.
+type TokamakError struct {
+	Name string
+	Args []interface{}
+}
+
+// Error implements the error interface
+func (e *TokamakError) Error() string {
+	return fmt.Sprintf("%v%v", e.Name, e.Args)
+}
+
+// UnpackTokamakError decodes the data of a reverted call into the custom
+// error it encodes.  It returns nil if the data isn't a custom error of the
+// contract.
+func UnpackTokamakError(data []byte) (*TokamakError, error) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	parsed, err := abi.JSON(strings.NewReader(TokamakErrorsABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil, nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &TokamakError{Name: method.RawName, Args: args}, nil
+}
+
+// TokamakRevertError returns the custom error of the contract in the revert
+// data of the error returned by an RPC call, or nil if there's none.
+func TokamakRevertError(err error) *TokamakError {
+	dataErr, ok := err.(interface{ ErrorData() interface{} })
+	if !ok {
+		return nil
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil
+	}
+	data, decodeErr := hexutil.Decode(hexData)
+	if decodeErr != nil {
+		return nil
+	}
+	revertErr, _ := UnpackTokamakError(data)
+	return revertErr
 }
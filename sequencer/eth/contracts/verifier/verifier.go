@@ -0,0 +1,203 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package verifier
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+)
+
+// VerifierRollupABI is the input ABI used to generate the binding from.
+const VerifierRollupABI = "[{\"type\":\"function\",\"name\":\"verifyProof\",\"inputs\":[{\"name\":\"proofA\",\"type\":\"uint256[2]\",\"internalType\":\"uint256[2]\"},{\"name\":\"proofB\",\"type\":\"uint256[2][2]\",\"internalType\":\"uint256[2][2]\"},{\"name\":\"proofC\",\"type\":\"uint256[2]\",\"internalType\":\"uint256[2]\"},{\"name\":\"input\",\"type\":\"uint256[1]\",\"internalType\":\"uint256[1]\"}],\"outputs\":[{\"name\":\"\",\"type\":\"bool\",\"internalType\":\"bool\"}],\"stateMutability\":\"view\"}]"
+
+// VerifierRollup is an auto generated Go binding around an Ethereum contract.
+type VerifierRollup struct {
+	VerifierRollupCaller     // Read-only binding to the contract
+	VerifierRollupTransactor // Write-only binding to the contract
+	VerifierRollupFilterer   // Log filterer for contract events
+}
+
+// VerifierRollupCaller is an auto generated read-only Go binding around an Ethereum contract.
+type VerifierRollupCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VerifierRollupTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type VerifierRollupTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VerifierRollupFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type VerifierRollupFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// VerifierRollupSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type VerifierRollupSession struct {
+	Contract     *VerifierRollup   // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// VerifierRollupCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type VerifierRollupCallerSession struct {
+	Contract *VerifierRollupCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts         // Call options to use throughout this session
+}
+
+// VerifierRollupTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type VerifierRollupTransactorSession struct {
+	Contract     *VerifierRollupTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts         // Transaction auth options to use throughout this session
+}
+
+// VerifierRollupRaw is an auto generated low-level Go binding around an Ethereum contract.
+type VerifierRollupRaw struct {
+	Contract *VerifierRollup // Generic contract binding to access the raw methods on
+}
+
+// VerifierRollupCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type VerifierRollupCallerRaw struct {
+	Contract *VerifierRollupCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// VerifierRollupTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type VerifierRollupTransactorRaw struct {
+	Contract *VerifierRollupTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewVerifierRollup creates a new instance of VerifierRollup, bound to a specific deployed contract.
+func NewVerifierRollup(address common.Address, backend bind.ContractBackend) (*VerifierRollup, error) {
+	contract, err := bindVerifierRollup(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierRollup{VerifierRollupCaller: VerifierRollupCaller{contract: contract}, VerifierRollupTransactor: VerifierRollupTransactor{contract: contract}, VerifierRollupFilterer: VerifierRollupFilterer{contract: contract}}, nil
+}
+
+// NewVerifierRollupCaller creates a new read-only instance of VerifierRollup, bound to a specific deployed contract.
+func NewVerifierRollupCaller(address common.Address, caller bind.ContractCaller) (*VerifierRollupCaller, error) {
+	contract, err := bindVerifierRollup(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierRollupCaller{contract: contract}, nil
+}
+
+// NewVerifierRollupTransactor creates a new write-only instance of VerifierRollup, bound to a specific deployed contract.
+func NewVerifierRollupTransactor(address common.Address, transactor bind.ContractTransactor) (*VerifierRollupTransactor, error) {
+	contract, err := bindVerifierRollup(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierRollupTransactor{contract: contract}, nil
+}
+
+// NewVerifierRollupFilterer creates a new log filterer instance of VerifierRollup, bound to a specific deployed contract.
+func NewVerifierRollupFilterer(address common.Address, filterer bind.ContractFilterer) (*VerifierRollupFilterer, error) {
+	contract, err := bindVerifierRollup(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierRollupFilterer{contract: contract}, nil
+}
+
+// bindVerifierRollup binds a generic wrapper to an already deployed contract.
+func bindVerifierRollup(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(VerifierRollupABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_VerifierRollup *VerifierRollupRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _VerifierRollup.Contract.VerifierRollupCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_VerifierRollup *VerifierRollupRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _VerifierRollup.Contract.VerifierRollupTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_VerifierRollup *VerifierRollupRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _VerifierRollup.Contract.VerifierRollupTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_VerifierRollup *VerifierRollupCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _VerifierRollup.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_VerifierRollup *VerifierRollupTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _VerifierRollup.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_VerifierRollup *VerifierRollupTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _VerifierRollup.Contract.contract.Transact(opts, method, params...)
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x43753b4d.
+//
+// Solidity: function verifyProof(uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256[1] input) view returns(bool)
+func (_VerifierRollup *VerifierRollupCaller) VerifyProof(opts *bind.CallOpts, proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input [1]*big.Int) (bool, error) {
+	var out []interface{}
+	err := _VerifierRollup.contract.Call(opts, &out, "verifyProof", proofA, proofB, proofC, input)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x43753b4d.
+//
+// Solidity: function verifyProof(uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256[1] input) view returns(bool)
+func (_VerifierRollup *VerifierRollupSession) VerifyProof(proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input [1]*big.Int) (bool, error) {
+	return _VerifierRollup.Contract.VerifyProof(&_VerifierRollup.CallOpts, proofA, proofB, proofC, input)
+}
+
+// VerifyProof is a free data retrieval call binding the contract method 0x43753b4d.
+//
+// Solidity: function verifyProof(uint256[2] proofA, uint256[2][2] proofB, uint256[2] proofC, uint256[1] input) view returns(bool)
+func (_VerifierRollup *VerifierRollupCallerSession) VerifyProof(proofA [2]*big.Int, proofB [2][2]*big.Int, proofC [2]*big.Int, input [1]*big.Int) (bool, error) {
+	return _VerifierRollup.Contract.VerifyProof(&_VerifierRollup.CallOpts, proofA, proofB, proofC, input)
+}
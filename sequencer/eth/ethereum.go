@@ -6,14 +6,15 @@ import (
 	"math/big"
 	"time"
 	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/etherscan"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var (
@@ -27,7 +28,6 @@ var (
 const (
 	// default values
 	defaultCallGasLimit = 300000
-	defaultGasPriceDiv  = 100
 )
 
 // ERC20Consts are the constants defined in a particular ERC20 Token instance
@@ -40,18 +40,22 @@ type ERC20Consts struct {
 // EthereumConfig defines the configuration parameters of the EthereumClient
 type EthereumConfig struct {
 	CallGasLimit uint64
-	GasPriceDiv  uint64
+	// GasPricer selects how the gas of the transactions is priced
+	GasPricer GasPricerConfig
+	// Etherscan is only required by the Etherscan gas pricer
+	Etherscan etherscan.Client
 }
 
 // EthereumClient is an ethereum client to call Smart Contract methods and check blockchain
 // information.
 type EthereumClient struct {
-	client  *ethclient.Client
-	chainID *big.Int
-	account *accounts.Account
-	ks      *ethKeystore.KeyStore
-	config  *EthereumConfig
-	opts    *bind.CallOpts
+	client    *ethclient.Client
+	rpcClient *rpc.Client
+	chainID   *big.Int
+	signer    Signer
+	config    *EthereumConfig
+	gasPricer GasPricer
+	opts      *bind.CallOpts
 }
 
 // EthereumInterface is the interface to Ethereum
@@ -68,7 +72,10 @@ type EthereumInterface interface {
 	EthPendingNonceAt(ctx context.Context, account ethCommon.Address) (uint64, error)
 	EthNonceAt(ctx context.Context, account ethCommon.Address, blockNumber *big.Int) (uint64, error)
 	EthSuggestGasPrice(ctx context.Context) (*big.Int, error)
-	EthKeyStore() *ethKeystore.KeyStore
+	EthSuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EthFeeHistory(ctx context.Context, blockCount int,
+		rewardPercentiles []float64) (*FeeHistory, error)
+	EthSigner() Signer
 	EthCall(ctx context.Context, tx *types.Transaction, blockNum *big.Int) ([]byte, error)
 }
 
@@ -106,10 +113,11 @@ func (c *EthereumClient) EthBlockByNumber(ctx context.Context, number int64) (*c
 
 // EthAddress returns the ethereum address of the account loaded into the EthereumClient
 func (c *EthereumClient) EthAddress() (*ethCommon.Address, error) {
-	if c.account == nil {
+	if c.signer == nil {
 		return nil, common.Wrap(ErrAccountNil)
 	}
-	return &c.account.Address, nil
+	address := c.signer.Address()
+	return &address, nil
 }
 
 // EthTransactionReceipt returns the transaction receipt of the given txHash
@@ -147,13 +155,13 @@ func (c *EthereumClient) EthSuggestGasPrice(ctx context.Context) (gasPrice *big.
 	var head *types.Header
 	head, err = c.client.HeaderByNumber(ctx, nil)
 	if err != nil {
-		err = fmt.Errorf("[EthSuggestGasPrice]. Error getting head: %s", err.Error())
+		err = fmt.Errorf("[EthSuggestGasPrice]. Error getting head: %w", err)
 		return
 	}
 	var tip *big.Int
 	tip, err = c.client.SuggestGasTipCap(ctx)
 	if err != nil {
-		err = fmt.Errorf("[EthSuggestGasPrice]. Error getting tip: %s", err.Error())
+		err = fmt.Errorf("[EthSuggestGasPrice]. Error getting tip: %w", err)
 		return
 	}
 	baseFee := head.BaseFee
@@ -162,20 +170,100 @@ func (c *EthereumClient) EthSuggestGasPrice(ctx context.Context) (gasPrice *big.
 	return
 }
 
-// EthKeyStore returns the keystore in the EthereumClient
-func (c *EthereumClient) EthKeyStore() *ethKeystore.KeyStore {
-	return c.ks
+// EthSuggestGasTipCap retrieves the currently suggested gas tip cap after
+// EIP-1559 to allow a timely execution of a transaction.
+func (c *EthereumClient) EthSuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	tip, err := c.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return tip, nil
+}
+
+// FeeHistory is the fee market history of a range of blocks returned by
+// eth_feeHistory
+type FeeHistory struct {
+	OldestBlock *big.Int
+	// Reward are the effective priority fees per gas at the requested
+	// percentiles of each block
+	Reward [][]*big.Int
+	// BaseFee are the base fees per gas of each block, and of the block
+	// after the newest one
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+}
+
+// EthFeeHistory returns the fee market history of the last blockCount blocks,
+// with the priority fees at the given percentiles of the gas used in each block
+func (c *EthereumClient) EthFeeHistory(ctx context.Context, blockCount int,
+	rewardPercentiles []float64) (*FeeHistory, error) {
+	var res struct {
+		OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+		Reward       [][]*hexutil.Big `json:"reward"`
+		BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+		GasUsedRatio []float64        `json:"gasUsedRatio"`
+	}
+	if err := c.rpcClient.CallContext(ctx, &res, "eth_feeHistory", hexutil.Uint(blockCount),
+		"latest", rewardPercentiles); err != nil {
+		return nil, common.Wrap(err)
+	}
+	if res.OldestBlock == nil {
+		return nil, common.Wrap(fmt.Errorf("eth_feeHistory: empty response"))
+	}
+	feeHistory := FeeHistory{
+		OldestBlock:  res.OldestBlock.ToInt(),
+		Reward:       make([][]*big.Int, len(res.Reward)),
+		BaseFee:      make([]*big.Int, len(res.BaseFee)),
+		GasUsedRatio: res.GasUsedRatio,
+	}
+	for i, rewards := range res.Reward {
+		feeHistory.Reward[i] = make([]*big.Int, len(rewards))
+		for j, reward := range rewards {
+			feeHistory.Reward[i][j] = reward.ToInt()
+		}
+	}
+	for i, baseFee := range res.BaseFee {
+		feeHistory.BaseFee[i] = baseFee.ToInt()
+	}
+	return &feeHistory, nil
+}
+
+// NewAuth builds a new auth object to make a dynamic fee transaction signed
+// with the account of the EthereumClient, with the fees suggested by the
+// GasPricer of the config.
+func (c *EthereumClient) NewAuth() (*bind.TransactOpts, error) {
+	if c.signer == nil {
+		return nil, common.Wrap(ErrAccountNil)
+	}
+	gasFeeCap, gasTipCap, err := c.gasPricer.SuggestGasFees(context.Background())
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	auth, err := NewTransactor(c.signer, c.chainID)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	auth.Value = big.NewInt(0) // in wei
+	auth.GasLimit = c.config.CallGasLimit
+	auth.GasFeeCap = gasFeeCap
+	auth.GasTipCap = gasTipCap
+	return auth, nil
+}
+
+// EthSigner returns the signer of the account of the EthereumClient
+func (c *EthereumClient) EthSigner() Signer {
+	return c.signer
 }
 
 // EthCall runs the transaction as a call (without paying) in the local node at
 // blockNum.
 func (c *EthereumClient) EthCall(ctx context.Context, tx *types.Transaction,
 	blockNum *big.Int) ([]byte, error) {
-	if c.account == nil {
+	if c.signer == nil {
 		return nil, common.Wrap(ErrAccountNil)
 	}
 	msg := ethereum.CallMsg{
-		From:     c.account.Address,
+		From:     c.signer.Address(),
 		To:       tx.To(),
 		Gas:      tx.Gas(),
 		GasPrice: tx.GasPrice(),
@@ -200,28 +288,31 @@ func newCallOpts() *bind.CallOpts {
 	}
 }
 
-// NewEthereumClient creates a EthereumClient instance.  The account is not mandatory (it can
-// be nil).  If the account is nil, CallAuth will fail with ErrAccountNil.
-func NewEthereumClient(client *ethclient.Client, account *accounts.Account,
-	ks *ethKeystore.KeyStore, config *EthereumConfig) (*EthereumClient, error) {
+// NewEthereumClient creates a EthereumClient instance.  The signer is not mandatory (it can
+// be nil).  If the signer is nil, CallAuth will fail with ErrAccountNil.
+func NewEthereumClient(rpcClient *rpc.Client, signer Signer,
+	config *EthereumConfig) (*EthereumClient, error) {
 	if config == nil {
 		config = &EthereumConfig{
 			CallGasLimit: defaultCallGasLimit,
-			GasPriceDiv:  defaultGasPriceDiv,
 		}
 	}
 	c := &EthereumClient{
-		client:  client,
-		account: account,
-		ks:      ks,
-		config:  config,
-		opts:    newCallOpts(),
+		client:    ethclient.NewClient(rpcClient),
+		rpcClient: rpcClient,
+		signer:    signer,
+		config:    config,
+		opts:      newCallOpts(),
 	}
 	chainID, err := c.EthChainID()
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
 	c.chainID = chainID
+	c.gasPricer, err = NewGasPricer(&config.GasPricer, c, config.Etherscan)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
 	return c, nil
 }
 
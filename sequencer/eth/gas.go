@@ -0,0 +1,200 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/etherscan"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	defaultFeeHistoryBlocks     = 20
+	defaultFeeHistoryPercentile = 50
+)
+
+// GasPricer is a strategy to price the gas of dynamic fee (EIP-1559)
+// transactions
+type GasPricer interface {
+	// SuggestGasFees returns the fee cap and the tip cap per gas for a
+	// transaction to be mined timely
+	SuggestGasFees(ctx context.Context) (gasFeeCap, gasTipCap *big.Int, err error)
+}
+
+// GasPricerStrategy describes the different available gas pricing strategies
+type GasPricerStrategy string
+
+const (
+	// GasPricerStrategyNode uses the gas price and the tip suggested by the
+	// ethereum node
+	GasPricerStrategyNode GasPricerStrategy = "Node"
+	// GasPricerStrategyEtherscan uses the gas oracle of etherscan
+	GasPricerStrategyEtherscan GasPricerStrategy = "Etherscan"
+	// GasPricerStrategyFeeHistory uses a percentile of the tips paid in the
+	// last blocks, from the fee history of the ethereum node
+	GasPricerStrategyFeeHistory GasPricerStrategy = "FeeHistory"
+)
+
+// GasPricerConfig describes how the gas of the transactions is priced
+type GasPricerConfig struct {
+	// Strategy is the gas pricing strategy, Node by default
+	Strategy GasPricerStrategy `env:"TONNODE_ETHCLIENT_GASPRICER_STRATEGY"`
+	// FeeHistoryBlocks is the number of blocks of the fee history used by
+	// the FeeHistory strategy
+	FeeHistoryBlocks int `validate:"gte=0" env:"TONNODE_ETHCLIENT_GASPRICER_FEEHISTORYBLOCKS"`
+	// FeeHistoryPercentile is the percentile of the tips of each block,
+	// weighted by gas used, used by the FeeHistory strategy
+	FeeHistoryPercentile float64 `validate:"gte=0,lte=100" env:"TONNODE_ETHCLIENT_GASPRICER_FEEHISTORYPERCENTILE"`
+}
+
+// NewGasPricer creates the GasPricer of the strategy in the config.  The
+// etherscan client is only required by the Etherscan strategy.
+func NewGasPricer(cfg *GasPricerConfig, client EthereumInterface,
+	etherscanClient etherscan.Client) (GasPricer, error) {
+	switch cfg.Strategy {
+	case GasPricerStrategyNode, "":
+		return &NodeGasPricer{client: client}, nil
+	case GasPricerStrategyEtherscan:
+		if etherscanClient == nil {
+			return nil, common.Wrap(fmt.Errorf("the Etherscan gas pricer requires etherscan"))
+		}
+		return &EtherscanGasPricer{etherscan: etherscanClient}, nil
+	case GasPricerStrategyFeeHistory:
+		blocks := cfg.FeeHistoryBlocks
+		if blocks == 0 {
+			blocks = defaultFeeHistoryBlocks
+		}
+		percentile := cfg.FeeHistoryPercentile
+		if percentile == 0 {
+			percentile = defaultFeeHistoryPercentile
+		}
+		return &FeeHistoryGasPricer{client: client, blocks: blocks,
+			percentile: percentile}, nil
+	default:
+		return nil, common.Wrap(fmt.Errorf("invalid gas pricer strategy: %v", cfg.Strategy))
+	}
+}
+
+// gasFees returns the fee cap and the tip cap for a base fee and a tip.  The
+// fee cap leaves room for the base fee to double before the transaction is
+// mined.
+func gasFees(baseFee, gasTipCap *big.Int) (*big.Int, *big.Int) {
+	gasFeeCap := new(big.Int).Mul(baseFee, big.NewInt(2)) //nolint:gomnd
+	gasFeeCap.Add(gasFeeCap, gasTipCap)
+	return gasFeeCap, gasTipCap
+}
+
+// NodeGasPricer prices the gas with the gas price and the tip suggested by the
+// ethereum node
+type NodeGasPricer struct {
+	client EthereumInterface
+}
+
+// SuggestGasFees implements the GasPricer interface
+func (p *NodeGasPricer) SuggestGasFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	gasPrice, err := p.client.EthSuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	gasTipCap, err := p.client.EthSuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	baseFee := new(big.Int).Sub(gasPrice, gasTipCap)
+	if baseFee.Sign() < 0 {
+		baseFee.SetInt64(0)
+	}
+	gasFeeCap, gasTipCap := gasFees(baseFee, gasTipCap)
+	return gasFeeCap, gasTipCap, nil
+}
+
+// EtherscanGasPricer prices the gas with the proposed gas price and the
+// suggested base fee of the etherscan gas oracle
+type EtherscanGasPricer struct {
+	etherscan etherscan.Client
+}
+
+// SuggestGasFees implements the GasPricer interface
+func (p *EtherscanGasPricer) SuggestGasFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	res, err := p.etherscan.GetGasPrice(ctx)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	gasPrice, err := parseGwei(res.ProposeGasPrice)
+	if err != nil {
+		return nil, nil, common.Wrap(fmt.Errorf("ProposeGasPrice: %w", err))
+	}
+	baseFee := big.NewInt(0)
+	if res.SuggestBaseFee != "" {
+		if baseFee, err = parseGwei(res.SuggestBaseFee); err != nil {
+			return nil, nil, common.Wrap(fmt.Errorf("SuggestBaseFee: %w", err))
+		}
+	}
+	gasTipCap := new(big.Int).Sub(gasPrice, baseFee)
+	if gasTipCap.Sign() < 0 {
+		gasTipCap.SetInt64(0)
+	}
+	gasFeeCap, gasTipCap := gasFees(baseFee, gasTipCap)
+	return gasFeeCap, gasTipCap, nil
+}
+
+// parseGwei parses a decimal amount of gwei into wei
+func parseGwei(s string) (*big.Int, error) {
+	const gweiDecimals = 9
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if len(fracPart) > gweiDecimals {
+		fracPart = fracPart[:gweiDecimals]
+	}
+	fracPart += strings.Repeat("0", gweiDecimals-len(fracPart))
+	digits := intPart + fracPart
+	if intPart == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, common.Wrap(fmt.Errorf("invalid gwei amount: %q", s))
+	}
+	wei, _ := new(big.Int).SetString(digits, 10) //nolint:gomnd
+	return wei, nil
+}
+
+// FeeHistoryGasPricer prices the gas with the next base fee and the average
+// of a percentile of the tips paid in the last blocks
+type FeeHistoryGasPricer struct {
+	client     EthereumInterface
+	blocks     int
+	percentile float64
+}
+
+// SuggestGasFees implements the GasPricer interface
+func (p *FeeHistoryGasPricer) SuggestGasFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	feeHistory, err := p.client.EthFeeHistory(ctx, p.blocks, []float64{p.percentile})
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	if len(feeHistory.BaseFee) == 0 {
+		return nil, nil, common.Wrap(fmt.Errorf("empty fee history"))
+	}
+	baseFee := feeHistory.BaseFee[len(feeHistory.BaseFee)-1]
+	gasTipCap := big.NewInt(0)
+	// Empty blocks have a reward of 0, so they are skipped
+	n := int64(0)
+	for i, rewards := range feeHistory.Reward {
+		if len(rewards) == 0 || (i < len(feeHistory.GasUsedRatio) &&
+			feeHistory.GasUsedRatio[i] == 0) {
+			continue
+		}
+		gasTipCap.Add(gasTipCap, rewards[0])
+		n++
+	}
+	if n > 0 {
+		gasTipCap.Div(gasTipCap, big.NewInt(n))
+	} else {
+		// Without txs in the last blocks, a tip of 1 gwei is enough
+		gasTipCap.SetInt64(params.GWei)
+	}
+	gasFeeCap, gasTipCap := gasFees(baseFee, gasTipCap)
+	return gasFeeCap, gasTipCap, nil
+}
@@ -0,0 +1,153 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"tokamak-sybil-resistance/etherscan"
+
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei))
+}
+
+// newTestRPCServer returns a JSON-RPC server that replies to each method with
+// the given result
+func newTestRPCServer(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result, ok := results[req.Method]
+		require.True(t, ok, req.Method)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) +
+			`,"result":` + result + `}`))
+	}))
+}
+
+func TestEthFeeHistory(t *testing.T) {
+	server := newTestRPCServer(t, map[string]string{
+		"eth_chainId": `"0x539"`,
+		"eth_feeHistory": `{"oldestBlock":"0xa","reward":[["0x3b9aca00"],["0x77359400"]],` +
+			`"baseFeePerGas":["0x4a817c800","0x4a817c800","0x37e11d600"],` +
+			`"gasUsedRatio":[0.5,0.3]}`,
+	})
+	defer server.Close()
+	rpcClient, err := rpc.Dial(server.URL)
+	require.NoError(t, err)
+	client, err := NewEthereumClient(rpcClient, nil, nil)
+	require.NoError(t, err)
+
+	feeHistory, err := client.EthFeeHistory(context.Background(), 2, []float64{50})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), feeHistory.OldestBlock)
+	assert.Equal(t, [][]*big.Int{{gwei(1)}, {gwei(2)}}, feeHistory.Reward)
+	assert.Equal(t, []*big.Int{gwei(20), gwei(20), gwei(15)}, feeHistory.BaseFee)
+	assert.Equal(t, []float64{0.5, 0.3}, feeHistory.GasUsedRatio)
+
+	// The FeeHistory strategy uses the base fee of the next block and the
+	// average of the tips
+	gasPricer, err := NewGasPricer(&GasPricerConfig{Strategy: GasPricerStrategyFeeHistory},
+		client, nil)
+	require.NoError(t, err)
+	gasFeeCap, gasTipCap, err := gasPricer.SuggestGasFees(context.Background())
+	require.NoError(t, err)
+	// tip = (1 + 2) / 2, feeCap = 2 * 15 + tip
+	assert.Equal(t, big.NewInt(1500000000), gasTipCap)
+	assert.Equal(t, big.NewInt(31500000000), gasFeeCap)
+}
+
+func TestEtherscanGasPricer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":{` +
+			`"LastBlock":"100","SafeGasPrice":"21","ProposeGasPrice":"22.5",` +
+			`"FastGasPrice":"23","suggestBaseFee":"20.123456789123"}}`))
+	}))
+	defer server.Close()
+	service, err := etherscan.NewEtherscanService(server.URL, "key")
+	require.NoError(t, err)
+
+	_, err = NewGasPricer(&GasPricerConfig{Strategy: GasPricerStrategyEtherscan}, nil, nil)
+	assert.Error(t, err)
+	gasPricer, err := NewGasPricer(&GasPricerConfig{Strategy: GasPricerStrategyEtherscan},
+		nil, service)
+	require.NoError(t, err)
+	gasFeeCap, gasTipCap, err := gasPricer.SuggestGasFees(context.Background())
+	require.NoError(t, err)
+	// tip = 22.5 - 20.123456789, feeCap = 2 * 20.123456789 + tip
+	assert.Equal(t, big.NewInt(2376543211), gasTipCap)
+	assert.Equal(t, big.NewInt(42623456789), gasFeeCap)
+}
+
+func TestParseGwei(t *testing.T) {
+	for s, wei := range map[string]int64{
+		"1":            1000000000,
+		"0.5":          500000000,
+		"12.0000001":   12000000100,
+		"3.123456789":  3123456789,
+		"0.0000000019": 1,
+	} {
+		v, err := parseGwei(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, big.NewInt(wei), v, s)
+	}
+	for _, s := range []string{"", ".5", "-1", "1e9", "1.2.3"} {
+		_, err := parseGwei(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// testHeader returns the JSON of a block header with the given base fee
+func testHeader(baseFee *big.Int) string {
+	zero := `"0x0000000000000000000000000000000000000000000000000000000000000000"`
+	return `{"parentHash":` + zero + `,"sha3Uncles":` + zero +
+		`,"miner":"0x0000000000000000000000000000000000000000","stateRoot":` + zero +
+		`,"transactionsRoot":` + zero + `,"receiptsRoot":` + zero +
+		`,"logsBloom":"0x` + strings.Repeat("00", 256) + `","difficulty":"0x0"` +
+		`,"number":"0x1","gasLimit":"0x0","gasUsed":"0x0","timestamp":"0x0"` +
+		`,"extraData":"0x","mixHash":` + zero + `,"nonce":"0x0000000000000000"` +
+		`,"baseFeePerGas":"0x` + baseFee.Text(16) + `"}`
+}
+
+func TestNewAuthGasPricer(t *testing.T) {
+	server := newTestRPCServer(t, map[string]string{
+		"eth_chainId":              `"0x539"`,
+		"eth_getBlockByNumber":     testHeader(gwei(19)),
+		"eth_maxPriorityFeePerGas": `"0x3b9aca00"`,
+	})
+	defer server.Close()
+	rpcClient, err := rpc.Dial(server.URL)
+	require.NoError(t, err)
+	key, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	signer, err := NewHSMSigner(key)
+	require.NoError(t, err)
+
+	// The Etherscan gas pricer requires etherscan
+	_, err = NewEthereumClient(rpcClient, signer,
+		&EthereumConfig{GasPricer: GasPricerConfig{Strategy: GasPricerStrategyEtherscan}})
+	assert.Error(t, err)
+
+	// The Node gas pricer is used by default
+	client, err := NewEthereumClient(rpcClient, signer, &EthereumConfig{})
+	require.NoError(t, err)
+	auth, err := client.NewAuth()
+	require.NoError(t, err)
+	// tip = 1, feeCap = 2 * 19 + tip
+	assert.Equal(t, gwei(39), auth.GasFeeCap)
+	assert.Equal(t, gwei(1), auth.GasTipCap)
+}
@@ -0,0 +1,631 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/log"
+	"tokamak-sybil-resistance/metric"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+)
+
+const (
+	defaultMultiClientAttempts           = 3
+	defaultMultiClientAttemptsDelay      = 500 * time.Millisecond
+	defaultMultiClientMaxAttemptsDelay   = 10 * time.Second
+	defaultMultiClientFailureThreshold   = 3
+	defaultMultiClientCircuitOpenTimeout = 30 * time.Second
+	defaultMultiClientMaxBlockLag        = 5
+	defaultMultiClientHealthInterval     = 15 * time.Second
+)
+
+// MultiClientConfig is the configuration of a MultiClient.  The zero values
+// are replaced by defaults.
+type MultiClientConfig struct {
+	// Attempts is the number of attempts of an idempotent call, each one
+	// to the next available endpoint
+	Attempts int
+	// AttemptsDelay is the delay before the second attempt of a call,
+	// which doubles in each further attempt up to MaxAttemptsDelay
+	AttemptsDelay    time.Duration
+	MaxAttemptsDelay time.Duration
+	// FailureThreshold is the number of consecutive failed calls to an
+	// endpoint after which its circuit is opened
+	FailureThreshold int
+	// CircuitOpenTimeout is the time an endpoint with an open circuit is
+	// not used.  After it, the endpoint is used again, and a single failed
+	// call opens the circuit again.
+	CircuitOpenTimeout time.Duration
+	// MaxBlockLag is the number of blocks an endpoint can be behind the
+	// most advanced one before it stops being used
+	MaxBlockLag int64
+	// HealthCheckInterval is the interval between checks of the block
+	// height of the endpoints
+	HealthCheckInterval time.Duration
+}
+
+func (cfg *MultiClientConfig) setDefaults() {
+	if cfg.Attempts == 0 {
+		cfg.Attempts = defaultMultiClientAttempts
+	}
+	if cfg.AttemptsDelay == 0 {
+		cfg.AttemptsDelay = defaultMultiClientAttemptsDelay
+	}
+	if cfg.MaxAttemptsDelay == 0 {
+		cfg.MaxAttemptsDelay = defaultMultiClientMaxAttemptsDelay
+	}
+	if cfg.FailureThreshold == 0 {
+		cfg.FailureThreshold = defaultMultiClientFailureThreshold
+	}
+	if cfg.CircuitOpenTimeout == 0 {
+		cfg.CircuitOpenTimeout = defaultMultiClientCircuitOpenTimeout
+	}
+	if cfg.MaxBlockLag == 0 {
+		cfg.MaxBlockLag = defaultMultiClientMaxBlockLag
+	}
+	if cfg.HealthCheckInterval == 0 {
+		cfg.HealthCheckInterval = defaultMultiClientHealthInterval
+	}
+}
+
+// Endpoint is an ethereum node used by a MultiClient
+type Endpoint struct {
+	// Name identifies the endpoint in logs and metrics, so it mustn't
+	// contain secrets like the API keys in the URLs of some providers
+	Name   string
+	Client ClientInterface
+}
+
+type endpoint struct {
+	Endpoint
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	lagging   bool
+}
+
+// MultiClient is a ClientInterface over several ethereum nodes.  The calls go
+// to the available endpoints in round robin, where an endpoint is not
+// available while its circuit is open after failing repeatedly, or while it's
+// lagging behind the others in block height.  Idempotent calls are retried
+// with backoff in the next endpoint when they fail because of the endpoint;
+// the calls that send transactions are made once, and the errors returned by
+// the node itself (like a reverted call) are never retried.
+type MultiClient struct {
+	cfg       MultiClientConfig
+	endpoints []*endpoint
+	next      uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMultiClient creates a MultiClient over the endpoints
+func NewMultiClient(endpoints []Endpoint, cfg MultiClientConfig) (*MultiClient, error) {
+	if len(endpoints) == 0 {
+		return nil, common.Wrap(fmt.Errorf("no ethereum node endpoints"))
+	}
+	cfg.setDefaults()
+	c := &MultiClient{cfg: cfg}
+	for _, e := range endpoints {
+		c.endpoints = append(c.endpoints, &endpoint{Endpoint: e})
+		metric.EthEndpointCircuitOpen.WithLabelValues(e.Name).Set(0)
+	}
+	return c, nil
+}
+
+// EndpointName returns the name of the endpoint at rawURL to be used in logs
+// and metrics, which is its host
+func EndpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+	return u.Host
+}
+
+// DialMultiClient creates a MultiClient over the ethereum nodes at the urls.
+// The nodes that can't be dialed are left out with a warning, and all the
+// nodes must be in the same chain.
+func DialMultiClient(urls []string, signer Signer, clientCfg *ClientConfig,
+	cfg MultiClientConfig) (*MultiClient, error) {
+	var endpoints []Endpoint
+	var chainID *big.Int
+	names := make(map[string]int)
+	for _, rawURL := range urls {
+		name := EndpointName(rawURL)
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%v#%v", name, names[name])
+		}
+		rpcClient, err := rpc.Dial(rawURL)
+		if err != nil {
+			log.Warnw("MultiClient: ethereum node not available", "endpoint", name, "err", err)
+			continue
+		}
+		client, err := NewClient(rpcClient, signer, clientCfg)
+		if err != nil {
+			log.Warnw("MultiClient: ethereum node not available", "endpoint", name, "err", err)
+			continue
+		}
+		if chainID == nil {
+			chainID = client.EthereumClient.chainID
+		} else if client.EthereumClient.chainID.Cmp(chainID) != 0 {
+			return nil, common.Wrap(fmt.Errorf("ethereum node %v is in chain %v instead of %v",
+				name, client.EthereumClient.chainID, chainID))
+		}
+		endpoints = append(endpoints, Endpoint{Name: name, Client: client})
+	}
+	return NewMultiClient(endpoints, cfg)
+}
+
+// Start checks the block height of the endpoints periodically until Stop is
+// called
+func (c *MultiClient) Start() {
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			c.CheckEndpoints()
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(c.cfg.HealthCheckInterval):
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks of the endpoints
+func (c *MultiClient) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	c.wg.Wait()
+}
+
+// CheckEndpoints updates the block height of the endpoints, and marks as
+// lagging those that are more than MaxBlockLag blocks behind the most advanced
+// one
+func (c *MultiClient) CheckEndpoints() {
+	lastBlocks := make([]int64, len(c.endpoints))
+	errs := make([]error, len(c.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range c.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			lastBlocks[i], errs[i] = callEndpoint(e, "EthLastBlock", &c.cfg,
+				func(client ClientInterface) (int64, error) {
+					return client.EthLastBlock()
+				})
+		}(i, e)
+	}
+	wg.Wait()
+	best := int64(-1)
+	for i := range c.endpoints {
+		if errs[i] == nil && lastBlocks[i] > best {
+			best = lastBlocks[i]
+		}
+	}
+	for i, e := range c.endpoints {
+		if errs[i] != nil {
+			log.Warnw("MultiClient: endpoint check failed", "endpoint", e.Name, "err", errs[i])
+			continue
+		}
+		lag := best - lastBlocks[i]
+		metric.EthEndpointBlockLag.WithLabelValues(e.Name).Set(float64(lag))
+		lagging := lag > c.cfg.MaxBlockLag
+		e.mutex.Lock()
+		if lagging != e.lagging {
+			log.Warnw("MultiClient: endpoint lagging state changed", "endpoint", e.Name,
+				"lagging", lagging, "lag", lag)
+		}
+		e.lagging = lagging
+		e.mutex.Unlock()
+	}
+}
+
+// available returns true if the endpoint can be used: its circuit is not open
+// and it's not lagging
+func (e *endpoint) available(now time.Time) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return !e.lagging && !now.Before(e.openUntil)
+}
+
+// record updates the circuit of the endpoint with the result of a call
+func (e *endpoint) record(failed bool, cfg *MultiClientConfig) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !failed {
+		if e.failures >= cfg.FailureThreshold {
+			log.Infow("MultiClient: endpoint circuit closed", "endpoint", e.Name)
+			metric.EthEndpointCircuitOpen.WithLabelValues(e.Name).Set(0)
+		}
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= cfg.FailureThreshold {
+		log.Warnw("MultiClient: endpoint circuit opened", "endpoint", e.Name,
+			"failures", e.failures, "timeout", cfg.CircuitOpenTimeout)
+		metric.EthEndpointCircuitOpen.WithLabelValues(e.Name).Set(1)
+		e.openUntil = time.Now().Add(cfg.CircuitOpenTimeout)
+	}
+}
+
+// nextEndpoint returns the next available endpoint in round robin, or the
+// next one if none is available
+func (c *MultiClient) nextEndpoint() *endpoint {
+	start := atomic.AddUint64(&c.next, 1) - 1
+	n := uint64(len(c.endpoints))
+	now := time.Now()
+	for i := uint64(0); i < n; i++ {
+		if e := c.endpoints[(start+i)%n]; e.available(now) {
+			if i > 0 {
+				// Skip the unavailable endpoints in the next call
+				atomic.AddUint64(&c.next, i)
+			}
+			return e
+		}
+	}
+	return c.endpoints[start%n]
+}
+
+// isEndpointError returns true if the error of a call is caused by the
+// endpoint not answering properly, and not by the node answering an error
+func isEndpointError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	var httpErr rpc.HTTPError
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode >= http.StatusInternalServerError ||
+			httpErr.StatusCode == http.StatusTooManyRequests
+	case errors.As(err, &netErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	return false
+}
+
+// callEndpoint makes a call to an endpoint, recording its result in the
+// metrics and the circuit of the endpoint
+func callEndpoint[T any](e *endpoint, method string, cfg *MultiClientConfig,
+	fn func(ClientInterface) (T, error)) (T, error) {
+	start := time.Now()
+	res, err := fn(e.Client)
+	metric.EthEndpointLatency.WithLabelValues(e.Name).Observe(time.Since(start).Seconds())
+	metric.EthEndpointRequests.WithLabelValues(e.Name, method).Inc()
+	failed := isEndpointError(err)
+	if failed {
+		metric.EthEndpointErrors.WithLabelValues(e.Name, method).Inc()
+	}
+	e.record(failed, cfg)
+	return res, err
+}
+
+// multiCall makes a call in the next available endpoint.  Idempotent calls
+// that fail because of the endpoint are retried in the next endpoints with
+// backoff.
+func multiCall[T any](ctx context.Context, c *MultiClient, method string, idempotent bool,
+	fn func(ClientInterface) (T, error)) (T, error) {
+	attempts := 1
+	if idempotent {
+		attempts = c.cfg.Attempts
+	}
+	delay := c.cfg.AttemptsDelay
+	var res T
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return res, common.Wrap(fmt.Errorf("%v: %w (last error: %v)", method,
+					ctx.Err(), err))
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > c.cfg.MaxAttemptsDelay {
+				delay = c.cfg.MaxAttemptsDelay
+			}
+		}
+		e := c.nextEndpoint()
+		res, err = callEndpoint(e, method, &c.cfg, fn)
+		if !isEndpointError(err) || ctx.Err() != nil {
+			return res, err
+		}
+		log.Warnw("MultiClient: endpoint call failed", "endpoint", e.Name,
+			"method", method, "attempt", attempt, "err", err)
+	}
+	return res, common.Wrap(fmt.Errorf("%v failed in %v attempts: %w", method, attempts, err))
+}
+
+//
+// Ethereum
+//
+
+// EthLastBlock implements the EthereumInterface
+func (c *MultiClient) EthLastBlock() (int64, error) {
+	return multiCall(context.Background(), c, "EthLastBlock", true,
+		func(client ClientInterface) (int64, error) {
+			return client.EthLastBlock()
+		})
+}
+
+// EthBlockByNumber implements the EthereumInterface
+func (c *MultiClient) EthBlockByNumber(ctx context.Context, number int64) (*common.Block, error) {
+	return multiCall(ctx, c, "EthBlockByNumber", true,
+		func(client ClientInterface) (*common.Block, error) {
+			return client.EthBlockByNumber(ctx, number)
+		})
+}
+
+// EthAddress implements the EthereumInterface.  The account is the same in
+// all the endpoints.
+func (c *MultiClient) EthAddress() (*ethCommon.Address, error) {
+	return c.endpoints[0].Client.EthAddress()
+}
+
+// EthTransactionReceipt implements the EthereumInterface
+func (c *MultiClient) EthTransactionReceipt(ctx context.Context,
+	txHash ethCommon.Hash) (*types.Receipt, error) {
+	return multiCall(ctx, c, "EthTransactionReceipt", true,
+		func(client ClientInterface) (*types.Receipt, error) {
+			return client.EthTransactionReceipt(ctx, txHash)
+		})
+}
+
+// EthChainID implements the EthereumInterface
+func (c *MultiClient) EthChainID() (*big.Int, error) {
+	return multiCall(context.Background(), c, "EthChainID", true,
+		func(client ClientInterface) (*big.Int, error) {
+			return client.EthChainID()
+		})
+}
+
+// EthPendingNonceAt implements the EthereumInterface
+func (c *MultiClient) EthPendingNonceAt(ctx context.Context,
+	account ethCommon.Address) (uint64, error) {
+	return multiCall(ctx, c, "EthPendingNonceAt", true,
+		func(client ClientInterface) (uint64, error) {
+			return client.EthPendingNonceAt(ctx, account)
+		})
+}
+
+// EthNonceAt implements the EthereumInterface
+func (c *MultiClient) EthNonceAt(ctx context.Context, account ethCommon.Address,
+	blockNumber *big.Int) (uint64, error) {
+	return multiCall(ctx, c, "EthNonceAt", true,
+		func(client ClientInterface) (uint64, error) {
+			return client.EthNonceAt(ctx, account, blockNumber)
+		})
+}
+
+// EthSuggestGasPrice implements the EthereumInterface
+func (c *MultiClient) EthSuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return multiCall(ctx, c, "EthSuggestGasPrice", true,
+		func(client ClientInterface) (*big.Int, error) {
+			return client.EthSuggestGasPrice(ctx)
+		})
+}
+
+// EthSuggestGasTipCap implements the EthereumInterface
+func (c *MultiClient) EthSuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return multiCall(ctx, c, "EthSuggestGasTipCap", true,
+		func(client ClientInterface) (*big.Int, error) {
+			return client.EthSuggestGasTipCap(ctx)
+		})
+}
+
+// EthFeeHistory implements the EthereumInterface
+func (c *MultiClient) EthFeeHistory(ctx context.Context, blockCount int,
+	rewardPercentiles []float64) (*FeeHistory, error) {
+	return multiCall(ctx, c, "EthFeeHistory", true,
+		func(client ClientInterface) (*FeeHistory, error) {
+			return client.EthFeeHistory(ctx, blockCount, rewardPercentiles)
+		})
+}
+
+// EthSigner implements the EthereumInterface.  The signer is the same in all
+// the endpoints.
+func (c *MultiClient) EthSigner() Signer {
+	return c.endpoints[0].Client.EthSigner()
+}
+
+// EthCall implements the EthereumInterface
+func (c *MultiClient) EthCall(ctx context.Context, tx *types.Transaction,
+	blockNum *big.Int) ([]byte, error) {
+	return multiCall(ctx, c, "EthCall", true,
+		func(client ClientInterface) ([]byte, error) {
+			return client.EthCall(ctx, tx, blockNum)
+		})
+}
+
+//
+// Rollup
+//
+
+// RollupForgeBatch implements the RollupInterface.  It's not retried.
+func (c *MultiClient) RollupForgeBatch(args *RollupForgeBatchArgs,
+	auth *bind.TransactOpts) (*types.Transaction, error) {
+	return multiCall(context.Background(), c, "RollupForgeBatch", false,
+		func(client ClientInterface) (*types.Transaction, error) {
+			return client.RollupForgeBatch(args, auth)
+		})
+}
+
+// RollupL1UserTxCreateAccountDeposit implements the RollupInterface.  It's not
+// retried.
+func (c *MultiClient) RollupL1UserTxCreateAccountDeposit(fromBJJ babyjub.PublicKeyComp,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return multiCall(context.Background(), c, "RollupL1UserTxCreateAccountDeposit", false,
+		func(client ClientInterface) (*types.Transaction, error) {
+			return client.RollupL1UserTxCreateAccountDeposit(fromBJJ, loadAmount)
+		})
+}
+
+// RollupL1UserTxDeposit implements the RollupInterface.  It's not retried.
+func (c *MultiClient) RollupL1UserTxDeposit(fromIdx int64,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return multiCall(context.Background(), c, "RollupL1UserTxDeposit", false,
+		func(client ClientInterface) (*types.Transaction, error) {
+			return client.RollupL1UserTxDeposit(fromIdx, loadAmount)
+		})
+}
+
+// RollupL1UserTxForceExit implements the RollupInterface.  It's not retried.
+func (c *MultiClient) RollupL1UserTxForceExit(fromIdx int64,
+	amount *big.Int) (*types.Transaction, error) {
+	return multiCall(context.Background(), c, "RollupL1UserTxForceExit", false,
+		func(client ClientInterface) (*types.Transaction, error) {
+			return client.RollupL1UserTxForceExit(fromIdx, amount)
+		})
+}
+
+// RollupL1UserTxForceExplode implements the RollupInterface.  It's not
+// retried.
+func (c *MultiClient) RollupL1UserTxForceExplode(fromIdx int64) (*types.Transaction, error) {
+	return multiCall(context.Background(), c, "RollupL1UserTxForceExplode", false,
+		func(client ClientInterface) (*types.Transaction, error) {
+			return client.RollupL1UserTxForceExplode(fromIdx)
+		})
+}
+
+// RollupLastForgedBatch implements the RollupInterface
+func (c *MultiClient) RollupLastForgedBatch() (int64, error) {
+	return multiCall(context.Background(), c, "RollupLastForgedBatch", true,
+		func(client ClientInterface) (int64, error) {
+			return client.RollupLastForgedBatch()
+		})
+}
+
+// RollupNextL1ToForgeQueue implements the RollupInterface
+func (c *MultiClient) RollupNextL1ToForgeQueue(blockNum int64) (int64, error) {
+	return multiCall(context.Background(), c, "RollupNextL1ToForgeQueue", true,
+		func(client ClientInterface) (int64, error) {
+			return client.RollupNextL1ToForgeQueue(blockNum)
+		})
+}
+
+// RollupNextL1FillingQueue implements the RollupInterface
+func (c *MultiClient) RollupNextL1FillingQueue(blockNum int64) (int64, error) {
+	return multiCall(context.Background(), c, "RollupNextL1FillingQueue", true,
+		func(client ClientInterface) (int64, error) {
+			return client.RollupNextL1FillingQueue(blockNum)
+		})
+}
+
+// RollupLastL1L2Batch implements the RollupInterface
+func (c *MultiClient) RollupLastL1L2Batch(blockNum int64) (int64, error) {
+	return multiCall(context.Background(), c, "RollupLastL1L2Batch", true,
+		func(client ClientInterface) (int64, error) {
+			return client.RollupLastL1L2Batch(blockNum)
+		})
+}
+
+// RollupL1TransactionQueue implements the RollupInterface
+func (c *MultiClient) RollupL1TransactionQueue(queueIndex, blockNum int64) ([]byte, error) {
+	return multiCall(context.Background(), c, "RollupL1TransactionQueue", true,
+		func(client ClientInterface) ([]byte, error) {
+			return client.RollupL1TransactionQueue(queueIndex, blockNum)
+		})
+}
+
+// RollupVerifiers implements the RollupInterface
+func (c *MultiClient) RollupVerifiers(blockNum int64) ([]common.RollupVerifierStruct, error) {
+	return multiCall(context.Background(), c, "RollupVerifiers", true,
+		func(client ClientInterface) ([]common.RollupVerifierStruct, error) {
+			return client.RollupVerifiers(blockNum)
+		})
+}
+
+// RollupOwner implements the RollupInterface
+func (c *MultiClient) RollupOwner(blockNum int64) (*ethCommon.Address, error) {
+	return multiCall(context.Background(), c, "RollupOwner", true,
+		func(client ClientInterface) (*ethCommon.Address, error) {
+			return client.RollupOwner(blockNum)
+		})
+}
+
+// RollupBatchRoots implements the RollupInterface
+func (c *MultiClient) RollupBatchRoots(batchNum int64) (*RollupBatchRoots, error) {
+	return multiCall(context.Background(), c, "RollupBatchRoots", true,
+		func(client ClientInterface) (*RollupBatchRoots, error) {
+			return client.RollupBatchRoots(batchNum)
+		})
+}
+
+// RollupConstants implements the RollupInterface
+func (c *MultiClient) RollupConstants() (*common.RollupConstants, error) {
+	return multiCall(context.Background(), c, "RollupConstants", true,
+		func(client ClientInterface) (*common.RollupConstants, error) {
+			return client.RollupConstants()
+		})
+}
+
+// RollupEventsByBlock implements the RollupInterface
+func (c *MultiClient) RollupEventsByBlock(blockNum int64,
+	blockHash *ethCommon.Hash) (*RollupEvents, error) {
+	return multiCall(context.Background(), c, "RollupEventsByBlock", true,
+		func(client ClientInterface) (*RollupEvents, error) {
+			return client.RollupEventsByBlock(blockNum, blockHash)
+		})
+}
+
+// RollupEventBlocks implements the RollupInterface
+func (c *MultiClient) RollupEventBlocks(fromBlock, toBlock int64) ([]int64, error) {
+	return multiCall(context.Background(), c, "RollupEventBlocks", true,
+		func(client ClientInterface) ([]int64, error) {
+			return client.RollupEventBlocks(fromBlock, toBlock)
+		})
+}
+
+// RollupForgeBatchArgs implements the RollupInterface
+func (c *MultiClient) RollupForgeBatchArgs(ethTxHash ethCommon.Hash,
+	l1UserTxsLen uint16) (*RollupForgeBatchArgs, *ethCommon.Address, error) {
+	type result struct {
+		args   *RollupForgeBatchArgs
+		sender *ethCommon.Address
+	}
+	res, err := multiCall(context.Background(), c, "RollupForgeBatchArgs", true,
+		func(client ClientInterface) (result, error) {
+			args, sender, err := client.RollupForgeBatchArgs(ethTxHash, l1UserTxsLen)
+			return result{args: args, sender: sender}, err
+		})
+	return res.args, res.sender, err
+}
+
+// RollupEventInit implements the RollupInterface
+func (c *MultiClient) RollupEventInit(genesisBlockNum int64) (*RollupEventInitialize,
+	int64, error) {
+	type result struct {
+		event    *RollupEventInitialize
+		blockNum int64
+	}
+	res, err := multiCall(context.Background(), c, "RollupEventInit", true,
+		func(client ClientInterface) (result, error) {
+			event, blockNum, err := client.RollupEventInit(genesisBlockNum)
+			return result{event: event, blockNum: blockNum}, err
+		})
+	return res.event, res.blockNum, err
+}
@@ -0,0 +1,203 @@
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestConnRefused = &net.OpError{Op: "dial", Net: "tcp",
+	Err: errors.New("connection refused")}
+
+// fakeClient is an endpoint of a MultiClient that returns err in the calls,
+// or lastBlock if it's nil
+type fakeClient struct {
+	ClientInterface
+	mutex     sync.Mutex
+	calls     int
+	lastBlock int64
+	err       error
+}
+
+func (c *fakeClient) call() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.calls++
+	return c.err
+}
+
+func (c *fakeClient) numCalls() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.calls
+}
+
+func (c *fakeClient) EthLastBlock() (int64, error) {
+	if err := c.call(); err != nil {
+		return 0, err
+	}
+	return c.lastBlock, nil
+}
+
+func (c *fakeClient) EthChainID() (*big.Int, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	return big.NewInt(1), nil
+}
+
+func (c *fakeClient) RollupForgeBatch(args *RollupForgeBatchArgs,
+	auth *bind.TransactOpts) (*types.Transaction, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	return types.NewTx(&types.DynamicFeeTx{}), nil
+}
+
+func newTestMultiClient(t *testing.T, cfg MultiClientConfig,
+	clients ...*fakeClient) *MultiClient {
+	endpoints := make([]Endpoint, len(clients))
+	for i, client := range clients {
+		endpoints[i] = Endpoint{Name: fmt.Sprintf("node%v", i), Client: client}
+	}
+	c, err := NewMultiClient(endpoints, cfg)
+	require.NoError(t, err)
+	return c
+}
+
+func TestMultiClientRoundRobin(t *testing.T) {
+	a, b := &fakeClient{}, &fakeClient{}
+	c := newTestMultiClient(t, MultiClientConfig{}, a, b)
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, a.numCalls())
+	assert.Equal(t, 2, b.numCalls())
+
+	_, err := NewMultiClient(nil, MultiClientConfig{})
+	assert.Error(t, err)
+}
+
+func TestMultiClientRetries(t *testing.T) {
+	cfg := MultiClientConfig{Attempts: 3, AttemptsDelay: time.Millisecond}
+	a, b := &fakeClient{err: errTestConnRefused}, &fakeClient{lastBlock: 10}
+	c := newTestMultiClient(t, cfg, a, b)
+
+	// The failed call to a is retried in b
+	lastBlock, err := c.EthLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), lastBlock)
+	assert.Equal(t, 1, a.numCalls())
+	assert.Equal(t, 1, b.numCalls())
+
+	// The errors returned by the node are not retried
+	b.err = errors.New("execution reverted")
+	_, err = c.EthLastBlock()
+	assert.Equal(t, b.err, err)
+	assert.Equal(t, 2, b.numCalls())
+
+	// The transactions are sent once
+	a.calls, b.calls, b.err = 0, 0, nil
+	c.next = 0
+	_, err = c.RollupForgeBatch(&RollupForgeBatchArgs{}, nil)
+	assert.ErrorIs(t, err, errTestConnRefused)
+	assert.Equal(t, 1, a.numCalls())
+	assert.Equal(t, 0, b.numCalls())
+
+	// Calls fail after the attempts
+	b.err = rpc.HTTPError{StatusCode: http.StatusServiceUnavailable}
+	a.calls, b.calls = 0, 0
+	_, err = c.EthLastBlock()
+	var httpErr rpc.HTTPError
+	assert.True(t, errors.As(err, &httpErr) || errors.Is(err, errTestConnRefused), err)
+	assert.Equal(t, 3, a.numCalls()+b.numCalls())
+}
+
+func TestMultiClientCircuitBreaker(t *testing.T) {
+	cfg := MultiClientConfig{
+		Attempts:           2,
+		AttemptsDelay:      time.Millisecond,
+		FailureThreshold:   2,
+		CircuitOpenTimeout: 50 * time.Millisecond,
+	}
+	a, b := &fakeClient{err: errTestConnRefused}, &fakeClient{}
+	c := newTestMultiClient(t, cfg, a, b)
+
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	// After failing twice, the circuit of a is open
+	assert.Equal(t, 2, a.numCalls())
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, a.numCalls())
+
+	// After the timeout a is tried again, and a single failure opens the
+	// circuit again
+	time.Sleep(cfg.CircuitOpenTimeout)
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, a.numCalls())
+
+	// A success closes it
+	time.Sleep(cfg.CircuitOpenTimeout)
+	a.err = nil
+	a.calls = 0
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, a.numCalls())
+}
+
+func TestMultiClientLaggingEndpoint(t *testing.T) {
+	a, b, d := &fakeClient{lastBlock: 100}, &fakeClient{lastBlock: 90},
+		&fakeClient{err: errTestConnRefused}
+	c := newTestMultiClient(t, MultiClientConfig{MaxBlockLag: 5, FailureThreshold: 1}, a, b, d)
+	c.CheckEndpoints()
+	a.calls, b.calls = 0, 0
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 4, a.numCalls())
+	assert.Equal(t, 0, b.numCalls())
+	assert.Equal(t, 1, d.numCalls())
+
+	// Once it catches up, it's used again
+	b.lastBlock = 98
+	c.CheckEndpoints()
+	a.calls, b.calls = 0, 0
+	for i := 0; i < 4; i++ {
+		_, err := c.EthChainID()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, b.numCalls())
+}
+
+func TestIsEndpointError(t *testing.T) {
+	assert.False(t, isEndpointError(nil))
+	assert.False(t, isEndpointError(errors.New("nonce too low")))
+	assert.False(t, isEndpointError(rpc.HTTPError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isEndpointError(rpc.HTTPError{StatusCode: http.StatusBadGateway}))
+	assert.True(t, isEndpointError(rpc.HTTPError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, isEndpointError(fmt.Errorf("call: %w", errTestConnRefused)))
+	assert.Equal(t, "mainnet.infura.io", EndpointName("https://mainnet.infura.io/v3/secret"))
+}
@@ -0,0 +1,227 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/log"
+	"tokamak-sybil-resistance/metric"
+
+	"github.com/ethereum/go-ethereum"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	defaultNotifierReconnectDelay    = 1 * time.Second
+	defaultNotifierMaxReconnectDelay = 1 * time.Minute
+	// notifierChanSize is the buffer of the subscription channels, so that
+	// the subscriptions aren't dropped by the node during a burst of
+	// blocks or logs
+	notifierChanSize = 128
+)
+
+// BlockNotifierConfig is the configuration of a BlockNotifier.  The zero
+// values of the delays are replaced by defaults.
+type BlockNotifierConfig struct {
+	// URL is the URL of the websocket RPC server of the ethereum node
+	URL string
+	// Addresses are the contracts whose logs are subscribed
+	Addresses []ethCommon.Address
+	// ReconnectDelay is the delay before reconnecting a lost subscription,
+	// which doubles on each failed attempt up to MaxReconnectDelay
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+func (cfg *BlockNotifierConfig) setDefaults() {
+	if cfg.ReconnectDelay == 0 {
+		cfg.ReconnectDelay = defaultNotifierReconnectDelay
+	}
+	if cfg.MaxReconnectDelay == 0 {
+		cfg.MaxReconnectDelay = defaultNotifierMaxReconnectDelay
+	}
+}
+
+// notifierClient is the part of ethclient.Client used by the BlockNotifier
+type notifierClient interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery,
+		ch chan<- types.Log) (ethereum.Subscription, error)
+	Close()
+}
+
+// BlockNotifier subscribes to the new heads and to the logs of some contracts
+// in an ethereum node via websocket, and signals in C when a new block may be
+// available.  The notifications only wake up the reader, which must still
+// fetch the blocks in order and check their parents, so a lost, late or
+// repeated notification never breaks the ordering or the reorg detection of
+// the reader.  The notifications of the same block are deduplicated, and the
+// pending ones are coalesced into a single one.  A lost subscription is
+// reconnected with backoff, and meanwhile the reader must keep polling.
+type BlockNotifier struct {
+	cfg       BlockNotifierConfig
+	dial      func(ctx context.Context, url string) (notifierClient, error)
+	c         chan struct{}
+	lastHash  ethCommon.Hash
+	connected int32
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBlockNotifier creates a BlockNotifier.  It doesn't connect to the node
+// until Start is called.
+func NewBlockNotifier(cfg BlockNotifierConfig) (*BlockNotifier, error) {
+	if cfg.URL == "" {
+		return nil, common.Wrap(fmt.Errorf("BlockNotifier: missing websocket URL"))
+	}
+	cfg.setDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BlockNotifier{
+		cfg: cfg,
+		dial: func(ctx context.Context, url string) (notifierClient, error) {
+			return ethclient.DialContext(ctx, url)
+		},
+		c:      make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// C returns the channel that receives a value when a new block may be
+// available
+func (n *BlockNotifier) C() <-chan struct{} {
+	return n.c
+}
+
+// Connected returns true while the subscriptions are active
+func (n *BlockNotifier) Connected() bool {
+	return atomic.LoadInt32(&n.connected) == 1
+}
+
+// Start connects to the node and keeps the subscriptions until Stop is called
+func (n *BlockNotifier) Start() {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.run()
+	}()
+}
+
+// Stop closes the subscriptions
+func (n *BlockNotifier) Stop() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+func (n *BlockNotifier) run() {
+	delay := n.cfg.ReconnectDelay
+	for {
+		start := time.Now()
+		err := n.subscribe()
+		n.setConnected(false)
+		if n.ctx.Err() != nil {
+			return
+		}
+		// A subscription that lasted long enough resets the backoff
+		if time.Since(start) > n.cfg.MaxReconnectDelay {
+			delay = n.cfg.ReconnectDelay
+		}
+		log.Warnw("BlockNotifier: subscription lost, polling until reconnected",
+			"err", err, "reconnectDelay", delay)
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > n.cfg.MaxReconnectDelay {
+			delay = n.cfg.MaxReconnectDelay
+		}
+	}
+}
+
+// subscribe connects to the node and sends the notifications until a
+// subscription fails or the BlockNotifier is stopped
+func (n *BlockNotifier) subscribe() error {
+	client, err := n.dial(n.ctx, n.cfg.URL)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer client.Close()
+	heads := make(chan *types.Header, notifierChanSize)
+	headSub, err := client.SubscribeNewHead(n.ctx, heads)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer headSub.Unsubscribe()
+	logs := make(chan types.Log, notifierChanSize)
+	var logErr <-chan error
+	if len(n.cfg.Addresses) > 0 {
+		logSub, err := client.SubscribeFilterLogs(n.ctx,
+			ethereum.FilterQuery{Addresses: n.cfg.Addresses}, logs)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		defer logSub.Unsubscribe()
+		logErr = logSub.Err()
+	}
+	n.setConnected(true)
+	log.Infow("BlockNotifier: subscribed", "addresses", n.cfg.Addresses)
+	// The blocks mined while disconnected haven't been notified
+	n.notify("reconnect")
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return nil
+		case err := <-headSub.Err():
+			return common.Wrap(fmt.Errorf("new heads subscription: %w", err))
+		case err := <-logErr:
+			return common.Wrap(fmt.Errorf("logs subscription: %w", err))
+		case head := <-heads:
+			n.notifyBlock(head.Hash(), "head")
+		case l := <-logs:
+			if l.Removed {
+				// The block of the log was reorged out
+				n.notify("log")
+			} else {
+				n.notifyBlock(l.BlockHash, "log")
+			}
+		}
+	}
+}
+
+// notifyBlock notifies the block with hash unless it was the last one notified
+func (n *BlockNotifier) notifyBlock(hash ethCommon.Hash, source string) {
+	if hash == n.lastHash {
+		return
+	}
+	n.lastHash = hash
+	n.notify(source)
+}
+
+// notify sends a notification unless there's already one pending
+func (n *BlockNotifier) notify(source string) {
+	select {
+	case n.c <- struct{}{}:
+		metric.EthNotifications.WithLabelValues(source).Inc()
+	default:
+	}
+}
+
+func (n *BlockNotifier) setConnected(connected bool) {
+	if connected {
+		atomic.StoreInt32(&n.connected, 1)
+		metric.EthSubscriptionConnected.Set(1)
+	} else {
+		atomic.StoreInt32(&n.connected, 0)
+		metric.EthSubscriptionConnected.Set(0)
+	}
+}
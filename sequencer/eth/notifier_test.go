@@ -0,0 +1,151 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSubscription struct {
+	err  chan error
+	once sync.Once
+}
+
+func newTestSubscription() *testSubscription {
+	return &testSubscription{err: make(chan error, 1)}
+}
+
+func (s *testSubscription) Unsubscribe() {
+	s.once.Do(func() { close(s.err) })
+}
+
+func (s *testSubscription) Err() <-chan error {
+	return s.err
+}
+
+// testNotifierClient is a stand-in of a websocket ethclient.Client whose
+// subscriptions are fed by the test
+type testNotifierClient struct {
+	heads   chan<- *types.Header
+	logs    chan<- types.Log
+	headSub *testSubscription
+	logSub  *testSubscription
+	query   ethereum.FilterQuery
+}
+
+func (c *testNotifierClient) SubscribeNewHead(ctx context.Context,
+	ch chan<- *types.Header) (ethereum.Subscription, error) {
+	c.heads = ch
+	return c.headSub, nil
+}
+
+func (c *testNotifierClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery,
+	ch chan<- types.Log) (ethereum.Subscription, error) {
+	c.logs, c.query = ch, q
+	return c.logSub, nil
+}
+
+func (c *testNotifierClient) Close() {}
+
+func newTestBlockNotifier(t *testing.T) (*BlockNotifier, chan *testNotifierClient) {
+	rollup := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	n, err := NewBlockNotifier(BlockNotifierConfig{
+		URL:            "ws://localhost:8546",
+		Addresses:      []ethCommon.Address{rollup},
+		ReconnectDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+	clients := make(chan *testNotifierClient, 1)
+	dials := 0
+	n.dial = func(ctx context.Context, url string) (notifierClient, error) {
+		// Every other dial fails, as a node that is restarting
+		dials++
+		if dials%2 == 0 {
+			return nil, fmt.Errorf("connection refused")
+		}
+		client := &testNotifierClient{
+			headSub: newTestSubscription(),
+			logSub:  newTestSubscription(),
+		}
+		clients <- client
+		return client, nil
+	}
+	return n, clients
+}
+
+func waitNotification(t *testing.T, n *BlockNotifier) {
+	select {
+	case <-n.C():
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not received")
+	}
+}
+
+func assertNoNotification(t *testing.T, n *BlockNotifier) {
+	select {
+	case <-n.C():
+		t.Fatal("unexpected notification")
+	default:
+	}
+}
+
+func TestBlockNotifierDedup(t *testing.T) {
+	n, _ := newTestBlockNotifier(t)
+	hash1 := ethCommon.BigToHash(big.NewInt(1))
+	hash2 := ethCommon.BigToHash(big.NewInt(2))
+
+	// The notifications of the same block are sent once
+	n.notifyBlock(hash1, "head")
+	waitNotification(t, n)
+	n.notifyBlock(hash1, "log")
+	assertNoNotification(t, n)
+
+	// The pending notifications are coalesced
+	n.notifyBlock(hash2, "head")
+	n.notify("log")
+	waitNotification(t, n)
+	assertNoNotification(t, n)
+}
+
+func TestBlockNotifierReconnect(t *testing.T) {
+	n, clients := newTestBlockNotifier(t)
+	n.Start()
+	defer n.Stop()
+
+	client := <-clients
+	waitNotification(t, n)
+	assert.True(t, n.Connected())
+	assert.Equal(t, n.cfg.Addresses, client.query.Addresses)
+
+	client.heads <- &types.Header{Number: big.NewInt(1)}
+	waitNotification(t, n)
+	client.logs <- types.Log{BlockHash: ethCommon.BigToHash(big.NewInt(2))}
+	waitNotification(t, n)
+	// A reorged log wakes up the reader even if its block was notified
+	client.logs <- types.Log{BlockHash: ethCommon.BigToHash(big.NewInt(2)), Removed: true}
+	waitNotification(t, n)
+
+	// A lost subscription is reconnected after a failed dial, and the
+	// reader is notified of the blocks that may have been missed
+	client.headSub.err <- fmt.Errorf("websocket: close 1006")
+	client = <-clients
+	waitNotification(t, n)
+	assert.True(t, n.Connected())
+	client.heads <- &types.Header{Number: big.NewInt(2)}
+	waitNotification(t, n)
+
+	client.logSub.err <- fmt.Errorf("websocket: close 1006")
+	<-clients
+	waitNotification(t, n)
+	n.Stop()
+	assert.False(t, n.Connected())
+}
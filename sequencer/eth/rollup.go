@@ -2,9 +2,11 @@ package eth
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"tokamak-sybil-resistance/common"
 	"tokamak-sybil-resistance/eth/contracts/tokamak"
 	"tokamak-sybil-resistance/log"
@@ -13,8 +15,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/iden3/go-iden3-crypto/babyjub"
 )
 
 // QueueStruct is the queue of L1Txs for a batch
@@ -34,7 +39,12 @@ func NewQueueStruct() *QueueStruct {
 // RollupState represents the state of the Rollup in the Smart Contract
 type RollupState struct {
 	StateRoot *big.Int
-	ExitRoots []*big.Int
+	// StateRoots, VouchRoots and ScoreRoots are the roots forged in each
+	// batch, indexed by BatchNum
+	StateRoots []*big.Int
+	VouchRoots []*big.Int
+	ScoreRoots []*big.Int
+	ExitRoots  []*big.Int
 	// ExitNullifierMap       map[[256 / 8]byte]bool
 	ExitNullifierMap       map[int64]map[int64]bool // batchNum -> idx -> bool
 	MapL1TxQueue           map[int64]*QueueStruct
@@ -44,6 +54,15 @@ type RollupState struct {
 	CurrentIdx             int64
 }
 
+// RollupBatchRoots are the roots of the merkle trees forged in a batch, as
+// stored in the stateRootMap, vouchRootMap and scoreRootMap of the Rollup
+// Smart Contract
+type RollupBatchRoots struct {
+	StateRoot *big.Int
+	VouchRoot *big.Int
+	ScoreRoot *big.Int
+}
+
 // RollupEventInitialize is the InitializeHermezEvent event of the
 // Smart Contract
 type RollupEventInitialize struct {
@@ -94,53 +113,27 @@ type RollupEventWithdraw struct {
 	TxHash          ethCommon.Hash // Hash of the transaction that generated this event
 }
 
-// RollupEventUpdateBucketWithdraw is an event of the Rollup Smart Contract
-type RollupEventUpdateBucketWithdraw struct {
-	NumBucket   int
-	BlockStamp  int64 // blockNum
-	Withdrawals *big.Int
-}
-
-type rollupEventUpdateBucketWithdrawAux struct {
-	NumBucket   uint8
-	BlockStamp  *big.Int
-	Withdrawals *big.Int
-}
-
 // RollupEventUpdateWithdrawalDelay is an event of the Rollup Smart Contract
 type RollupEventUpdateWithdrawalDelay struct {
 	NewWithdrawalDelay uint64
 }
 
-// RollupUpdateBucketsParameters are the bucket parameters used in an update
-type RollupUpdateBucketsParameters struct {
-	CeilUSD         *big.Int
-	BlockStamp      *big.Int
-	Withdrawals     *big.Int
-	RateBlocks      *big.Int
-	RateWithdrawals *big.Int
-	MaxWithdrawals  *big.Int
-}
-
-type rollupEventUpdateBucketsParametersAux struct {
-	ArrayBuckets []*big.Int
-}
+// RollupEventSafeMode is an event of the Rollup Smart Contract
+type RollupEventSafeMode struct{}
 
-// RollupEventUpdateBucketsParameters is an event of the Rollup Smart Contract
-type RollupEventUpdateBucketsParameters struct {
-	ArrayBuckets []RollupUpdateBucketsParameters
-	SafeMode     bool
+// RollupEventOwnershipTransferred is an event of the Rollup Smart Contract
+type RollupEventOwnershipTransferred struct {
+	PreviousOwner ethCommon.Address
+	NewOwner      ethCommon.Address
 }
 
-// RollupEventUpdateTokenExchange is an event of the Rollup Smart Contract
-type RollupEventUpdateTokenExchange struct {
-	AddressArray []ethCommon.Address
-	ValueArray   []uint64
+// RollupEventInitialized is an event of the Rollup Smart Contract emitted on
+// each initialization.  A Version greater than 1 is a reinitialization after
+// an upgrade, which may have changed the verifiers.
+type RollupEventInitialized struct {
+	Version uint64
 }
 
-// RollupEventSafeMode is an event of the Rollup Smart Contract
-type RollupEventSafeMode struct{}
-
 // RollupEvents is the list of events in a block of the Rollup Smart Contract
 type RollupEvents struct {
 	L1UserTx                    []RollupEventL1UserTx
@@ -149,10 +142,9 @@ type RollupEvents struct {
 	UpdateFeeAddToken           []RollupEventUpdateFeeAddToken
 	Withdraw                    []RollupEventWithdraw
 	UpdateWithdrawalDelay       []RollupEventUpdateWithdrawalDelay
-	UpdateBucketWithdraw        []RollupEventUpdateBucketWithdraw
-	UpdateBucketsParameters     []RollupEventUpdateBucketsParameters
-	UpdateTokenExchange         []RollupEventUpdateTokenExchange
 	SafeMode                    []RollupEventSafeMode
+	OwnershipTransferred        []RollupEventOwnershipTransferred
+	Initialized                 []RollupEventInitialized
 }
 
 // NewRollupEvents creates an empty RollupEvents with the slices initialized.
@@ -170,6 +162,8 @@ func NewRollupEvents() RollupEvents {
 type RollupForgeBatchArgs struct {
 	NewLastIdx            int64
 	NewStRoot             *big.Int
+	NewVouchRoot          *big.Int
+	NewScoreRoot          *big.Int
 	NewExitRoot           *big.Int
 	L1UserTxs             []common.L1Tx
 	L1CoordinatorTxs      []common.L1Tx
@@ -182,12 +176,16 @@ type RollupForgeBatchArgs struct {
 	ProofA      [2]*big.Int
 	ProofB      [2][2]*big.Int
 	ProofC      [2]*big.Int
+	// Input is the public input of the proof
+	Input *big.Int
 }
 
 // RollupForgeBatchArgsAux are the arguments to the ForgeBatch function in the Rollup Smart Contract
 type rollupForgeBatchArgsAux struct {
 	NewLastIdx             *big.Int
 	NewStRoot              *big.Int
+	NewVouchRoot           *big.Int
+	NewScoreRoot           *big.Int
 	NewExitRoot            *big.Int
 	EncodedL1CoordinatorTx []byte
 	L1L2TxsData            []byte
@@ -198,6 +196,141 @@ type rollupForgeBatchArgsAux struct {
 	ProofA      [2]*big.Int
 	ProofB      [2][2]*big.Int
 	ProofC      [2]*big.Int
+	Input       *big.Int
+}
+
+// RollupL1UserTxArgs are the arguments to the addL1Transaction function in the
+// Rollup Smart Contract
+type RollupL1UserTxArgs struct {
+	// BabyPubKey is the compressed BabyJubJub key in big endian, or the
+	// empty string if the tx doesn't create an account
+	BabyPubKey  string
+	FromIdx     int64
+	LoadAmount  *big.Int
+	LoadAmountF uint64
+	Amount      *big.Int
+	AmountF     uint64
+	ToIdx       int64
+}
+
+// NewRollupL1UserTxArgs builds the arguments of an L1 user tx, checking the
+// encoding of the amounts and the conditions that the Rollup Smart Contract
+// checks, except that fromIdx is not greater than its lastIdx.  A nil amount
+// is taken as 0.
+func NewRollupL1UserTxArgs(fromBJJ babyjub.PublicKeyComp, fromIdx int64,
+	loadAmount, amount *big.Int, toIdx int64) (*RollupL1UserTxArgs, error) {
+	if loadAmount == nil {
+		loadAmount = big.NewInt(0)
+	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	if loadAmount.Cmp(common.RollupConstLimitDepositAmount) >= 0 {
+		return nil, common.Wrap(fmt.Errorf("loadAmount %v exceeds the limit %v",
+			loadAmount, common.RollupConstLimitDepositAmount))
+	}
+	if amount.Cmp(common.RollupConstLimitL2TransferAmount) >= 0 {
+		return nil, common.Wrap(fmt.Errorf("amount %v exceeds the limit %v",
+			amount, common.RollupConstLimitL2TransferAmount))
+	}
+	loadAmountF, err := common.RollupAmountToF(loadAmount)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("loadAmount %v: %w", loadAmount, err))
+	}
+	amountF, err := common.RollupAmountToF(amount)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("amount %v: %w", amount, err))
+	}
+
+	withBJJ := fromBJJ != common.EmptyBJJComp
+	fromUser := fromIdx > common.RollupConstReservedIDx
+	switch {
+	case fromIdx == 0 && toIdx == 0:
+		if !withBJJ || amount.Sign() != 0 {
+			return nil, common.Wrap(fmt.Errorf(
+				"invalid create account tx: a BJJ and no amount are required"))
+		}
+	case toIdx == 0 && fromUser:
+		if withBJJ || amount.Sign() != 0 {
+			return nil, common.Wrap(fmt.Errorf(
+				"invalid deposit tx: no BJJ and no amount are required"))
+		}
+	case toIdx == common.RollupConstExitIDx && fromUser:
+		if withBJJ || loadAmount.Sign() != 0 {
+			return nil, common.Wrap(fmt.Errorf(
+				"invalid force exit tx: no BJJ and no loadAmount are required"))
+		}
+	case toIdx == common.RollupConstExplodeIDx && fromUser:
+		if withBJJ || amount.Sign() != 0 || loadAmount.Sign() != 0 {
+			return nil, common.Wrap(fmt.Errorf(
+				"invalid force explode tx: no BJJ, no amount and no loadAmount are required"))
+		}
+	default:
+		return nil, common.Wrap(fmt.Errorf("invalid tx parameters: fromIdx %v, toIdx %v",
+			fromIdx, toIdx))
+	}
+
+	var babyPubKey string
+	if withBJJ {
+		babyPubKey = string(common.SwapEndianness(fromBJJ[:]))
+	}
+	return &RollupL1UserTxArgs{
+		BabyPubKey:  babyPubKey,
+		FromIdx:     fromIdx,
+		LoadAmount:  loadAmount,
+		LoadAmountF: loadAmountF,
+		Amount:      amount,
+		AmountF:     amountF,
+		ToIdx:       toIdx,
+	}, nil
+}
+
+// rollupL1UserTxIdxBytes is the size of the idxs packed in the L1 user tx
+// queues of the Rollup Smart Contract
+const rollupL1UserTxIdxBytes = 6
+
+// RollupL1UserTxBytes encodes an L1UserTx as it's packed in the L1 user tx
+// queues of the Rollup Smart Contract: [20 bytes] fromEthAddr + [32 bytes]
+// fromBjj-compressed in big endian, only if it's not empty + [6 bytes] fromIdx
+// + [5 bytes] loadAmountF + [5 bytes] amountF + [6 bytes] toIdx.  The tx is
+// not validated.
+//
+// The contract packs an empty fromBjj as an empty string too, so a tx without
+// it takes 42 bytes, but it computes the position of a tx in its queue and
+// the number of txs of a queue assuming 74 bytes per tx.  So the positions of
+// the L1UserTxEvents of a queue with txs without fromBjj (deposits, force
+// exits and force explodes) don't match the order of its txs, which is a bug
+// of the contract and not of the sync.
+func RollupL1UserTxBytes(tx *common.L1Tx) ([]byte, error) {
+	loadAmount, amount := tx.DepositAmount, tx.Amount
+	if loadAmount == nil {
+		loadAmount = big.NewInt(0)
+	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	loadAmountF, err := common.RollupAmountToF(loadAmount)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("loadAmount %v: %w", loadAmount, err))
+	}
+	amountF, err := common.RollupAmountToF(amount)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("amount %v: %w", amount, err))
+	}
+	appendUint := func(b []byte, v uint64, n int) []byte {
+		var vBytes [8]byte
+		binary.BigEndian.PutUint64(vBytes[:], v)
+		return append(b, vBytes[8-n:]...)
+	}
+	b := append([]byte{}, tx.FromEthAddr.Bytes()...)
+	if tx.FromBJJ != common.EmptyBJJComp {
+		b = append(b, common.SwapEndianness(tx.FromBJJ[:])...)
+	}
+	b = appendUint(b, uint64(tx.FromIdx), rollupL1UserTxIdxBytes)
+	b = appendUint(b, loadAmountF, common.Float40BytesLength)
+	b = appendUint(b, amountF, common.Float40BytesLength)
+	b = appendUint(b, uint64(tx.ToIdx), rollupL1UserTxIdxBytes)
+	return b, nil
 }
 
 // TODO: Update interfaces and the functions
@@ -209,7 +342,12 @@ type RollupInterface interface {
 
 	// Public Functions
 
-	// RollupForgeBatch(*RollupForgeBatchArgs, *bind.TransactOpts) (*types.Transaction, error)
+	RollupForgeBatch(*RollupForgeBatchArgs, *bind.TransactOpts) (*types.Transaction, error)
+	RollupL1UserTxCreateAccountDeposit(fromBJJ babyjub.PublicKeyComp,
+		loadAmount *big.Int) (*types.Transaction, error)
+	RollupL1UserTxDeposit(fromIdx int64, loadAmount *big.Int) (*types.Transaction, error)
+	RollupL1UserTxForceExit(fromIdx int64, amount *big.Int) (*types.Transaction, error)
+	RollupL1UserTxForceExplode(fromIdx int64) (*types.Transaction, error)
 
 	// RollupWithdrawMerkleProof(babyPubKey babyjub.PublicKeyComp, tokenID uint32, numExitRoot,
 	// 	idx int64, amount *big.Int, siblings []*big.Int, instantWithdraw bool) (*types.Transaction,
@@ -222,6 +360,13 @@ type RollupInterface interface {
 
 	// Viewers
 	RollupLastForgedBatch() (int64, error)
+	RollupBatchRoots(batchNum int64) (*RollupBatchRoots, error)
+	RollupNextL1ToForgeQueue(blockNum int64) (int64, error)
+	RollupNextL1FillingQueue(blockNum int64) (int64, error)
+	RollupLastL1L2Batch(blockNum int64) (int64, error)
+	RollupL1TransactionQueue(queueIndex, blockNum int64) ([]byte, error)
+	RollupVerifiers(blockNum int64) ([]common.RollupVerifierStruct, error)
+	RollupOwner(blockNum int64) (*ethCommon.Address, error)
 
 	//
 	// Smart Contract Status
@@ -229,6 +374,7 @@ type RollupInterface interface {
 
 	RollupConstants() (*common.RollupConstants, error)
 	RollupEventsByBlock(blockNum int64, blockHash *ethCommon.Hash) (*RollupEvents, error)
+	RollupEventBlocks(fromBlock, toBlock int64) ([]int64, error)
 	RollupForgeBatchArgs(ethCommon.Hash, uint16) (*RollupForgeBatchArgs, *ethCommon.Address, error)
 	RollupEventInit(genesisBlockNum int64) (*RollupEventInitialize, int64, error)
 }
@@ -246,15 +392,39 @@ type RollupClient struct {
 	contractAbi abi.ABI
 	opts        *bind.CallOpts
 	consts      *common.RollupConstants
+	constsLock  *sync.Mutex
+}
+
+// verifier returns the verifier at idx.  A verifier that is not in the
+// constants may have been added by a reinitialization, so the verifiers are
+// read again before failing.
+func (c *RollupClient) verifier(idx uint8) (*common.RollupVerifierStruct, error) {
+	c.constsLock.Lock()
+	defer c.constsLock.Unlock()
+	if int(idx) >= len(c.consts.Verifiers) {
+		verifiers, err := c.RollupVerifiers(-1)
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		c.consts.Verifiers = verifiers
+	}
+	if int(idx) >= len(c.consts.Verifiers) {
+		return nil, common.Wrap(fmt.Errorf("unknown verifier %v", idx))
+	}
+	verifier := c.consts.Verifiers[idx]
+	return &verifier, nil
 }
 
-// RollupVariables returns the RollupVariables from the initialize event
+// RollupVariables returns the RollupVariables from the initialize event.  The
+// storage of the proxy is empty before the initialization, so the owner is the
+// zero address until an OwnershipTransferred event.  The verifiers are not in
+// the event, so they are left empty.
 func (ei *RollupEventInitialize) RollupVariables() *common.RollupVariables {
 	return &common.RollupVariables{
 		EthBlockNum:           0,
 		ForgeL1L2BatchTimeout: int64(ei.ForgeL1L2BatchTimeout),
-		Buckets:               []common.BucketParams{},
 		SafeMode:              false,
+		Owner:                 &ethCommon.Address{},
 	}
 }
 
@@ -273,15 +443,15 @@ func (c *RollupClient) RollupEventInit(genesisBlockNum int64) (*RollupEventIniti
 		return nil, 0, common.Wrap(err)
 	}
 	if len(logs) != 1 {
-		return nil, 0, common.Wrap(fmt.Errorf("no event of type InitializeSYBEvent found"))
+		return nil, 0, common.Wrap(fmt.Errorf("no event of type Initialize found"))
 	}
 	vLog := logs[0]
 	if vLog.Topics[0] != logSYBInitialize {
-		return nil, 0, common.Wrap(fmt.Errorf("event is not InitializeSYBEvent"))
+		return nil, 0, common.Wrap(fmt.Errorf("event is not Initialize"))
 	}
 
 	var rollupInit RollupEventInitialize
-	if err := c.contractAbi.UnpackIntoInterface(&rollupInit, "InitializeSYBEvent",
+	if err := c.contractAbi.UnpackIntoInterface(&rollupInit, "Initialize",
 		vLog.Data); err != nil {
 		return nil, 0, common.Wrap(err)
 	}
@@ -309,6 +479,7 @@ func NewRollupClient(client *EthereumClient, address ethCommon.Address) (*Rollup
 		tokamak:     tokamak,
 		contractAbi: contractAbi,
 		opts:        newCallOpts(),
+		constsLock:  &sync.Mutex{},
 	}
 	consts, err := c.RollupConstants()
 	if err != nil {
@@ -331,21 +502,7 @@ func (c *RollupClient) RollupConstants() (rollupConstants *common.RollupConstant
 		if err != nil {
 			return common.Wrap(err)
 		}
-		rollupVerifiersLength, err := c.tokamak.RollupVerifiersLength(c.opts)
-		if err != nil {
-			return common.Wrap(err)
-		}
-		for i := int64(0); i < rollupVerifiersLength.Int64(); i++ {
-			var newRollupVerifier common.RollupVerifierStruct
-			rollupVerifier, err := c.tokamak.RollupVerifiers(c.opts, big.NewInt(i))
-			if err != nil {
-				return common.Wrap(err)
-			}
-			newRollupVerifier.MaxTx = rollupVerifier.MaxTx.Int64()
-			newRollupVerifier.NLevels = rollupVerifier.NLevels.Int64()
-			rollupConstants.Verifiers = append(rollupConstants.Verifiers,
-				newRollupVerifier)
-		}
+		rollupConstants.Verifiers, err = c.rollupVerifiers(c.opts)
 		return common.Wrap(err)
 	}); err != nil {
 		return nil, common.Wrap(err)
@@ -353,6 +510,139 @@ func (c *RollupClient) RollupConstants() (rollupConstants *common.RollupConstant
 	return rollupConstants, nil
 }
 
+// rollupVerifiers reads the verifiers of the Rollup Smart Contract.  The
+// contract doesn't expose the number of verifiers, so they are read until the
+// access out of the array reverts.
+func (c *RollupClient) rollupVerifiers(opts *bind.CallOpts) ([]common.RollupVerifierStruct, error) {
+	var verifiers []common.RollupVerifierStruct
+	for i := int64(0); ; i++ {
+		rollupVerifier, err := c.tokamak.RollupVerifiers(opts, big.NewInt(i))
+		if err != nil && i > 0 && isExecutionReverted(err) {
+			break
+		} else if err != nil {
+			return nil, common.Wrap(err)
+		}
+		verifiers = append(verifiers, common.RollupVerifierStruct{
+			MaxTx:   rollupVerifier.MaxTxs.Int64(),
+			NLevels: rollupVerifier.NLevels.Int64(),
+		})
+	}
+	return verifiers, nil
+}
+
+// blockCallOpts returns the CallOpts of a call at blockNum, or at the last
+// block if blockNum is negative
+func (c *RollupClient) blockCallOpts(blockNum int64) *bind.CallOpts {
+	opts := *c.opts
+	if blockNum >= 0 {
+		opts.BlockNumber = big.NewInt(blockNum)
+	}
+	return &opts
+}
+
+// RollupVerifiers returns the verifiers of the Rollup Smart Contract at
+// blockNum, or at the last block if blockNum is negative.  The verifiers are
+// set on initialization, so they only change on a reinitialization after an
+// upgrade.
+func (c *RollupClient) RollupVerifiers(blockNum int64) (verifiers []common.RollupVerifierStruct, err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		verifiers, err = c.rollupVerifiers(c.blockCallOpts(blockNum))
+		return common.Wrap(err)
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return verifiers, nil
+}
+
+// RollupOwner returns the owner of the Rollup Smart Contract at blockNum, or
+// at the last block if blockNum is negative
+func (c *RollupClient) RollupOwner(blockNum int64) (owner *ethCommon.Address, err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		_owner, err := c.tokamak.Owner(c.blockCallOpts(blockNum))
+		owner = &_owner
+		return common.Wrap(err)
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return owner, nil
+}
+
+// isExecutionReverted returns true if the error of a call is caused by the
+// contract reverting it
+func isExecutionReverted(err error) bool {
+	return strings.Contains(err.Error(), vm.ErrExecutionReverted.Error())
+}
+
+// RollupForgeBatch is the interface to call the smart contract function.  If
+// auth is nil, a new one is built with the account of the client.
+func (c *RollupClient) RollupForgeBatch(args *RollupForgeBatchArgs,
+	auth *bind.TransactOpts) (tx *types.Transaction, err error) {
+	if auth == nil {
+		auth, err = c.client.NewAuth()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	tx, err = c.tokamak.ForgeBatch(auth, big.NewInt(args.NewLastIdx), args.NewStRoot,
+		args.NewVouchRoot, args.NewScoreRoot, args.NewExitRoot, args.VerifierIdx,
+		args.L1Batch, args.ProofA, args.ProofB, args.ProofC, args.Input)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("Tokamak.ForgeBatch: %w", err))
+	}
+	return tx, nil
+}
+
+// RollupL1UserTxCreateAccountDeposit is the interface to call the smart
+// contract function to create an account with a deposit
+func (c *RollupClient) RollupL1UserTxCreateAccountDeposit(fromBJJ babyjub.PublicKeyComp,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(fromBJJ, 0, loadAmount, nil, 0)
+}
+
+// RollupL1UserTxDeposit is the interface to call the smart contract function
+// to deposit into an existing account
+func (c *RollupClient) RollupL1UserTxDeposit(fromIdx int64,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, loadAmount, nil, 0)
+}
+
+// RollupL1UserTxForceExit is the interface to call the smart contract function
+// to force the exit of an amount of an account
+func (c *RollupClient) RollupL1UserTxForceExit(fromIdx int64,
+	amount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, nil, amount,
+		common.RollupConstExitIDx)
+}
+
+// RollupL1UserTxForceExplode is the interface to call the smart contract
+// function to force the explode of an account
+func (c *RollupClient) RollupL1UserTxForceExplode(fromIdx int64) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, nil, nil,
+		common.RollupConstExplodeIDx)
+}
+
+// rollupL1UserTx validates the L1 user tx and sends it with the loadAmount as
+// value, which the contract requires
+func (c *RollupClient) rollupL1UserTx(fromBJJ babyjub.PublicKeyComp, fromIdx int64,
+	loadAmount, amount *big.Int, toIdx int64) (*types.Transaction, error) {
+	args, err := NewRollupL1UserTxArgs(fromBJJ, fromIdx, loadAmount, amount, toIdx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	auth, err := c.client.NewAuth()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	auth.Value = args.LoadAmount
+	tx, err := c.tokamak.AddL1Transaction(auth, args.BabyPubKey, big.NewInt(args.FromIdx),
+		new(big.Int).SetUint64(args.LoadAmountF), new(big.Int).SetUint64(args.AmountF),
+		big.NewInt(args.ToIdx))
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("Tokamak.AddL1Transaction: %w", err))
+	}
+	return tx, nil
+}
+
 // RollupLastForgedBatch is the interface to call the smart contract function
 func (c *RollupClient) RollupLastForgedBatch() (lastForgedBatch int64, err error) {
 	if err := c.client.Call(func(ec *ethclient.Client) error {
@@ -365,6 +655,84 @@ func (c *RollupClient) RollupLastForgedBatch() (lastForgedBatch int64, err error
 	return lastForgedBatch, nil
 }
 
+// RollupNextL1ToForgeQueue is the interface to call the smart contract
+// function that returns the index of the next L1 user tx queue to be forged,
+// at blockNum, or at the last block if blockNum is negative
+func (c *RollupClient) RollupNextL1ToForgeQueue(blockNum int64) (queueIndex int64, err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		_queueIndex, err := c.tokamak.NextL1ToForgeQueue(c.blockCallOpts(blockNum))
+		queueIndex = int64(_queueIndex)
+		return common.Wrap(err)
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return queueIndex, nil
+}
+
+// RollupNextL1FillingQueue is the interface to call the smart contract
+// function that returns the index of the L1 user tx queue being filled, at
+// blockNum, or at the last block if blockNum is negative
+func (c *RollupClient) RollupNextL1FillingQueue(blockNum int64) (queueIndex int64, err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		_queueIndex, err := c.tokamak.NextL1FillingQueue(c.blockCallOpts(blockNum))
+		queueIndex = int64(_queueIndex)
+		return common.Wrap(err)
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return queueIndex, nil
+}
+
+// RollupLastL1L2Batch is the interface to call the smart contract function
+// that returns the ethereum block number of the last forged L1 batch, at
+// blockNum, or at the last block if blockNum is negative
+func (c *RollupClient) RollupLastL1L2Batch(blockNum int64) (lastL1L2Batch int64, err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		_lastL1L2Batch, err := c.tokamak.LastL1L2Batch(c.blockCallOpts(blockNum))
+		lastL1L2Batch = int64(_lastL1L2Batch)
+		return common.Wrap(err)
+	}); err != nil {
+		return 0, common.Wrap(err)
+	}
+	return lastL1L2Batch, nil
+}
+
+// RollupL1TransactionQueue is the interface to call the smart contract
+// function that returns the L1 user txs of a queue, packed as described in
+// RollupL1UserTxBytes, at blockNum, or at the last block if blockNum is
+// negative.  The queues are deleted when they are forged.
+func (c *RollupClient) RollupL1TransactionQueue(queueIndex, blockNum int64) (queue []byte,
+	err error) {
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		queue, err = c.tokamak.GetL1TransactionQueue(c.blockCallOpts(blockNum),
+			uint32(queueIndex))
+		return common.Wrap(err)
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return queue, nil
+}
+
+// RollupBatchRoots is the interface to call the smart contract viewers of the
+// roots forged in a batch
+func (c *RollupClient) RollupBatchRoots(batchNum int64) (*RollupBatchRoots, error) {
+	var roots RollupBatchRoots
+	if err := c.client.Call(func(ec *ethclient.Client) error {
+		var err error
+		if roots.StateRoot, err = c.tokamak.StateRootMap(c.opts, uint32(batchNum)); err != nil {
+			return common.Wrap(err)
+		}
+		if roots.VouchRoot, err = c.tokamak.VouchRootMap(c.opts, uint32(batchNum)); err != nil {
+			return common.Wrap(err)
+		}
+		roots.ScoreRoot, err = c.tokamak.ScoreRootMap(c.opts, uint32(batchNum))
+		return common.Wrap(err)
+	}); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &roots, nil
+}
+
 var (
 	logSYBL1UserTxEvent = crypto.Keccak256Hash([]byte(
 		"L1UserTxEvent(uint32,uint8,bytes)"))
@@ -373,15 +741,15 @@ var (
 	logSYBUpdateForgeL1L2BatchTimeout = crypto.Keccak256Hash([]byte(
 		"UpdateForgeL1L2BatchTimeout(uint8)"))
 	logSYBWithdrawEvent = crypto.Keccak256Hash([]byte(
-		"WithdrawEvent(uint48,uint32,bool)"))
-	logSYBUpdateBucketWithdraw = crypto.Keccak256Hash([]byte(
-		"UpdateBucketWithdraw(uint8,uint256,uint256)"))
-	logSYBUpdateBucketsParameters = crypto.Keccak256Hash([]byte(
-		"UpdateBucketsParameters(uint256[])"))
+		"WithdrawEvent(uint48,uint32)"))
 	logSYBSafeMode = crypto.Keccak256Hash([]byte(
 		"SafeMode()"))
 	logSYBInitialize = crypto.Keccak256Hash([]byte(
-		"InitializeSYBEvent(uint8,uint256,uint64)"))
+		"Initialize(uint8)"))
+	logSYBOwnershipTransferred = crypto.Keccak256Hash([]byte(
+		"OwnershipTransferred(address,address)"))
+	logSYBInitialized = crypto.Keccak256Hash([]byte(
+		"Initialized(uint64)"))
 )
 
 // RollupEventsByBlock returns the events in a block that happened in the
@@ -483,72 +851,61 @@ func (c *RollupClient) RollupEventsByBlock(blockNum int64,
 			var withdraw RollupEventWithdraw
 			withdraw.Idx = new(big.Int).SetBytes(vLog.Topics[1][:]).Uint64()
 			withdraw.NumExitRoot = new(big.Int).SetBytes(vLog.Topics[2][:]).Uint64()
-			instantWithdraw := new(big.Int).SetBytes(vLog.Topics[3][:]).Uint64()
-			if instantWithdraw == 1 {
-				withdraw.InstantWithdraw = true
-			}
+			// sybil.sol doesn't have delayed withdrawals
+			withdraw.InstantWithdraw = true
 			withdraw.TxHash = vLog.TxHash
 			rollupEvents.Withdraw = append(rollupEvents.Withdraw, withdraw)
-		case logSYBUpdateBucketWithdraw:
-			var updateBucketWithdrawAux rollupEventUpdateBucketWithdrawAux
-			var updateBucketWithdraw RollupEventUpdateBucketWithdraw
-			err := c.contractAbi.UnpackIntoInterface(&updateBucketWithdrawAux,
-				"UpdateBucketWithdraw", vLog.Data)
-			if err != nil {
-				return nil, common.Wrap(err)
-			}
-			updateBucketWithdraw.Withdrawals = updateBucketWithdrawAux.Withdrawals
-			updateBucketWithdraw.NumBucket = int(new(big.Int).SetBytes(vLog.Topics[1][:]).Int64())
-			updateBucketWithdraw.BlockStamp = new(big.Int).SetBytes(vLog.Topics[2][:]).Int64()
-			rollupEvents.UpdateBucketWithdraw =
-				append(rollupEvents.UpdateBucketWithdraw, updateBucketWithdraw)
-		// case logSYBUpdateBucketsParameters:
-		// 	var bucketsParametersAux rollupEventUpdateBucketsParametersAux
-		// 	var bucketsParameters RollupEventUpdateBucketsParameters
-		// 	err := c.contractAbi.UnpackIntoInterface(&bucketsParametersAux,
-		// 		"UpdateBucketsParameters", vLog.Data)
-		// 	if err != nil {
-		// 		return nil, common.Wrap(err)
-		// 	}
-		// 	bucketsParameters.ArrayBuckets = make([]RollupUpdateBucketsParameters, len(bucketsParametersAux.ArrayBuckets))
-		// 	for i, bucket := range bucketsParametersAux.ArrayBuckets {
-		// 		bucket, err := c.hermez.UnpackBucket(c.opts, bucket)
-		// 		if err != nil {
-		// 			return nil, common.Wrap(err)
-		// 		}
-		// 		bucketsParameters.ArrayBuckets[i].CeilUSD = bucket.CeilUSD
-		// 		bucketsParameters.ArrayBuckets[i].BlockStamp = bucket.BlockStamp
-		// 		bucketsParameters.ArrayBuckets[i].Withdrawals = bucket.Withdrawals
-		// 		bucketsParameters.ArrayBuckets[i].RateBlocks = bucket.RateBlocks
-		// 		bucketsParameters.ArrayBuckets[i].RateWithdrawals = bucket.RateWithdrawals
-		// 		bucketsParameters.ArrayBuckets[i].MaxWithdrawals = bucket.MaxWithdrawals
-		// 	}
-		// 	rollupEvents.UpdateBucketsParameters =
-		// 		append(rollupEvents.UpdateBucketsParameters, bucketsParameters)
 		case logSYBSafeMode:
 			var safeMode RollupEventSafeMode
 			rollupEvents.SafeMode = append(rollupEvents.SafeMode, safeMode)
-			// Also add an UpdateBucketsParameter with
-			// SafeMode=true to keep the order between `safeMode`
-			// and `UpdateBucketsParameters`
-			bucketsParameters := RollupEventUpdateBucketsParameters{
-				SafeMode: true,
-			}
-			for i := range bucketsParameters.ArrayBuckets {
-				bucketsParameters.ArrayBuckets[i].CeilUSD = big.NewInt(0)
-				bucketsParameters.ArrayBuckets[i].BlockStamp = big.NewInt(0)
-				bucketsParameters.ArrayBuckets[i].Withdrawals = big.NewInt(0)
-				bucketsParameters.ArrayBuckets[i].RateBlocks = big.NewInt(0)
-				bucketsParameters.ArrayBuckets[i].RateWithdrawals = big.NewInt(0)
-				bucketsParameters.ArrayBuckets[i].MaxWithdrawals = big.NewInt(0)
+		case logSYBOwnershipTransferred:
+			rollupEvents.OwnershipTransferred = append(rollupEvents.OwnershipTransferred,
+				RollupEventOwnershipTransferred{
+					PreviousOwner: ethCommon.BytesToAddress(vLog.Topics[1].Bytes()),
+					NewOwner:      ethCommon.BytesToAddress(vLog.Topics[2].Bytes()),
+				})
+		case logSYBInitialized:
+			var initialized RollupEventInitialized
+			err := c.contractAbi.UnpackIntoInterface(&initialized, "Initialized", vLog.Data)
+			if err != nil {
+				return nil, common.Wrap(err)
 			}
-			rollupEvents.UpdateBucketsParameters = append(rollupEvents.UpdateBucketsParameters,
-				bucketsParameters)
+			rollupEvents.Initialized = append(rollupEvents.Initialized, initialized)
 		}
 	}
 	return &rollupEvents, nil
 }
 
+// RollupEventBlocks returns the numbers of the blocks between fromBlock and
+// toBlock (both included) that have events of the Rollup Smart Contract
+// handled by RollupEventsByBlock, in ascending order.  All the range is
+// queried with a single FilterLogs call.
+func (c *RollupClient) RollupEventBlocks(fromBlock, toBlock int64) ([]int64, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: big.NewInt(fromBlock),
+		ToBlock:   big.NewInt(toBlock),
+		Addresses: []ethCommon.Address{
+			c.address,
+		},
+		Topics: [][]ethCommon.Hash{{logSYBL1UserTxEvent, logSYBForgeBatch,
+			logSYBUpdateForgeL1L2BatchTimeout, logSYBWithdrawEvent, logSYBSafeMode,
+			logSYBOwnershipTransferred, logSYBInitialized}},
+	}
+	logs, err := c.client.client.FilterLogs(context.Background(), query)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	blockNums := []int64{}
+	for _, vLog := range logs {
+		blockNum := int64(vLog.BlockNumber)
+		// the logs are sorted by block
+		if len(blockNums) == 0 || blockNums[len(blockNums)-1] != blockNum {
+			blockNums = append(blockNums, blockNum)
+		}
+	}
+	return blockNums, nil
+}
+
 // RollupForgeBatchArgs returns the arguments used in a ForgeBatch call in the
 // Rollup Smart Contract in the given transaction, and the sender address.
 func (c *RollupClient) RollupForgeBatchArgs(ethTxHash ethCommon.Hash,
@@ -583,16 +940,23 @@ func (c *RollupClient) RollupForgeBatchArgs(ethTxHash ethCommon.Hash,
 		NewExitRoot:           aux.NewExitRoot,
 		NewLastIdx:            aux.NewLastIdx.Int64(),
 		NewStRoot:             aux.NewStRoot,
+		NewVouchRoot:          aux.NewVouchRoot,
+		NewScoreRoot:          aux.NewScoreRoot,
 		ProofA:                aux.ProofA,
 		ProofB:                aux.ProofB,
 		ProofC:                aux.ProofC,
+		Input:                 aux.Input,
 		VerifierIdx:           aux.VerifierIdx,
 		L1CoordinatorTxs:      []common.L1Tx{},
 		L1CoordinatorTxsAuths: [][]byte{},
 		L2TxsData:             []common.L2Tx{},
 		FeeIdxCoordinator:     []common.AccountIdx{},
 	}
-	nLevels := c.consts.Verifiers[rollupForgeBatchArgs.VerifierIdx].NLevels
+	verifier, err := c.verifier(rollupForgeBatchArgs.VerifierIdx)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	nLevels := verifier.NLevels
 	lenL1L2TxsBytes := int((nLevels/8)*2 + common.Float40BytesLength + 1) //nolint:gomnd
 	numBytesL1TxUser := int(l1UserTxsLen) * lenL1L2TxsBytes
 	numTxsL1Coord := len(aux.EncodedL1CoordinatorTx) / common.RollupConstL1CoordinatorTotalBytes
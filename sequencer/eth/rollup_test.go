@@ -0,0 +1,142 @@
+package eth
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth/contracts/tokamak"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRollupL1UserTxArgs(t *testing.T) {
+	var bjj babyjub.PublicKeyComp
+	bjj[0] = 0x01
+	bjj[31] = 0x02
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	// Create account deposit: the key is sent in big endian, and the
+	// amounts in units of 10^10 wei
+	args, err := NewRollupL1UserTxArgs(bjj, 0, ether, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, string(common.SwapEndianness(bjj[:])), args.BabyPubKey)
+	assert.Equal(t, uint64(100000000), args.LoadAmountF)
+	assert.Equal(t, uint64(0), args.AmountF)
+	assert.Equal(t, big.NewInt(0), args.Amount)
+
+	// Deposit, force exit and force explode
+	args, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, ether, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "", args.BabyPubKey)
+	args, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, nil, ether,
+		common.RollupConstExitIDx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100000000), args.AmountF)
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, nil, nil,
+		common.RollupConstExplodeIDx)
+	require.NoError(t, err)
+
+	// Invalid combinations of parameters
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 0, ether, nil, 0)
+	assert.Error(t, err)
+	_, err = NewRollupL1UserTxArgs(bjj, 256, ether, nil, 0)
+	assert.Error(t, err)
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, ether, ether,
+		common.RollupConstExitIDx)
+	assert.Error(t, err)
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, nil, ether,
+		common.RollupConstExplodeIDx)
+	assert.Error(t, err)
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, common.RollupConstReservedIDx,
+		nil, ether, common.RollupConstExitIDx)
+	assert.Error(t, err)
+
+	// Amounts that can't be encoded
+	_, err = NewRollupL1UserTxArgs(bjj, 0, big.NewInt(1), nil, 0)
+	assert.ErrorIs(t, err, common.ErrFloat40NotEnoughPrecission)
+	tooBig := new(big.Int).Mul(big.NewInt(1<<40), big.NewInt(10000000000))
+	_, err = NewRollupL1UserTxArgs(bjj, 0, tooBig, nil, 0)
+	assert.ErrorIs(t, err, common.ErrFloat40Overflow)
+
+	// Amounts over the limits of the contract
+	_, err = NewRollupL1UserTxArgs(bjj, 0, common.RollupConstLimitDepositAmount, nil, 0)
+	assert.Error(t, err)
+	_, err = NewRollupL1UserTxArgs(common.EmptyBJJComp, 256, nil,
+		common.RollupConstLimitL2TransferAmount, common.RollupConstExitIDx)
+	assert.Error(t, err)
+}
+
+func TestRollupL1UserTxBytes(t *testing.T) {
+	var bjj babyjub.PublicKeyComp
+	bjj[0] = 0x01
+	bjj[31] = 0x02
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	from := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	// Create account deposit, with the key in big endian
+	b, err := RollupL1UserTxBytes(&common.L1Tx{FromEthAddr: from, FromBJJ: bjj,
+		DepositAmount: ether})
+	require.NoError(t, err)
+	require.Equal(t, 74, len(b))
+	assert.Equal(t, from.Bytes(), b[0:20])
+	assert.Equal(t, common.SwapEndianness(bjj[:]), b[20:52])
+	assert.Equal(t, make([]byte, 6), b[52:58])
+	assert.Equal(t, []byte{0x00, 0x05, 0xf5, 0xe1, 0x00}, b[58:63]) // 10^8
+	assert.Equal(t, make([]byte, 5+6), b[63:74])
+	tx, err := common.L1UserTxFromBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, from, tx.FromEthAddr)
+	assert.Equal(t, bjj, tx.FromBJJ)
+	assert.Equal(t, common.AccountIdx(0), tx.FromIdx)
+	assert.Equal(t, ether.String(), tx.DepositAmount.String())
+	assert.Equal(t, "0", tx.Amount.String())
+
+	// Force exit, without key
+	b, err = RollupL1UserTxBytes(&common.L1Tx{FromEthAddr: from, FromIdx: 256,
+		Amount: ether, ToIdx: common.RollupConstExitIDx})
+	require.NoError(t, err)
+	require.Equal(t, 42, len(b))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0x01, 0x00}, b[20:26])
+	assert.Equal(t, make([]byte, 5), b[26:31])
+	assert.Equal(t, []byte{0x00, 0x05, 0xf5, 0xe1, 0x00}, b[31:36])
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0x01}, b[36:42])
+	tx, err = common.L1UserTxFromBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, from, tx.FromEthAddr)
+	assert.Equal(t, common.EmptyBJJComp, tx.FromBJJ)
+	assert.Equal(t, common.AccountIdx(256), tx.FromIdx)
+	assert.Equal(t, "0", tx.DepositAmount.String())
+	assert.Equal(t, ether.String(), tx.Amount.String())
+	assert.Equal(t, common.AccountIdx(common.RollupConstExitIDx), tx.ToIdx)
+	_, err = common.L1UserTxFromBytes(b[1:])
+	assert.Error(t, err)
+
+	_, err = RollupL1UserTxBytes(&common.L1Tx{FromEthAddr: from, FromBJJ: bjj,
+		DepositAmount: big.NewInt(1)})
+	assert.ErrorIs(t, err, common.ErrFloat40NotEnoughPrecission)
+}
+
+// TestRollupEventTopics checks that the topics of the events parsed by
+// RollupEventsByBlock match the ones in the ABI of the smart contract
+func TestRollupEventTopics(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(string(tokamak.TokamakABI)))
+	require.NoError(t, err)
+	for name, topic := range map[string]ethCommon.Hash{
+		"L1UserTxEvent":               logSYBL1UserTxEvent,
+		"ForgeBatch":                  logSYBForgeBatch,
+		"UpdateForgeL1L2BatchTimeout": logSYBUpdateForgeL1L2BatchTimeout,
+		"WithdrawEvent":               logSYBWithdrawEvent,
+		"Initialize":                  logSYBInitialize,
+		"OwnershipTransferred":        logSYBOwnershipTransferred,
+		"Initialized":                 logSYBInitialized,
+	} {
+		event, ok := contractAbi.Events[name]
+		require.True(t, ok, name)
+		assert.Equal(t, event.ID, topic, name)
+	}
+}
@@ -0,0 +1,289 @@
+package eth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+	"tokamak-sybil-resistance/common"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const externalSignerTimeout = 60 * time.Second
+
+// Signer signs the transactions of an ethereum account.  The key of the
+// account can be kept out of the node, in an external signer or an HSM.
+type Signer interface {
+	// Address returns the address of the account
+	Address() ethCommon.Address
+	// SignTx signs the transaction for the chain chainID
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// SignerType describes the different available signers of the forger account
+type SignerType string
+
+const (
+	// SignerTypeKeyStore signs with a key of the local ethereum keystore
+	SignerTypeKeyStore SignerType = "KeyStore"
+	// SignerTypeExternal signs with a Clef compatible external signer
+	SignerTypeExternal SignerType = "External"
+)
+
+// NewTransactor creates a transaction signer for the account of signer in the
+// chain chainID
+func NewTransactor(signer Signer, chainID *big.Int) (*bind.TransactOpts, error) {
+	if signer == nil {
+		return nil, common.Wrap(ErrAccountNil)
+	}
+	if chainID == nil {
+		return nil, common.Wrap(bind.ErrNoChainID)
+	}
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address ethCommon.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(tx, chainID)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
+// KeyStoreSigner signs with an unlocked key of the local ethereum keystore
+type KeyStoreSigner struct {
+	ks      *ethKeystore.KeyStore
+	account accounts.Account
+}
+
+// NewKeyStoreSigner unlocks the key of address in the keystore with password
+// and creates a KeyStoreSigner for it
+func NewKeyStoreSigner(ks *ethKeystore.KeyStore, address ethCommon.Address,
+	password string) (*KeyStoreSigner, error) {
+	if !ks.HasAddress(address) {
+		return nil, common.Wrap(fmt.Errorf(
+			"ethereum keystore doesn't have the key for address %v", address))
+	}
+	account := accounts.Account{Address: address}
+	if err := ks.Unlock(account, password); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return &KeyStoreSigner{ks: ks, account: account}, nil
+}
+
+// Address implements the Signer interface
+func (s *KeyStoreSigner) Address() ethCommon.Address {
+	return s.account.Address
+}
+
+// SignTx implements the Signer interface
+func (s *KeyStoreSigner) SignTx(tx *types.Transaction,
+	chainID *big.Int) (*types.Transaction, error) {
+	signedTx, err := s.ks.SignTx(s.account, tx, chainID)
+	return signedTx, common.Wrap(err)
+}
+
+// PrivateKey returns the private key of the account, decrypted from the
+// keystore with password
+func (s *KeyStoreSigner) PrivateKey(password string) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := s.ks.Export(s.account, password, password)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	key, err := ethKeystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return key.PrivateKey, nil
+}
+
+// ExternalSigner signs with a Clef compatible external signer through its
+// JSON-RPC API.  Every transaction may need to be approved in the signer.
+type ExternalSigner struct {
+	client  *rpc.Client
+	address ethCommon.Address
+}
+
+// NewExternalSigner connects to the external signer at url (http, ws or ipc)
+// and creates an ExternalSigner for the account address, which must be
+// managed by the signer
+func NewExternalSigner(url string, address ethCommon.Address) (*ExternalSigner, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalSignerTimeout)
+	defer cancel()
+	var addresses []ethCommon.Address
+	if err := client.CallContext(ctx, &addresses, "account_list"); err != nil {
+		client.Close()
+		return nil, common.Wrap(err)
+	}
+	for _, a := range addresses {
+		if a == address {
+			return &ExternalSigner{client: client, address: address}, nil
+		}
+	}
+	client.Close()
+	return nil, common.Wrap(fmt.Errorf("external signer doesn't manage the address %v", address))
+}
+
+// Address implements the Signer interface
+func (s *ExternalSigner) Address() ethCommon.Address {
+	return s.address
+}
+
+// externalSignerTxArgs are the arguments of account_signTransaction
+type externalSignerTxArgs struct {
+	From                 ethCommon.Address  `json:"from"`
+	To                   *ethCommon.Address `json:"to"`
+	Gas                  hexutil.Uint64     `json:"gas"`
+	GasPrice             *hexutil.Big       `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big        `json:"value"`
+	Nonce                hexutil.Uint64     `json:"nonce"`
+	Data                 hexutil.Bytes      `json:"data"`
+	ChainID              *hexutil.Big       `json:"chainId"`
+}
+
+// SignTx implements the Signer interface.  The transaction returned by the
+// signer must be the requested one, signed by the account.
+func (s *ExternalSigner) SignTx(tx *types.Transaction,
+	chainID *big.Int) (*types.Transaction, error) {
+	args := externalSignerTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	} else {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), externalSignerTimeout)
+	defer cancel()
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, common.Wrap(err)
+	}
+	var signedTx types.Transaction
+	if err := signedTx.UnmarshalBinary(res.Raw); err != nil {
+		return nil, common.Wrap(err)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(&signedTx) != signer.Hash(tx) {
+		return nil, common.Wrap(fmt.Errorf("external signer signed a different transaction"))
+	}
+	from, err := types.Sender(signer, &signedTx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if from != s.address {
+		return nil, common.Wrap(fmt.Errorf("external signer signed with %v instead of %v",
+			from, s.address))
+	}
+	return &signedTx, nil
+}
+
+// Close closes the connection to the external signer
+func (s *ExternalSigner) Close() {
+	s.client.Close()
+}
+
+// HSMSigner signs with a secp256k1 key kept in a hardware security module.
+// PKCS#11 bindings expose the keys of the tokens as crypto.Signer, which
+// returns ASN.1 encoded ECDSA signatures of the digests (CKM_ECDSA).
+type HSMSigner struct {
+	key     crypto.Signer
+	address ethCommon.Address
+}
+
+// NewHSMSigner creates an HSMSigner for the secp256k1 key of an HSM
+func NewHSMSigner(key crypto.Signer) (*HSMSigner, error) {
+	pubKey, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, common.Wrap(fmt.Errorf("the HSM key is not an ECDSA key"))
+	}
+	if !ethCrypto.S256().IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, common.Wrap(fmt.Errorf("the HSM key is not a secp256k1 key"))
+	}
+	return &HSMSigner{
+		key:     key,
+		address: ethCrypto.PubkeyToAddress(*pubKey),
+	}, nil
+}
+
+// Address implements the Signer interface
+func (s *HSMSigner) Address() ethCommon.Address {
+	return s.address
+}
+
+// SignTx implements the Signer interface
+func (s *HSMSigner) SignTx(tx *types.Transaction,
+	chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+	sig, err := s.signHash(hash[:])
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	signedTx, err := tx.WithSignature(signer, sig)
+	return signedTx, common.Wrap(err)
+}
+
+// signHash signs the hash in the HSM and returns the signature in the
+// [R || S || V] format.  The HSM doesn't return the recovery id V, which is
+// found by recovering the address, nor enforces a low S, which is required by
+// ethereum.
+func (s *HSMSigner) signHash(hash []byte) ([]byte, error) {
+	der, err := s.key.Sign(rand.Reader, hash, crypto.Hash(0))
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	var rs struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, common.Wrap(err)
+	} else if len(rest) != 0 {
+		return nil, common.Wrap(fmt.Errorf("invalid HSM signature"))
+	}
+	curveN := ethCrypto.S256().Params().N
+	if rs.R.Sign() <= 0 || rs.R.Cmp(curveN) >= 0 || rs.S.Sign() <= 0 || rs.S.Cmp(curveN) >= 0 {
+		return nil, common.Wrap(fmt.Errorf("invalid HSM signature"))
+	}
+	if rs.S.Cmp(new(big.Int).Rsh(curveN, 1)) > 0 {
+		rs.S.Sub(curveN, rs.S)
+	}
+	sig := make([]byte, ethCrypto.SignatureLength)
+	rs.R.FillBytes(sig[0:32])
+	rs.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		pubKey, err := ethCrypto.SigToPub(hash, sig)
+		if err == nil && ethCrypto.PubkeyToAddress(*pubKey) == s.address {
+			return sig, nil
+		}
+	}
+	return nil, common.Wrap(fmt.Errorf("the HSM signature doesn't match the HSM key"))
+}
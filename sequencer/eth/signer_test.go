@@ -0,0 +1,184 @@
+package eth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSignerTx(nonce uint64) *types.Transaction {
+	to := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: gwei(2),
+		GasFeeCap: gwei(30),
+		Gas:       500000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x01, 0x02},
+	})
+}
+
+// testSigned checks that signedTx is tx signed by address
+func testSigned(t *testing.T, tx, signedTx *types.Transaction, chainID *big.Int,
+	address ethCommon.Address) {
+	signer := types.LatestSignerForChainID(chainID)
+	from, err := types.Sender(signer, signedTx)
+	require.NoError(t, err)
+	assert.Equal(t, address, from)
+	assert.Equal(t, signer.Hash(tx), signer.Hash(signedTx))
+}
+
+func TestKeyStoreSigner(t *testing.T) {
+	ks := ethKeystore.NewKeyStore(t.TempDir(), ethKeystore.LightScryptN,
+		ethKeystore.LightScryptP)
+	account, err := ks.NewAccount("pass")
+	require.NoError(t, err)
+
+	_, err = NewKeyStoreSigner(ks, account.Address, "wrong")
+	assert.Error(t, err)
+	_, err = NewKeyStoreSigner(ks, ethCommon.Address{}, "pass")
+	assert.Error(t, err)
+	signer, err := NewKeyStoreSigner(ks, account.Address, "pass")
+	require.NoError(t, err)
+	assert.Equal(t, account.Address, signer.Address())
+
+	chainID := big.NewInt(1337)
+	auth, err := NewTransactor(signer, chainID)
+	require.NoError(t, err)
+	tx := newTestSignerTx(3)
+	signedTx, err := auth.Signer(account.Address, tx)
+	require.NoError(t, err)
+	testSigned(t, tx, signedTx, chainID, account.Address)
+	_, err = auth.Signer(ethCommon.Address{}, tx)
+	assert.Error(t, err)
+
+	key, err := signer.PrivateKey("pass")
+	require.NoError(t, err)
+	assert.Equal(t, account.Address, ethCrypto.PubkeyToAddress(key.PublicKey))
+}
+
+// testClef is a stand-in of the account API of the Clef external signer,
+// which signs the transactions with key
+type testClef struct {
+	key *ecdsa.PrivateKey
+}
+
+func (c *testClef) List() []ethCommon.Address {
+	return []ethCommon.Address{ethCrypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *testClef) SignTransaction(args externalSignerTxArgs) (map[string]hexutil.Bytes, error) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Data,
+	})
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), c.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+func newTestClef(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", &testClef{key: key}))
+	return httptest.NewServer(server)
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	address := ethCrypto.PubkeyToAddress(key.PublicKey)
+	clef := newTestClef(t, key)
+	defer clef.Close()
+
+	_, err = NewExternalSigner(clef.URL, ethCommon.Address{})
+	assert.Error(t, err)
+	signer, err := NewExternalSigner(clef.URL, address)
+	require.NoError(t, err)
+	defer signer.Close()
+
+	chainID := big.NewInt(1337)
+	tx := newTestSignerTx(3)
+	signedTx, err := signer.SignTx(tx, chainID)
+	require.NoError(t, err)
+	testSigned(t, tx, signedTx, chainID, address)
+
+	// A transaction signed by another account is rejected
+	otherKey, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	signer.client.Close()
+	otherClef := newTestClef(t, otherKey)
+	defer otherClef.Close()
+	signer.client, err = rpc.Dial(otherClef.URL)
+	require.NoError(t, err)
+	_, err = signer.SignTx(tx, chainID)
+	assert.Error(t, err)
+}
+
+// testHSMKey is a stand-in of the key of a PKCS#11 token, which returns ASN.1
+// signatures without recovery id, and with a high S if highS is set
+type testHSMKey struct {
+	key   *ecdsa.PrivateKey
+	highS bool
+}
+
+func (k *testHSMKey) Public() crypto.PublicKey {
+	return &k.key.PublicKey
+}
+
+func (k *testHSMKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	sig, err := ethCrypto.Sign(digest, k.key)
+	if err != nil {
+		return nil, err
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if k.highS {
+		s.Sub(ethCrypto.S256().Params().N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+func TestHSMSigner(t *testing.T) {
+	key, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	address := ethCrypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1337)
+
+	for _, highS := range []bool{false, true} {
+		signer, err := NewHSMSigner(&testHSMKey{key: key, highS: highS})
+		require.NoError(t, err)
+		assert.Equal(t, address, signer.Address())
+		// Sign several times so that both recovery ids are found
+		for i := 0; i < 8; i++ {
+			tx := newTestSignerTx(uint64(i))
+			signedTx, err := signer.SignTx(tx, chainID)
+			require.NoError(t, err)
+			testSigned(t, tx, signedTx, chainID, address)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package etherscan
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
+	"tokamak-sybil-resistance/common"
 
 	"github.com/dghubble/sling"
 )
@@ -19,12 +21,14 @@ type etherscanResponse struct {
 	Result  GasPriceEtherscan `json:"result"`
 }
 
-// GasPriceEtherscan definition
+// GasPriceEtherscan definition.  The prices are in gwei, and SuggestBaseFee
+// is the base fee of the next block.
 type GasPriceEtherscan struct {
 	LastBlock       string `json:"LastBlock"`
 	SafeGasPrice    string `json:"SafeGasPrice"`
 	ProposeGasPrice string `json:"ProposeGasPrice"`
 	FastGasPrice    string `json:"FastGasPrice"`
+	SuggestBaseFee  string `json:"suggestBaseFee"`
 }
 
 // Service definition
@@ -53,3 +57,26 @@ func NewEtherscanService(etherscanURL string, apikey string) (*Service, error) {
 		apiKey:          apikey,
 	}, nil
 }
+
+// GetGasPrice retrieves the gas price estimation from the etherscan gas oracle
+func (p *Service) GetGasPrice(ctx context.Context) (*GasPriceEtherscan, error) {
+	var resBody etherscanResponse
+	query := "/api?module=gastracker&action=gasoracle&apikey=" + p.apiKey
+	req, err := p.clientEtherscan.New().Get(query).Request()
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	res, err := p.clientEtherscan.Do(req.WithContext(ctx), &resBody, nil)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, common.Wrap(fmt.Errorf("etherscan http response status is %v",
+			res.StatusCode))
+	}
+	if resBody.Message != "OK" {
+		return nil, common.Wrap(fmt.Errorf("etherscan response message is %v",
+			resBody.Message))
+	}
+	return &resBody.Result, nil
+}
@@ -0,0 +1,50 @@
+package etherscan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGasPrice(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api", r.URL.Path)
+		assert.Equal(t, "gastracker", r.URL.Query().Get("module"))
+		assert.Equal(t, "gasoracle", r.URL.Query().Get("action"))
+		if r.URL.Query().Get("apikey") != "key" {
+			_, _ = w.Write([]byte(`{"status":"0","message":"NOTOK","result":{}}`))
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":{` +
+			`"LastBlock":"100","SafeGasPrice":"21","ProposeGasPrice":"22",` +
+			`"FastGasPrice":"23","suggestBaseFee":"20.5","gasUsedRatio":"0.5,0.4"}}`))
+	}))
+	defer server.Close()
+
+	service, err := NewEtherscanService(server.URL, "key")
+	require.NoError(t, err)
+	gasPrice, err := service.GetGasPrice(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, GasPriceEtherscan{
+		LastBlock:       "100",
+		SafeGasPrice:    "21",
+		ProposeGasPrice: "22",
+		FastGasPrice:    "23",
+		SuggestBaseFee:  "20.5",
+	}, *gasPrice)
+
+	status = http.StatusInternalServerError
+	_, err = service.GetGasPrice(context.Background())
+	assert.Error(t, err)
+
+	service, err = NewEtherscanService(server.URL, "wrong")
+	require.NoError(t, err)
+	_, err = service.GetGasPrice(context.Background())
+	assert.Error(t, err)
+}
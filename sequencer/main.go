@@ -1,15 +1,27 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"os/signal"
+	"strconv"
+	"time"
 	"tokamak-sybil-resistance/common"
 	"tokamak-sybil-resistance/config"
+	dbUtils "tokamak-sybil-resistance/database"
+	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/kvdb"
+	"tokamak-sybil-resistance/database/snapshot"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/eth"
 	"tokamak-sybil-resistance/log"
 	"tokamak-sybil-resistance/node"
+	"tokamak-sybil-resistance/synchronizer"
 
-	"github.com/gin-gonic/gin"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/jmoiron/sqlx"
 	"github.com/urfave/cli"
 )
 
@@ -21,9 +33,12 @@ const (
 	flagBlock   = "block"
 	modeSync    = "sync"
 	modeCoord   = "coord"
-	nMigrations = "nMigrations"
 	flagAccount = "account"
 	flagPath    = "path"
+	flagBatch   = "batchnum"
+	flagRepair  = "repair"
+	flagSnapBat = "batch"
+	flagFile    = "file"
 )
 
 // Config is the configuration of the node execution
@@ -109,6 +124,383 @@ func cmdRun(c *cli.Context) error {
 	return nil
 }
 
+// openSQLDB connects to the write SQL DB configured in the node configuration
+// without running the migrations.  Only the database sections of the
+// configuration are loaded.
+func openSQLDB(c *cli.Context) (*config.DB, *sqlx.DB, error) {
+	cfg, err := config.LoadDB(c.String(flagCfg))
+	if err != nil {
+		return nil, nil, common.Wrap(fmt.Errorf("error parsing flags and config: %w", err))
+	}
+	db, err := connectSQLDB(cfg)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	return cfg, db, nil
+}
+
+// connectSQLDB connects to the write SQL DB of the database configuration
+func connectSQLDB(cfg *config.DB) (*sqlx.DB, error) {
+	var db *sqlx.DB
+	var err error
+	if cfg.Database.Driver == config.DatabaseDriverSQLite {
+		db, err = dbUtils.ConnectSQLiteDB(cfg.Database.SQLitePath)
+	} else {
+		db, err = dbUtils.ConnectSQLDB(
+			cfg.PostgreSQL.PortWrite,
+			cfg.PostgreSQL.HostWrite,
+			cfg.PostgreSQL.UserWrite,
+			cfg.PostgreSQL.PasswordWrite,
+			cfg.PostgreSQL.NameWrite,
+		)
+	}
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("error connecting to the SQL DB: %w", err))
+	}
+	return db, nil
+}
+
+func cmdMigrateUp(c *cli.Context) error {
+	_, db, err := openSQLDB(c)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer db.Close() //nolint:errcheck
+	return common.Wrap(dbUtils.MigrationsUp(db.DB))
+}
+
+func cmdMigrateDown(c *cli.Context) error {
+	n, err := strconv.ParseUint(c.Args().First(), 10, 32)
+	if err != nil || n == 0 {
+		return common.Wrap(fmt.Errorf(
+			"the number of migrations to run down must be a positive integer, got \"%v\"",
+			c.Args().First()))
+	}
+	_, db, err := openSQLDB(c)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer db.Close() //nolint:errcheck
+	// Keep the node lock while the migrations run so that a node can't
+	// start in the middle
+	lock, err := dbUtils.TryNodeLock(db)
+	if errors.Is(err, dbUtils.ErrNodeLockHeld) {
+		return common.Wrap(fmt.Errorf("refusing to run migrations down: %w", err))
+	} else if err != nil {
+		return common.Wrap(err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Errorw("NodeLock.Release", "err", err)
+		}
+	}()
+	return common.Wrap(dbUtils.MigrationsDown(db.DB, uint(n)))
+}
+
+func cmdMigrateStatus(c *cli.Context) error {
+	_, db, err := openSQLDB(c)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer db.Close() //nolint:errcheck
+	status, err := dbUtils.MigrationsStatus(db.DB)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	for _, s := range status {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = "applied at " + s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Unknown {
+			applied += " (unknown migration)"
+		}
+		fmt.Printf("%v\t%v\n", s.ID, applied)
+	}
+	return nil
+}
+
+func cmdMigrateVerify(c *cli.Context) error {
+	cfg, db, err := openSQLDB(c)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer db.Close() //nolint:errcheck
+	// Apply all the migrations to a scratch DB to get the expected schema
+	var scratch *sqlx.DB
+	closeScratch := func() error { return nil }
+	if dbUtils.IsSQLite(db) {
+		scratch, err = dbUtils.ConnectSQLiteDB(dbUtils.SQLiteMemory)
+		if scratch != nil {
+			closeScratch = scratch.Close
+		}
+	} else {
+		scratch, closeScratch, err = dbUtils.CreateScratchSQLDB(db,
+			cfg.PostgreSQL.PortWrite,
+			cfg.PostgreSQL.HostWrite,
+			cfg.PostgreSQL.UserWrite,
+			cfg.PostgreSQL.PasswordWrite,
+			fmt.Sprintf("%v_verify_%v", cfg.PostgreSQL.NameWrite, time.Now().Unix()),
+		)
+	}
+	if err != nil {
+		return common.Wrap(fmt.Errorf("error creating the scratch DB: %w", err))
+	}
+	defer func() {
+		if err := closeScratch(); err != nil {
+			log.Errorw("error removing the scratch DB", "err", err)
+		}
+	}()
+	diff, err := dbUtils.VerifySchema(db, scratch)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	for _, line := range diff.Missing {
+		fmt.Printf("- %v\n", line)
+	}
+	for _, line := range diff.Unexpected {
+		fmt.Printf("+ %v\n", line)
+	}
+	if !diff.Empty() {
+		return common.Wrap(fmt.Errorf("the DB schema differs from the migrations: "+
+			"%v missing (-) and %v unexpected (+) objects",
+			len(diff.Missing), len(diff.Unexpected)))
+	}
+	fmt.Println("The DB schema matches the migrations")
+	return nil
+}
+
+func cmdStateDBVerify(c *cli.Context) error {
+	cfg, historyDB, stateDB, closeDBs, err := openSynchronizerDBs(c, "verify the StateDB")
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer closeDBs()
+	client, err := newRollupClient(cfg)
+	if err != nil {
+		return common.Wrap(err)
+	}
+
+	batchNums := []int{}
+	if c.IsSet(flagBatch) {
+		batchNums = append(batchNums, c.Int(flagBatch))
+	} else if batchNums, err = stateDB.ListCheckpoints(); err != nil {
+		return common.Wrap(err)
+	}
+	good := map[common.BatchNum]bool{}
+	var corrupt []common.BatchNum
+	for _, bn := range batchNums {
+		batchNum := common.BatchNum(bn)
+		report, err := stateDB.VerifyCheckpoint(batchNum)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		ok := report.Consistent()
+		for _, issue := range report.Issues {
+			fmt.Printf("batch %v: %v\n", batchNum, issue)
+		}
+		for _, tree := range []struct {
+			name             string
+			stored, computed *big.Int
+		}{
+			{"account", report.Stored.Account, report.Computed.Account},
+			{"vouch", report.Stored.Vouch, report.Computed.Vouch},
+			{"score", report.Stored.Score, report.Computed.Score},
+		} {
+			if tree.stored != nil && tree.computed != nil && tree.stored.Cmp(tree.computed) != 0 {
+				fmt.Printf("batch %v: stored %v root %v != recomputed %v\n",
+					batchNum, tree.name, tree.stored, tree.computed)
+			}
+		}
+		// The recomputed roots must match the roots forged on chain
+		onChain, err := client.RollupBatchRoots(int64(batchNum))
+		if err != nil {
+			return common.Wrap(err)
+		}
+		for _, tree := range []struct {
+			name              string
+			onChain, computed *big.Int
+		}{
+			{"account", onChain.StateRoot, report.Computed.Account},
+			{"vouch", onChain.VouchRoot, report.Computed.Vouch},
+			{"score", onChain.ScoreRoot, report.Computed.Score},
+		} {
+			if tree.computed != nil && tree.onChain.Cmp(tree.computed) != 0 {
+				fmt.Printf("batch %v: on-chain %v root %v != recomputed %v\n",
+					batchNum, tree.name, tree.onChain, tree.computed)
+				ok = false
+			}
+		}
+		if ok {
+			fmt.Printf("batch %v: ok\n", batchNum)
+			good[batchNum] = true
+		} else {
+			corrupt = append(corrupt, batchNum)
+		}
+	}
+	if len(corrupt) == 0 {
+		return nil
+	}
+	if !c.Bool(flagRepair) {
+		return common.Wrap(fmt.Errorf("%v corrupt checkpoints found: %v", len(corrupt), corrupt))
+	}
+
+	// Replay all the batches after the last good checkpoint before the
+	// first corrupt one, up to the current batch
+	currentBatch := stateDB.CurrentBatch()
+	from := common.BatchNum(0)
+	for batchNum := corrupt[0] - 1; batchNum > 0; batchNum-- {
+		if good[batchNum] {
+			from = batchNum
+			break
+		}
+		report, err := stateDB.VerifyCheckpoint(batchNum)
+		if err != nil {
+			// The checkpoint was deleted because of the Keep limit
+			break
+		}
+		if report.Consistent() {
+			from = batchNum
+			break
+		}
+	}
+	fmt.Printf("Replaying batches %v to %v from the checkpoint at batch %v\n",
+		from+1, currentBatch, from)
+	if err := synchronizer.ReplayBatches(stateDB, historyDB, from, currentBatch); err != nil {
+		return common.Wrap(err)
+	}
+	fmt.Printf("Rebuilt the checkpoints of batches %v to %v\n", from+1, currentBatch)
+	return nil
+}
+
+// openSynchronizerDBs opens the SQL DB and the synchronizer StateDB
+// configured in the node configuration while holding the node lock.  The
+// returned function releases the lock and closes the DBs.
+func openSynchronizerDBs(c *cli.Context, action string) (*config.Node,
+	*historydb.HistoryDB, *statedb.StateDB, func(), error) {
+	cfg, err := config.LoadNode(c.String(flagCfg), false)
+	if err != nil {
+		return nil, nil, nil, nil, common.Wrap(fmt.Errorf("error parsing flags and config: %w", err))
+	}
+	db, err := connectSQLDB(&config.DB{Database: cfg.Database, PostgreSQL: cfg.PostgreSQL})
+	if err != nil {
+		return nil, nil, nil, nil, common.Wrap(err)
+	}
+	backend, err := kvdb.BackendByName(cfg.StateDB.Backend)
+	if err != nil {
+		db.Close() //nolint:errcheck
+		return nil, nil, nil, nil, common.Wrap(err)
+	}
+	// The StateDB can't be opened while a node is using it
+	lock, err := dbUtils.TryNodeLock(db)
+	if err != nil {
+		db.Close() //nolint:errcheck
+		if errors.Is(err, dbUtils.ErrNodeLockHeld) {
+			return nil, nil, nil, nil, common.Wrap(fmt.Errorf("refusing to %v: %w", action, err))
+		}
+		return nil, nil, nil, nil, common.Wrap(err)
+	}
+	stateDB, err := statedb.NewStateDB(statedb.Config{
+		Path:    cfg.StateDB.Path,
+		Keep:    cfg.StateDB.Keep,
+		Type:    statedb.TypeSynchronizer,
+		NLevels: statedb.MaxNLevels,
+		Backend: backend,
+	})
+	closeDBs := func() {
+		if stateDB != nil {
+			stateDB.Close()
+		}
+		if err := lock.Release(); err != nil {
+			log.Errorw("NodeLock.Release", "err", err)
+		}
+		db.Close() //nolint:errcheck
+	}
+	if err != nil {
+		closeDBs()
+		return nil, nil, nil, nil, common.Wrap(err)
+	}
+	return cfg, historydb.NewHistoryDB(db, db, nil), stateDB, closeDBs, nil
+}
+
+// newRollupClient connects to the rollup smart contract configured in the node
+// configuration, without a signer
+func newRollupClient(cfg *config.Node) (*eth.Client, error) {
+	rpcClient, err := rpc.Dial(cfg.Web3.URL)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	client, err := eth.NewClient(rpcClient, nil, &eth.ClientConfig{
+		Rollup: eth.RollupConfig{
+			Address: cfg.SmartContracts.Rollup,
+		},
+	})
+	return client, common.Wrap(err)
+}
+
+func cmdSnapshotExport(c *cli.Context) error {
+	if !c.IsSet(flagSnapBat) {
+		return common.Wrap(fmt.Errorf("the batch to export must be set with --%v", flagSnapBat))
+	}
+	batchNum := common.BatchNum(c.Int(flagSnapBat))
+	_, historyDB, stateDB, closeDBs, err := openSynchronizerDBs(c, "export a snapshot")
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer closeDBs()
+
+	file := c.String(flagFile)
+	if file == "" {
+		file = fmt.Sprintf("snapshot-%v.tar.gz", batchNum)
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600) //nolint:gomnd
+	if err != nil {
+		return common.Wrap(err)
+	}
+	manifest, err := snapshot.Export(f, stateDB, historyDB, batchNum)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		if err := os.Remove(file); err != nil {
+			log.Errorw("os.Remove", "file", file, "err", err)
+		}
+		return common.Wrap(err)
+	}
+	fmt.Printf("Exported batch %v (block %v) to %v\n", manifest.BatchNum,
+		manifest.EthBlockNum, file)
+	return nil
+}
+
+func cmdSnapshotImport(c *cli.Context) error {
+	file := c.String(flagFile)
+	if file == "" {
+		return common.Wrap(fmt.Errorf("the snapshot must be set with --%v", flagFile))
+	}
+	cfg, historyDB, stateDB, closeDBs, err := openSynchronizerDBs(c, "import a snapshot")
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer closeDBs()
+
+	client, err := newRollupClient(cfg)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	f, err := os.Open(file) //nolint:gosec
+	if err != nil {
+		return common.Wrap(err)
+	}
+	defer f.Close() //nolint:errcheck
+	manifest, err := snapshot.Import(f, stateDB, historyDB, client)
+	if err != nil {
+		return common.Wrap(err)
+	}
+	fmt.Printf("Imported batch %v, the sync will resume from block %v\n",
+		manifest.BatchNum, manifest.EthBlockNum+1)
+	return nil
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Name = "tokamak-node"
@@ -127,6 +519,39 @@ func main() {
 		},
 	}
 
+	migrateFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:     flagCfg,
+			Usage:    "Node configuration `FILE`",
+			Required: false,
+		},
+	}
+
+	stateDBVerifyFlags := append(migrateFlags,
+		&cli.IntFlag{
+			Name:  flagBatch,
+			Usage: "Verify only the checkpoint of the batch `NUM`",
+		},
+		&cli.BoolFlag{
+			Name: flagRepair,
+			Usage: "Rebuild the corrupt checkpoints by replaying the batches " +
+				"from the previous good checkpoint",
+		},
+	)
+
+	snapshotFileFlag := &cli.StringFlag{
+		Name:  flagFile,
+		Usage: "Snapshot archive `FILE`",
+	}
+	snapshotExportFlags := append(append([]cli.Flag{}, migrateFlags...),
+		&cli.IntFlag{
+			Name:  flagSnapBat,
+			Usage: "Export the state at the batch `NUM`",
+		},
+		snapshotFileFlag,
+	)
+	snapshotImportFlags := append(append([]cli.Flag{}, migrateFlags...), snapshotFileFlag)
+
 	app.Commands = []cli.Command{
 		{
 			Name:    "run",
@@ -135,6 +560,76 @@ func main() {
 			Action:  cmdRun,
 			Flags:   flags,
 		},
+		{
+			Name:  "migrate",
+			Usage: "Manage the migrations of the SQL DB",
+			Subcommands: []cli.Command{
+				{
+					Name:   "up",
+					Usage:  "Apply all the pending migrations",
+					Action: cmdMigrateUp,
+					Flags:  migrateFlags,
+				},
+				{
+					Name: "down",
+					Usage: "Revert the last N applied migrations.  Refuses to run " +
+						"while a node is using the DB",
+					ArgsUsage: "N",
+					Action:    cmdMigrateDown,
+					Flags:     migrateFlags,
+				},
+				{
+					Name:   "status",
+					Usage:  "Show the applied and pending migrations",
+					Action: cmdMigrateStatus,
+					Flags:  migrateFlags,
+				},
+				{
+					Name: "verify",
+					Usage: "Compare the schema of the DB with the schema " +
+						"obtained by applying all the migrations to a scratch DB",
+					Action: cmdMigrateVerify,
+					Flags:  migrateFlags,
+				},
+			},
+		},
+		{
+			Name:  "statedb",
+			Usage: "Manage the synchronizer StateDB",
+			Subcommands: []cli.Command{
+				{
+					Name: "verify",
+					Usage: "Recompute the merkle tree roots of the StateDB " +
+						"checkpoints from their leaves and compare them with the " +
+						"stored and on-chain roots.  Refuses to run while a node " +
+						"is using the DB",
+					Action: cmdStateDBVerify,
+					Flags:  stateDBVerifyFlags,
+				},
+			},
+		},
+		{
+			Name:  "snapshot",
+			Usage: "Export and import snapshots of the synchronizer state",
+			Subcommands: []cli.Command{
+				{
+					Name: "export",
+					Usage: "Write the StateDB checkpoint and the HistoryDB rows of a " +
+						"batch to a snapshot archive.  Refuses to run while a node " +
+						"is using the DB",
+					Action: cmdSnapshotExport,
+					Flags:  snapshotExportFlags,
+				},
+				{
+					Name: "import",
+					Usage: "Bootstrap an empty node from a snapshot archive after " +
+						"verifying it against the roots forged on chain.  Refuses " +
+						"to run while a node is using the DB",
+					Action: cmdSnapshotImport,
+					Flags:  snapshotImportFlags,
+				},
+			},
+		},
 	}
 
 	err := app.Run(os.Args)
@@ -142,7 +637,4 @@ func main() {
 		fmt.Printf("\nError: %v\n", common.Wrap(err))
 		os.Exit(1)
 	}
-
-	router := gin.Default()
-	router.Run("localhost:8080")
 }
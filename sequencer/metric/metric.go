@@ -3,7 +3,9 @@ package metric
 import "github.com/prometheus/client_golang/prometheus"
 
 const (
-	namespaceSync = "synchronizer"
+	namespaceSync      = "synchronizer"
+	namespaceHistoryDB = "historydb"
+	namespaceEth       = "eth"
 )
 
 var (
@@ -45,4 +47,106 @@ var (
 			Name:      "eth_last_batch_num",
 			Help:      "",
 		})
+
+	// L1QueueMismatches number of differences between the L1 user tx
+	// queues in the HistoryDB and in the smart contract found in the last
+	// reconciliation
+	L1QueueMismatches = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespaceSync,
+			Name:      "l1_queue_mismatches",
+			Help:      "",
+		})
+
+	// ReplicaLagBlocks number of blocks the historyDB read replica is
+	// behind the write server
+	ReplicaLagBlocks = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespaceHistoryDB,
+			Name:      "replica_lag_blocks",
+			Help:      "",
+		})
 )
+
+var (
+	// EthEndpointRequests number of calls made to each ethereum node
+	// endpoint, by method
+	EthEndpointRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEth,
+			Name:      "endpoint_requests",
+			Help:      "",
+		}, []string{"endpoint", "method"})
+
+	// EthEndpointErrors number of calls to each ethereum node endpoint that
+	// failed because of the endpoint, by method
+	EthEndpointErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEth,
+			Name:      "endpoint_errors",
+			Help:      "",
+		}, []string{"endpoint", "method"})
+
+	// EthEndpointLatency duration of the calls to each ethereum node
+	// endpoint, in seconds
+	EthEndpointLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespaceEth,
+			Name:      "endpoint_latency_seconds",
+			Help:      "",
+		}, []string{"endpoint"})
+
+	// EthEndpointBlockLag number of blocks each ethereum node endpoint is
+	// behind the most advanced one
+	EthEndpointBlockLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEth,
+			Name:      "endpoint_block_lag",
+			Help:      "",
+		}, []string{"endpoint"})
+
+	// EthEndpointCircuitOpen is 1 while the circuit breaker of an ethereum
+	// node endpoint is open, and 0 otherwise
+	EthEndpointCircuitOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEth,
+			Name:      "endpoint_circuit_open",
+			Help:      "",
+		}, []string{"endpoint"})
+
+	// EthSubscriptionConnected is 1 while the websocket subscriptions to
+	// the new blocks are active, and 0 while the synchronizer falls back
+	// to polling
+	EthSubscriptionConnected = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespaceEth,
+			Name:      "subscription_connected",
+			Help:      "",
+		})
+
+	// EthNotifications number of new block notifications sent to the
+	// synchronizer, by source
+	EthNotifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceEth,
+			Name:      "notifications",
+			Help:      "",
+		}, []string{"source"})
+)
+
+func init() {
+	prometheus.MustRegister(Reorgs)
+	prometheus.MustRegister(LastBlockNum)
+	prometheus.MustRegister(EthLastBlockNum)
+	prometheus.MustRegister(LastBatchNum)
+	prometheus.MustRegister(EthLastBatchNum)
+	prometheus.MustRegister(L1QueueMismatches)
+	prometheus.MustRegister(ReplicaLagBlocks)
+	prometheus.MustRegister(EthEndpointRequests)
+	prometheus.MustRegister(EthEndpointErrors)
+	prometheus.MustRegister(EthEndpointLatency)
+	prometheus.MustRegister(EthEndpointBlockLag)
+	prometheus.MustRegister(EthEndpointCircuitOpen)
+	prometheus.MustRegister(EthSubscriptionConnected)
+	prometheus.MustRegister(EthNotifications)
+}
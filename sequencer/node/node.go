@@ -29,6 +29,7 @@ import (
 	"tokamak-sybil-resistance/coordinator"
 	dbUtils "tokamak-sybil-resistance/database"
 	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/kvdb"
 	"tokamak-sybil-resistance/database/l2db"
 	"tokamak-sybil-resistance/database/statedb"
 	"tokamak-sybil-resistance/eth"
@@ -41,7 +42,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/iden3/go-iden3-crypto/babyjub"
@@ -49,6 +52,11 @@ import (
 	"github.com/russross/meddler"
 )
 
+const (
+	defaultReplicaLagCheckInterval = 5 * time.Second
+	defaultL1QueueCheckInterval    = 5 * time.Minute
+)
+
 // Mode sets the working mode of the node (synchronizer or coordinator)
 type Mode string
 
@@ -73,13 +81,18 @@ type Node struct {
 	coord *coordinator.Coordinator
 
 	// Synchronizer
-	sync *synchronizer.Synchronizer
+	sync          *synchronizer.Synchronizer
+	blockNotifier *eth.BlockNotifier
+
+	// Ethereum client
+	ethClient *eth.MultiClient
 
 	// General
 	cfg          *config.Node
 	mode         Mode
 	sqlConnRead  *sqlx.DB
 	sqlConnWrite *sqlx.DB
+	nodeLock     *dbUtils.NodeLock
 	historyDB    *historydb.HistoryDB
 	ctx          context.Context
 	wg           sync.WaitGroup
@@ -129,11 +142,18 @@ func NewNodeAPI(
 	}, nil
 }
 
-// NewNode creates a Node
-func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
-	meddler.Debug = cfg.Debug.MeddlerLogs
-	// Stablish DB connection
-	dbWrite, err := dbUtils.InitSQLDB(
+// initSQLDBs opens the write and read connections to the SQL DB configured in
+// cfg, and runs the migrations.  Both connections are the same unless a
+// PostgreSQL read server is configured.
+func initSQLDBs(cfg *config.Node) (dbWrite, dbRead *sqlx.DB, err error) {
+	if cfg.Database.Driver == config.DatabaseDriverSQLite {
+		dbWrite, err = dbUtils.InitSQLiteDB(cfg.Database.SQLitePath)
+		if err != nil {
+			return nil, nil, common.Wrap(fmt.Errorf("dbUtils.InitSQLiteDB: %w", err))
+		}
+		return dbWrite, dbWrite, nil
+	}
+	dbWrite, err = dbUtils.InitSQLDB(
 		cfg.PostgreSQL.PortWrite,
 		cfg.PostgreSQL.HostWrite,
 		cfg.PostgreSQL.UserWrite,
@@ -141,13 +161,12 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 		cfg.PostgreSQL.NameWrite,
 	)
 	if err != nil {
-		return nil, common.Wrap(fmt.Errorf("dbUtils.InitSQLDB: %w", err))
+		return nil, nil, common.Wrap(fmt.Errorf("dbUtils.InitSQLDB: %w", err))
 	}
-	var dbRead *sqlx.DB
 	if cfg.PostgreSQL.HostRead == "" {
 		dbRead = dbWrite
 	} else if cfg.PostgreSQL.HostRead == cfg.PostgreSQL.HostWrite {
-		return nil, common.Wrap(fmt.Errorf(
+		return nil, nil, common.Wrap(fmt.Errorf(
 			"PostgreSQL.HostRead and PostgreSQL.HostWrite must be different",
 		))
 	} else {
@@ -159,9 +178,36 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 			cfg.PostgreSQL.NameRead,
 		)
 		if err != nil {
-			return nil, common.Wrap(fmt.Errorf("dbUtils.InitSQLDB: %w", err))
+			return nil, nil, common.Wrap(fmt.Errorf("dbUtils.InitSQLDB: %w", err))
 		}
 	}
+	return dbWrite, dbRead, nil
+}
+
+// NewNode creates a Node
+func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
+	meddler.Debug = cfg.Debug.MeddlerLogs
+	// Stablish DB connection
+	dbWrite, dbRead, err := initSQLDBs(cfg)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	// Hold the node lock while running so that the migrations can't be
+	// run down under our feet
+	nodeLock, err := dbUtils.TryNodeLock(dbWrite)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("dbUtils.TryNodeLock: %w", err))
+	}
+	// Release the node lock if the node can't be created
+	created := false
+	defer func() {
+		if created {
+			return
+		}
+		if err := nodeLock.Release(); err != nil {
+			log.Errorw("NodeLock.Release", "err", err)
+		}
+	}()
 	var apiConnCon *dbUtils.APIConnectionController
 	if cfg.API.Explorer || mode == ModeCoordinator {
 		apiConnCon = dbUtils.NewAPIConnectionController(
@@ -171,18 +217,34 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 	}
 
 	historyDB := historydb.NewHistoryDB(dbRead, dbWrite, apiConnCon)
+	historyDB.SetMaxReplicaLag(cfg.PostgreSQL.MaxReplicaLag)
 
-	ethClient, err := ethclient.Dial(cfg.Web3.URL)
+	rpcClient, err := rpc.Dial(cfg.Web3.URL)
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
+	ethClient := ethclient.NewClient(rpcClient)
+	var etherScanService *etherscan.Service
+	if cfg.Coordinator.Etherscan.URL != "" && cfg.Coordinator.Etherscan.APIKey != "" {
+		log.Info("EtherScan method detected in cofiguration file")
+		etherScanService, _ = etherscan.NewEtherscanService(cfg.Coordinator.Etherscan.URL,
+			cfg.Coordinator.Etherscan.APIKey)
+	} else {
+		log.Info("EtherScan method not configured in config file")
+		etherScanService = nil
+	}
 	var ethCfg eth.EthereumConfig
-	var account *accounts.Account
+	var signer eth.Signer
 	var keyStore *keystore.KeyStore
 	if mode == ModeCoordinator {
+		// The transactions sent by the eth client are priced like the
+		// forgeBatch ones of the TxManager
 		ethCfg = eth.EthereumConfig{
 			CallGasLimit: 0, // cfg.Coordinator.EthClient.CallGasLimit,
-			GasPriceDiv:  0, // cfg.Coordinator.EthClient.GasPriceDiv,
+			GasPricer:    cfg.Coordinator.EthClient.GasPricer,
+		}
+		if etherScanService != nil {
+			ethCfg.Etherscan = etherScanService
 		}
 
 		scryptN := keystore.StandardScryptN
@@ -211,29 +273,45 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 			"minForgeBalance", minForgeBalance,
 		)
 
-		// Unlock Coordinator ForgerAddr in the keystore to make calls
-		// to ForgeBatch in the smart contract
-		if !keyStore.HasAddress(cfg.Coordinator.ForgerAddress) {
-			return nil, common.Wrap(fmt.Errorf(
-				"ethereum keystore doesn't have the key for address %v",
-				cfg.Coordinator.ForgerAddress))
-		}
-		account = &accounts.Account{
-			Address: cfg.Coordinator.ForgerAddress,
-		}
-		if err := keyStore.Unlock(*account,
-			cfg.Coordinator.EthClient.Keystore.Password); err != nil {
-			return nil, common.Wrap(err)
+		// Set up the signer of the Coordinator ForgerAddr to make
+		// calls to ForgeBatch in the smart contract
+		switch cfg.Coordinator.EthClient.Signer.Type {
+		case eth.SignerTypeKeyStore, "":
+			signer, err = eth.NewKeyStoreSigner(keyStore, cfg.Coordinator.ForgerAddress,
+				cfg.Coordinator.EthClient.Keystore.Password)
+			if err != nil {
+				return nil, common.Wrap(err)
+			}
+			log.Infow("Forger ethereum account unlocked in the keystore",
+				"addr", cfg.Coordinator.ForgerAddress)
+		case eth.SignerTypeExternal:
+			signer, err = eth.NewExternalSigner(cfg.Coordinator.EthClient.Signer.URL,
+				cfg.Coordinator.ForgerAddress)
+			if err != nil {
+				return nil, common.Wrap(err)
+			}
+			log.Infow("Forger ethereum account managed by the external signer",
+				"addr", cfg.Coordinator.ForgerAddress)
+		default:
+			return nil, common.Wrap(fmt.Errorf("invalid signer type: %v",
+				cfg.Coordinator.EthClient.Signer.Type))
 		}
-		log.Infow("Forger ethereum account unlocked in the keystore",
-			"addr", cfg.Coordinator.ForgerAddress)
 	}
-	client, err := eth.NewClient(ethClient, account, keyStore, &eth.ClientConfig{
-		Ethereum: ethCfg,
-		Rollup: eth.RollupConfig{
-			Address: cfg.SmartContracts.Rollup,
-		},
-	})
+	client, err := eth.DialMultiClient(append([]string{cfg.Web3.URL}, cfg.Web3.URLs...),
+		signer, &eth.ClientConfig{
+			Ethereum: ethCfg,
+			Rollup: eth.RollupConfig{
+				Address: cfg.SmartContracts.Rollup,
+			},
+		}, eth.MultiClientConfig{
+			Attempts:            cfg.Web3.Attempts,
+			AttemptsDelay:       cfg.Web3.AttemptsDelay.Duration,
+			MaxAttemptsDelay:    cfg.Web3.MaxAttemptsDelay.Duration,
+			FailureThreshold:    cfg.Web3.FailureThreshold,
+			CircuitOpenTimeout:  cfg.Web3.CircuitOpenTimeout.Duration,
+			MaxBlockLag:         cfg.Web3.MaxBlockLag,
+			HealthCheckInterval: cfg.Web3.HealthCheckInterval.Duration,
+		})
 
 	if err != nil {
 		return nil, common.Wrap(err)
@@ -254,11 +332,16 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 	}
 	chainIDU16 := uint16(chainIDU64)
 
+	backend, err := kvdb.BackendByName(cfg.StateDB.Backend)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
 	stateDB, err := statedb.NewStateDB(statedb.Config{
 		Path:    cfg.StateDB.Path,
 		Keep:    cfg.StateDB.Keep,
 		Type:    statedb.TypeSynchronizer,
 		NLevels: statedb.MaxNLevels,
+		Backend: backend,
 	})
 	if err != nil {
 		return nil, common.Wrap(err)
@@ -281,11 +364,25 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 		StatsUpdateBlockNumDiffThreshold: cfg.Synchronizer.StatsUpdateBlockNumDiffThreshold,
 		StatsUpdateFrequencyDivider:      cfg.Synchronizer.StatsUpdateFrequencyDivider,
 		ChainID:                          chainIDU16,
+		CatchUpBlocks:                    cfg.Synchronizer.CatchUpBlocks,
+		CatchUpWorkers:                   cfg.Synchronizer.CatchUpWorkers,
+		FinalityDepth:                    cfg.Synchronizer.FinalityDepth,
 	})
 	if err != nil {
 		return nil, common.Wrap(err)
 	}
 	initSCVars := sync.SCVars()
+	var blockNotifier *eth.BlockNotifier
+	if cfg.Synchronizer.WebSocketURL != "" {
+		blockNotifier, err = eth.NewBlockNotifier(eth.BlockNotifierConfig{
+			URL:            cfg.Synchronizer.WebSocketURL,
+			Addresses:      []ethCommon.Address{cfg.SmartContracts.Rollup},
+			ReconnectDelay: cfg.Synchronizer.WebSocketReconnectDelay.Duration,
+		})
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
 
 	scConsts := common.SCConsts{
 		Rollup: *sync.RollupConstants(),
@@ -310,15 +407,6 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 	if err := historyDB.SetConstants(&hdbConsts); err != nil {
 		return nil, common.Wrap(err)
 	}
-	var etherScanService *etherscan.Service
-	if cfg.Coordinator.Etherscan.URL != "" && cfg.Coordinator.Etherscan.APIKey != "" {
-		log.Info("EtherScan method detected in cofiguration file")
-		etherScanService, _ = etherscan.NewEtherscanService(cfg.Coordinator.Etherscan.URL,
-			cfg.Coordinator.Etherscan.APIKey)
-	} else {
-		log.Info("EtherScan method not configured in config file")
-		etherScanService = nil
-	}
 	stateAPIUpdater, err := stateapiupdater.NewUpdater(
 		historyDB,
 		&hdbNodeCfg,
@@ -402,7 +490,7 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 		}
 		var verifierIdx int
 		if cfg.Coordinator.Debug.RollupVerifierIndex == nil {
-			verifierIdx, err = scConsts.Rollup.FindVerifierIdx(
+			verifierIdx, err = initSCVars.Rollup.FindVerifierIdx(&scConsts.Rollup,
 				cfg.Coordinator.Circuit.MaxTx,
 				cfg.Coordinator.Circuit.NLevels,
 			)
@@ -413,13 +501,14 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 		} else {
 			verifierIdx = *cfg.Coordinator.Debug.RollupVerifierIndex
 			log.Infow("Using debug verifier index from config", "verifierIdx", verifierIdx)
-			if verifierIdx >= len(scConsts.Rollup.Verifiers) {
+			verifiers := initSCVars.Rollup.GetVerifiers(&scConsts.Rollup)
+			if verifierIdx >= len(verifiers) {
 				return nil, common.Wrap(
 					fmt.Errorf("verifierIdx (%v) >= "+
-						"len(scConsts.Rollup.Verifiers) (%v)",
-						verifierIdx, len(scConsts.Rollup.Verifiers)))
+						"len(verifiers) (%v)",
+						verifierIdx, len(verifiers)))
 			}
-			verifier := scConsts.Rollup.Verifiers[verifierIdx]
+			verifier := verifiers[verifierIdx]
 			if verifier.MaxTx != cfg.Coordinator.Circuit.MaxTx ||
 				verifier.NLevels != cfg.Coordinator.Circuit.NLevels {
 				return nil, common.Wrap(
@@ -452,6 +541,7 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 				MaxGasPrice:             cfg.Coordinator.EthClient.MaxGasPrice,
 				MinGasPrice:             cfg.Coordinator.EthClient.MinGasPrice,
 				GasPriceIncPerc:         cfg.Coordinator.EthClient.GasPriceIncPerc,
+				GasPricer:               cfg.Coordinator.EthClient.GasPricer,
 				TxManagerCheckInterval:  cfg.Coordinator.EthClient.CheckLoopInterval.Duration,
 				DebugBatchPath:          cfg.Coordinator.Debug.BatchPath,
 				Purger: coordinator.PurgerCfg{
@@ -502,18 +592,20 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 				if err != nil {
 					log.Warn("error getting registered addresses from the SMC or no addresses registered. error:", err.Error())
 				}
-				// Get Ethereum private key of the coordinator
-				keyJSON, err := keyStore.Export(*account, cfg.Coordinator.EthClient.Keystore.Password, cfg.Coordinator.EthClient.Keystore.Password)
-				if err != nil {
-					return nil, common.Wrap(err)
+				// Get Ethereum private key of the coordinator, only
+				// available when it's kept in the keystore
+				keyStoreSigner, ok := signer.(*eth.KeyStoreSigner)
+				if !ok {
+					return nil, common.Wrap(fmt.Errorf(
+						"the coordinator network requires the KeyStore signer"))
 				}
-				key, err := keystore.DecryptKey(keyJSON, cfg.Coordinator.EthClient.Keystore.Password)
+				key, err := keyStoreSigner.PrivateKey(cfg.Coordinator.EthClient.Keystore.Password)
 				if err != nil {
 					return nil, common.Wrap(err)
 				}
 				coordnetConfig = &api.CoordinatorNetworkConfig{
 					BootstrapPeers: nil,
-					EthPrivKey:     key.PrivateKey,
+					EthPrivKey:     key,
 				}
 			}
 		}
@@ -526,6 +618,7 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 			HistoryDB:                historyDB,
 			L2DB:                     l2DB,
 			StateDB:                  stateDB,
+			Finality:                 sync,
 			EthClient:                ethClient,
 			ForgerAddress:            &cfg.Coordinator.ForgerAddress,
 			CoordinatorNetworkConfig: coordnetConfig,
@@ -541,38 +634,174 @@ func NewNode(mode Mode, cfg *config.Node, version string) (*Node, error) {
 	// 	debugAPI = debugapi.NewDebugAPI(cfg.Debug.APIAddress, stateDB, sync)
 	// }
 	ctx, cancel := context.WithCancel(context.Background())
+	created = true
 	return &Node{
 		stateAPIUpdater: stateAPIUpdater,
 		nodeAPI:         nodeAPI,
 		debugAPI:        nil, //debugAPI
 		coord:           coord,
 		sync:            sync,
+		blockNotifier:   blockNotifier,
+		ethClient:       client,
 		cfg:             cfg,
 		mode:            mode,
 		sqlConnRead:     dbRead,
 		sqlConnWrite:    dbWrite,
+		nodeLock:        nodeLock,
 		historyDB:       historyDB,
 		ctx:             ctx,
 		cancel:          cancel,
 	}, nil
 }
 
-// TODO: Update Start and Stop functionalities and Start functionality for coordinator, synchronizer
+// StartReplicaLagChecker periodically measures the lag of the read replica so
+// that explorer reads fall back to the write server when it's too far behind
+func (n *Node) StartReplicaLagChecker() {
+	if n.sqlConnRead == n.sqlConnWrite {
+		return
+	}
+	interval := n.cfg.PostgreSQL.ReplicaLagCheckInterval.Duration
+	if interval == 0 {
+		interval = defaultReplicaLagCheckInterval
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for {
+			if lag, err := n.historyDB.UpdateReplicaLag(); err != nil {
+				log.Warnw("historyDB.UpdateReplicaLag", "err", err)
+			} else {
+				log.Debugw("HistoryDB read replica lag", "blocks", lag)
+			}
+			select {
+			case <-n.ctx.Done():
+				log.Info("ReplicaLagChecker done")
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// StartL1QueueReconciler periodically checks that the L1 user tx queues
+// synced in the HistoryDB match the ones in the Rollup Smart Contract.  Each
+// check makes a call per unforged queue, so it's only run by the coordinator,
+// which forges the queues.
+func (n *Node) StartL1QueueReconciler() {
+	interval := n.cfg.Synchronizer.L1QueueCheckInterval.Duration
+	if interval == 0 {
+		interval = defaultL1QueueCheckInterval
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		for {
+			select {
+			case <-n.ctx.Done():
+				log.Info("L1QueueReconciler done")
+				return
+			case <-time.After(interval):
+			}
+			if report, err := n.sync.ReconcileL1Queue(n.ctx); err != nil {
+				log.Warnw("Synchronizer.ReconcileL1Queue", "err", err)
+			} else if report != nil {
+				log.Debugw("L1 user tx queues reconciled", "blockNum", report.BlockNum,
+					"mismatches", len(report.Mismatches))
+			}
+		}
+	}()
+}
+
+// syncLoopFn synchronizes the next block, and returns the last synced block
+// and the time to wait before the next call
+func (n *Node) syncLoopFn(ctx context.Context, lastBlock *common.Block) (*common.Block,
+	time.Duration, error) {
+	blockData, discarded, err := n.sync.Sync(ctx, lastBlock)
+	if err != nil {
+		// case: error
+		return nil, n.cfg.Synchronizer.SyncLoopInterval.Duration, common.Wrap(err)
+	} else if discarded != nil {
+		// case: reorg
+		log.Infow("Synchronizer.Sync reorg", "discarded", *discarded)
+		if n.mode == ModeCoordinator {
+			n.coord.SendMsg(ctx, coordinator.MsgSyncReorg{
+				Stats: *n.sync.Stats(),
+				Vars:  *n.sync.SCVars().AsPtr(),
+			})
+		}
+		return nil, time.Duration(0), nil
+	} else if blockData != nil {
+		// case: new block
+		if n.mode == ModeCoordinator {
+			n.coord.SendMsg(ctx, coordinator.MsgSyncBlock{
+				Stats:   *n.sync.Stats(),
+				Batches: blockData.Rollup.Batches,
+				Vars:    common.SCVariablesPtr{Rollup: blockData.Rollup.Vars},
+			})
+		}
+		return &blockData.Block, time.Duration(0), nil
+	}
+	// case: no block
+	return lastBlock, n.cfg.Synchronizer.SyncLoopInterval.Duration, nil
+}
+
+// StartSynchronizer starts the loop that synchronizes the blocks.  The loop
+// polls every SyncLoopInterval, and if the block notifier is enabled it's
+// also woken up as soon as a new block is mined.  The blocks are always
+// synchronized in order by the Synchronizer, which detects the reorgs, so a
+// notification only brings the next Sync forward.
+func (n *Node) StartSynchronizer() {
+	log.Info("Starting Synchronizer...")
+	var notifications <-chan struct{}
+	if n.blockNotifier != nil {
+		n.blockNotifier.Start()
+		notifications = n.blockNotifier.C()
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		var err error
+		var lastBlock *common.Block
+		waitDuration := time.Duration(0)
+		for {
+			select {
+			case <-n.ctx.Done():
+				log.Info("Synchronizer done")
+				return
+			case <-notifications:
+			case <-time.After(waitDuration):
+			}
+			if lastBlock, waitDuration, err = n.syncLoopFn(n.ctx, lastBlock); err != nil {
+				if n.ctx.Err() != nil {
+					continue
+				}
+				log.Errorw("Synchronizer.Sync", "err", err)
+			}
+		}
+	}()
+}
+
+// TODO: Update Start and Stop functionalities and Start functionality for coordinator
 // StartDebugAPI, StartNodeAPI
 // Start the node
 func (n *Node) Start() {
 	log.Infow("Starting node...", "mode", n.mode)
+	n.ethClient.Start()
+	n.StartReplicaLagChecker()
 	// if n.debugAPI != nil {
 	// 	n.StartDebugAPI()
 	// }
 	// if n.nodeAPI != nil {
 	// 	n.StartNodeAPI()
 	// }
-	// if n.mode == ModeCoordinator {
-	// 	log.Info("Starting Coordinator...")
-	// 	n.coord.Start()
-	// }
-	// n.StartSynchronizer()
+	if n.mode == ModeCoordinator {
+		log.Info("Starting Coordinator...")
+		n.coord.Start()
+	}
+	n.StartSynchronizer()
+	if n.mode == ModeCoordinator {
+		n.StartL1QueueReconciler()
+	}
 }
 
 // Stop the node
@@ -580,10 +809,17 @@ func (n *Node) Stop() {
 	log.Infow("Stopping node...")
 	n.cancel()
 	n.wg.Wait()
-	// if n.mode == ModeCoordinator {
-	// 	log.Info("Stopping Coordinator...")
-	// 	n.coord.Stop()
-	// }
+	if n.blockNotifier != nil {
+		n.blockNotifier.Stop()
+	}
+	n.ethClient.Stop()
+	if err := n.nodeLock.Release(); err != nil {
+		log.Errorw("NodeLock.Release", "err", err)
+	}
+	if n.mode == ModeCoordinator {
+		log.Info("Stopping Coordinator...")
+		n.coord.Stop()
+	}
 	// // Close kv DBs
 	// n.sync.StateDB().Close()
 	// if n.mode == ModeCoordinator {
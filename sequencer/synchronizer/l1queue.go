@@ -0,0 +1,140 @@
+package synchronizer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/log"
+	"tokamak-sybil-resistance/metric"
+)
+
+// L1QueueReport is the result of the reconciliation of the L1UserTx queues
+// synced in the HistoryDB with the ones in the Rollup Smart Contract
+type L1QueueReport struct {
+	// BlockNum is the ethereum block at which the queues were compared
+	BlockNum int64
+	// NextToForgeQueue, NextFillingQueue and LastL1L2Batch are read from
+	// the Rollup Smart Contract
+	NextToForgeQueue int64
+	NextFillingQueue int64
+	LastL1L2Batch    int64
+	// Mismatches describes each difference found
+	Mismatches []string
+}
+
+// ReconcileL1Queue checks that the L1UserTx queues synced in the HistoryDB
+// match the state of the Rollup Smart Contract at the last synced block: the
+// next queue to forge, the block of the last L1 batch and the bytes of every
+// unforged queue.  The check is skipped, returning a nil report, if no block
+// has been synced yet, or if the last synced block is reorged or a new one is
+// synced while checking.  The mismatches are logged as errors and exported in
+// the L1QueueMismatches metric.  A queue with L1UserTxs without BJJ can
+// mismatch because of the positions assigned by the smart contract, as
+// described in eth.RollupL1UserTxBytes, and not because of a sync bug.  It's
+// safe to call concurrently with Sync.
+func (s *Synchronizer) ReconcileL1Queue(ctx context.Context) (*L1QueueReport, error) {
+	lastBlock, err := s.historyDB.GetLastBlock()
+	if common.Unwrap(err) == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, common.Wrap(fmt.Errorf("historyDB.GetLastBlock: %w", err))
+	}
+
+	report := L1QueueReport{BlockNum: lastBlock.Num}
+	if report.NextToForgeQueue, err = s.EthClient.RollupNextL1ToForgeQueue(
+		lastBlock.Num); err != nil {
+		return nil, common.Wrap(fmt.Errorf("RollupNextL1ToForgeQueue: %w", err))
+	}
+	if report.NextFillingQueue, err = s.EthClient.RollupNextL1FillingQueue(
+		lastBlock.Num); err != nil {
+		return nil, common.Wrap(fmt.Errorf("RollupNextL1FillingQueue: %w", err))
+	}
+	if report.LastL1L2Batch, err = s.EthClient.RollupLastL1L2Batch(lastBlock.Num); err != nil {
+		return nil, common.Wrap(fmt.Errorf("RollupLastL1L2Batch: %w", err))
+	}
+	queues := make(map[int64][]byte)
+	for i := report.NextToForgeQueue; i <= report.NextFillingQueue; i++ {
+		if queues[i], err = s.EthClient.RollupL1TransactionQueue(i, lastBlock.Num); err != nil {
+			return nil, common.Wrap(fmt.Errorf("RollupL1TransactionQueue(%v): %w", i, err))
+		}
+	}
+	// The calls are done by block number, so they read another chain if
+	// the block has been reorged but the reorg is not synced yet
+	ethBlock, err := s.EthClient.EthBlockByNumber(ctx, lastBlock.Num)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("EthBlockByNumber: %w", err))
+	}
+	if ethBlock.Hash != lastBlock.Hash {
+		log.Debugw("ReconcileL1Queue skipped: reorg", "blockNum", lastBlock.Num)
+		return nil, nil
+	}
+
+	lastForgeL1TxsNum, err := s.historyDB.GetLastL1TxsNum()
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("historyDB.GetLastL1TxsNum: %w", err))
+	}
+	nextForgeL1TxsNum := int64(0)
+	if lastForgeL1TxsNum != nil {
+		nextForgeL1TxsNum = *lastForgeL1TxsNum + 1
+	}
+	if nextForgeL1TxsNum != report.NextToForgeQueue {
+		report.Mismatches = append(report.Mismatches, fmt.Sprintf(
+			"next queue to forge: %v in HistoryDB, %v in the smart contract",
+			nextForgeL1TxsNum, report.NextToForgeQueue))
+	}
+	lastL1BatchBlockNum, err := s.historyDB.GetLastL1BatchBlockNum()
+	if err != nil && common.Unwrap(err) != sql.ErrNoRows {
+		return nil, common.Wrap(fmt.Errorf("historyDB.GetLastL1BatchBlockNum: %w", err))
+	}
+	if lastL1BatchBlockNum != report.LastL1L2Batch {
+		report.Mismatches = append(report.Mismatches, fmt.Sprintf(
+			"last L1 batch block: %v in HistoryDB, %v in the smart contract",
+			lastL1BatchBlockNum, report.LastL1L2Batch))
+	}
+	for i := report.NextToForgeQueue; i <= report.NextFillingQueue; i++ {
+		l1UserTxs, err := s.historyDB.GetUnforgedL1UserTxs(i)
+		if err != nil {
+			return nil, common.Wrap(fmt.Errorf("historyDB.GetUnforgedL1UserTxs: %w", err))
+		}
+		var queue []byte
+		for j := range l1UserTxs {
+			txBytes, err := eth.RollupL1UserTxBytes(&l1UserTxs[j])
+			if err != nil {
+				report.Mismatches = append(report.Mismatches, fmt.Sprintf(
+					"queue %v: L1UserTx %v can't be packed as in the smart contract: %v",
+					i, l1UserTxs[j].TxID, err))
+				queue = nil
+				break
+			}
+			queue = append(queue, txBytes...)
+		}
+		if queue == nil && len(l1UserTxs) > 0 {
+			continue
+		}
+		if !bytes.Equal(queue, queues[i]) {
+			report.Mismatches = append(report.Mismatches, fmt.Sprintf(
+				"queue %v: %v L1UserTxs (%v bytes) in HistoryDB, %v bytes in the smart contract",
+				i, len(l1UserTxs), len(queue), len(queues[i])))
+		}
+	}
+
+	// The HistoryDB is read at the last synced block, which is not the one
+	// of the calls if a block has been synced while checking
+	if lastBlockAfter, err := s.historyDB.GetLastBlock(); err != nil {
+		return nil, common.Wrap(fmt.Errorf("historyDB.GetLastBlock: %w", err))
+	} else if lastBlockAfter.Num != lastBlock.Num {
+		log.Debugw("ReconcileL1Queue skipped: new block synced while checking",
+			"blockNum", lastBlock.Num, "lastBlock", lastBlockAfter.Num)
+		return nil, nil
+	}
+
+	for _, mismatch := range report.Mismatches {
+		log.Errorw("L1 user tx queue mismatch between HistoryDB and the smart contract",
+			"blockNum", report.BlockNum, "mismatch", mismatch)
+	}
+	metric.L1QueueMismatches.Set(float64(len(report.Mismatches)))
+	return &report, nil
+}
@@ -0,0 +1,69 @@
+package synchronizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileL1Queue(t *testing.T) {
+	// The amounts of the unforged txs must be representable in the
+	// smart contract
+	blocks, _ := reorgBlocks(t, reorgPrefix+`
+		CreateAccountDeposit D: 30000000000000 // Idx=259
+		Deposit A: 10000000000
+		> block
+	`, "")
+	n := newReorgNode(t)
+	defer n.close()
+	n.addBlocks(blocks, 0)
+	ctx := context.Background()
+
+	// The queues are compared at the last synced block, which doesn't
+	// need to be the last block of the chain
+	lastBlock, err := n.historyDB.GetLastBlock()
+	require.NoError(t, err)
+	report, err := n.sync.ReconcileL1Queue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Equal(t, lastBlock.Num, report.BlockNum)
+	assert.Empty(t, report.Mismatches)
+
+	n.syncAll()
+	report, err = n.sync.ReconcileL1Queue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Empty(t, report.Mismatches)
+	// Two queues have been forged, and the txs of the last block fill
+	// the queue after the next one
+	assert.Equal(t, int64(2), report.NextToForgeQueue)
+	assert.Equal(t, int64(3), report.NextFillingQueue)
+	lastL1BatchBlockNum, err := n.historyDB.GetLastL1BatchBlockNum()
+	require.NoError(t, err)
+	assert.Equal(t, lastL1BatchBlockNum, report.LastL1L2Batch)
+	queue, err := n.client.RollupL1TransactionQueue(3, -1)
+	require.NoError(t, err)
+	// til sets the BJJ of both txs
+	assert.Equal(t, 2*74, len(queue))
+
+	// An unforged tx that differs from the queue is found
+	for _, depositAmount := range []string{"20000000000", "1"} {
+		_, err = n.historyDB.DB().Exec(`UPDATE tx SET deposit_amount = $1
+			WHERE to_forge_l1_txs_num = 3 AND position = 1;`, depositAmount)
+		require.NoError(t, err)
+		report, err = n.sync.ReconcileL1Queue(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, report)
+		require.Equal(t, 1, len(report.Mismatches))
+		assert.Contains(t, report.Mismatches[0], "queue 3")
+	}
+
+	// The check is skipped while the reorg of the last synced block is
+	// not synced
+	n.fork(1, nil, 1)
+	report, err = n.sync.ReconcileL1Queue(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, report)
+}
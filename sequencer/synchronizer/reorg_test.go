@@ -0,0 +1,426 @@
+package synchronizer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/l2db"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/test"
+	"tokamak-sybil-resistance/test/til"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reorgNode is a Synchronizer with its own test ethereum client and
+// databases, used to compare the state reached after a reorg with the one
+// reached by a clean sync of the canonical chain
+type reorgNode struct {
+	t         *testing.T
+	client    *test.Client
+	sync      *Synchronizer
+	stateDB   *statedb.StateDB
+	historyDB *historydb.HistoryDB
+	l2DB      *l2db.L2DB
+}
+
+func newReorgNode(t *testing.T) *reorgNode {
+	stateDB, historyDB, l2DB := newTestModules(t)
+	var timer timer
+	clientSetup := test.NewClientSetupExample()
+	clientSetup.ChainID = big.NewInt(int64(chainID))
+	client := test.NewClient(true, &timer, &ethCommon.Address{}, clientSetup)
+	s, err := NewSynchronizer(client, historyDB, l2DB, stateDB, Config{
+		StatsUpdateBlockNumDiffThreshold: 100,
+		StatsUpdateFrequencyDivider:      100,
+	})
+	require.NoError(t, err)
+	n := &reorgNode{
+		t:         t,
+		client:    client,
+		sync:      s,
+		stateDB:   stateDB,
+		historyDB: historyDB,
+		l2DB:      l2DB,
+	}
+	require.Nil(t, n.syncAll())
+	return n
+}
+
+func (n *reorgNode) close() {
+	closeTestModules(n.t, n.stateDB, n.historyDB, n.l2DB)
+}
+
+// addBlocks mines the blocks generated with til followed by emptyBlocks
+// blocks without events
+func (n *reorgNode) addBlocks(blocks []common.BlockData, emptyBlocks int) {
+	require.NoError(n.t, n.client.CtlAddBlocks(blocks))
+	for i := 0; i < emptyBlocks; i++ {
+		n.client.CtlMineBlock()
+	}
+}
+
+// fork discards the last depth blocks of the chain and mines the given ones
+// instead
+func (n *reorgNode) fork(depth int, blocks []common.BlockData, emptyBlocks int) {
+	for i := 0; i < depth; i++ {
+		n.client.CtlRollback()
+	}
+	n.addBlocks(blocks, emptyBlocks)
+}
+
+// addPoolTxs adds to the L2DB pool, as pending, the L2Txs forged in the given
+// blocks, and returns their TxIDs
+func (n *reorgNode) addPoolTxs(blocks []common.BlockData) []common.TxID {
+	var txIDs []common.TxID
+	for _, block := range blocks {
+		for _, batch := range block.Rollup.Batches {
+			for _, l2Tx := range batch.L2Txs {
+				tx := common.PoolL2Tx{
+					FromIdx: l2Tx.FromIdx,
+					ToIdx:   l2Tx.ToIdx,
+					Amount:  l2Tx.Amount,
+					Nonce:   l2Tx.Nonce,
+					Type:    l2Tx.Type,
+					State:   common.PoolL2TxStatePending,
+				}
+				if tx.Amount == nil {
+					tx.Amount = big.NewInt(0)
+				}
+				require.NoError(n.t, tx.SetID())
+				require.NoError(n.t, n.l2DB.AddTxTest(&tx))
+				txIDs = append(txIDs, tx.TxID)
+			}
+		}
+	}
+	return txIDs
+}
+
+// poolTxs returns the state of the pool txs with the given TxIDs
+func (n *reorgNode) poolTxs(txIDs []common.TxID) []poolState {
+	txs := make([]poolState, len(txIDs))
+	for i, txID := range txIDs {
+		require.NoError(n.t, n.l2DB.DB().Get(&txs[i],
+			"SELECT tx_id, state, batch_num FROM tx_pool WHERE tx_id = $1;", txID))
+	}
+	return txs
+}
+
+// syncAll syncs up to the last block of the chain, and returns the number of
+// blocks discarded by each reorg found
+func (n *reorgNode) syncAll() []int64 {
+	var discards []int64
+	for {
+		blockData, discarded, err := n.sync.Sync(context.Background(), nil)
+		require.NoError(n.t, err)
+		if discarded != nil {
+			discards = append(discards, *discarded)
+		} else if blockData == nil {
+			return discards
+		}
+	}
+}
+
+// poolState is the state of a tx in the L2DB pool
+type poolState struct {
+	TxID     common.TxID          `db:"tx_id"`
+	State    common.PoolL2TxState `db:"state"`
+	BatchNum *common.BatchNum     `db:"batch_num"`
+}
+
+// reorgState is the state of a node that must be the same after a reorg as
+// after a clean sync of the canonical chain.  The values that depend on the
+// hashes and times of the test chain are cleared, since they differ between
+// the two chains.
+type reorgState struct {
+	Blocks    []common.Block
+	Batches   []common.Batch
+	Accounts  []common.Account
+	Exits     []common.ExitInfo
+	L1UserTxs []common.L1Tx
+	L2Txs     []common.L2Tx
+	Vars      *common.RollupVariables
+	// StateDB accounts and tree roots at every batch checkpoint
+	StateAccounts []common.Account
+	Roots         []*statedb.TreeRoots
+	// L2DB
+	PoolTxs      []poolState
+	AccountAuths int
+}
+
+func (n *reorgNode) state() *reorgState {
+	t := n.t
+	var s reorgState
+	var err error
+	s.Blocks, err = n.historyDB.GetAllBlocks()
+	require.NoError(t, err)
+	for i := range s.Blocks {
+		s.Blocks[i].Hash = ethCommon.Hash{}
+		s.Blocks[i].ParentHash = ethCommon.Hash{}
+		s.Blocks[i].Timestamp = time.Time{}
+	}
+	s.Batches, err = n.historyDB.GetAllBatches()
+	require.NoError(t, err)
+	for i := range s.Batches {
+		s.Batches[i].EthTxHash = ethCommon.Hash{}
+	}
+	s.Accounts, err = n.historyDB.GetAllAccounts()
+	require.NoError(t, err)
+	s.Exits, err = n.historyDB.GetAllExits()
+	require.NoError(t, err)
+	s.L1UserTxs, err = n.historyDB.GetAllL1UserTxs()
+	require.NoError(t, err)
+	for i := range s.L1UserTxs {
+		s.L1UserTxs[i].EthTxHash = ethCommon.Hash{}
+	}
+	s.L2Txs, err = n.historyDB.GetAllL2Txs()
+	require.NoError(t, err)
+	s.Vars, err = n.historyDB.GetSCVars()
+	require.NoError(t, err)
+
+	s.StateAccounts, err = n.stateDB.TestGetAccounts()
+	require.NoError(t, err)
+	sort.SliceStable(s.StateAccounts, accountsCmp(s.StateAccounts))
+	for batchNum := common.BatchNum(1); batchNum <= n.stateDB.CurrentBatch(); batchNum++ {
+		view, err := n.stateDB.OpenAt(batchNum)
+		require.NoError(t, err)
+		roots, err := view.MTGetRoots()
+		view.Close()
+		require.NoError(t, err)
+		s.Roots = append(s.Roots, roots)
+	}
+	current, err := n.stateDB.LastMTGetRoots()
+	require.NoError(t, err)
+	s.Roots = append(s.Roots, current)
+
+	require.NoError(t, n.l2DB.DB().Select(&s.PoolTxs,
+		"SELECT tx_id, state, batch_num FROM tx_pool ORDER BY tx_id;"))
+	require.NoError(t, n.l2DB.DB().Get(&s.AccountAuths,
+		"SELECT COUNT(*) FROM account_creation_auth;"))
+	return &s
+}
+
+// reorgBlocks generates with til the blocks of the set prefix followed by
+// branch, and returns separately the ones of the prefix and of the branch.
+// The prefix must end with a new block.
+func reorgBlocks(t *testing.T, prefix, branch string) (prefixBlocks,
+	branchBlocks []common.BlockData) {
+	tc := til.NewContext(chainID, common.RollupConstMaxL1UserTx)
+	onlyPrefix, err := tc.GenerateBlocks(prefix)
+	require.NoError(t, err)
+
+	tc = til.NewContext(chainID, common.RollupConstMaxL1UserTx)
+	blocks, err := tc.GenerateBlocks(prefix + branch)
+	require.NoError(t, err)
+	// til doesn't set the StateRoots
+	for i := range blocks {
+		for j := range blocks[i].Rollup.Batches {
+			blocks[i].Rollup.Batches[j].Batch.StateRoot = big.NewInt(0)
+		}
+	}
+	require.NoError(t, tc.FillBlocksExtra(blocks, &til.ConfigExtra{CoordUser: "A"}))
+	tc.FillBlocksL1UserTxsBatchNum(blocks)
+	require.NoError(t, tc.FillBlocksForgedL1UserTxs(blocks))
+	return blocks[:len(onlyPrefix)], blocks[len(onlyPrefix):]
+}
+
+var reorgPrefix = `
+	Type: Blockchain
+
+	CreateAccountDeposit A: 2000 // Idx=256
+	CreateAccountDeposit B: 1000 // Idx=257
+	CreateAccountDeposit C: 500  // Idx=258
+
+	> batchL1 // forge L1UserTxs{nil}, freeze L1UserTxs{3}
+	> batchL1 // forge L1UserTxs{3}
+	> block
+
+	CreateVouch A-B
+	CreateVouch B-C
+
+	> batch
+	> block
+`
+
+func TestSyncReorg(t *testing.T) {
+	testCases := []struct {
+		name string
+		// til set of the blocks mined after reorgPrefix, and number
+		// of empty blocks mined after them, in the discarded chain
+		// (A) and in the canonical chain (B)
+		branchA string
+		emptyA  int
+		branchB string
+		emptyB  int
+	}{
+		{
+			name: "several forged batches and created accounts",
+			branchA: `
+				CreateAccountDeposit D: 300 // Idx=259
+				Deposit A: 100
+				> batchL1 // forge L1UserTxs{nil}, freeze L1UserTxs{2}
+				> batchL1 // forge L1UserTxs{2}
+				> block
+
+				CreateVouch D-A
+				Exit C: 50
+				> batch
+				> block
+
+				ForceExit B: 200
+				> batchL1 // freeze L1UserTxs{1}
+				> batchL1 // forge L1UserTxs{1}
+				> block
+			`,
+			branchB: `
+				CreateAccountDeposit E: 700 // Idx=259
+				CreateAccountDeposit F: 800 // Idx=260
+				> batchL1
+				> batchL1
+				> block
+
+				DeleteVouch A-B
+				CreateVouch F-E
+				> batch
+				> block
+
+				> block
+
+				Exit A: 10
+				> batch
+				> block
+			`,
+		},
+		{
+			name: "L1 queue frozen in the discarded chain",
+			branchA: `
+				CreateAccountDeposit D: 300
+				> batchL1 // freeze L1UserTxs{1}
+				> block
+				Deposit C: 20
+				> block
+			`,
+			emptyA: 2,
+			branchB: `
+				CreateAccountDeposit E: 10
+				Deposit B: 30
+				> block
+				> batchL1 // freeze L1UserTxs{2}
+				> block
+				> batchL1 // forge L1UserTxs{2}
+				> block
+			`,
+			emptyB: 2,
+		},
+		{
+			name: "all the forged batches discarded",
+			branchA: `
+				Deposit A: 10
+				> batchL1
+				> batchL1
+				> block
+				CreateVouch C-A
+				> batch
+				> block
+			`,
+			branchB: ``,
+			emptyB:  3,
+		},
+		{
+			name:    "deep fork through empty blocks",
+			branchA: ``,
+			emptyA:  6,
+			branchB: `
+				> block
+				> block
+				> block
+				CreateAccountDeposit D: 300
+				> batchL1
+				> batchL1
+				> block
+			`,
+			emptyB: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prefixBlocks, blocksA := reorgBlocks(t, reorgPrefix, tc.branchA)
+			_, blocksB := reorgBlocks(t, reorgPrefix, tc.branchB)
+			depth := len(blocksA) + tc.emptyA
+			require.Less(t, depth, len(blocksB)+tc.emptyB,
+				"the canonical chain must be longer than the discarded one")
+
+			// Sync the chain A, with its L2Txs in the pool, and then
+			// fork it
+			node := newReorgNode(t)
+			poolTxIDs := node.addPoolTxs(blocksA)
+			node.addBlocks(prefixBlocks, 0)
+			node.addBlocks(blocksA, tc.emptyA)
+			require.Nil(t, node.syncAll())
+			for _, tx := range node.poolTxs(poolTxIDs) {
+				assert.Equal(t, common.PoolL2TxStateForged, tx.State)
+				assert.NotNil(t, tx.BatchNum)
+			}
+			node.fork(depth, blocksB, tc.emptyB)
+			assert.Equal(t, []int64{int64(depth)}, node.syncAll())
+			// the pool txs forged in the discarded chain are pending
+			// again
+			for _, tx := range node.poolTxs(poolTxIDs) {
+				assert.Equal(t, common.PoolL2TxStatePending, tx.State)
+				assert.Nil(t, tx.BatchNum)
+			}
+			reorged := node.state()
+			node.close()
+
+			// Clean sync of the chain B
+			node = newReorgNode(t)
+			assert.Equal(t, poolTxIDs, node.addPoolTxs(blocksA))
+			node.addBlocks(prefixBlocks, 0)
+			node.addBlocks(blocksB, tc.emptyB)
+			require.Nil(t, node.syncAll())
+			clean := node.state()
+			node.close()
+
+			assert.Equal(t, clean, reorged)
+		})
+	}
+}
+
+// TestSyncResetKeepsForgingPoolTxs checks that the reset of the intermediate
+// state done after a Sync error or at startup doesn't touch the pool txs that
+// the coordinator is forging in a batch not synced yet
+func TestSyncResetKeepsForgingPoolTxs(t *testing.T) {
+	prefixBlocks, blocksA := reorgBlocks(t, reorgPrefix, `
+		CreateVouch C-A
+		> batch
+		> block
+	`)
+	node := newReorgNode(t)
+	defer node.close()
+	poolTxIDs := node.addPoolTxs(blocksA)
+	node.addBlocks(prefixBlocks, 0)
+	require.Nil(t, node.syncAll())
+
+	lastBatchNum, err := node.historyDB.GetLastBatchNum()
+	require.NoError(t, err)
+	_, err = node.l2DB.DB().Exec("UPDATE tx_pool SET state = $1, batch_num = $2;",
+		common.PoolL2TxStateForging, lastBatchNum+1)
+	require.NoError(t, err)
+
+	require.NoError(t, node.sync.resetIntermediateState())
+	_, err = NewSynchronizer(node.client, node.historyDB, node.l2DB, node.stateDB,
+		node.sync.cfg)
+	require.NoError(t, err)
+	for _, tx := range node.poolTxs(poolTxIDs) {
+		assert.Equal(t, common.PoolL2TxStateForging, tx.State)
+		require.NotNil(t, tx.BatchNum)
+		assert.Equal(t, lastBatchNum+1, *tx.BatchNum)
+	}
+}
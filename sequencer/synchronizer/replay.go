@@ -0,0 +1,78 @@
+package synchronizer
+
+import (
+	"fmt"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/historydb"
+	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/log"
+	"tokamak-sybil-resistance/txprocessor"
+)
+
+// ReplayBatches resets the stateDB to the checkpoint at fromBatchNum and
+// rebuilds the checkpoints up to toBatchNum by processing again the txs
+// forged in each batch, as stored in the historyDB.  The checkpoints after
+// fromBatchNum are deleted by the reset.
+func ReplayBatches(stateDB *statedb.StateDB, historyDB *historydb.HistoryDB,
+	fromBatchNum, toBatchNum common.BatchNum) error {
+	consts, err := historyDB.GetConstants()
+	if err != nil {
+		return common.Wrap(fmt.Errorf("historyDB.GetConstants: %w", err))
+	}
+	if consts == nil || len(consts.Rollup.Verifiers) == 0 {
+		return common.Wrap(fmt.Errorf("no rollup verifiers in the historyDB constants"))
+	}
+	// The verifier used to forge each batch is not stored, so use the one
+	// that accepts the most txs to avoid rejecting any forged batch
+	verifier := consts.Rollup.Verifiers[0]
+	for _, v := range consts.Rollup.Verifiers[1:] {
+		if v.MaxTx > verifier.MaxTx {
+			verifier = v
+		}
+	}
+	tpc := txprocessor.Config{
+		NLevels:  uint32(verifier.NLevels),
+		MaxTx:    uint32(verifier.MaxTx),
+		ChainID:  consts.ChainID,
+		MaxFeeTx: common.RollupConstMaxFeeIdxCoordinator,
+		MaxL1Tx:  common.RollupConstMaxL1Tx,
+	}
+
+	if err := stateDB.Reset(fromBatchNum); err != nil {
+		return common.Wrap(fmt.Errorf("stateDB.Reset: %w", err))
+	}
+	for batchNum := fromBatchNum + 1; batchNum <= toBatchNum; batchNum++ {
+		batch, err := historyDB.GetBatch(batchNum)
+		if err != nil {
+			return common.Wrap(fmt.Errorf("historyDB.GetBatch(%v): %w", batchNum, err))
+		}
+		l1UserTxs, err := historyDB.GetBatchL1UserTxs(batchNum)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		l1CoordinatorTxs, err := historyDB.GetBatchL1CoordinatorTxs(batchNum)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		l2Txs, err := historyDB.GetBatchL2Txs(batchNum)
+		if err != nil {
+			return common.Wrap(err)
+		}
+		for i := range l2Txs {
+			if err := l2Txs[i].SetType(); err != nil {
+				return common.Wrap(err)
+			}
+		}
+		tp := txprocessor.NewTxProcessor(stateDB, tpc)
+		if _, err := tp.ProcessTxs(batch.FeeIdxsCoordinator, l1UserTxs,
+			l1CoordinatorTxs, common.L2TxsToPoolL2Txs(l2Txs)); err != nil {
+			return common.Wrap(fmt.Errorf("ProcessTxs in batch %v: %w", batchNum, err))
+		}
+		if stateDB.CurrentBatch() != batchNum {
+			return common.Wrap(fmt.Errorf("stateDB.BatchNum (%v) != batchNum (%v)",
+				stateDB.CurrentBatch(), batchNum))
+		}
+		log.Debugw("Replayed batch", "batch", batchNum)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package synchronizer
+
+import (
+	"context"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/test/sim"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncSim syncs the batches forged in the Sybil contract running in a
+// simulated chain
+func TestSyncSim(t *testing.T) {
+	h := sim.NewTest(t, sim.Config{Accounts: 1, MaxTx: 512, NLevels: 32,
+		ForgeL1L2BatchTimeout: 10, AutoMine: true})
+	client, err := h.NewClient(h.Accounts[0])
+	require.NoError(t, err)
+
+	stateDB, historyDB, l2DB := newTestModules(t)
+	defer closeTestModules(t, stateDB, historyDB, l2DB)
+	s, err := NewSynchronizer(client, historyDB, l2DB, stateDB, Config{
+		StatsUpdateBlockNumDiffThreshold: 100,
+		StatsUpdateFrequencyDivider:      100,
+	})
+	require.NoError(t, err)
+
+	var forgeBlocks []int64
+	for _, l1Batch := range []bool{true, false} {
+		tx, err := client.RollupForgeBatch(sim.ForgeBatchArgs(255, l1Batch), nil)
+		require.NoError(t, err)
+		receipt, err := client.EthTransactionReceipt(context.Background(), tx.Hash())
+		require.NoError(t, err)
+		require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		forgeBlocks = append(forgeBlocks, receipt.BlockNumber.Int64())
+	}
+
+	for {
+		blockData, discarded, err := s.Sync(context.Background(), nil)
+		require.NoError(t, err)
+		require.Nil(t, discarded)
+		if blockData == nil {
+			break
+		}
+	}
+	lastBlock, err := client.EthLastBlock()
+	require.NoError(t, err)
+	dbBlock, err := historyDB.GetLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, lastBlock, dbBlock.Num)
+
+	batches, err := historyDB.GetAllBatches()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(batches))
+	for i, batch := range batches {
+		assert.Equal(t, common.BatchNum(i+1), batch.BatchNum)
+		assert.Equal(t, forgeBlocks[i], batch.EthBlockNum)
+		assert.Equal(t, int64(255), batch.LastIdx)
+	}
+	assert.NotNil(t, batches[0].ForgeL1TxsNum)
+	assert.Nil(t, batches[1].ForgeL1TxsNum)
+}
@@ -25,6 +25,9 @@ const (
 	// errStrUnknownBlock is the string returned by geth when querying an
 	// unknown block
 	errStrUnknownBlock = "unknown block"
+	// defaultCatchUpWorkers is the number of blocks fetched concurrently
+	// in the catch-up mode when Config.CatchUpWorkers is not set
+	defaultCatchUpWorkers = 8
 )
 
 var (
@@ -51,6 +54,13 @@ type Stats struct {
 		// l1Batch was forged
 		LastL1BatchBlock  int64
 		LastForgeL1TxsNum int64
+		// FinalizedBlockNum is the last synced block that is at least
+		// FinalityDepth blocks behind the last ethereum block, and
+		// FinalizedBatchNum the last batch forged up to it.  The
+		// blocks and batches after them are pending, since they can
+		// still be discarded by a reorg.
+		FinalizedBlockNum int64
+		FinalizedBatchNum common.BatchNum
 		Auction           struct {
 			CurrentSlot common.Slot
 			NextSlot    common.Slot
@@ -97,6 +107,14 @@ func (s *StatsHolder) UpdateSync(lastBlock *common.Block, lastBatch *common.Batc
 	s.rw.Unlock()
 }
 
+// UpdateFinalized updates the finalized block and batch of the stats
+func (s *StatsHolder) UpdateFinalized(blockNum int64, batchNum common.BatchNum) {
+	s.rw.Lock()
+	s.Sync.FinalizedBlockNum = blockNum
+	s.Sync.FinalizedBatchNum = batchNum
+	s.rw.Unlock()
+}
+
 // CopyStats returns a copy of the inner Stats
 func (s *StatsHolder) CopyStats() *Stats {
 	s.rw.RLock()
@@ -114,6 +132,17 @@ type Config struct {
 	StatsUpdateBlockNumDiffThreshold uint16
 	StatsUpdateFrequencyDivider      uint16
 	ChainID                          uint16
+	// CatchUpBlocks is the size of the ranges of blocks synchronized at
+	// once while the synchronizer is at least CatchUpBlocks behind the
+	// last ethereum block.  0 disables the catch-up mode.
+	CatchUpBlocks int64
+	// CatchUpWorkers is the number of blocks fetched concurrently in the
+	// catch-up mode
+	CatchUpWorkers int
+	// FinalityDepth is the number of blocks behind the last ethereum block
+	// after which a synced block is final.  0 makes every synced block
+	// final.
+	FinalityDepth int64
 }
 
 // Synchronizer implements the Synchronizer type
@@ -201,6 +230,37 @@ func (s *Synchronizer) Stats() *Stats {
 	return s.stats.CopyStats()
 }
 
+// FinalizedBatchNum returns the last finalized batch (see
+// Stats.Sync.FinalizedBatchNum).  It is safe to call it during a Sync call.
+func (s *Synchronizer) FinalizedBatchNum() common.BatchNum {
+	s.stats.rw.RLock()
+	defer s.stats.rw.RUnlock()
+	return s.stats.Sync.FinalizedBatchNum
+}
+
+// updateFinalized updates the finalized block and batch in the stats from the
+// last synced block and the last ethereum block.  The synced blocks are
+// processed in the StateDB and stored in the HistoryDB as soon as they are
+// mined; the ones after the finalized block form a pending layer that is
+// only exposed once they are FinalityDepth blocks deep.
+func (s *Synchronizer) updateFinalized() error {
+	lastBlockNum := s.stats.Sync.LastBlock.Num
+	finalizedBlockNum := s.stats.Eth.LastBlock.Num - s.cfg.FinalityDepth
+	if s.cfg.FinalityDepth == 0 || finalizedBlockNum >= lastBlockNum {
+		s.stats.UpdateFinalized(lastBlockNum, s.stats.Sync.LastBatch.BatchNum)
+		return nil
+	}
+	if finalizedBlockNum < 0 {
+		finalizedBlockNum = 0
+	}
+	batchNum, err := s.historyDB.GetLastBatchNumByBlock(finalizedBlockNum)
+	if err != nil {
+		return common.Wrap(fmt.Errorf("historyDB.GetLastBatchNumByBlock: %w", err))
+	}
+	s.stats.UpdateFinalized(finalizedBlockNum, batchNum)
+	return nil
+}
+
 func (s *Synchronizer) resetIntermediateState() error {
 	lastBlock, err := s.historyDB.GetLastBlock()
 	if common.Unwrap(err) == sql.ErrNoRows {
@@ -220,7 +280,9 @@ func (s *Synchronizer) resetIntermediateState() error {
 // If lastSavedBlock is nil, the lastSavedBlock value is obtained from de DB.
 // If a block is synced, it will be returned and also stored in the DB.  If a
 // reorg is detected, the number of discarded blocks will be returned and no
-// synchronization will be made.
+// synchronization will be made.  In the catch-up mode (see catchUp) a range of
+// blocks is synced at once, and the last one is returned with the data of the
+// whole range.
 func (s *Synchronizer) Sync(ctx context.Context,
 	lastSavedBlock *common.Block) (blockData *common.BlockData, discarded *int64, err error) {
 	if s.resetStateFailed {
@@ -306,18 +368,44 @@ func (s *Synchronizer) Sync(ctx context.Context,
 		}
 	}()
 
-	// Get data from the rollup contract
-	rollupData, err := s.rollupSync(ethBlock)
+	// While far behind the last ethereum block, sync a whole range of
+	// blocks at once
+	if s.cfg.CatchUpBlocks > 0 && lastSavedBlock != nil &&
+		s.stats.Eth.LastBlock.Num-nextBlockNum >= s.cfg.CatchUpBlocks {
+		blockData, err = s.catchUp(ctx, lastSavedBlock, ethBlock)
+		if err != nil {
+			return nil, nil, common.Wrap(err)
+		}
+		return blockData, nil, nil
+	}
+
+	rollupEvents, err := s.fetchRollupEvents(ethBlock)
 	if err != nil {
 		return nil, nil, common.Wrap(err)
 	}
+	blockData, err = s.storeBlock(ethBlock, rollupEvents)
+	if err != nil {
+		return nil, nil, common.Wrap(err)
+	}
+	return blockData, nil, nil
+}
+
+// storeBlock processes the rollup events of ethBlock, and stores the resulting
+// block data in the HistoryDB
+func (s *Synchronizer) storeBlock(ethBlock *common.Block,
+	rollupEvents *rollupBlockEvents) (*common.BlockData, error) {
+	// Get data from the rollup contract
+	rollupData, err := s.rollupSync(ethBlock, rollupEvents)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
 
 	// for i := range rollupData.Withdrawals {
 	// 	withdrawal := &rollupData.Withdrawals[i]
 	// 	if !withdrawal.InstantWithdraw {
 	// 		wDelayerTransfers := wDelayerData.DepositsByTxHash[withdrawal.TxHash]
 	// 		if len(wDelayerTransfers) == 0 {
-	// 			return nil, nil, common.Wrap(fmt.Errorf("WDelayer deposit corresponding to " +
+	// 			return nil, common.Wrap(fmt.Errorf("WDelayer deposit corresponding to " +
 	// 				"non-instant rollup withdrawal not found"))
 	// 		}
 	// 		// Pop the first wDelayerTransfer to consume them in chronological order
@@ -331,14 +419,28 @@ func (s *Synchronizer) Sync(ctx context.Context,
 	// }
 
 	// Group all the block data into the structs to save into HistoryDB
-	blockData = &common.BlockData{
+	blockData := &common.BlockData{
 		Block:  *ethBlock,
 		Rollup: *rollupData,
 	}
 
 	err = s.historyDB.AddBlockSCData(blockData)
 	if err != nil {
-		return nil, nil, common.Wrap(err)
+		return nil, common.Wrap(err)
+	}
+
+	// Mark the forged L2Txs in the pool, only if the node runs as a
+	// coordinator
+	if s.l2DB != nil {
+		for _, batchData := range rollupData.Batches {
+			txIDs := make([]common.TxID, len(batchData.L2Txs))
+			for i := range batchData.L2Txs {
+				txIDs[i] = batchData.L2Txs[i].TxID
+			}
+			if err := s.l2DB.DoneForging(txIDs, batchData.Batch.BatchNum); err != nil {
+				return nil, common.Wrap(fmt.Errorf("l2DB.DoneForging: %w", err))
+			}
+		}
 	}
 
 	batchesLen := len(rollupData.Batches)
@@ -357,12 +459,15 @@ func (s *Synchronizer) Sync(ctx context.Context,
 			&rollupData.Batches[batchesLen-1].Batch,
 			lastL1BatchBlock, lastForgeL1TxsNum)
 	}
+	if err := s.updateFinalized(); err != nil {
+		return nil, common.Wrap(err)
+	}
 	// hasBatch := false
 	// if len(rollupData.Batches) > 0 {
 	// 	hasBatch = true
 	// }
 	// if err = s.updateCurrentNextSlotIfSync(false, hasBatch); err != nil {
-	// 	return nil, nil, common.Wrap(err)
+	// 	return nil, common.Wrap(err)
 	// }
 
 	for _, batchData := range rollupData.Batches {
@@ -383,7 +488,123 @@ func (s *Synchronizer) Sync(ctx context.Context,
 		"ethLastBlockNum", s.stats.Eth.LastBlock.Num,
 	)
 
-	return blockData, nil, nil
+	return blockData, nil
+}
+
+// catchUp synchronizes at once the range of CatchUpBlocks blocks that starts at
+// firstBlock, which follows lastSavedBlock.  The blocks with Rollup events in
+// the range are found with a single query, and their events and forge calldata
+// are fetched concurrently; then they are processed and stored in order.  The
+// blocks without events are not stored, except the last one of the range.
+// The returned data is the one of the last block with the batches, L1UserTxs,
+// withdrawals, tokens and last variables of all the blocks of the range.  If
+// the chain changed while fetching, nothing is stored and nil is returned, so
+// that the next call to Sync handles the reorg.
+func (s *Synchronizer) catchUp(ctx context.Context, lastSavedBlock,
+	firstBlock *common.Block) (*common.BlockData, error) {
+	fromBlockNum := firstBlock.Num
+	toBlockNum := fromBlockNum + s.cfg.CatchUpBlocks - 1
+	lastBlock, err := s.EthClient.EthBlockByNumber(ctx, toBlockNum)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("EthBlockByNumber: %w", err))
+	}
+	blockNums, err := s.EthClient.RollupEventBlocks(fromBlockNum, toBlockNum)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("RollupEventBlocks: %w", err))
+	}
+	// The last block of the range is always stored, so that the next
+	// range starts after it
+	if len(blockNums) == 0 || blockNums[len(blockNums)-1] != toBlockNum {
+		blockNums = append(blockNums, toBlockNum)
+	}
+	log.Debugw("Catching up",
+		"from", fromBlockNum,
+		"to", toBlockNum,
+		"blocksWithEvents", len(blockNums),
+	)
+
+	workers := s.cfg.CatchUpWorkers
+	if workers <= 0 {
+		workers = defaultCatchUpWorkers
+	}
+	blocks := make([]*common.Block, len(blockNums))
+	events := make([]*rollupBlockEvents, len(blockNums))
+	errs := make([]error, len(blockNums))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, blockNum := range blockNums {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, blockNum int64) {
+			defer func() { <-sem; wg.Done() }()
+			block := firstBlock
+			switch blockNum {
+			case fromBlockNum:
+			case toBlockNum:
+				block = lastBlock
+			default:
+				block, errs[i] = s.EthClient.EthBlockByNumber(ctx, blockNum)
+				if errs[i] != nil {
+					errs[i] = fmt.Errorf("EthBlockByNumber: %w", errs[i])
+					return
+				}
+			}
+			blocks[i] = block
+			events[i], errs[i] = s.fetchRollupEvents(block)
+		}(i, blockNum)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+
+	// Check that the range is still in the canonical chain at both
+	// boundaries, otherwise leave the reorg to the next call to Sync
+	checkFirstBlock, err := s.EthClient.EthBlockByNumber(ctx, fromBlockNum)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("EthBlockByNumber: %w", err))
+	}
+	checkLastBlock, err := s.EthClient.EthBlockByNumber(ctx, toBlockNum)
+	if err != nil {
+		return nil, common.Wrap(fmt.Errorf("EthBlockByNumber: %w", err))
+	}
+	if checkFirstBlock.ParentHash != lastSavedBlock.Hash ||
+		checkLastBlock.Hash != lastBlock.Hash {
+		log.Debugw("Chain changed while catching up", "from", fromBlockNum, "to", toBlockNum)
+		return nil, nil
+	}
+
+	// The returned block data has the data of all the blocks of the
+	// range, so that the batches forged in the intermediate blocks reach
+	// the coordinator
+	rangeData := &common.BlockData{Rollup: common.NewRollupData()}
+	for i := range blocks {
+		blockData, err := s.storeBlock(blocks[i], events[i])
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		rangeData.Block = blockData.Block
+		rangeData.Rollup.L1UserTxs = append(rangeData.Rollup.L1UserTxs,
+			blockData.Rollup.L1UserTxs...)
+		rangeData.Rollup.Batches = append(rangeData.Rollup.Batches,
+			blockData.Rollup.Batches...)
+		rangeData.Rollup.Withdrawals = append(rangeData.Rollup.Withdrawals,
+			blockData.Rollup.Withdrawals...)
+		rangeData.Rollup.AddedTokens = append(rangeData.Rollup.AddedTokens,
+			blockData.Rollup.AddedTokens...)
+		if blockData.Rollup.Vars != nil {
+			rangeData.Rollup.Vars = blockData.Rollup.Vars
+		}
+	}
+	// Sync only refreshes the Eth stats once in UpdateFrequencyDivider
+	// blocks while far behind, which a range of blocks can skip.  The
+	// blocks are already stored, so a failure is only logged.
+	if err := s.stats.UpdateEth(s.EthClient); err != nil {
+		log.Warnw("Synchronizer.catchUp: UpdateEth", "err", err)
+	}
+	return rangeData, nil
 }
 
 // reorg manages a reorg, updating History and State DB as needed.  Keeps
@@ -396,14 +617,20 @@ func (s *Synchronizer) reorg(uncleBlock *common.Block) (int64, error) {
 
 	var block *common.Block
 	for blockNum >= s.startBlockNum {
-		ethBlock, err := s.EthClient.EthBlockByNumber(context.Background(), blockNum)
-		if err != nil {
-			return 0, common.Wrap(fmt.Errorf("ethClient.EthBlockByNumber: %w", err))
+		dbBlock, err := s.historyDB.GetBlock(blockNum)
+		if common.Unwrap(err) == sql.ErrNoRows {
+			// Blocks without events are not stored in the
+			// catch-up mode
+			blockNum--
+			continue
+		} else if err != nil {
+			return 0, common.Wrap(fmt.Errorf("historyDB.GetBlock: %w", err))
 		}
+		block = dbBlock
 
-		block, err = s.historyDB.GetBlock(blockNum)
+		ethBlock, err := s.EthClient.EthBlockByNumber(context.Background(), blockNum)
 		if err != nil {
-			return 0, common.Wrap(fmt.Errorf("historyDB.GetBlock: %w", err))
+			return 0, common.Wrap(fmt.Errorf("ethClient.EthBlockByNumber: %w", err))
 		}
 		if block.Hash == ethBlock.Hash {
 			log.Debugf("Found valid block: %v", blockNum)
@@ -411,8 +638,28 @@ func (s *Synchronizer) reorg(uncleBlock *common.Block) (int64, error) {
 		}
 		blockNum--
 	}
+	if block == nil {
+		return 0, common.Wrap(fmt.Errorf("no block found in historyDB from %v", uncleBlock.Num))
+	}
 	total := uncleBlock.Num - block.Num
 	log.Debugw("Discarding blocks", "total", total, "from", uncleBlock.Num, "to", block.Num+1)
+	if block.Num < s.stats.Sync.FinalizedBlockNum {
+		log.Warnw("Reorg deeper than FinalityDepth discards finalized blocks",
+			"finalizedBlock", s.stats.Sync.FinalizedBlockNum, "lastValidBlock", block.Num)
+	}
+
+	// The pool txs forged in the discarded batches are pending again.
+	// This is done before discarding the blocks in the HistoryDB, so that
+	// the reorg is detected again by the next Sync if it fails.
+	if s.l2DB != nil {
+		lastValidBatchNum, err := s.historyDB.GetLastBatchNumByBlock(block.Num)
+		if err != nil {
+			return 0, common.Wrap(fmt.Errorf("historyDB.GetLastBatchNumByBlock: %w", err))
+		}
+		if err := s.l2DB.Reorg(lastValidBatchNum); err != nil {
+			return 0, common.Wrap(fmt.Errorf("l2DB.Reorg: %w", err))
+		}
+	}
 
 	// Set History DB and State DB to the correct state
 	if err := s.historyDB.Reorg(block.Num); err != nil {
@@ -505,6 +752,18 @@ func (s *Synchronizer) resetState(block *common.Block) error {
 	} else {
 		s.vars.Rollup = *rollup
 	}
+	// The vars synced before the owner was tracked don't have it, so it's
+	// read from the smart contract at the last synced block.  Meanwhile
+	// it's unknown, and the coordinator doesn't forge.
+	if s.vars.Rollup.Owner == nil {
+		owner, err := s.EthClient.RollupOwner(block.Num)
+		if err != nil {
+			log.Warnw("Rollup owner unknown: RollupOwner not available at the last synced block",
+				"blockNum", block.Num, "err", err)
+		} else {
+			s.vars.Rollup.Owner = owner
+		}
+	}
 
 	batch, err := s.historyDB.GetLastBatch()
 	if err != nil && common.Unwrap(err) != sql.ErrNoRows {
@@ -537,7 +796,7 @@ func (s *Synchronizer) resetState(block *common.Block) error {
 	}
 
 	s.stats.UpdateSync(block, batch, &lastL1BatchBlockNum, lastForgeL1TxsNum)
-	return nil
+	return common.Wrap(s.updateFinalized())
 }
 
 // TODO: Update consts variable above to SConsts
@@ -554,21 +813,55 @@ func (s *Synchronizer) SCVars() *common.SCVariables {
 	}
 }
 
-// rollupSync retrieves all the Rollup Smart Contract Data that happened at
-// ethBlock.blockNum with ethBlock.Hash.
-func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, error) {
-	blockNum := ethBlock.Num
-	var rollupData = common.NewRollupData()
-	// var forgeL1TxsNum int64
+// rollupBlockEvents are the Rollup Smart Contract events of a block, along
+// with the arguments and sender of each ForgeBatch event transaction
+type rollupBlockEvents struct {
+	events         *eth.RollupEvents
+	forgeBatchArgs []*eth.RollupForgeBatchArgs
+	senders        []*ethCommon.Address
+}
 
+// fetchRollupEvents retrieves from the ethereum node the Rollup Smart Contract
+// events that happened at ethBlock.blockNum with ethBlock.Hash, and the
+// calldata of the ForgeBatch transactions.  It doesn't access the DBs, so it
+// can be called concurrently for different blocks.
+func (s *Synchronizer) fetchRollupEvents(ethBlock *common.Block) (*rollupBlockEvents, error) {
 	// Get rollup events in the block, and make sure the block hash matches
 	// the expected one.
-	rollupEvents, err := s.EthClient.RollupEventsByBlock(blockNum, &ethBlock.Hash)
+	rollupEvents, err := s.EthClient.RollupEventsByBlock(ethBlock.Num, &ethBlock.Hash)
 	if err != nil && err.Error() == errStrUnknownBlock {
 		return nil, common.Wrap(ErrUnknownBlock)
 	} else if err != nil {
 		return nil, common.Wrap(fmt.Errorf("RollupEventsByBlock: %w", err))
 	}
+	blockEvents := &rollupBlockEvents{events: rollupEvents}
+	// No events in this block
+	if rollupEvents == nil {
+		return blockEvents, nil
+	}
+	for _, evtForgeBatch := range rollupEvents.ForgeBatch {
+		// Get the input for each Tx
+		forgeBatchArgs, sender, err := s.EthClient.RollupForgeBatchArgs(evtForgeBatch.EthTxHash,
+			evtForgeBatch.L1UserTxsLen)
+		if err != nil {
+			return nil, common.Wrap(fmt.Errorf("RollupForgeBatchArgs: %w", err))
+		}
+		blockEvents.forgeBatchArgs = append(blockEvents.forgeBatchArgs, forgeBatchArgs)
+		blockEvents.senders = append(blockEvents.senders, sender)
+	}
+	return blockEvents, nil
+}
+
+// rollupSync processes all the Rollup Smart Contract Data that happened at
+// ethBlock.blockNum with ethBlock.Hash, previously fetched with
+// fetchRollupEvents.
+func (s *Synchronizer) rollupSync(ethBlock *common.Block,
+	blockEvents *rollupBlockEvents) (*common.RollupData, error) {
+	blockNum := ethBlock.Num
+	var rollupData = common.NewRollupData()
+	// var forgeL1TxsNum int64
+
+	rollupEvents := blockEvents.events
 	// No events in this block
 	if rollupEvents == nil {
 		return &rollupData, nil
@@ -585,6 +878,13 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 		nextForgeL1TxsNum = 0
 	}
 
+	// Update the governance variables before processing the batches, which
+	// may use a verifier set in this block
+	varsUpdate, err := s.rollupSyncVars(blockNum, rollupEvents)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+
 	// Get L1UserTX
 	rollupData.L1UserTxs, err = getL1UserTx(rollupEvents.L1UserTx, blockNum)
 	if err != nil {
@@ -592,16 +892,11 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 	}
 
 	// Get ForgeBatch events to get the L1CoordinatorTxs
-	for _, evtForgeBatch := range rollupEvents.ForgeBatch {
+	for i, evtForgeBatch := range rollupEvents.ForgeBatch {
 		batchData := common.NewBatchData()
 		position := 0
 
-		// Get the input for each Tx
-		forgeBatchArgs, sender, err := s.EthClient.RollupForgeBatchArgs(evtForgeBatch.EthTxHash,
-			evtForgeBatch.L1UserTxsLen)
-		if err != nil {
-			return nil, common.Wrap(fmt.Errorf("RollupForgeBatchArgs: %w", err))
-		}
+		forgeBatchArgs, sender := blockEvents.forgeBatchArgs[i], blockEvents.senders[i]
 		ethTxHash := evtForgeBatch.EthTxHash
 		gasUsed := evtForgeBatch.GasUsed
 		gasPrice := evtForgeBatch.GasPrice
@@ -692,14 +987,15 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 		// NOTE: This is a big ugly, find a better way
 		poolL2Txs := common.L2TxsToPoolL2Txs(forgeBatchArgs.L2TxsData)
 
-		if int(forgeBatchArgs.VerifierIdx) >= len(s.consts.Rollup.Verifiers) {
+		verifiers := s.vars.Rollup.GetVerifiers(&s.consts.Rollup)
+		if int(forgeBatchArgs.VerifierIdx) >= len(verifiers) {
 			return nil, common.Wrap(fmt.Errorf("forgeBatchArgs.VerifierIdx (%v) >= "+
-				" len(s.consts.Rollup.Verifiers) (%v)",
-				forgeBatchArgs.VerifierIdx, len(s.consts.Rollup.Verifiers)))
+				" len(verifiers) (%v)",
+				forgeBatchArgs.VerifierIdx, len(verifiers)))
 		}
 		tpc := txprocessor.Config{
-			NLevels:  uint32(s.consts.Rollup.Verifiers[forgeBatchArgs.VerifierIdx].NLevels),
-			MaxTx:    uint32(s.consts.Rollup.Verifiers[forgeBatchArgs.VerifierIdx].MaxTx),
+			NLevels:  uint32(verifiers[forgeBatchArgs.VerifierIdx].NLevels),
+			MaxTx:    uint32(verifiers[forgeBatchArgs.VerifierIdx].MaxTx),
 			ChainID:  s.cfg.ChainID,
 			MaxFeeTx: common.RollupConstMaxFeeIdxCoordinator,
 			MaxL1Tx:  common.RollupConstMaxL1Tx,
@@ -825,17 +1121,6 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 	// 	rollupData.AddedTokens = append(rollupData.AddedTokens, token)
 	// }
 
-	rollupData.UpdateBucketWithdraw = make([]common.BucketUpdate, 0, len(rollupEvents.UpdateBucketWithdraw))
-	for _, evt := range rollupEvents.UpdateBucketWithdraw {
-		rollupData.UpdateBucketWithdraw = append(rollupData.UpdateBucketWithdraw,
-			common.BucketUpdate{
-				EthBlockNum: blockNum,
-				NumBucket:   evt.NumBucket,
-				BlockStamp:  evt.BlockStamp,
-				Withdrawals: evt.Withdrawals,
-			})
-	}
-
 	rollupData.Withdrawals = make([]common.WithdrawInfo, 0, len(rollupEvents.Withdraw))
 	for _, evt := range rollupEvents.Withdraw {
 		rollupData.Withdrawals = append(rollupData.Withdrawals, common.WithdrawInfo{
@@ -846,22 +1131,17 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 		})
 	}
 
-	// for _, evt := range rollupEvents.UpdateTokenExchange {
-	// 	if len(evt.AddressArray) != len(evt.ValueArray) {
-	// 		return nil, common.Wrap(fmt.Errorf("in RollupEventUpdateTokenExchange "+
-	// 			"len(AddressArray) != len(ValueArray) (%v != %v)",
-	// 			len(evt.AddressArray), len(evt.ValueArray)))
-	// 	}
-	// 	for i := range evt.AddressArray {
-	// 		rollupData.TokenExchanges = append(rollupData.TokenExchanges,
-	// 			common.TokenExchange{
-	// 				EthBlockNum: blockNum,
-	// 				Address:     evt.AddressArray[i],
-	// 				ValueUSD:    int64(evt.ValueArray[i]),
-	// 			})
-	// 	}
-	// }
+	if varsUpdate {
+		s.vars.Rollup.EthBlockNum = blockNum
+		rollupData.Vars = s.vars.Rollup.Copy()
+	}
 
+	return &rollupData, nil
+}
+
+// rollupSyncVars applies the governance events of the Rollup Smart Contract in
+// blockNum to the variables, and returns true if they changed
+func (s *Synchronizer) rollupSyncVars(blockNum int64, rollupEvents *eth.RollupEvents) (bool, error) {
 	varsUpdate := false
 
 	for _, evt := range rollupEvents.UpdateForgeL1L2BatchTimeout {
@@ -879,32 +1159,44 @@ func (s *Synchronizer) rollupSync(ethBlock *common.Block) (*common.RollupData, e
 	// 	varsUpdate = true
 	// }
 
-	// NOTE: We skip the event rollupEvents.SafeMode because the
-	// implementation RollupEventsByBlock already inserts a non-existing
-	// RollupEventUpdateBucketsParameters into UpdateBucketsParameters with
-	// all the bucket values at 0 and SafeMode = true
-	for _, evt := range rollupEvents.UpdateBucketsParameters {
-		s.vars.Rollup.Buckets = make([]common.BucketParams, 0, len(evt.ArrayBuckets))
-		for _, bucket := range evt.ArrayBuckets {
-			s.vars.Rollup.Buckets = append(s.vars.Rollup.Buckets, common.BucketParams{
-				CeilUSD:         bucket.CeilUSD,
-				BlockStamp:      bucket.BlockStamp,
-				Withdrawals:     bucket.Withdrawals,
-				RateBlocks:      bucket.RateBlocks,
-				RateWithdrawals: bucket.RateWithdrawals,
-				MaxWithdrawals:  bucket.MaxWithdrawals,
-			})
-		}
-		s.vars.Rollup.SafeMode = evt.SafeMode
+	if len(rollupEvents.SafeMode) > 0 {
+		s.vars.Rollup.SafeMode = true
 		varsUpdate = true
 	}
 
-	if varsUpdate {
-		s.vars.Rollup.EthBlockNum = blockNum
-		rollupData.Vars = s.vars.Rollup.Copy()
+	for _, evt := range rollupEvents.OwnershipTransferred {
+		newOwner := evt.NewOwner
+		s.vars.Rollup.Owner = &newOwner
+		varsUpdate = true
 	}
 
-	return &rollupData, nil
+	// The verifiers are only set by the initializers, and the events
+	// don't contain them, so they are read from the smart contract after
+	// a reinitialization.  The first initialization is the deployment,
+	// whose verifiers are the ones in the constants.
+	for _, evt := range rollupEvents.Initialized {
+		if evt.Version <= 1 {
+			continue
+		}
+		verifiers, err := s.EthClient.RollupVerifiers(blockNum)
+		if err != nil {
+			// A node without the historical state can only be
+			// called at the last block, whose verifiers are
+			// the ones of the last reinitialization
+			log.Warnw("RollupVerifiers not available at block, reading them at the last block",
+				"blockNum", blockNum, "err", err)
+			if verifiers, err = s.EthClient.RollupVerifiers(-1); err != nil {
+				return false, common.Wrap(fmt.Errorf("RollupVerifiers: %w", err))
+			}
+		}
+		log.Infow("Rollup verifiers changed on reinitialization", "blockNum", blockNum,
+			"version", evt.Version, "verifiers", verifiers)
+		s.vars.Rollup.Verifiers = verifiers
+		varsUpdate = true
+		break
+	}
+
+	return varsUpdate, nil
 }
 
 func getL1UserTx(eventsL1UserTx []eth.RollupEventL1UserTx, blockNum int64) ([]common.L1Tx, error) {
@@ -8,12 +8,14 @@ import (
 	"math/big"
 	"os"
 	"sort"
+	"sync/atomic"
 	"testing"
 	"time"
 	"tokamak-sybil-resistance/common"
 	"tokamak-sybil-resistance/database/historydb"
 	"tokamak-sybil-resistance/database/l2db"
 	"tokamak-sybil-resistance/database/statedb"
+	"tokamak-sybil-resistance/eth"
 	"tokamak-sybil-resistance/test"
 	"tokamak-sybil-resistance/test/til"
 
@@ -523,3 +525,301 @@ func TestSyncGeneral(t *testing.T) {
 	rollupVars.EthBlockNum = syncBlock.Block.Num
 	assert.Equal(t, rollupVars, dbRollupVars)
 }
+
+// countingClient is a test ethereum client that counts the calls to
+// RollupEventsByBlock
+type countingClient struct {
+	*test.Client
+	eventsByBlockCalls int64
+}
+
+func (c *countingClient) RollupEventsByBlock(blockNum int64,
+	blockHash *ethCommon.Hash) (*eth.RollupEvents, error) {
+	atomic.AddInt64(&c.eventsByBlockCalls, 1)
+	return c.Client.RollupEventsByBlock(blockNum, blockHash)
+}
+
+func TestSyncCatchUp(t *testing.T) {
+	stateDB, historyDB, l2DB := newTestModules(t)
+	defer closeTestModules(t, stateDB, historyDB, l2DB)
+
+	var timer timer
+	clientSetup := test.NewClientSetupExample()
+	clientSetup.ChainID = big.NewInt(int64(chainID))
+	client := &countingClient{
+		Client: test.NewClient(true, &timer, &ethCommon.Address{}, clientSetup),
+	}
+
+	s, err := NewSynchronizer(client, historyDB, l2DB, stateDB, Config{
+		StatsUpdateBlockNumDiffThreshold: 100,
+		StatsUpdateFrequencyDivider:      100,
+		CatchUpBlocks:                    5,
+		CatchUpWorkers:                   2,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Genesis block
+	syncBlock, discards, err := s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, discards)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(1), syncBlock.Block.Num)
+
+	set := `
+		Type: Blockchain
+
+		CreateAccountDeposit C: 2000
+		CreateAccountDeposit A: 2000
+
+		> batchL1
+		> batchL1
+		> block // blockNum=2
+
+		CreateVouch C-A
+
+		> batchL1
+		> batchL1
+		> block // blockNum=3
+	`
+	tc := til.NewContext(chainID, common.RollupConstMaxL1UserTx)
+	blocks, err := tc.GenerateBlocks(set)
+	require.NoError(t, err)
+	for i := range blocks {
+		for j := range blocks[i].Rollup.Batches {
+			blocks[i].Rollup.Batches[j].Batch.StateRoot = big.NewInt(0)
+		}
+	}
+	require.NoError(t, tc.FillBlocksExtra(blocks, &til.ConfigExtra{CoordUser: "A"}))
+	tc.FillBlocksL1UserTxsBatchNum(blocks)
+	require.NoError(t, tc.FillBlocksForgedL1UserTxs(blocks))
+	require.NoError(t, client.CtlAddBlocks(blocks))
+	// Blocks 4 to 13 are empty
+	for i := 0; i < 10; i++ {
+		client.CtlMineBlock()
+	}
+
+	// Blocks 2 to 6 are synced at once, fetching only the events of the
+	// blocks 2 and 3 and of the last block of the range
+	client.eventsByBlockCalls = 0
+	syncBlock, discards, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, discards)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(6), syncBlock.Block.Num)
+	assert.Equal(t, int64(3), client.eventsByBlockCalls)
+	assert.Equal(t, int64(6), s.Stats().Sync.LastBlock.Num)
+	// the batches and L1UserTxs of the blocks 2 and 3 are returned
+	require.Equal(t, 4, len(syncBlock.Rollup.Batches))
+	for i, batch := range syncBlock.Rollup.Batches {
+		assert.Equal(t, common.BatchNum(i+1), batch.Batch.BatchNum)
+	}
+	assert.Equal(t, 2, len(syncBlock.Rollup.L1UserTxs))
+
+	// Blocks 7 to 11
+	syncBlock, discards, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, discards)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(11), syncBlock.Block.Num)
+
+	// Less than CatchUpBlocks left, so the blocks are synced one by one
+	for _, blockNum := range []int64{12, 13} {
+		syncBlock, discards, err = s.Sync(ctx, nil)
+		require.NoError(t, err)
+		require.Nil(t, discards)
+		require.NotNil(t, syncBlock)
+		assert.Equal(t, blockNum, syncBlock.Block.Num)
+	}
+	syncBlock, discards, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, discards)
+	require.Nil(t, syncBlock)
+
+	dbBlocks, err := historyDB.GetAllBlocks()
+	require.NoError(t, err)
+	blockNums := make([]int64, len(dbBlocks))
+	for i, block := range dbBlocks {
+		blockNums[i] = block.Num
+	}
+	assert.Equal(t, []int64{0, 1, 2, 3, 6, 11, 12, 13}, blockNums)
+	dbBatches, err := historyDB.GetAllBatches()
+	require.NoError(t, err)
+	assert.Equal(t, 4, len(dbBatches))
+	assert.Equal(t, common.BatchNum(4), stateDB.CurrentBatch())
+	dbAccounts, err := historyDB.GetAllAccounts()
+	require.NoError(t, err)
+	sdbAccounts, err := stateDB.TestGetAccounts()
+	require.NoError(t, err)
+	assertEqualAccountsHistoryDBStateDB(t, dbAccounts, sdbAccounts)
+
+	// Replace the blocks 9 to 13.  The reorg skips the blocks that were
+	// not stored during the catch-up, and finds the block 6 valid.
+	for i := 0; i < 5; i++ {
+		client.CtlRollback()
+	}
+	for i := 0; i < 6; i++ {
+		client.CtlMineBlock()
+	}
+	syncBlock, discards, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, syncBlock)
+	require.NotNil(t, discards)
+	assert.Equal(t, int64(7), *discards)
+	assert.Equal(t, common.BatchNum(4), stateDB.CurrentBatch())
+
+	syncBlock, discards, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, discards)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(11), syncBlock.Block.Num)
+}
+
+func TestSyncCatchUpEthStats(t *testing.T) {
+	stateDB, historyDB, l2DB := newTestModules(t)
+	defer closeTestModules(t, stateDB, historyDB, l2DB)
+
+	var timer timer
+	clientSetup := test.NewClientSetupExample()
+	clientSetup.ChainID = big.NewInt(int64(chainID))
+	client := test.NewClient(true, &timer, &ethCommon.Address{}, clientSetup)
+
+	// With a 0 threshold Sync only refreshes the Eth stats when it reaches
+	// the last known block, or once in 100 blocks
+	s, err := NewSynchronizer(client, historyDB, l2DB, stateDB, Config{
+		StatsUpdateBlockNumDiffThreshold: 0,
+		StatsUpdateFrequencyDivider:      100,
+		CatchUpBlocks:                    5,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	syncBlock, _, err := s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(1), s.Stats().Eth.LastBlock.Num)
+	for i := 0; i < 20; i++ {
+		client.CtlMineBlock()
+	}
+
+	// Blocks 2 to 6
+	syncBlock, _, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(6), syncBlock.Block.Num)
+	assert.Equal(t, int64(21), s.Stats().Eth.LastBlock.Num)
+
+	// Blocks 7 to 11.  The blocks mined meanwhile are seen at the end of
+	// the range.
+	client.CtlMineBlock()
+	client.CtlMineBlock()
+	syncBlock, _, err = s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, syncBlock)
+	assert.Equal(t, int64(11), syncBlock.Block.Num)
+	assert.Equal(t, int64(23), s.Stats().Eth.LastBlock.Num)
+}
+
+func TestSyncFinality(t *testing.T) {
+	node := newReorgNode(t)
+	defer node.close()
+	node.sync.cfg.FinalityDepth = 3
+	s := node.sync
+	ctx := context.Background()
+
+	checkSync := func(blockNum, finalizedBlockNum int64, lastBatchNum,
+		finalizedBatchNum common.BatchNum) {
+		syncBlock, discards, err := s.Sync(ctx, nil)
+		require.NoError(t, err)
+		require.Nil(t, discards)
+		require.NotNil(t, syncBlock)
+		require.Equal(t, blockNum, syncBlock.Block.Num)
+		stats := s.Stats()
+		assert.Equal(t, lastBatchNum, stats.Sync.LastBatch.BatchNum)
+		assert.Equal(t, finalizedBlockNum, stats.Sync.FinalizedBlockNum)
+		assert.Equal(t, finalizedBatchNum, stats.Sync.FinalizedBatchNum)
+		assert.Equal(t, finalizedBatchNum, s.FinalizedBatchNum())
+	}
+
+	// Block 2 forges the batches 1 and 2, and block 3 the batch 3.  While
+	// the head is block 3 they are all pending.
+	blocks, _ := reorgBlocks(t, reorgPrefix, "")
+	node.addBlocks(blocks, 0)
+	checkSync(2, 0, 2, 0)
+	checkSync(3, 0, 3, 0)
+	// Each new block finalizes the one 3 blocks behind
+	node.addBlocks(nil, 1)
+	checkSync(4, 1, 3, 0)
+	node.addBlocks(nil, 1)
+	checkSync(5, 2, 3, 2)
+	node.addBlocks(nil, 1)
+	checkSync(6, 3, 3, 3)
+
+	// A reorg of the pending blocks keeps the finalized batch
+	node.fork(2, nil, 3)
+	_, discards, err := s.Sync(ctx, nil)
+	require.NoError(t, err)
+	require.NotNil(t, discards)
+	assert.Equal(t, int64(2), *discards)
+	stats := s.Stats()
+	assert.Equal(t, int64(4), stats.Sync.FinalizedBlockNum)
+	assert.Equal(t, common.BatchNum(3), stats.Sync.FinalizedBatchNum)
+	checkSync(5, 4, 3, 3)
+}
+
+func TestSyncGovernance(t *testing.T) {
+	n := newReorgNode(t)
+	defer n.close()
+	// The owner is the zero address until it's transferred
+	vars := n.sync.SCVars()
+	require.NotNil(t, vars.Rollup.Owner)
+	assert.Equal(t, ethCommon.Address{}, *vars.Rollup.Owner)
+	assert.Empty(t, vars.Rollup.Verifiers)
+
+	owner := ethCommon.HexToAddress("0xDcC5dD922fb1D0fd0c450a0636a8cE827521f0eD")
+	_, err := n.client.RollupTransferOwnership(owner)
+	require.NoError(t, err)
+	n.addBlocks(nil, 1)
+	require.Nil(t, n.syncAll())
+	vars = n.sync.SCVars()
+	require.NotNil(t, vars.Rollup.Owner)
+	assert.Equal(t, owner, *vars.Rollup.Owner)
+	ownerBlockNum := vars.Rollup.EthBlockNum
+
+	// A reinitialization sets the verifiers, and the first version is
+	// ignored
+	verifiers := []common.RollupVerifierStruct{{MaxTx: 2048, NLevels: 32},
+		{MaxTx: 512, NLevels: 32}}
+	_, err = n.client.RollupReinitialize(2, verifiers)
+	require.NoError(t, err)
+	n.addBlocks(nil, 1)
+	require.Nil(t, n.syncAll())
+	vars = n.sync.SCVars()
+	assert.Equal(t, verifiers, vars.Rollup.Verifiers)
+	assert.Equal(t, owner, *vars.Rollup.Owner)
+	verifierIdx, err := vars.Rollup.FindVerifierIdx(&n.sync.consts.Rollup, 512, 32)
+	require.NoError(t, err)
+	assert.Equal(t, 1, verifierIdx)
+
+	// Each change is kept in the history of the vars
+	dbVars, err := n.historyDB.GetSCVars()
+	require.NoError(t, err)
+	assert.Equal(t, vars.Rollup, *dbVars)
+	var history []int64
+	require.NoError(t, n.historyDB.DB().Select(&history,
+		"SELECT eth_block_num FROM rollup_vars ORDER BY eth_block_num;"))
+	assert.Equal(t, []int64{0, ownerBlockNum, dbVars.EthBlockNum}, history)
+
+	// The owner of the vars synced before it was tracked is read from the
+	// smart contract
+	_, err = n.historyDB.DB().Exec("UPDATE rollup_vars SET owner = NULL;")
+	require.NoError(t, err)
+	s, err := NewSynchronizer(n.client, n.historyDB, n.l2DB, n.stateDB, Config{
+		StatsUpdateBlockNumDiffThreshold: 100,
+		StatsUpdateFrequencyDivider:      100,
+	})
+	require.NoError(t, err)
+	vars = s.SCVars()
+	require.NotNil(t, vars.Rollup.Owner)
+	assert.Equal(t, owner, *vars.Rollup.Owner)
+}
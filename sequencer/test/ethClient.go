@@ -16,7 +16,6 @@ import (
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	ethKeystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/iden3/go-iden3-crypto/babyjub"
@@ -123,7 +122,7 @@ func NewClientSetupExample() *ClientSetup {
 	}
 	rollupVariables := &common.RollupVariables{
 		ForgeL1L2BatchTimeout: 10,
-		Buckets:               []common.BucketParams{},
+		Owner:                 &ethCommon.Address{},
 	}
 	return &ClientSetup{
 		RollupConstants: rollupConstants,
@@ -186,6 +185,9 @@ func NewClient(l bool, timer Timer, addr *ethCommon.Address, setup *ClientSetup)
 		Rollup: &RollupBlock{
 			State: eth.RollupState{
 				StateRoot:              big.NewInt(0),
+				StateRoots:             []*big.Int{big.NewInt(0)},
+				VouchRoots:             []*big.Int{big.NewInt(0)},
+				ScoreRoots:             []*big.Int{big.NewInt(0)},
 				ExitRoots:              make([]*big.Int, 1),
 				ExitNullifierMap:       make(map[int64]map[int64]bool),
 				MapL1TxQueue:           mapL1TxQueue,
@@ -216,6 +218,7 @@ func NewClient(l bool, timer Timer, addr *ethCommon.Address, setup *ClientSetup)
 		rw:                    &sync.RWMutex{},
 		log:                   l,
 		addr:                  addr,
+		chainID:               setup.ChainID,
 		rollupConstants:       setup.RollupConstants,
 		blocks:                blocks,
 		timer:                 timer,
@@ -382,9 +385,58 @@ func (c *Client) EthSuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return big.NewInt(0), nil
 }
 
-// EthKeyStore returns the keystore in the Client
-func (c *Client) EthKeyStore() *ethKeystore.KeyStore {
-	return nil
+// EthSuggestGasTipCap retrieves the currently suggested gas tip cap after
+// EIP-1559 to allow a timely execution of a transaction.
+func (c *Client) EthSuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	// NOTE: For now Client doesn't simulate gasPrice
+	return big.NewInt(0), nil
+}
+
+// EthFeeHistory returns the fee market history of the last blockCount blocks
+func (c *Client) EthFeeHistory(ctx context.Context, blockCount int,
+	rewardPercentiles []float64) (*eth.FeeHistory, error) {
+	// NOTE: For now Client doesn't simulate gasPrice
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+	feeHistory := eth.FeeHistory{
+		OldestBlock:  big.NewInt(max(0, c.blockNum-int64(blockCount)+1)),
+		Reward:       make([][]*big.Int, blockCount),
+		BaseFee:      make([]*big.Int, blockCount+1),
+		GasUsedRatio: make([]float64, blockCount),
+	}
+	for i := range feeHistory.Reward {
+		feeHistory.Reward[i] = make([]*big.Int, len(rewardPercentiles))
+		for j := range feeHistory.Reward[i] {
+			feeHistory.Reward[i][j] = big.NewInt(0)
+		}
+	}
+	for i := range feeHistory.BaseFee {
+		feeHistory.BaseFee[i] = big.NewInt(0)
+	}
+	return &feeHistory, nil
+}
+
+// nopSigner is the signer of the Client, which doesn't sign the transactions
+type nopSigner struct {
+	address ethCommon.Address
+}
+
+// Address implements the eth.Signer interface
+func (s *nopSigner) Address() ethCommon.Address {
+	return s.address
+}
+
+// SignTx implements the eth.Signer interface
+func (s *nopSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+
+// EthSigner returns the signer of the account of the Client
+func (c *Client) EthSigner() eth.Signer {
+	if c.addr == nil {
+		return nil
+	}
+	return &nopSigner{address: *c.addr}
 }
 
 // EthCall runs the transaction as a call (without paying) in the local node at
@@ -581,6 +633,43 @@ func (c *Client) RollupL1UserTxERC20ETH(
 	return r.addTransaction(c.newTransaction("l1UserTxERC20ETH", l1Tx)), nil
 }
 
+// rollupL1UserTx validates the L1UserTx like the Rollup client and sends it
+func (c *Client) rollupL1UserTx(fromBJJ babyjub.PublicKeyComp, fromIdx int64,
+	loadAmount, amount *big.Int, toIdx int64) (*types.Transaction, error) {
+	args, err := eth.NewRollupL1UserTxArgs(fromBJJ, fromIdx, loadAmount, amount, toIdx)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	return c.RollupL1UserTxERC20ETH(fromBJJ, fromIdx, args.LoadAmount, args.Amount, toIdx)
+}
+
+// RollupL1UserTxCreateAccountDeposit is the interface to call the smart
+// contract function
+func (c *Client) RollupL1UserTxCreateAccountDeposit(fromBJJ babyjub.PublicKeyComp,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(fromBJJ, 0, loadAmount, nil, 0)
+}
+
+// RollupL1UserTxDeposit is the interface to call the smart contract function
+func (c *Client) RollupL1UserTxDeposit(fromIdx int64,
+	loadAmount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, loadAmount, nil, 0)
+}
+
+// RollupL1UserTxForceExit is the interface to call the smart contract function
+func (c *Client) RollupL1UserTxForceExit(fromIdx int64,
+	amount *big.Int) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, nil, amount,
+		common.RollupConstExitIDx)
+}
+
+// RollupL1UserTxForceExplode is the interface to call the smart contract
+// function
+func (c *Client) RollupL1UserTxForceExplode(fromIdx int64) (*types.Transaction, error) {
+	return c.rollupL1UserTx(common.EmptyBJJComp, fromIdx, nil, nil,
+		common.RollupConstExplodeIDx)
+}
+
 // RollupL1UserTxERC777 is the interface to call the smart contract function
 // func (c *Client) RollupL1UserTxERC777(fromBJJ *babyjub.PublicKey, fromIdx int64,
 // 	depositAmount *big.Int, amount *big.Int,
@@ -605,6 +694,133 @@ func (c *Client) RollupLastForgedBatch() (int64, error) {
 	return int64(len(e.State.ExitRoots)) - 1, nil
 }
 
+// RollupNextL1ToForgeQueue is the interface to call the smart contract function
+func (c *Client) RollupNextL1ToForgeQueue(blockNum int64) (int64, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return 0, common.Wrap(err)
+	}
+	return block.Rollup.State.CurrentToForgeL1TxsNum, nil
+}
+
+// RollupNextL1FillingQueue is the interface to call the smart contract function
+func (c *Client) RollupNextL1FillingQueue(blockNum int64) (int64, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return 0, common.Wrap(err)
+	}
+	return block.Rollup.State.LastToForgeL1TxsNum, nil
+}
+
+// RollupLastL1L2Batch is the interface to call the smart contract function
+func (c *Client) RollupLastL1L2Batch(blockNum int64) (int64, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return 0, common.Wrap(err)
+	}
+	return block.Rollup.State.LastL1L2Batch, nil
+}
+
+// RollupL1TransactionQueue is the interface to call the smart contract
+// function
+func (c *Client) RollupL1TransactionQueue(queueIndex, blockNum int64) ([]byte, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	r := block.Rollup
+	queue, ok := r.State.MapL1TxQueue[queueIndex]
+	// The forged queues are deleted
+	if !ok || queueIndex < r.State.CurrentToForgeL1TxsNum {
+		return []byte{}, nil
+	}
+	b := []byte{}
+	for i := range queue.L1TxQueue {
+		txBytes, err := eth.RollupL1UserTxBytes(&queue.L1TxQueue[i])
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		b = append(b, txBytes...)
+	}
+	return b, nil
+}
+
+// RollupVerifiers is the interface to call the smart contract function
+func (c *Client) RollupVerifiers(blockNum int64) ([]common.RollupVerifierStruct, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if len(block.Rollup.Vars.Verifiers) == 0 {
+		return block.Rollup.Constants.Verifiers, nil
+	}
+	return block.Rollup.Vars.Verifiers, nil
+}
+
+// RollupOwner is the interface to call the smart contract function
+func (c *Client) RollupOwner(blockNum int64) (*ethCommon.Address, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	block, err := c.blockAt(blockNum)
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if block.Rollup.Vars.Owner == nil {
+		return &ethCommon.Address{}, nil
+	}
+	owner := *block.Rollup.Vars.Owner
+	return &owner, nil
+}
+
+// blockAt returns the mined block blockNum, or the last mined block if
+// blockNum is negative
+func (c *Client) blockAt(blockNum int64) (*Block, error) {
+	if blockNum < 0 {
+		return c.currentBlock(), nil
+	}
+	block, ok := c.blocks[blockNum]
+	if !ok || blockNum > c.blockNum {
+		return nil, common.Wrap(fmt.Errorf("Block %v doesn't exist", blockNum))
+	}
+	return block, nil
+}
+
+// RollupBatchRoots is the interface to call the smart contract function
+func (c *Client) RollupBatchRoots(batchNum int64) (*eth.RollupBatchRoots, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	currentBlock := c.currentBlock()
+	s := currentBlock.Rollup.State
+	if batchNum < 0 || batchNum >= int64(len(s.StateRoots)) {
+		// Like the mappings of the smart contract, return zero for the
+		// batches not forged yet
+		return &eth.RollupBatchRoots{StateRoot: big.NewInt(0), VouchRoot: big.NewInt(0),
+			ScoreRoot: big.NewInt(0)}, nil
+	}
+	return &eth.RollupBatchRoots{
+		StateRoot: s.StateRoots[batchNum],
+		VouchRoot: s.VouchRoots[batchNum],
+		ScoreRoot: s.ScoreRoots[batchNum],
+	}, nil
+}
+
 // RollupWithdrawCircuit is the interface to call the smart contract function
 func (c *Client) RollupWithdrawCircuit(proofA, proofC [2]*big.Int, proofB [2][2]*big.Int,
 	numExitRoot, idx int64, amount *big.Int,
@@ -709,6 +925,9 @@ func (c *Client) addBatch(args *eth.RollupForgeBatchArgs) (*types.Transaction, e
 	nextBlock := c.nextBlock()
 	r := nextBlock.Rollup
 	r.State.StateRoot = args.NewStRoot
+	r.State.StateRoots = append(r.State.StateRoots, args.NewStRoot)
+	r.State.VouchRoots = append(r.State.VouchRoots, args.NewVouchRoot)
+	r.State.ScoreRoots = append(r.State.ScoreRoots, args.NewScoreRoot)
 	if args.NewLastIdx < r.State.CurrentIdx {
 		return nil, common.Wrap(fmt.Errorf("args.NewLastIdx < r.State.CurrentIdx"))
 	}
@@ -716,6 +935,7 @@ func (c *Client) addBatch(args *eth.RollupForgeBatchArgs) (*types.Transaction, e
 	r.State.ExitNullifierMap[int64(len(r.State.ExitRoots))] = make(map[int64]bool)
 	r.State.ExitRoots = append(r.State.ExitRoots, args.NewExitRoot)
 	if args.L1Batch {
+		r.State.LastL1L2Batch = nextBlock.Eth.BlockNum
 		r.State.CurrentToForgeL1TxsNum++
 		if r.State.CurrentToForgeL1TxsNum == r.State.LastToForgeL1TxsNum {
 			r.State.LastToForgeL1TxsNum++
@@ -762,6 +982,51 @@ func (c *Client) RollupUpdateForgeL1L2BatchTimeout(newForgeL1Timeout int64) (tx
 	return r.addTransaction(c.newTransaction("updateForgeL1L2BatchTimeout", newForgeL1Timeout)), nil
 }
 
+// RollupTransferOwnership is the interface to call the smart contract function
+func (c *Client) RollupTransferOwnership(newOwner ethCommon.Address) (tx *types.Transaction,
+	err error) {
+	c.rw.Lock()
+	defer c.rw.Unlock()
+	cpy := c.nextBlock().copy()
+	defer func() { c.revertIfErr(err, cpy) }()
+	if c.addr == nil {
+		return nil, common.Wrap(eth.ErrAccountNil)
+	}
+
+	nextBlock := c.nextBlock()
+	r := nextBlock.Rollup
+	previousOwner := ethCommon.Address{}
+	if r.Vars.Owner != nil {
+		previousOwner = *r.Vars.Owner
+	}
+	r.Vars.Owner = &newOwner
+	r.Events.OwnershipTransferred = append(r.Events.OwnershipTransferred,
+		eth.RollupEventOwnershipTransferred{PreviousOwner: previousOwner, NewOwner: newOwner})
+
+	return r.addTransaction(c.newTransaction("transferOwnership", newOwner)), nil
+}
+
+// RollupReinitialize simulates an upgrade of the Rollup Smart Contract whose
+// reinitializer sets the verifiers
+func (c *Client) RollupReinitialize(version uint64,
+	verifiers []common.RollupVerifierStruct) (tx *types.Transaction, err error) {
+	c.rw.Lock()
+	defer c.rw.Unlock()
+	cpy := c.nextBlock().copy()
+	defer func() { c.revertIfErr(err, cpy) }()
+	if c.addr == nil {
+		return nil, common.Wrap(eth.ErrAccountNil)
+	}
+
+	nextBlock := c.nextBlock()
+	r := nextBlock.Rollup
+	r.Vars.Verifiers = verifiers
+	r.Events.Initialized = append(r.Events.Initialized,
+		eth.RollupEventInitialized{Version: version})
+
+	return r.addTransaction(c.newTransaction("reinitialize", verifiers)), nil
+}
+
 // RollupUpdateFeeAddToken is the interface to call the smart contract function
 func (c *Client) RollupUpdateFeeAddToken(newFeeAddToken *big.Int) (tx *types.Transaction,
 	err error) {
@@ -802,6 +1067,28 @@ func (c *Client) RollupEventsByBlock(blockNum int64,
 	return &block.Rollup.Events, nil
 }
 
+// RollupEventBlocks returns the numbers of the mined blocks between fromBlock
+// and toBlock with events of the Rollup Smart Contract
+func (c *Client) RollupEventBlocks(fromBlock, toBlock int64) ([]int64, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	blockNums := []int64{}
+	for blockNum := fromBlock; blockNum <= toBlock && blockNum <= c.blockNum; blockNum++ {
+		block, ok := c.blocks[blockNum]
+		if !ok {
+			return nil, common.Wrap(fmt.Errorf("Block %v doesn't exist", blockNum))
+		}
+		events := &block.Rollup.Events
+		if len(events.L1UserTx) > 0 || len(events.ForgeBatch) > 0 ||
+			len(events.UpdateForgeL1L2BatchTimeout) > 0 || len(events.Withdraw) > 0 ||
+			len(events.SafeMode) > 0 {
+			blockNums = append(blockNums, blockNum)
+		}
+	}
+	return blockNums, nil
+}
+
 // RollupEventInit returns the initialize event with its corresponding block number
 func (c *Client) RollupEventInit(genesisBlockNum int64) (*eth.RollupEventInitialize, int64, error) {
 	vars := c.blocks[0].Rollup.Vars
@@ -0,0 +1,453 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// replacementPriceBump is the minimum percentage increase of both fees of a
+// transaction that replaces a pending one, as in the geth tx pool
+const replacementPriceBump = 10
+
+// Backend is a simulated ethereum chain served by an in-process JSON-RPC
+// server, so that the eth clients run against it exactly as they do against
+// an ethereum node.  Only the methods used by the eth clients are served.
+type Backend struct {
+	*backends.SimulatedBackend
+	db       ethdb.Database
+	server   *rpc.Server
+	autoMine bool
+	mu       sync.Mutex
+	// pending are the transactions of the pending block, in order
+	pending []*types.Transaction
+}
+
+// NewBackend creates a simulated chain with the alloc genesis accounts.  If
+// autoMine is set, each transaction sent through the RPC server is mined in a
+// new block; otherwise blocks are mined with Commit.
+func NewBackend(alloc core.GenesisAlloc, gasLimit uint64, autoMine bool) (*Backend, error) {
+	db := rawdb.NewMemoryDatabase()
+	b := &Backend{
+		SimulatedBackend: backends.NewSimulatedBackendWithDatabase(db, alloc, gasLimit),
+		db:               db,
+		server:           rpc.NewServer(),
+		autoMine:         autoMine,
+	}
+	if err := b.server.RegisterName("eth", &ethAPI{b: b}); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Client returns a JSON-RPC client connected to the simulated chain
+func (b *Backend) Client() *rpc.Client {
+	return rpc.DialInProc(b.server)
+}
+
+// ChainID returns the chain ID of the simulated chain
+func (b *Backend) ChainID() *big.Int {
+	return b.Blockchain().Config().ChainID
+}
+
+// SendTransaction adds a transaction to the pending block.  A transaction with
+// the nonce of a pending transaction of the same sender replaces it if both of
+// its fees are at least replacementPriceBump percent higher, and fails with
+// core.ErrReplaceUnderpriced otherwise.
+func (b *Backend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	signer := types.LatestSignerForChainID(b.ChainID())
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	for i, pendingTx := range b.pending {
+		pendingFrom, err := types.Sender(signer, pendingTx)
+		if err != nil {
+			return err
+		}
+		if pendingFrom != from || pendingTx.Nonce() != tx.Nonce() {
+			continue
+		}
+		if !replaces(pendingTx, tx) {
+			return core.ErrReplaceUnderpriced
+		}
+		// The simulated backend can't remove a transaction from the
+		// pending block, so the block is rebuilt with the replacement
+		// in the place of the replaced transaction
+		pending := append([]*types.Transaction{}, b.pending...)
+		pending[i] = tx
+		b.SimulatedBackend.Rollback()
+		b.pending = nil
+		for _, tx := range pending {
+			if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+				return err
+			}
+			b.pending = append(b.pending, tx)
+		}
+		return nil
+	}
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.pending = append(b.pending, tx)
+	return nil
+}
+
+// replaces returns true if the fees of tx are high enough to replace oldTx
+func replaces(oldTx, tx *types.Transaction) bool {
+	minFee := func(fee *big.Int) *big.Int {
+		threshold := new(big.Int).Mul(fee, big.NewInt(100+replacementPriceBump))
+		return threshold.Div(threshold, big.NewInt(100)) //nolint:gomnd
+	}
+	return tx.GasFeeCap().Cmp(oldTx.GasFeeCap()) > 0 &&
+		tx.GasTipCap().Cmp(oldTx.GasTipCap()) > 0 &&
+		tx.GasFeeCap().Cmp(minFee(oldTx.GasFeeCap())) >= 0 &&
+		tx.GasTipCap().Cmp(minFee(oldTx.GasTipCap())) >= 0
+}
+
+// Commit mines the pending block
+func (b *Backend) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.SimulatedBackend.Commit()
+	b.pending = nil
+}
+
+// Rollback discards the pending block
+func (b *Backend) Rollback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.SimulatedBackend.Rollback()
+	b.pending = nil
+}
+
+// Close stops the RPC server and the simulated chain
+func (b *Backend) Close() error {
+	b.server.Stop()
+	return b.SimulatedBackend.Close()
+}
+
+// ethAPI serves the eth namespace of the JSON-RPC API from the simulated chain
+type ethAPI struct {
+	b *Backend
+}
+
+// blockNumber returns the block number to be passed to the simulated backend,
+// which is nil for the latest and the pending blocks
+func blockNumber(n rpc.BlockNumber) *big.Int {
+	if n < 0 {
+		return nil
+	}
+	return big.NewInt(n.Int64())
+}
+
+// ChainId returns the chain ID
+func (api *ethAPI) ChainId() *hexutil.Big { //nolint:golint,stylecheck
+	return (*hexutil.Big)(api.b.ChainID())
+}
+
+// BlockNumber returns the number of the last block
+func (api *ethAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.b.Blockchain().CurrentBlock().NumberU64())
+}
+
+// GetBlockByNumber returns a block by number
+func (api *ethAPI) GetBlockByNumber(ctx context.Context, n rpc.BlockNumber,
+	fullTx bool) (map[string]interface{}, error) {
+	block, err := api.b.BlockByNumber(ctx, blockNumber(n))
+	if err != nil {
+		return nil, nil //nolint:nilerr
+	}
+	return api.rpcBlock(block, fullTx)
+}
+
+// GetBlockByHash returns a block by hash
+func (api *ethAPI) GetBlockByHash(ctx context.Context, hash ethCommon.Hash,
+	fullTx bool) (map[string]interface{}, error) {
+	block, err := api.b.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, nil //nolint:nilerr
+	}
+	return api.rpcBlock(block, fullTx)
+}
+
+// GetBalance returns the balance of an account
+func (api *ethAPI) GetBalance(ctx context.Context, address ethCommon.Address,
+	n rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := api.b.BalanceAt(ctx, address, blockNumber(n))
+	return (*hexutil.Big)(balance), err
+}
+
+// GetCode returns the code of an account
+func (api *ethAPI) GetCode(ctx context.Context, address ethCommon.Address,
+	n rpc.BlockNumber) (hexutil.Bytes, error) {
+	if n == rpc.PendingBlockNumber {
+		return api.b.PendingCodeAt(ctx, address)
+	}
+	return api.b.CodeAt(ctx, address, blockNumber(n))
+}
+
+// GetTransactionCount returns the nonce of an account
+func (api *ethAPI) GetTransactionCount(ctx context.Context, address ethCommon.Address,
+	n rpc.BlockNumber) (hexutil.Uint64, error) {
+	if n == rpc.PendingBlockNumber {
+		nonce, err := api.b.PendingNonceAt(ctx, address)
+		return hexutil.Uint64(nonce), err
+	}
+	nonce, err := api.b.NonceAt(ctx, address, blockNumber(n))
+	return hexutil.Uint64(nonce), err
+}
+
+// callArgs are the arguments of a call or a gas estimation
+type callArgs struct {
+	From                 ethCommon.Address  `json:"from"`
+	To                   *ethCommon.Address `json:"to"`
+	Gas                  hexutil.Uint64     `json:"gas"`
+	GasPrice             *hexutil.Big       `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big       `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big       `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big       `json:"value"`
+	Data                 hexutil.Bytes      `json:"data"`
+	Input                hexutil.Bytes      `json:"input"`
+}
+
+func (args *callArgs) msg() ethereum.CallMsg {
+	data := args.Input
+	if data == nil {
+		data = args.Data
+	}
+	return ethereum.CallMsg{
+		From:      args.From,
+		To:        args.To,
+		Gas:       uint64(args.Gas),
+		GasPrice:  args.GasPrice.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		Value:     args.Value.ToInt(),
+		Data:      data,
+	}
+}
+
+// Call runs a call in the state of a block.  Only the last block and the
+// pending one are supported.
+func (api *ethAPI) Call(ctx context.Context, args callArgs,
+	n rpc.BlockNumber) (hexutil.Bytes, error) {
+	if n == rpc.PendingBlockNumber {
+		return api.b.PendingCallContract(ctx, args.msg())
+	}
+	return api.b.CallContract(ctx, args.msg(), blockNumber(n))
+}
+
+// EstimateGas estimates the gas of a transaction in the pending block
+func (api *ethAPI) EstimateGas(ctx context.Context, args callArgs) (hexutil.Uint64, error) {
+	gas, err := api.b.EstimateGas(ctx, args.msg())
+	return hexutil.Uint64(gas), err
+}
+
+// GasPrice returns the suggested gas price
+func (api *ethAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	gasPrice, err := api.b.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(gasPrice), err
+}
+
+// MaxPriorityFeePerGas returns the suggested tip
+func (api *ethAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	gasTipCap, err := api.b.SuggestGasTipCap(ctx)
+	return (*hexutil.Big)(gasTipCap), err
+}
+
+// feeHistory is the result of eth_feeHistory
+type feeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the base fees, the gas used and the percentiles of the
+// tips of the blockCount blocks up to lastBlock, and the base fee of the
+// block after them
+func (api *ethAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint,
+	lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistory, error) {
+	chain := api.b.Blockchain()
+	last := chain.CurrentBlock().NumberU64()
+	if lastBlock >= 0 && uint64(lastBlock) < last {
+		last = uint64(lastBlock)
+	}
+	count := uint64(blockCount)
+	if count > last+1 {
+		count = last + 1
+	}
+	oldest := last + 1 - count
+	res := &feeHistory{
+		OldestBlock:  (*hexutil.Big)(new(big.Int).SetUint64(oldest)),
+		GasUsedRatio: []float64{},
+	}
+	for n := oldest; n <= last && count > 0; n++ {
+		block := chain.GetBlockByNumber(n)
+		header := block.Header()
+		baseFee := header.BaseFee
+		if baseFee == nil {
+			baseFee = big.NewInt(0)
+		}
+		res.BaseFee = append(res.BaseFee, (*hexutil.Big)(baseFee))
+		res.GasUsedRatio = append(res.GasUsedRatio,
+			float64(header.GasUsed)/float64(header.GasLimit))
+		if len(rewardPercentiles) > 0 {
+			res.Reward = append(res.Reward, rewards(block, baseFee, rewardPercentiles))
+		}
+		if n == last {
+			res.BaseFee = append(res.BaseFee,
+				(*hexutil.Big)(misc.CalcBaseFee(chain.Config(), header)))
+		}
+	}
+	return res, nil
+}
+
+// rewards returns the percentiles of the tips paid by the txs of a block
+func rewards(block *types.Block, baseFee *big.Int, percentiles []float64) []*hexutil.Big {
+	tips := make([]*big.Int, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		tips[i] = tx.EffectiveGasTipValue(baseFee)
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	res := make([]*hexutil.Big, len(percentiles))
+	for i, p := range percentiles {
+		if len(tips) == 0 {
+			res[i] = (*hexutil.Big)(big.NewInt(0))
+			continue
+		}
+		res[i] = (*hexutil.Big)(tips[int(p/100*float64(len(tips)-1))]) //nolint:gomnd
+	}
+	return res
+}
+
+// SendRawTransaction sends a signed transaction, which is mined right away in
+// auto mine mode
+func (api *ethAPI) SendRawTransaction(ctx context.Context,
+	input hexutil.Bytes) (ethCommon.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return ethCommon.Hash{}, err
+	}
+	if err := api.b.SendTransaction(ctx, tx); err != nil {
+		return ethCommon.Hash{}, err
+	}
+	if api.b.autoMine {
+		api.b.Commit()
+	}
+	return tx.Hash(), nil
+}
+
+// GetTransactionByHash returns a mined or pending transaction
+func (api *ethAPI) GetTransactionByHash(ctx context.Context,
+	hash ethCommon.Hash) (map[string]interface{}, error) {
+	tx, blockHash, blockNum, index := rawdb.ReadTransaction(api.b.db, hash)
+	if tx != nil {
+		return api.rpcTx(tx, blockHash, blockNum, index)
+	}
+	tx, _, err := api.b.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, nil //nolint:nilerr
+	}
+	return api.rpcTx(tx, ethCommon.Hash{}, 0, 0)
+}
+
+// GetTransactionByBlockHashAndIndex returns a mined transaction
+func (api *ethAPI) GetTransactionByBlockHashAndIndex(ctx context.Context,
+	blockHash ethCommon.Hash, index hexutil.Uint) (map[string]interface{}, error) {
+	block, err := api.b.BlockByHash(ctx, blockHash)
+	if err != nil || int(index) >= len(block.Transactions()) {
+		return nil, nil //nolint:nilerr
+	}
+	return api.rpcTx(block.Transactions()[index], blockHash, block.NumberU64(), uint64(index))
+}
+
+// GetTransactionReceipt returns the receipt of a mined transaction
+func (api *ethAPI) GetTransactionReceipt(ctx context.Context,
+	hash ethCommon.Hash) (*types.Receipt, error) {
+	return api.b.TransactionReceipt(ctx, hash)
+}
+
+// GetLogs returns the logs that match a filter
+func (api *ethAPI) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]types.Log, error) {
+	logs, err := api.b.FilterLogs(ctx, ethereum.FilterQuery(crit))
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []types.Log{}
+	}
+	return logs, nil
+}
+
+// jsonFields returns the fields of the JSON encoding of v
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(b, &fields)
+	return fields, err
+}
+
+// rpcBlock returns the JSON-RPC encoding of a block
+func (api *ethAPI) rpcBlock(block *types.Block, fullTx bool) (map[string]interface{}, error) {
+	fields, err := jsonFields(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]interface{}, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !fullTx {
+			txs[i] = tx.Hash()
+			continue
+		}
+		if txs[i], err = api.rpcTx(tx, block.Hash(), block.NumberU64(), uint64(i)); err != nil {
+			return nil, err
+		}
+	}
+	fields["transactions"] = txs
+	fields["uncles"] = []ethCommon.Hash{}
+	fields["size"] = hexutil.Uint64(block.Size())
+	return fields, nil
+}
+
+// rpcTx returns the JSON-RPC encoding of a transaction.  The block fields are
+// left empty for a pending transaction, with a zero blockHash.
+func (api *ethAPI) rpcTx(tx *types.Transaction, blockHash ethCommon.Hash, blockNum,
+	index uint64) (map[string]interface{}, error) {
+	fields, err := jsonFields(tx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("sender of %v: %w", tx.Hash(), err)
+	}
+	fields["from"] = from
+	if blockHash != (ethCommon.Hash{}) {
+		fields["blockHash"] = blockHash
+		fields["blockNumber"] = (*hexutil.Big)(new(big.Int).SetUint64(blockNum))
+		fields["transactionIndex"] = hexutil.Uint64(index)
+	}
+	return fields, nil
+}
@@ -0,0 +1,262 @@
+/*
+Package sim is an integration test harness that runs the compiled Smart
+Contracts in a simulated ethereum chain.  The Sybil contract is deployed with
+the Poseidon contracts and a verifier stub that accepts any proof, so that
+batches can be forged without proofs.
+
+The contracts are loaded from the Foundry artifacts in contracts/out, which are
+built with:
+
+	go generate ./test/sim
+
+The tests that use the harness are skipped when the artifacts are missing,
+unless the SIM_REQUIRE_ARTIFACTS envvar is set, as in CI, where they fail.
+*/
+package sim
+
+//go:generate sh -c "cd ../../../contracts && forge build"
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/eth/contracts/tokamak"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	defaultGasLimit = 30000000
+	// accountBalance is the genesis balance of the accounts, in ether
+	accountBalance = 1000000
+)
+
+// ErrNoArtifacts is returned when the contracts haven't been compiled
+var ErrNoArtifacts = fmt.Errorf("contracts not compiled: run go generate ./test/sim")
+
+// RequireArtifactsEnv is the envvar that makes the tests that use the harness
+// fail instead of being skipped when the contracts haven't been compiled
+const RequireArtifactsEnv = "SIM_REQUIRE_ARTIFACTS"
+
+// OutDir returns the out directory of the Foundry project of the contracts
+func OutDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "contracts", "out")
+}
+
+// LoadBytecode returns the creation bytecode of contract, declared in the
+// Solidity file source, from the Foundry artifacts in outDir
+func LoadBytecode(outDir, source, contract string) ([]byte, error) {
+	artifact, err := os.ReadFile(filepath.Join(outDir, source, contract+".json")) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil, common.Wrap(ErrNoArtifacts)
+	} else if err != nil {
+		return nil, common.Wrap(err)
+	}
+	var a struct {
+		Bytecode struct {
+			Object         string                     `json:"object"`
+			LinkReferences map[string]json.RawMessage `json:"linkReferences"`
+		} `json:"bytecode"`
+	}
+	if err := json.Unmarshal(artifact, &a); err != nil {
+		return nil, common.Wrap(fmt.Errorf("%v artifact: %w", contract, err))
+	}
+	if len(a.Bytecode.LinkReferences) != 0 {
+		return nil, common.Wrap(fmt.Errorf("%v artifact: linked libraries are not supported",
+			contract))
+	}
+	code, err := hexutil.Decode(a.Bytecode.Object)
+	return code, common.Wrap(err)
+}
+
+// Config is the configuration of the contracts deployed by the harness
+type Config struct {
+	// OutDir is the out directory of the Foundry project, OutDir() by
+	// default
+	OutDir string
+	// Accounts is the number of funded accounts besides the deployer
+	Accounts int
+	// MaxTx and NLevels describe the circuit of the verifier stub
+	MaxTx   int64
+	NLevels int64
+	// ForgeL1L2BatchTimeout is the initial forgeL1L2BatchTimeout
+	ForgeL1L2BatchTimeout uint8
+	// AutoMine mines a block with each transaction
+	AutoMine bool
+}
+
+// Harness is a simulated chain with the Sybil contract deployed
+type Harness struct {
+	Backend *Backend
+	// Deployer is the key of the account that deployed the contracts,
+	// which is the owner of Sybil
+	Deployer *ecdsa.PrivateKey
+	// Accounts are the keys of the funded accounts
+	Accounts []*ecdsa.PrivateKey
+	// Sybil, Verifier and Poseidon are the addresses of the contracts
+	Sybil    ethCommon.Address
+	Verifier ethCommon.Address
+	Poseidon [3]ethCommon.Address
+}
+
+// New creates a simulated chain and deploys the contracts in it.  It returns
+// ErrNoArtifacts if the contracts haven't been compiled.
+func New(cfg Config) (*Harness, error) {
+	if cfg.OutDir == "" {
+		cfg.OutDir = OutDir()
+	}
+	verifierCode, err := LoadBytecode(cfg.OutDir, "VerifierRollupStub.sol", "VerifierRollupStub")
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	var poseidonCodes [3][]byte
+	for i := range poseidonCodes {
+		poseidonCodes[i], err = LoadBytecode(cfg.OutDir, "sybilHelpers.sol",
+			fmt.Sprintf("PoseidonUnit%v", i+2)) //nolint:gomnd
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	sybilCode, err := LoadBytecode(cfg.OutDir, "sybil.sol", "Sybil")
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+
+	h := &Harness{Accounts: make([]*ecdsa.PrivateKey, cfg.Accounts)}
+	alloc := core.GenesisAlloc{}
+	balance := new(big.Int).Mul(big.NewInt(accountBalance), big.NewInt(params.Ether))
+	newAccount := func() (*ecdsa.PrivateKey, error) {
+		key, err := ethCrypto.GenerateKey()
+		if err != nil {
+			return nil, common.Wrap(err)
+		}
+		alloc[Address(key)] = core.GenesisAccount{Balance: balance}
+		return key, nil
+	}
+	if h.Deployer, err = newAccount(); err != nil {
+		return nil, common.Wrap(err)
+	}
+	for i := range h.Accounts {
+		if h.Accounts[i], err = newAccount(); err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	if h.Backend, err = NewBackend(alloc, defaultGasLimit, cfg.AutoMine); err != nil {
+		return nil, common.Wrap(err)
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(h.Deployer, h.Backend.ChainID())
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	deploy := func(name string, contractABI abi.ABI, code []byte,
+		params ...interface{}) (ethCommon.Address, error) {
+		address, _, _, err := bind.DeployContract(auth, contractABI, code,
+			h.Backend.SimulatedBackend, params...)
+		if err != nil {
+			return ethCommon.Address{}, common.Wrap(fmt.Errorf("deploy %v: %w", name, err))
+		}
+		h.Backend.Commit()
+		return address, nil
+	}
+	if h.Verifier, err = deploy("VerifierRollupStub", abi.ABI{}, verifierCode); err != nil {
+		return nil, common.Wrap(err)
+	}
+	for i := range poseidonCodes {
+		if h.Poseidon[i], err = deploy("Poseidon", abi.ABI{}, poseidonCodes[i]); err != nil {
+			return nil, common.Wrap(err)
+		}
+	}
+	sybilABI, err := abi.JSON(strings.NewReader(tokamak.TokamakABI))
+	if err != nil {
+		return nil, common.Wrap(err)
+	}
+	if h.Sybil, err = deploy("Sybil", sybilABI, sybilCode,
+		[]ethCommon.Address{h.Verifier}, []*big.Int{big.NewInt(cfg.MaxTx)},
+		[]*big.Int{big.NewInt(cfg.NLevels)}, cfg.ForgeL1L2BatchTimeout,
+		h.Poseidon[0], h.Poseidon[1], h.Poseidon[2]); err != nil {
+		return nil, common.Wrap(err)
+	}
+	return h, nil
+}
+
+// NewTest creates a Harness for a test, which is skipped if the contracts
+// haven't been compiled, unless RequireArtifactsEnv is set
+func NewTest(t *testing.T, cfg Config) *Harness {
+	h, err := New(cfg)
+	if common.Unwrap(err) == ErrNoArtifacts && os.Getenv(RequireArtifactsEnv) == "" {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = h.Backend.Close() })
+	return h
+}
+
+// NewClient returns an eth.Client of the Sybil contract in the simulated chain
+// that signs with key
+func (h *Harness) NewClient(key *ecdsa.PrivateKey) (*eth.Client, error) {
+	client, err := eth.NewClient(h.Backend.Client(), &KeySigner{Key: key}, &eth.ClientConfig{
+		Ethereum: eth.EthereumConfig{
+			CallGasLimit: 1000000, //nolint:gomnd
+		},
+		Rollup: eth.RollupConfig{Address: h.Sybil},
+	})
+	return client, common.Wrap(err)
+}
+
+// Address returns the address of the account of key
+func Address(key *ecdsa.PrivateKey) ethCommon.Address {
+	return ethCrypto.PubkeyToAddress(key.PublicKey)
+}
+
+// KeySigner is an eth.Signer that signs with a private key
+type KeySigner struct {
+	Key *ecdsa.PrivateKey
+}
+
+// Address implements the eth.Signer interface
+func (s *KeySigner) Address() ethCommon.Address {
+	return Address(s.Key)
+}
+
+// SignTx implements the eth.Signer interface
+func (s *KeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.Key)
+	return signedTx, common.Wrap(err)
+}
+
+// ForgeBatchArgs returns the arguments of a batch without transactions that
+// keeps lastIdx, with zero roots and proof, which the verifier stub accepts
+func ForgeBatchArgs(lastIdx int64, l1Batch bool) *eth.RollupForgeBatchArgs {
+	zero := func() *big.Int { return big.NewInt(0) }
+	return &eth.RollupForgeBatchArgs{
+		NewLastIdx:   lastIdx,
+		NewStRoot:    zero(),
+		NewVouchRoot: zero(),
+		NewScoreRoot: zero(),
+		NewExitRoot:  zero(),
+		L1Batch:      l1Batch,
+		ProofA:       [2]*big.Int{zero(), zero()},
+		ProofB:       [2][2]*big.Int{{zero(), zero()}, {zero(), zero()}},
+		ProofC:       [2]*big.Int{zero(), zero()},
+		Input:        zero(),
+	}
+}
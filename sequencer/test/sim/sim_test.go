@@ -0,0 +1,284 @@
+package sim
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/eth"
+	"tokamak-sybil-resistance/eth/contracts/tokamak"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackend checks the JSON-RPC API of the simulated chain through the eth
+// client, without contracts
+func TestBackend(t *testing.T) {
+	key, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	from := Address(key)
+	to := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	backend, err := NewBackend(core.GenesisAlloc{
+		from: {Balance: big.NewInt(params.Ether)},
+	}, defaultGasLimit, true)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+	client, err := eth.NewEthereumClient(backend.Client(), &KeySigner{Key: key}, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	chainID, err := client.EthChainID()
+	require.NoError(t, err)
+	assert.Equal(t, backend.ChainID(), chainID)
+	lastBlock, err := client.EthLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), lastBlock)
+
+	// A transfer is mined in a new block
+	nonce, err := client.EthPendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	gasPrice, err := client.EthSuggestGasPrice(ctx)
+	require.NoError(t, err)
+	gasTipCap, err := client.EthSuggestGasTipCap(ctx)
+	require.NoError(t, err)
+	tx, err := (&KeySigner{Key: key}).SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: new(big.Int).Mul(gasPrice, big.NewInt(2)),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000),
+	}), chainID)
+	require.NoError(t, err)
+	require.NoError(t, client.Client().SendTransaction(ctx, tx))
+
+	lastBlock, err = client.EthLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lastBlock)
+	receipt, err := client.EthTransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	block, err := client.EthBlockByNumber(ctx, -1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), block.Num)
+	assert.Equal(t, receipt.BlockHash, block.Hash)
+	assert.Equal(t, backend.Blockchain().CurrentBlock().Hash(), block.Hash)
+
+	minedTx, pending, err := client.Client().TransactionByHash(ctx, tx.Hash())
+	require.NoError(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, tx.Hash(), minedTx.Hash())
+	sender, err := client.Client().TransactionSender(ctx, minedTx, receipt.BlockHash, 0)
+	require.NoError(t, err)
+	assert.Equal(t, from, sender)
+	nonce, err = client.EthNonceAt(ctx, from, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+	balance, err := client.Client().BalanceAt(ctx, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), balance)
+
+	feeHistory, err := client.EthFeeHistory(ctx, 2, []float64{50})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), feeHistory.OldestBlock.Int64())
+	assert.Equal(t, 3, len(feeHistory.BaseFee))
+	assert.Equal(t, 2, len(feeHistory.Reward))
+	assert.Equal(t, gasTipCap, feeHistory.Reward[1][0])
+}
+
+// TestBackendReplacement checks that a pending transaction is replaced by one
+// with the same nonce only if both of its fees are 10% higher
+func TestBackendReplacement(t *testing.T) {
+	key, err := ethCrypto.GenerateKey()
+	require.NoError(t, err)
+	from := Address(key)
+	to := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	backend, err := NewBackend(core.GenesisAlloc{
+		from: {Balance: big.NewInt(params.Ether)},
+	}, defaultGasLimit, false)
+	require.NoError(t, err)
+	defer func() { _ = backend.Close() }()
+	client := backend.Client()
+	ctx := context.Background()
+
+	gasFeeCap := big.NewInt(10 * params.GWei)
+	gasTipCap := big.NewInt(params.GWei)
+	newTx := func(nonce uint64, perc int64) *types.Transaction {
+		inc := func(v *big.Int) *big.Int {
+			v = new(big.Int).Mul(v, big.NewInt(100+perc))
+			return v.Div(v, big.NewInt(100))
+		}
+		tx, err := (&KeySigner{Key: key}).SignTx(types.NewTx(&types.DynamicFeeTx{
+			ChainID:   backend.ChainID(),
+			Nonce:     nonce,
+			GasTipCap: inc(gasTipCap),
+			GasFeeCap: inc(gasFeeCap),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1000),
+		}), backend.ChainID())
+		require.NoError(t, err)
+		return tx
+	}
+	send := func(tx *types.Transaction) error {
+		b, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		return client.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(b))
+	}
+	tx0 := newTx(0, 0)
+	require.NoError(t, send(tx0))
+	tx1 := newTx(1, 0)
+	require.NoError(t, send(tx1))
+
+	// A replacement below the 10% bump is rejected
+	err = send(newTx(0, 5))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), core.ErrReplaceUnderpriced.Error())
+	replacement := newTx(0, 10)
+	require.NoError(t, send(replacement))
+
+	// The replacement is mined instead of the replaced tx, and the later
+	// txs are kept
+	backend.Commit()
+	ethClient := ethclient.NewClient(client)
+	_, err = ethClient.TransactionReceipt(ctx, tx0.Hash())
+	assert.Equal(t, ethereum.NotFound, err)
+	for _, tx := range []*types.Transaction{replacement, tx1} {
+		receipt, err := ethClient.TransactionReceipt(ctx, tx.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		assert.Equal(t, big.NewInt(1), receipt.BlockNumber)
+	}
+}
+
+// TestSybil runs the eth.Client against the Sybil contract
+func TestSybil(t *testing.T) {
+	h := NewTest(t, Config{Accounts: 1, MaxTx: 512, NLevels: 32, AutoMine: true})
+	client, err := h.NewClient(h.Accounts[0])
+	require.NoError(t, err)
+
+	consts, err := client.RollupConstants()
+	require.NoError(t, err)
+	assert.Equal(t, int64(240), consts.AbsoluteMaxL1L2BatchTimeout)
+	assert.Equal(t, []common.RollupVerifierStruct{{MaxTx: 512, NLevels: 32}},
+		consts.Verifiers)
+	rollupInit, initBlock, err := client.RollupEventInit(0)
+	require.NoError(t, err)
+	assert.NotNil(t, rollupInit)
+	assert.Greater(t, initBlock, int64(0))
+	initEvents, err := client.RollupEventsByBlock(initBlock, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []eth.RollupEventInitialized{{Version: 1}}, initEvents.Initialized)
+	verifiers, err := client.RollupVerifiers(-1)
+	require.NoError(t, err)
+	assert.Equal(t, consts.Verifiers, verifiers)
+	// initialize doesn't set an owner
+	owner, err := client.RollupOwner(-1)
+	require.NoError(t, err)
+	assert.Equal(t, ethCommon.Address{}, *owner)
+
+	// The L1 user tx is packed as in sybil.sol
+	bjjKey := babyjub.NewRandPrivKey()
+	bjj := bjjKey.Public().Compress()
+	loadAmount := new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether))
+	tx, err := client.RollupL1UserTxCreateAccountDeposit(bjj, loadAmount)
+	require.NoError(t, err)
+	receipt, err := client.EthTransactionReceipt(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	binding, err := tokamak.NewTokamak(h.Sybil, ethclient.NewClient(h.Backend.Client()))
+	require.NoError(t, err)
+	events, err := binding.FilterL1UserTxEvent(&bind.FilterOpts{}, nil, nil)
+	require.NoError(t, err)
+	require.True(t, events.Next())
+	assert.Equal(t, uint32(1), events.Event.QueueIndex)
+	assert.Equal(t, uint8(0), events.Event.Position)
+	args, err := eth.NewRollupL1UserTxArgs(bjj, 0, loadAmount, nil, 0)
+	require.NoError(t, err)
+	l1Tx := append(Address(h.Accounts[0]).Bytes(), []byte(args.BabyPubKey)...)
+	l1Tx = append(l1Tx, 0, 0, 0, 0, 0, 0) // fromIdx
+	l1Tx = append(l1Tx, big.NewInt(int64(args.LoadAmountF)).FillBytes(make([]byte, 5))...)
+	l1Tx = append(l1Tx, 0, 0, 0, 0, 0)    // amountF
+	l1Tx = append(l1Tx, 0, 0, 0, 0, 0, 0) // toIdx
+	assert.Equal(t, l1Tx, events.Event.L1UserTx)
+	txBytes, err := eth.RollupL1UserTxBytes(&common.L1Tx{FromEthAddr: Address(h.Accounts[0]),
+		FromBJJ: bjj, DepositAmount: loadAmount})
+	require.NoError(t, err)
+	assert.Equal(t, l1Tx, txBytes)
+	queue, err := client.RollupL1TransactionQueue(1, -1)
+	require.NoError(t, err)
+	assert.Equal(t, l1Tx, queue)
+	// and decoded from the L1UserTxEvent
+	l1Events, err := client.RollupEventsByBlock(receipt.BlockNumber.Int64(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(l1Events.L1UserTx))
+	l1UserTx := l1Events.L1UserTx[0].L1UserTx
+	assert.Equal(t, Address(h.Accounts[0]), l1UserTx.FromEthAddr)
+	assert.Equal(t, bjj, l1UserTx.FromBJJ)
+	assert.Equal(t, common.AccountIdx(0), l1UserTx.FromIdx)
+	assert.Equal(t, loadAmount.String(), l1UserTx.DepositAmount.String())
+	assert.Equal(t, int64(1), *l1UserTx.ToForgeL1TxsNum)
+	assert.Equal(t, 0, l1UserTx.Position)
+	nextToForgeQueue, err := client.RollupNextL1ToForgeQueue(-1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), nextToForgeQueue)
+	nextFillingQueue, err := client.RollupNextL1FillingQueue(-1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), nextFillingQueue)
+
+	// The verifier stub accepts any proof
+	forgeBatchArgs := &eth.RollupForgeBatchArgs{
+		NewLastIdx:   256,
+		NewStRoot:    big.NewInt(1),
+		NewVouchRoot: big.NewInt(2),
+		NewScoreRoot: big.NewInt(3),
+		NewExitRoot:  big.NewInt(4),
+		L1Batch:      true,
+		ProofA:       [2]*big.Int{big.NewInt(0), big.NewInt(0)},
+		ProofB: [2][2]*big.Int{{big.NewInt(0), big.NewInt(0)},
+			{big.NewInt(0), big.NewInt(0)}},
+		ProofC: [2]*big.Int{big.NewInt(0), big.NewInt(0)},
+		Input:  big.NewInt(0),
+	}
+	tx, err = client.RollupForgeBatch(forgeBatchArgs, nil)
+	require.NoError(t, err)
+	receipt, err = client.EthTransactionReceipt(context.Background(), tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	lastForgedBatch, err := client.RollupLastForgedBatch()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lastForgedBatch)
+	lastL1L2Batch, err := client.RollupLastL1L2Batch(-1)
+	require.NoError(t, err)
+	assert.Equal(t, receipt.BlockNumber.Int64(), lastL1L2Batch)
+
+	rollupEvents, err := client.RollupEventsByBlock(receipt.BlockNumber.Int64(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(rollupEvents.ForgeBatch))
+	assert.Equal(t, int64(1), rollupEvents.ForgeBatch[0].BatchNum)
+	assert.Equal(t, uint16(1), rollupEvents.ForgeBatch[0].L1UserTxsLen)
+	assert.Equal(t, tx.Hash(), rollupEvents.ForgeBatch[0].EthTxHash)
+	blockNums, err := client.RollupEventBlocks(0, receipt.BlockNumber.Int64())
+	require.NoError(t, err)
+	assert.Contains(t, blockNums, receipt.BlockNumber.Int64())
+
+	minedArgs, sender, err := client.RollupForgeBatchArgs(tx.Hash(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, Address(h.Accounts[0]), *sender)
+	assert.Equal(t, forgeBatchArgs.NewLastIdx, minedArgs.NewLastIdx)
+	assert.Equal(t, forgeBatchArgs.NewStRoot, minedArgs.NewStRoot)
+	assert.Equal(t, forgeBatchArgs.NewExitRoot, minedArgs.NewExitRoot)
+	assert.Equal(t, forgeBatchArgs.L1Batch, minedArgs.L1Batch)
+}
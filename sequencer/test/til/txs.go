@@ -220,6 +220,7 @@ func (tc *Context) generateBlocks() ([]common.BlockData, error) {
 			}
 			tx := common.L1Tx{
 				// TokenID:       inst.TokenID,
+				Amount:        big.NewInt(0),
 				DepositAmount: inst.DepositAmount,
 				Type:          inst.Typ,
 			}
@@ -309,6 +310,11 @@ func (tc *Context) generateBlocks() ([]common.BlockData, error) {
 		case TypeNewBatchL1:
 			// for each L1UserTx of the Queues[ToForgeNum], accumulate Txs into map
 			for _, tx := range tc.Queues[tc.ToForgeNum] {
+				if tx.L1Tx.Type != common.TxTypeCreateAccountDeposit {
+					continue
+				}
+				// the account gets the next idx when the tx is forged
+				tc.Accounts[tx.fromIdxName].Idx = common.AccountIdx(tc.idx)
 				tc.l1CreatedAccounts[tx.fromIdxName] = tc.Accounts[tx.fromIdxName]
 				tc.accountsByIdx[tc.idx] = tc.Accounts[tx.fromIdxName]
 				tc.idx++
@@ -85,17 +85,15 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"os"
 	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
 	"tokamak-sybil-resistance/database/statedb"
 	"tokamak-sybil-resistance/log"
 
 	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/iden3/go-merkletree"
 	"github.com/iden3/go-merkletree/db"
-	"github.com/iden3/go-merkletree/db/pebble"
 )
 
 // TxProcessor represents the TxProcessor object
@@ -226,24 +224,12 @@ func (txProcessor *TxProcessor) ProcessTxs(coordIdxs []common.AccountIdx, l1user
 	// 	txProcessor.zki.Metadata.NewLastIdxRaw = txProcessor.state.CurrentIdx()
 	// }
 
-	// TBD if ExitTree is only in memory or stored in disk, for the moment
-	// is only needed in memory
+	// The ExitTree is only needed while processing the batch, so it's
+	// kept in memory
 	if txProcessor.state.Type() == statedb.TypeSynchronizer || txProcessor.state.Type() == statedb.TypeBatchBuilder {
-		tmpDir, err := ioutil.TempDir("", "hermez-statedb-exittree")
-		if err != nil {
-			return nil, common.Wrap(err)
-		}
-		defer func() {
-			if err := os.RemoveAll(tmpDir); err != nil {
-				log.Errorw("Deleting statedb temp exit tree", "err", err)
-			}
-		}()
-		sto, err := pebble.NewPebbleStorage(tmpDir, false)
-		if err != nil {
-			return nil, common.Wrap(err)
-		}
-		defer sto.Close()
-		exitTree, err = merkletree.NewMerkleTree(sto, txProcessor.state.AccountTree.MaxLevels())
+		var err error
+		exitTree, err = merkletree.NewMerkleTree(kvdb.NewMemoryStorage(),
+			txProcessor.state.AccountTree.MaxLevels())
 		if err != nil {
 			return nil, common.Wrap(err)
 		}
@@ -0,0 +1,116 @@
+package txprocessor
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"tokamak-sybil-resistance/common"
+	"tokamak-sybil-resistance/database/kvdb"
+	"tokamak-sybil-resistance/database/statedb"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = Config{
+	NLevels:  32,
+	MaxFeeTx: common.RollupConstMaxFeeIdxCoordinator,
+	MaxTx:    512,
+	MaxL1Tx:  common.RollupConstMaxL1Tx,
+	ChainID:  0,
+}
+
+func newTestStateDB(tb testing.TB, backend kvdb.Backend) *statedb.StateDB {
+	dir, err := ioutil.TempDir("", "tmpdb")
+	require.NoError(tb, err)
+	sdb, err := statedb.NewStateDB(statedb.Config{Path: dir, Keep: 128,
+		Type: statedb.TypeSynchronizer, NLevels: statedb.MaxNLevels, Backend: backend})
+	require.NoError(tb, err)
+	tb.Cleanup(func() {
+		sdb.Close()
+		os.RemoveAll(dir) //nolint:errcheck
+	})
+	return sdb
+}
+
+// testBatch returns a batch of nCreate L1 txs that create accounts and nExit
+// L1 txs that exit from the accounts 256 to 256+nExit-1
+func testBatch(nCreate, nExit int) []common.L1Tx {
+	var sk babyjub.PrivateKey
+	bjj := sk.Public().Compress()
+	ethAddr := ethCommon.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	txs := []common.L1Tx{}
+	for i := 0; i < nCreate; i++ {
+		txs = append(txs, common.L1Tx{
+			Position:      len(txs),
+			UserOrigin:    true,
+			FromEthAddr:   ethAddr,
+			FromBJJ:       bjj,
+			Amount:        big.NewInt(0),
+			DepositAmount: big.NewInt(1000),
+			Type:          common.TxTypeCreateAccountDeposit,
+		})
+	}
+	for i := 0; i < nExit; i++ {
+		txs = append(txs, common.L1Tx{
+			Position:      len(txs),
+			UserOrigin:    true,
+			FromIdx:       common.AccountIdx(256 + i),
+			FromEthAddr:   ethAddr,
+			ToIdx:         common.AccountIdx(1),
+			Amount:        big.NewInt(1),
+			DepositAmount: big.NewInt(0),
+			Type:          common.TxTypeForceExit,
+		})
+	}
+	return txs
+}
+
+func TestProcessTxsExits(t *testing.T) {
+	sdb := newTestStateDB(t, kvdb.NewMemoryBackend())
+	tp := NewTxProcessor(sdb, testConfig)
+	_, err := tp.ProcessTxs(nil, testBatch(4, 0), nil, nil)
+	require.NoError(t, err)
+
+	tp = NewTxProcessor(sdb, testConfig)
+	out, err := tp.ProcessTxs(nil, testBatch(0, 3), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(out.ExitInfos))
+	for i, exitInfo := range out.ExitInfos {
+		assert.Equal(t, common.AccountIdx(256+i), exitInfo.AccountIdx)
+		assert.Equal(t, big.NewInt(1), exitInfo.Balance)
+	}
+	account, err := sdb.GetAccount(256)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(999), account.Balance)
+}
+
+// BenchmarkProcessTxs measures the processing of a batch with 16 account
+// creations and 16 exits by the synchronizer, with the StateDB in each
+// backend
+func BenchmarkProcessTxs(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		backend func() kvdb.Backend
+	}{
+		{"pebble", func() kvdb.Backend { return kvdb.PebbleBackend{} }},
+		{"leveldb", func() kvdb.Backend { return kvdb.LevelDBBackend{} }},
+		{"memory", func() kvdb.Backend { return kvdb.NewMemoryBackend() }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			sdb := newTestStateDB(b, bc.backend())
+			tp := NewTxProcessor(sdb, testConfig)
+			_, err := tp.ProcessTxs(nil, testBatch(16, 0), nil, nil)
+			require.NoError(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tp := NewTxProcessor(sdb, testConfig)
+				_, err := tp.ProcessTxs(nil, testBatch(16, 16), nil, nil)
+				require.NoError(b, err)
+			}
+		})
+	}
+}